@@ -0,0 +1,154 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+)
+
+// Account PnL guard actions.
+const (
+	accountActionTighten      = "tighten"
+	accountActionCloseWeakest = "close_weakest"
+)
+
+// accountGuard tracks the session peak of the total unrealized PnL.
+type accountGuard struct {
+	peak      float64
+	retracing bool // the retracement threshold is currently exceeded
+}
+
+// checkAccountPnL sums the unrealized PnL of the managed linear positions and,
+// when it has retraced more than ACCOUNT_PNL_RETRACE_PERCENT from its session
+// peak, applies ACCOUNT_PNL_ACTION. It returns the keys of the positions it
+// closed so the cycle skips them.
+func (ts *TradingService) checkAccountPnL(positions []*Position) map[string]bool {
+	cfg := ts.config()
+	if cfg.AccountPnLRetracePct <= 0 {
+		return nil
+	}
+
+	var open []*PositionData
+	var total float64
+	for _, position := range positions {
+		if !ts.isSymbolManaged(position.Symbol) {
+			continue
+		}
+		data, err := newPositionData(position)
+		if err != nil || data == nil {
+			continue
+		}
+		// Positions under manual control count towards the total but are never closed
+		if !ts.isSymbolManual(data.Symbol) {
+			open = append(open, data)
+		}
+		// Inverse contracts settle in coins and cannot be added to quote PnL
+		if data.ContractSize == 0 {
+			total += positionPnL(data, data.MarkPrice)
+		}
+	}
+
+	ts.mu.Lock()
+	guard := &ts.accountGuard
+	if total > guard.peak {
+		guard.peak = total
+	}
+	peak := guard.peak
+	retrace := 0.0
+	if peak > 0 && peak >= cfg.AccountPnLMinPeak {
+		retrace = (peak - total) / peak * 100
+	}
+	exceeded := retrace >= cfg.AccountPnLRetracePct
+	started := exceeded && !guard.retracing
+	guard.retracing = exceeded
+	ts.mu.Unlock()
+
+	if !started {
+		return nil
+	}
+
+	msg := fmt.Sprintf("📉 Account unrealized PnL retraced %.2f%% from its session peak (%.2f → %.2f), applying %s",
+		retrace, peak, total, cfg.AccountPnLAction)
+	log.Println(msg)
+	ts.notify(SeverityWarning, msg)
+
+	if cfg.AccountPnLAction != accountActionCloseWeakest {
+		return nil
+	}
+
+	// Close the positions with the lowest profit first
+	sort.Slice(open, func(i, j int) bool { return open[i].CurrentProfitPct < open[j].CurrentProfitPct })
+	closed := make(map[string]bool)
+	var names []string
+	for _, data := range open[:min(cfg.AccountPnLCloseCount, len(open))] {
+		unlock := ts.lockPosition(data.Symbol, data.PositionSide)
+		err := ts.reducePosition(data, 100, "account PnL retracement")
+		unlock()
+		if err != nil {
+			log.Printf("Warning: %v", err)
+			continue
+		}
+		closed[trackedKey(data.Symbol, data.PositionSide)] = true
+		names = append(names, fmt.Sprintf("%s %s (%.2f%%)", data.Symbol, data.PositionSide, data.CurrentProfitPct))
+	}
+	if len(names) > 0 {
+		ts.notify(SeverityWarning, "📉 Closed weakest positions: "+strings.Join(names, ", "))
+	}
+
+	// The remaining positions start a new session peak
+	ts.mu.Lock()
+	guard.peak, guard.retracing = 0, false
+	ts.mu.Unlock()
+	return closed
+}
+
+// accountRetracing reports whether the account PnL guard is tightening stops.
+func (ts *TradingService) accountRetracing() bool {
+	if ts.config().AccountPnLAction != accountActionTighten {
+		return false
+	}
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	return ts.accountGuard.retracing
+}
+
+// applyAccountGuard tightens the stop to the lock of the next ladder stage while
+// the account PnL guard is retracing. The tighter stop is only used when it is
+// still on the valid side of the mark price.
+func (ts *TradingService) applyAccountGuard(data *PositionData, stopPrice float64) float64 {
+	if !data.AccountTighten {
+		return stopPrice
+	}
+	next := ts.ladderStage(data) + 1
+	levels := ts.stopLadder(data.Symbol).Levels
+	if next >= len(levels) {
+		return stopPrice
+	}
+
+	offset := ts.lockPct(data, levels[next].StopLossValue) / 100
+	tighter := data.EntryPrice * (1 + offset)
+	if data.IsShort {
+		tighter = data.EntryPrice * (1 - offset)
+	}
+	if (data.IsLong && (tighter >= data.MarkPrice || tighter <= stopPrice)) ||
+		(data.IsShort && (tighter <= data.MarkPrice || tighter >= stopPrice)) {
+		return stopPrice
+	}
+
+	log.Printf("Tightening SL for %s from %.8f to %.8f (stage %d) due to account PnL retracement",
+		data.Symbol, stopPrice, tighter, next)
+	data.RawSLPct = rawStopLossPct(data, tighter)
+	data.LeveragedSLPct = data.RawSLPct * data.Leverage
+	return tighter
+}
+
+// parseAccountPnLAction normalizes the configured account PnL guard action.
+func parseAccountPnLAction(value string) (string, error) {
+	switch action := strings.ToLower(strings.TrimSpace(value)); action {
+	case accountActionTighten, accountActionCloseWeakest:
+		return action, nil
+	default:
+		return "", fmt.Errorf("want %s or %s", accountActionTighten, accountActionCloseWeakest)
+	}
+}
@@ -0,0 +1,423 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// apiShutdownTimeout bounds how long in-flight API requests may take on shutdown.
+const apiShutdownTimeout = 5 * time.Second
+
+// redacted replaces secrets in the configuration served by the API.
+const redacted = "REDACTED"
+
+// openRequest is the body of POST /positions/open.
+type openRequest struct {
+	Symbol       string  `json:"symbol"`
+	Side         string  `json:"side"`
+	PositionSide string  `json:"position_side"`
+	Quantity     float64 `json:"quantity"`
+	Risk         float64 `json:"risk"`
+	Limit        float64 `json:"limit"`
+	Tag          string  `json:"tag"`
+}
+
+// stopLossRequest is the body of PUT /symbols/{symbol}/sl.
+type stopLossRequest struct {
+	Price float64 `json:"price"`
+	Side  string  `json:"side"`
+}
+
+// serveAPI runs the HTTP control API on APIAddr until ctx is cancelled.
+func (ts *TradingService) serveAPI(ctx context.Context) {
+	server := &http.Server{
+		Addr:              ts.config().APIAddr,
+		Handler:           ts.apiHandler(),
+		ReadHeaderTimeout: defaultTimeout,
+	}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), apiShutdownTimeout)
+		defer cancel()
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			log.Printf("Warning: Error shutting down control API: %v", err)
+		}
+	}()
+
+	log.Printf("Control API listening on %s", ts.config().APIAddr)
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		log.Printf("Error running control API: %v", err)
+	}
+}
+
+// apiHandler routes the unauthenticated health probes and, when API_TOKEN is set,
+// the control API endpoints behind token authentication.
+func (ts *TradingService) apiHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /healthz", ts.handleHealthz)
+	mux.HandleFunc("GET /readyz", ts.handleReadyz)
+	// TradingView alerts authenticate with the secret in their body
+	if ts.config().TradingViewSecret != "" {
+		mux.Handle("POST /webhooks/tradingview", ts.requireLeader(http.HandlerFunc(ts.handleTradingView)))
+	}
+
+	if ts.config().APIToken == "" {
+		log.Println("Warning: API_TOKEN is empty; serving health probes only")
+		return mux
+	}
+
+	control := http.NewServeMux()
+	control.HandleFunc("GET /positions", ts.handleGetPositions)
+	control.HandleFunc("GET /positions/export", ts.handleExportPositions)
+	control.HandleFunc("GET /config", ts.handleGetConfig)
+	control.HandleFunc("POST /pause", ts.handlePause(true))
+	control.HandleFunc("POST /resume", ts.handlePause(false))
+	control.HandleFunc("GET /control", ts.handleGetControl)
+	control.HandleFunc("POST /symbols/{symbol}/pause", ts.handlePauseSymbol(true))
+	control.HandleFunc("POST /symbols/{symbol}/resume", ts.handlePauseSymbol(false))
+	control.HandleFunc("POST /symbols/{symbol}/manual", ts.handleManualSymbol(true))
+	control.HandleFunc("POST /symbols/{symbol}/unlock", ts.handleManualSymbol(false))
+	control.HandleFunc("POST /kill-switch/reset", ts.handleResetKillSwitch)
+	control.HandleFunc("POST /positions/open", ts.handleOpenPosition)
+	control.HandleFunc("POST /symbols/{symbol}/close", ts.handleCloseSymbol)
+	control.HandleFunc("PUT /symbols/{symbol}/sl", ts.handleSetStopLoss)
+	mux.Handle("/", ts.requireToken(ts.requireLeader(control)))
+	return mux
+}
+
+// requireToken rejects requests that do not carry the configured API token,
+// either as a bearer token or in the X-API-Token header.
+func (ts *TradingService) requireToken(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := r.Header.Get("X-API-Token")
+		if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+			token = strings.TrimPrefix(auth, "Bearer ")
+		}
+		if subtle.ConstantTimeCompare([]byte(token), []byte(ts.config().APIToken)) != 1 {
+			writeError(w, http.StatusUnauthorized, errors.New("invalid or missing API token"))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// requireLeader rejects requests that change orders or the control state while
+// this instance is on standby, so only the leader acts on them.
+func (ts *TradingService) requireLeader(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && !ts.isLeader() {
+			writeError(w, http.StatusServiceUnavailable, errors.New("this instance is on standby; send the request to the leader"))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// handleGetPositions serves the open positions with their live SL/TP orders.
+func (ts *TradingService) handleGetPositions(w http.ResponseWriter, r *http.Request) {
+	positions, err := ts.snapshotPositions("")
+	if err != nil {
+		writeError(w, http.StatusBadGateway, err)
+		return
+	}
+	if positions == nil {
+		positions = []*PositionData{}
+	}
+	writeJSON(w, http.StatusOK, positions)
+}
+
+// handleExportPositions serves the positions snapshot as CSV or JSON, selected by
+// the format query parameter.
+func (ts *TradingService) handleExportPositions(w http.ResponseWriter, r *http.Request) {
+	format, err := parseExportFormat(r.URL.Query().Get("format"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	positions, err := ts.snapshotPositions(strings.ToUpper(r.URL.Query().Get("symbol")))
+	if err != nil {
+		writeError(w, http.StatusBadGateway, err)
+		return
+	}
+
+	if format == exportFormatCSV {
+		w.Header().Set("Content-Type", "text/csv")
+		w.Header().Set("Content-Disposition", `attachment; filename="positions.csv"`)
+	} else {
+		w.Header().Set("Content-Type", "application/json")
+	}
+	if err := writePositionsExport(w, format, positions); err != nil {
+		log.Printf("Warning: Error encoding positions export: %v", err)
+	}
+}
+
+// handleGetConfig serves the active configuration with secrets redacted.
+func (ts *TradingService) handleGetConfig(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, redactConfig(*ts.config()))
+}
+
+// handlePause returns the handler that pauses or resumes order management.
+func (ts *TradingService) handlePause(paused bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ts.setPaused(paused)
+
+		msg := "▶️ Order management resumed via API"
+		if paused {
+			msg = "⏸️ Order management paused via API"
+		}
+		log.Println(msg)
+		ts.notify(SeverityWarning, msg)
+		writeJSON(w, http.StatusOK, map[string]bool{"paused": paused})
+	}
+}
+
+// handleGetControl serves the persisted control state.
+func (ts *TradingService) handleGetControl(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, ts.control())
+}
+
+// handlePauseSymbol returns the handler that pauses or resumes order management
+// of a single symbol.
+func (ts *TradingService) handlePauseSymbol(paused bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		symbol := strings.ToUpper(r.PathValue("symbol"))
+		control := ts.updateControl(func(c *ControlState) { c.setSymbolPaused(symbol, paused) })
+
+		msg := fmt.Sprintf("▶️ Order management of %s resumed via API", symbol)
+		if paused {
+			msg = fmt.Sprintf("⏸️ Order management of %s paused via API", symbol)
+		}
+		log.Println(msg)
+		ts.notify(SeverityWarning, msg)
+		writeJSON(w, http.StatusOK, control)
+	}
+}
+
+// handleManualSymbol returns the handler that puts a symbol under manual control
+// or hands it back to the bot.
+func (ts *TradingService) handleManualSymbol(manual bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		symbol := strings.ToUpper(r.PathValue("symbol"))
+		control := ts.updateControl(func(c *ControlState) { c.setSymbolManual(symbol, manual) })
+
+		msg := fmt.Sprintf("🤖 %s unlocked via API; the bot manages its orders again", symbol)
+		if manual {
+			msg = fmt.Sprintf("✋ %s under manual control via API; the bot reports it but changes none of its orders", symbol)
+		}
+		log.Println(msg)
+		ts.notify(SeverityWarning, msg)
+		writeJSON(w, http.StatusOK, control)
+	}
+}
+
+// handleResetKillSwitch allows new entries again after an emergency flatten.
+func (ts *TradingService) handleResetKillSwitch(w http.ResponseWriter, r *http.Request) {
+	ts.resetKillSwitch()
+	msg := "🔓 Kill switch reset via API; new entries allowed"
+	log.Println(msg)
+	ts.notify(SeverityWarning, msg)
+	writeJSON(w, http.StatusOK, ts.control())
+}
+
+// handleOpenPosition opens a position with its SL/TP bracket.
+func (ts *TradingService) handleOpenPosition(w http.ResponseWriter, r *http.Request) {
+	var req openRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("error decoding request body: %w", err))
+		return
+	}
+	isLong, err := parseDirection(req.Side)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if err := ts.entryBlocked(strings.ToUpper(req.Symbol)); err != nil {
+		writeError(w, http.StatusConflict, err)
+		return
+	}
+
+	data, err := ts.openPosition(entryRequest{
+		Symbol:       strings.ToUpper(req.Symbol),
+		PositionSide: strings.ToUpper(req.PositionSide),
+		IsLong:       isLong,
+		Quantity:     req.Quantity,
+		RiskPct:      req.Risk,
+		LimitPrice:   req.Limit,
+		Tag:          req.Tag,
+	})
+	if err != nil {
+		writeError(w, http.StatusBadGateway, err)
+		return
+	}
+
+	msg := formatEntry(data, req.Limit > 0) + "\n(via API)"
+	log.Println(msg)
+	ts.notify(SeverityInfo, msg)
+	writeJSON(w, http.StatusOK, data)
+}
+
+// handleCloseSymbol cancels every order on a symbol and closes its positions at market.
+func (ts *TradingService) handleCloseSymbol(w http.ResponseWriter, r *http.Request) {
+	symbol := strings.ToUpper(r.PathValue("symbol"))
+	if err := ts.closeSymbol(symbol); err != nil {
+		writeError(w, http.StatusBadGateway, err)
+		return
+	}
+
+	msg := fmt.Sprintf("🚪 Closed %s positions and cancelled its orders via API", symbol)
+	log.Println(msg)
+	ts.notify(SeverityWarning, msg)
+	writeJSON(w, http.StatusOK, map[string]string{"symbol": symbol, "status": "closed"})
+}
+
+// handleSetStopLoss replaces the stop-loss of a position with a manual price.
+func (ts *TradingService) handleSetStopLoss(w http.ResponseWriter, r *http.Request) {
+	symbol := strings.ToUpper(r.PathValue("symbol"))
+
+	var req stopLossRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("error decoding request body: %w", err))
+		return
+	}
+	if req.Price <= 0 {
+		writeError(w, http.StatusBadRequest, errors.New("price must be positive"))
+		return
+	}
+
+	data, err := ts.setStopLoss(symbol, strings.ToUpper(req.Side), req.Price)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	msg := fmt.Sprintf("✋ Stop-loss for %s (%s) set to %s via API", symbol, data.PositionSide, data.StopPriceStr)
+	log.Println(msg)
+	ts.notify(SeverityWarning, msg)
+	writeJSON(w, http.StatusOK, data)
+}
+
+// setStopLoss replaces the stop-loss order of one position of symbol with a stop
+// at price. The side may be empty when the symbol has a single open position.
+// Later cycles only move the stop if the ladder produces a better one.
+func (ts *TradingService) setStopLoss(symbol, side string, price float64) (*PositionData, error) {
+	unlock := ts.lockSymbol(symbol)
+	defer unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+
+	// Act on live orders rather than those cached by the last cycle
+	ts.orderCache().invalidate(symbol)
+
+	positions, err := ts.exchange().Positions(ctx, symbol)
+	if err != nil {
+		return nil, fmt.Errorf("error getting positions for %s: %w", symbol, err)
+	}
+
+	var matches []*PositionData
+	for _, position := range positions {
+		data, err := newPositionData(position)
+		if err != nil {
+			return nil, err
+		}
+		if data != nil && (side == "" || data.PositionSide == side) {
+			matches = append(matches, data)
+		}
+	}
+	switch {
+	case len(matches) == 0:
+		return nil, fmt.Errorf("no open position on %s matching side %q", symbol, side)
+	case len(matches) > 1:
+		return nil, fmt.Errorf("%s has positions on both sides; specify side LONG or SHORT", symbol)
+	}
+	data := matches[0]
+
+	// A stop on the wrong side of the mark price would trigger immediately
+	if (data.IsLong && price >= data.MarkPrice) || (data.IsShort && price <= data.MarkPrice) {
+		return nil, fmt.Errorf("stop %.8f is on the wrong side of mark price %.8f", price, data.MarkPrice)
+	}
+
+	data.StopPrice = price
+	data.RawSLPct = rawStopLossPct(data, price)
+	data.LeveragedSLPct = data.RawSLPct * data.Leverage
+	data.CurrentSLPct = math.Abs(data.LeveragedSLPct)
+	if data.TakePrice, err = ts.getCurrentTakeProfit(symbol, data.PositionSide); err != nil {
+		log.Printf("Warning: Unable to get current take profit: %v", err)
+	}
+	if err := ts.calculateRiskMetrics(data); err != nil {
+		return nil, err
+	}
+
+	openOrders, err := ts.exchange().OpenOrders(ctx, symbol)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching open orders for %s: %w", symbol, err)
+	}
+	for _, order := range openOrders {
+		if isStopLossOrder(order) && orderMatchesSide(order, data.PositionSide) && ts.ownsOrder(order) {
+			if err := ts.cancelOrder(ctx, order); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if err := ts.createStopLossOrder(data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// redactConfig returns a copy of config with credentials and webhook URLs masked.
+func redactConfig(config Config) Config {
+	for _, secret := range []*string{
+		&config.TelegramBotToken,
+		&config.DiscordWebhookURL,
+		&config.SlackWebhookURL,
+		&config.SMTPPassword,
+		&config.PushoverAppToken,
+		&config.PushoverUserKey,
+		&config.NtfyToken,
+		&config.APIToken,
+		&config.WebhookSecret,
+		&config.InfluxToken,
+		&config.TradingViewSecret,
+		&config.EventBrokerURL,
+		&config.RedisURL,
+		&config.PostgresURL,
+	} {
+		if *secret != "" {
+			*secret = redacted
+		}
+	}
+	// The copy shares its slices with config
+	if len(config.WebhookURLs) > 0 {
+		urls := make([]string, len(config.WebhookURLs))
+		for i := range urls {
+			urls[i] = redacted
+		}
+		config.WebhookURLs = urls
+	}
+	return config
+}
+
+// writeJSON writes v as a JSON response with the given status code.
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("Warning: Error encoding API response: %v", err)
+	}
+}
+
+// writeError writes err as a JSON error response.
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
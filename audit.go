@@ -0,0 +1,192 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// Audited orders.
+const (
+	auditStopLoss   = "SL"
+	auditTakeProfit = "TP"
+)
+
+// Audit actions: what the bot decided for an order, or found done outside it.
+const (
+	auditSet     = "set"
+	auditMoved   = "moved"
+	auditKept    = "kept"
+	auditRemoved = "removed"
+	auditManual  = "manual"
+)
+
+// auditMaxRecords is the number of most recent audit records kept in the state.
+const auditMaxRecords = 2000
+
+// AuditRecord is a decision the bot made about the stop loss or take profit of a
+// position, with the reason behind it.
+type AuditRecord struct {
+	Time         time.Time `json:"time"`
+	Symbol       string    `json:"symbol"`
+	PositionSide string    `json:"positionSide"`
+	Order        string    `json:"order"`
+	Action       string    `json:"action"`
+	From         float64   `json:"from,omitempty"`
+	To           float64   `json:"to,omitempty"`
+	Reason       string    `json:"reason"`
+}
+
+// String returns the record as a line of the decision history.
+func (r *AuditRecord) String() string {
+	var prices string
+	switch {
+	case r.Action == auditKept:
+		prices = fmt.Sprintf("%v (new %v)", r.From, r.To)
+	case r.From > 0 && r.To > 0 && r.From != r.To:
+		prices = fmt.Sprintf("%v → %v", r.From, r.To)
+	case r.From > 0:
+		prices = fmt.Sprint(r.From)
+	default:
+		prices = fmt.Sprint(r.To)
+	}
+	return fmt.Sprintf("%s %-5s %s %-7s %-22s %s",
+		r.Time.UTC().Format(time.DateTime), r.PositionSide, r.Order, r.Action, prices, r.Reason)
+}
+
+// audit records a decision about an order of data. A decision repeating the
+// last one of the order, such as keeping the same stop for the same reason every
+// cycle, is recorded once.
+func (ts *TradingService) audit(data *PositionData, order, action string, from, to float64, reason string) {
+	record := &AuditRecord{
+		Time:         time.Now().UTC(),
+		Symbol:       data.Symbol,
+		PositionSide: data.PositionSide,
+		Order:        order,
+		Action:       action,
+		From:         from,
+		To:           to,
+		Reason:       reason,
+	}
+
+	ts.mu.Lock()
+	for i := len(ts.state.Audit) - 1; i >= 0; i-- {
+		last := ts.state.Audit[i]
+		if last.Symbol != data.Symbol || last.PositionSide != data.PositionSide || last.Order != order {
+			continue
+		}
+		if last.Action == action && last.Reason == reason && last.From == from && (action == auditKept || last.To == to) {
+			ts.mu.Unlock()
+			return
+		}
+		break
+	}
+	ts.state.Audit = append(ts.state.Audit, record)
+	if excess := len(ts.state.Audit) - auditMaxRecords; excess > 0 {
+		ts.state.Audit = append([]*AuditRecord(nil), ts.state.Audit[excess:]...)
+	}
+	ts.mu.Unlock()
+	ts.saveState()
+}
+
+// adoptManualStop records currentSL as the stop of data when it differs from the
+// one the bot placed last, and returns that last stop and whether it did.
+func (ts *TradingService) adoptManualStop(data *PositionData, currentSL float64) (float64, bool) {
+	var last float64
+	ts.mu.Lock()
+	if st, ok := ts.state.Orders[trackedKey(data.Symbol, data.PositionSide)]; ok {
+		last = st.StopPrice
+	}
+	ts.mu.Unlock()
+	if last <= 0 || currentSL <= 0 || currentSL == ts.roundPrice(data.Symbol, last) {
+		return 0, false
+	}
+
+	ts.mu.Lock()
+	ts.orderState(data.Symbol, data.PositionSide).StopPrice = currentSL
+	ts.mu.Unlock()
+	return last, true
+}
+
+// stopReason explains the stop computed for data: the guard that tightened it,
+// or else its strategy and, for the ladder, the stage reached.
+func (ts *TradingService) stopReason(data *PositionData, stage int, guard string) string {
+	if guard != "" {
+		return "tightened by the " + guard
+	}
+	if strategy := ts.stopLossStrategyFor(data.Symbol).Name(); strategy != strategyLadder {
+		return strategy + " strategy"
+	}
+	cfg := ts.config()
+	var trend string
+	if preset, regime := ts.trendLadder(cfg, data.Symbol); preset != "" {
+		trend = fmt.Sprintf(", %s ladder for a %s trend", preset, regime)
+	}
+	if stage < 0 {
+		return "default stop, below the first threshold" + trend
+	}
+	if cfg.LadderInterpolate {
+		return fmt.Sprintf("interpolated lock, stage %d%s", stage, trend)
+	}
+	return fmt.Sprintf("threshold crossed, stage %d%s", stage, trend)
+}
+
+// auditHistory returns the records of symbol, and of positionSide unless empty,
+// oldest first, keeping the last limit when limit is positive.
+func auditHistory(records []*AuditRecord, symbol, positionSide string, limit int) []*AuditRecord {
+	var history []*AuditRecord
+	for _, record := range records {
+		if record.Symbol == symbol && (positionSide == "" || record.PositionSide == positionSide) {
+			history = append(history, record)
+		}
+	}
+	if limit > 0 && len(history) > limit {
+		history = history[len(history)-limit:]
+	}
+	return history
+}
+
+// newAuditCommand builds the `audit` command that prints the SL/TP decision
+// history of a position.
+func newAuditCommand() *cobra.Command {
+	var side string
+	var limit int
+
+	cmd := &cobra.Command{
+		Use:     "audit <symbol>",
+		Short:   "Print why and when the bot set, moved or kept the SL and TP of a position",
+		Example: "  futures-guard audit BTCUSDT\n  futures-guard audit ETHUSDT --side SHORT --limit 20",
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			// The history only needs the persisted state, not an exchange connection
+			config, err := loadConfig()
+			if err != nil {
+				return err
+			}
+			store, err := openStateStore(config)
+			if err != nil {
+				return err
+			}
+			state, err := store.Load()
+			if err != nil {
+				return err
+			}
+
+			symbol := strings.ToUpper(args[0])
+			history := auditHistory(state.Audit, symbol, strings.ToUpper(side), limit)
+			if len(history) == 0 {
+				fmt.Printf("No SL/TP decisions recorded for %s\n", symbol)
+				return nil
+			}
+			for _, record := range history {
+				fmt.Println(record)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&side, "side", "", "only show one side of a hedge-mode position: LONG, SHORT or BOTH")
+	cmd.Flags().IntVar(&limit, "limit", 0, "only show the last N decisions")
+	return cmd
+}
@@ -0,0 +1,228 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"os"
+	"strings"
+	"time"
+
+	binance "github.com/adshao/go-binance/v2/futures"
+	"github.com/spf13/cobra"
+)
+
+// BacktestResult summarizes how the ladder handled one simulated trade.
+type BacktestResult struct {
+	Symbol      string
+	IsLong      bool
+	EntryTime   time.Time
+	EntryPrice  float64
+	ExitTime    time.Time
+	ExitPrice   float64
+	ExitReason  string
+	MaxStage    int
+	SLMoves     int
+	MaxFavorPct float64
+	PnLPct      float64
+}
+
+// newOfflineTradingService creates a trading service for public market data only,
+// without API credentials or persisted state.
+func newOfflineTradingService(config Config) *TradingService {
+	ts := &TradingService{}
+	ts.cfg.Store(&config)
+	ts.conn.Store(&connection{client: newBinanceClient(binance.NewClient("", ""), config)})
+	return ts
+}
+
+// leveragedPnLPct returns the leveraged profit in percent of exiting data at price.
+func leveragedPnLPct(data *PositionData, price float64) float64 {
+	raw := (price - data.EntryPrice) / data.EntryPrice * 100
+	if data.IsShort {
+		raw = -raw
+	}
+	return raw * data.Leverage
+}
+
+// simulateLadder replays candles against the live stop-loss and take-profit logic.
+// The entry is the open of the first candle; within a candle that touches both
+// levels the stop is assumed to trigger first.
+func (ts *TradingService) simulateLadder(symbol string, isLong bool, leverage float64, candles []Candle) (*BacktestResult, error) {
+	if len(candles) == 0 {
+		return nil, fmt.Errorf("no candles to backtest %s", symbol)
+	}
+
+	entry := candles[0]
+	data := &PositionData{
+		Symbol:       symbol,
+		PositionSide: "BOTH",
+		EntryPrice:   entry.Open,
+		MarkPrice:    entry.Open,
+		PositionAmt:  1,
+		AbsAmt:       1,
+		Leverage:     leverage,
+		IsLong:       isLong,
+		IsShort:      !isLong,
+	}
+	if !isLong {
+		data.PositionAmt = -1
+	}
+
+	stop := ts.calculateStopLoss(data)
+	data.TakePrice = ts.calculateTakeProfit(data)
+	take := data.TakePrice
+
+	res := &BacktestResult{
+		Symbol:     symbol,
+		IsLong:     isLong,
+		EntryTime:  entry.OpenTime,
+		EntryPrice: entry.Open,
+		MaxStage:   -1,
+	}
+
+	for _, c := range candles {
+		favorable, adverse := c.High, c.Low
+		if !isLong {
+			favorable, adverse = c.Low, c.High
+		}
+		res.MaxFavorPct = math.Max(res.MaxFavorPct, leveragedPnLPct(data, favorable))
+
+		stopHit := stop > 0 && ((isLong && adverse <= stop) || (!isLong && adverse >= stop))
+		takeHit := (isLong && favorable >= take) || (!isLong && favorable <= take)
+		if stopHit || takeHit {
+			res.ExitTime = c.OpenTime
+			res.ExitPrice, res.ExitReason = take, "TP"
+			if stopHit {
+				res.ExitPrice, res.ExitReason = stop, "SL"
+			}
+			res.PnLPct = leveragedPnLPct(data, res.ExitPrice)
+			return res, nil
+		}
+
+		// Re-evaluate the ladder at the candle close, as a processing cycle would
+		data.MarkPrice = c.Close
+		data.CurrentProfitPct = leveragedPnLPct(data, c.Close)
+		data.RawProfitPct = data.CurrentProfitPct / leverage
+		if stage := ts.ladderStage(data); stage > res.MaxStage {
+			res.MaxStage = stage
+		}
+
+		// The bot only ever moves the stop in the position's favor
+		if newSL := ts.calculateStopLoss(data); (isLong && newSL > stop) || (!isLong && newSL < stop) {
+			stop = newSL
+			res.SLMoves++
+		}
+		if newTP := ts.calculateTakeProfit(data); math.Abs(take-newTP) > ts.tpUpdateThreshold(symbol, take) {
+			take = newTP
+		}
+		data.TakePrice = take
+	}
+
+	last := candles[len(candles)-1]
+	res.ExitTime = last.CloseTime
+	res.ExitPrice, res.ExitReason = last.Close, "OPEN"
+	res.PnLPct = leveragedPnLPct(data, last.Close)
+	return res, nil
+}
+
+// formatBacktestResults renders one line per simulated trade plus a total.
+func formatBacktestResults(results []*BacktestResult) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%-12s %-5s %-16s %14s %-16s %14s %-4s %5s %4s %9s %9s\n",
+		"SYMBOL", "SIDE", "ENTRY TIME", "ENTRY", "EXIT TIME", "EXIT", "WHY", "STAGE", "MOVE", "MFE%", "PNL%")
+
+	var total float64
+	for _, r := range results {
+		side := "SHORT"
+		if r.IsLong {
+			side = "LONG"
+		}
+		fmt.Fprintf(&b, "%-12s %-5s %-16s %14.6f %-16s %14.6f %-4s %5d %4d %8.2f%% %8.2f%%\n",
+			r.Symbol, side,
+			r.EntryTime.UTC().Format("2006-01-02 15:04"), r.EntryPrice,
+			r.ExitTime.UTC().Format("2006-01-02 15:04"), r.ExitPrice,
+			r.ExitReason, r.MaxStage, r.SLMoves, r.MaxFavorPct, r.PnLPct)
+		total += r.PnLPct
+	}
+	fmt.Fprintf(&b, "Total leveraged PnL: %.2f%% over %d trade(s)", total, len(results))
+	return b.String()
+}
+
+// newBacktestCommand builds the `backtest` command that replays historical klines through the ladder.
+func newBacktestCommand() *cobra.Command {
+	var (
+		interval  string
+		startStr  string
+		endStr    string
+		direction string
+		leverage  float64
+		verbose   bool
+	)
+
+	cmd := &cobra.Command{
+		Use:     "backtest <symbol>...",
+		Short:   "Replay historical klines through the stop-loss ladder and take-profit logic",
+		Example: "  futures-guard backtest BTCUSDT ETHUSDT --start 2024-01-01 --end 2024-02-01 --side long --leverage 20",
+		Args:    cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			isLong, err := parseDirection(direction)
+			if err != nil {
+				return err
+			}
+			end := time.Now()
+			if endStr != "" {
+				if end, err = time.Parse(time.DateOnly, endStr); err != nil {
+					return fmt.Errorf("invalid --end date: %w", err)
+				}
+			}
+			start := end.AddDate(0, 0, -30)
+			if startStr != "" {
+				if start, err = time.Parse(time.DateOnly, startStr); err != nil {
+					return fmt.Errorf("invalid --start date: %w", err)
+				}
+			}
+			if leverage <= 0 {
+				return fmt.Errorf("--leverage must be positive")
+			}
+
+			config, err := loadConfig()
+			if err != nil {
+				return err
+			}
+			ts := newOfflineTradingService(config)
+
+			// The ladder logs every calculation; keep the report readable unless asked
+			if !verbose {
+				log.SetOutput(io.Discard)
+				defer log.SetOutput(os.Stderr)
+			}
+
+			var results []*BacktestResult
+			for _, symbol := range args {
+				symbol = strings.ToUpper(symbol)
+				candles, err := ts.getKlinesRange(symbol, interval, start, end)
+				if err != nil {
+					return err
+				}
+				res, err := ts.simulateLadder(symbol, isLong, leverage, candles)
+				if err != nil {
+					return err
+				}
+				results = append(results, res)
+			}
+
+			fmt.Println(formatBacktestResults(results))
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&interval, "interval", "1h", "kline interval to replay")
+	cmd.Flags().StringVar(&startStr, "start", "", "start date (YYYY-MM-DD, default 30 days before end)")
+	cmd.Flags().StringVar(&endStr, "end", "", "end date (YYYY-MM-DD, default now)")
+	cmd.Flags().StringVar(&direction, "side", "long", "simulated entry direction (long or short)")
+	cmd.Flags().Float64Var(&leverage, "leverage", 10, "simulated leverage")
+	cmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "log every ladder calculation")
+	return cmd
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Economic calendar defaults.
+const (
+	defaultCalendarEvents          = "CPI,FOMC,Federal Funds Rate,Non-Farm"
+	defaultCalendarCountries       = "USD"
+	defaultCalendarLeadTime        = 30 * time.Minute
+	defaultCalendarHoldTime        = 15 * time.Minute
+	defaultCalendarRefreshInterval = time.Hour
+)
+
+// calendarEvent is an economic release in the Forex Factory JSON feed format,
+// which custom feeds and webhooks can serve as well.
+type calendarEvent struct {
+	Title   string    `json:"title"`
+	Country string    `json:"country"`
+	Date    time.Time `json:"date"`
+	Impact  string    `json:"impact"`
+}
+
+// economicCalendar caches the watched releases of CALENDAR_URL and remembers the
+// positions whose stop was tightened ahead of one.
+type economicCalendar struct {
+	mu        sync.Mutex
+	events    []calendarEvent
+	fetchedAt time.Time
+	tightened map[string]bool // By trackedKey
+}
+
+// refreshCalendar re-fetches the calendar when the cached copy is older than
+// CALENDAR_REFRESH_INTERVAL, keeping the previous events on failure.
+func (ts *TradingService) refreshCalendar() {
+	if ts.config().CalendarURL == "" {
+		return
+	}
+
+	c := &ts.calendar
+	c.mu.Lock()
+	stale := time.Since(c.fetchedAt) >= ts.config().CalendarRefreshInterval
+	c.mu.Unlock()
+	if !stale {
+		return
+	}
+
+	events, err := fetchCalendar(ts.config().CalendarURL)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.fetchedAt = time.Now()
+	if err != nil {
+		log.Printf("Warning: %v", err)
+		return
+	}
+	c.events = c.events[:0]
+	for _, event := range events {
+		if ts.watchesCalendarEvent(event) {
+			c.events = append(c.events, event)
+		}
+	}
+	log.Printf("Economic calendar refreshed: %d watched releases", len(c.events))
+}
+
+// watchesCalendarEvent reports whether event matches CALENDAR_COUNTRIES and one
+// of the CALENDAR_EVENTS keywords.
+func (ts *TradingService) watchesCalendarEvent(event calendarEvent) bool {
+	if len(ts.config().CalendarCountries) > 0 &&
+		!slices.Contains(ts.config().CalendarCountries, strings.ToUpper(event.Country)) {
+		return false
+	}
+	title := strings.ToLower(event.Title)
+	for _, keyword := range ts.config().CalendarEvents {
+		if strings.Contains(title, strings.ToLower(keyword)) {
+			return true
+		}
+	}
+	return false
+}
+
+// upcomingRelease returns the watched release whose protection window, from
+// CALENDAR_LEAD_TIME before it until CALENDAR_HOLD_TIME after it, contains now.
+func (ts *TradingService) upcomingRelease(now time.Time) (calendarEvent, bool) {
+	c := &ts.calendar
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, event := range c.events {
+		if now.After(event.Date.Add(-ts.config().CalendarLeadTime)) && now.Before(event.Date.Add(ts.config().CalendarHoldTime)) {
+			return event, true
+		}
+	}
+	return calendarEvent{}, false
+}
+
+// checkCalendar flags a position for a breakeven stop inside the window of a
+// watched release, and for restoring its ladder stop once the window has passed.
+func (ts *TradingService) checkCalendar(data *PositionData) {
+	if ts.config().CalendarURL == "" {
+		return
+	}
+	key := trackedKey(data.Symbol, data.PositionSide)
+	event, active := ts.upcomingRelease(time.Now())
+
+	c := &ts.calendar
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.tightened == nil {
+		c.tightened = make(map[string]bool)
+	}
+
+	switch {
+	case active:
+		data.CalendarEvent = event.Title
+		if !c.tightened[key] {
+			c.tightened[key] = true
+			msg := fmt.Sprintf("📅 %s at %s UTC: tightening %s %s stop to breakeven",
+				event.Title, event.Date.UTC().Format("15:04"), data.Symbol, data.PositionSide)
+			log.Println(msg)
+			ts.notify(SeverityWarning, msg)
+		}
+	case c.tightened[key]:
+		delete(c.tightened, key)
+		data.RestoreLadder = true
+		log.Printf("Economic release window passed, restoring the ladder stop of %s %s", data.Symbol, data.PositionSide)
+	}
+}
+
+// applyCalendarGuard moves the stop to breakeven inside the window of a watched release.
+func (ts *TradingService) applyCalendarGuard(data *PositionData, stopPrice float64) float64 {
+	if data.CalendarEvent == "" {
+		return stopPrice
+	}
+	return moveStopToBreakeven(data, stopPrice, data.CalendarEvent)
+}
+
+// fetchCalendar downloads the economic calendar feed at url.
+func fetchCalendar(url string) ([]calendarEvent, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error creating economic calendar request: %w", err)
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching economic calendar: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("economic calendar returned error code: %d", resp.StatusCode)
+	}
+
+	var events []calendarEvent
+	if err := json.NewDecoder(resp.Body).Decode(&events); err != nil {
+		return nil, fmt.Errorf("error decoding economic calendar: %w", err)
+	}
+	return events, nil
+}
+
+// parseList splits a comma-separated list, trimming blanks.
+func parseList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"log"
+	"math"
+
+	"futures-guard/ladder"
+	"futures-guard/settings"
+)
+
+// Chart dimensions in pixels.
+const (
+	chartWidth  = 640
+	chartHeight = 360
+	chartMargin = 12
+)
+
+// Chart colors: candles, the entry, the stop loss, the take profit and the ladder
+// thresholds on a dark background.
+var (
+	chartBackground = color.RGBA{0x13, 0x17, 0x22, 0xff}
+	chartUp         = color.RGBA{0x26, 0xa6, 0x9a, 0xff}
+	chartDown       = color.RGBA{0xef, 0x53, 0x50, 0xff}
+	chartEntry      = color.RGBA{0x42, 0x8b, 0xf5, 0xff}
+	chartStop       = color.RGBA{0xff, 0x45, 0x45, 0xff}
+	chartTake       = color.RGBA{0x4c, 0xe0, 0x6e, 0xff}
+	chartLadder     = color.RGBA{0x9e, 0x9e, 0x9e, 0xff}
+)
+
+// chartLine is a horizontal level drawn across the chart.
+type chartLine struct {
+	Price  float64
+	Color  color.RGBA
+	Dashed bool
+}
+
+// positionChart renders the recent candles of data with its entry, stop loss,
+// take profit and ladder thresholds as a PNG image.
+func (ts *TradingService) positionChart(data *PositionData) ([]byte, error) {
+	candles, err := ts.getKlines(data.Symbol, ts.config().ChartInterval, ts.config().ChartCandles)
+	if err != nil {
+		return nil, err
+	}
+
+	lines := []chartLine{{Price: data.EntryPrice, Color: chartEntry}}
+	if data.StopPrice > 0 {
+		lines = append(lines, chartLine{Price: data.StopPrice, Color: chartStop})
+	}
+	if data.TakePrice > 0 {
+		lines = append(lines, chartLine{Price: data.TakePrice, Color: chartTake})
+	}
+	for _, level := range ts.stopLadder(data.Symbol).Levels {
+		offset := ts.lockPct(data, level.ProfitThreshold)
+		if data.IsShort {
+			offset = -offset
+		}
+		lines = append(lines, chartLine{Price: ladder.ScalePrice(data.EntryPrice, offset), Color: chartLadder, Dashed: true})
+	}
+	return renderChart(candles, lines)
+}
+
+// renderChart draws candles and lines as a PNG image. The price axis spans the
+// candles, the entry, stop and target; ladder lines outside of it are left out
+// so that distant thresholds do not flatten the candles.
+func renderChart(candles []Candle, lines []chartLine) ([]byte, error) {
+	if len(candles) == 0 {
+		return nil, fmt.Errorf("no candles to chart")
+	}
+
+	low, high := math.Inf(1), math.Inf(-1)
+	for _, c := range candles {
+		low, high = math.Min(low, c.Low), math.Max(high, c.High)
+	}
+	for _, line := range lines {
+		if !line.Dashed {
+			low, high = math.Min(low, line.Price), math.Max(high, line.Price)
+		}
+	}
+	if high <= low {
+		high, low = high*1.001, low*0.999
+	}
+	pad := (high - low) * 0.05
+	low, high = low-pad, high+pad
+
+	img := image.NewRGBA(image.Rect(0, 0, chartWidth, chartHeight))
+	draw.Draw(img, img.Bounds(), &image.Uniform{chartBackground}, image.Point{}, draw.Src)
+	y := func(price float64) int {
+		return chartMargin + int(math.Round((high-price)/(high-low)*float64(chartHeight-2*chartMargin)))
+	}
+
+	for _, line := range lines {
+		if line.Price < low || line.Price > high {
+			continue
+		}
+		row := y(line.Price)
+		for x := chartMargin; x < chartWidth-chartMargin; x++ {
+			if !line.Dashed || x/6%2 == 0 {
+				img.SetRGBA(x, row, line.Color)
+			}
+		}
+	}
+
+	slot := float64(chartWidth-2*chartMargin) / float64(len(candles))
+	body := max(1, int(slot*0.7))
+	for i, c := range candles {
+		col := chartUp
+		if c.Close < c.Open {
+			col = chartDown
+		}
+		left := chartMargin + int(float64(i)*slot)
+		center := left + body/2
+		fillRect(img, center, y(c.High), center+1, y(c.Low)+1, col)
+		top, bottom := y(math.Max(c.Open, c.Close)), y(math.Min(c.Open, c.Close))
+		fillRect(img, left, top, left+body, bottom+1, col)
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, fmt.Errorf("error encoding chart: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// fillRect paints the rectangle from (x0, y0) to (x1, y1), exclusive, in c.
+func fillRect(img *image.RGBA, x0, y0, x1, y1 int, c color.RGBA) {
+	draw.Draw(img, image.Rect(x0, y0, x1, y1), &image.Uniform{c}, image.Point{}, draw.Src)
+}
+
+// notifyPositionChart sends msg with a chart of data attached on the channels
+// that accept images, or msg alone when NOTIFY_CHART is off or the chart cannot
+// be drawn.
+func (ts *TradingService) notifyPositionChart(severity Severity, data *PositionData, msg string) {
+	if !ts.config().NotifyChart || ts.notifier == nil {
+		ts.notify(severity, msg)
+		return
+	}
+	chart, err := ts.positionChart(data)
+	if err != nil {
+		log.Printf("Warning: Unable to chart %s: %v", data.Symbol, err)
+	}
+	if err := ts.notifier.NotifyImage(severity, msg, chart); err != nil {
+		log.Printf("Error sending notification: %v", err)
+	}
+}
+
+// loadChartConfig reads the chart attachment settings from src.
+func loadChartConfig(src *settings.Settings, config *Config) {
+	config.ChartInterval = "15m"
+	config.ChartCandles = 60
+	config.NotifyChart = src.Bool("NOTIFY_CHART", config.NotifyChart)
+	if interval := src.String("CHART_INTERVAL"); interval != "" {
+		config.ChartInterval = interval
+	}
+	config.ChartCandles = src.Int("CHART_CANDLES", config.ChartCandles)
+	if config.ChartCandles < 2 || config.ChartCandles > klinePageLimit {
+		src.Fail("CHART_CANDLES", fmt.Errorf("must be between 2 and %d, got %d", klinePageLimit, config.ChartCandles))
+		config.ChartCandles = 60
+	}
+}
@@ -0,0 +1,543 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	binance "github.com/adshao/go-binance/v2/futures"
+	"github.com/spf13/cobra"
+)
+
+// defaultRunInterval is the cycle interval of the run command when RUN_INTERVAL is not set.
+const defaultRunInterval = time.Minute
+
+// newRootCommand builds the futures-guard command tree.
+func newRootCommand() *cobra.Command {
+	var configPath string
+
+	root := &cobra.Command{
+		Use:   "futures-guard",
+		Short: "Binance futures position manager with automated stop-loss and take-profit",
+		Long: "Futures Guard manages open Binance futures positions with a tiered stop-loss ladder and take-profit orders.\n" +
+			"Without a subcommand it processes positions once, or continuously when RUN_INTERVAL is set.",
+		SilenceUsage: true,
+		PersistentPreRun: func(cmd *cobra.Command, args []string) {
+			// The flag takes precedence over CONFIG_FILE, also for hot reloads
+			if configPath != "" {
+				os.Setenv("CONFIG_FILE", configPath)
+			}
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ts, err := newTradingServiceFromEnv()
+			if err != nil {
+				return err
+			}
+			if ts.config().RunInterval > 0 {
+				return runDaemon(ts)
+			}
+			return runOnce(ts)
+		},
+	}
+
+	root.PersistentFlags().StringVar(&configPath, "config", "", "env file to load the configuration from (overrides CONFIG_FILE)")
+	root.PersistentFlags().StringArrayVar(&configOverrides, "set", nil, "KEY=VALUE setting overriding the environment and the config file (repeatable)")
+
+	root.AddCommand(
+		newRunCommand(),
+		newOnceCommand(),
+		newStatusCommand(),
+		newCloseCommand(),
+		newCloseAllCommand(),
+		newReportCommand(),
+		newExportCommand(),
+		newStatsCommand(),
+		newSizeCommand(),
+		newOpenCommand(),
+		newBacktestCommand(),
+		newReplayCommand(),
+		newSecretsCommand(),
+		newControlCommand(),
+		newConfigCommand(),
+		newAuditCommand(),
+	)
+	return root
+}
+
+// newTradingServiceFromEnv loads the configuration and connects the trading service.
+func newTradingServiceFromEnv() (*TradingService, error) {
+	config, err := loadConfig()
+	if err != nil {
+		return nil, err
+	}
+	if err := checkConfig(config); err != nil {
+		return nil, err
+	}
+
+	exchange, err := setupExchange(config)
+	if err != nil {
+		return nil, fmt.Errorf("error connecting to %s API: %w", config.Exchange, err)
+	}
+
+	// Binance public market data backs the funding, kline and stream features on every exchange
+	client := binance.NewClient("", "")
+	if be, ok := exchange.(*binanceExchange); ok {
+		client = be.client
+	}
+
+	ts, err := NewTradingService(exchange, newBinanceClient(client, config), config)
+	if err != nil {
+		return nil, fmt.Errorf("error initializing trading service: %w", err)
+	}
+	return ts, nil
+}
+
+// startup runs the steps shared by every command that manages orders.
+func startup(ts *TradingService) {
+	log.Println("Starting Binance Futures Guard Bot")
+	if ts.config().ObserveOnly {
+		log.Println("OBSERVE_ONLY enabled: positions are analyzed and reported, orders are never placed or cancelled")
+	}
+
+	// A standby leaves orders alone; taking over as leader reconciles them
+	if ts.leader != nil {
+		ts.campaign()
+		if !ts.isLeader() {
+			log.Printf("Instance %s is on standby until the leader lease at %s expires", ts.leader.id, ts.config().LeaderLockFile)
+		}
+		return
+	}
+
+	// Repair live orders against the persisted state before the first cycle
+	if ts.config().ReconcileOnStartup && !ts.config().ObserveOnly {
+		if err := ts.reconcile(); err != nil {
+			log.Printf("Warning: State reconciliation failed: %v", err)
+		}
+	}
+}
+
+// runOnce processes all positions a single time.
+func runOnce(ts *TradingService) error {
+	startup(ts)
+	if err := ts.processPositions(); err != nil {
+		return fmt.Errorf("error processing positions: %w", err)
+	}
+	log.Println("Processing complete")
+	return nil
+}
+
+// runDaemon processes positions continuously until interrupted.
+func runDaemon(ts *TradingService) error {
+	ts.leader = newLeaderElection(*ts.config())
+	if ts.leader != nil {
+		defer ts.leader.resign()
+	}
+	startup(ts)
+	if ts.config().StartupReport {
+		ts.sendStartupReport()
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := ts.run(ctx); err != nil {
+		return fmt.Errorf("error running trading service: %w", err)
+	}
+	log.Println("Shutdown complete")
+	return nil
+}
+
+// newRunCommand builds the `run` command that keeps the guard running as a daemon.
+func newRunCommand() *cobra.Command {
+	var interval time.Duration
+
+	cmd := &cobra.Command{
+		Use:   "run",
+		Short: "Run continuously, processing positions at a fixed interval",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ts, err := newTradingServiceFromEnv()
+			if err != nil {
+				return err
+			}
+			config := *ts.config()
+			if cmd.Flags().Changed("interval") {
+				config.RunInterval = interval
+			}
+			if config.RunInterval <= 0 {
+				config.RunInterval = defaultRunInterval
+			}
+			ts.cfg.Store(&config)
+			return runDaemon(ts)
+		},
+	}
+	cmd.Flags().DurationVar(&interval, "interval", defaultRunInterval, "interval between processing cycles (overrides RUN_INTERVAL)")
+	return cmd
+}
+
+// newOnceCommand builds the `once` command that processes positions a single time.
+func newOnceCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "once",
+		Short: "Process all positions once and exit",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ts, err := newTradingServiceFromEnv()
+			if err != nil {
+				return err
+			}
+			return runOnce(ts)
+		},
+	}
+}
+
+// newStatusCommand builds the `status` command that prints open positions and their orders.
+func newStatusCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "status [symbol]",
+		Short: "Show open positions with their live stop-loss and take-profit orders",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ts, err := newTradingServiceFromEnv()
+			if err != nil {
+				return err
+			}
+
+			symbol := ""
+			if len(args) == 1 {
+				symbol = strings.ToUpper(args[0])
+			}
+			positions, err := ts.snapshotPositions(symbol)
+			if err != nil {
+				return err
+			}
+			fmt.Println(formatStatusTable(positions))
+			return nil
+		},
+	}
+}
+
+// newCloseCommand builds the `close` command that flattens a single symbol.
+func newCloseCommand() *cobra.Command {
+	var yes bool
+
+	cmd := &cobra.Command{
+		Use:   "close <symbol>",
+		Short: "Cancel all orders and close the positions of a symbol at market",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			symbol := strings.ToUpper(args[0])
+			if !yes && !confirm(fmt.Sprintf("Close all %s positions at market? Type the symbol to confirm: ", symbol), symbol) {
+				return fmt.Errorf("close of %s aborted", symbol)
+			}
+
+			ts, err := newTradingServiceFromEnv()
+			if err != nil {
+				return err
+			}
+			if err := ts.closeSymbol(symbol); err != nil {
+				return err
+			}
+
+			msg := fmt.Sprintf("🚪 Closed %s positions and cancelled its orders", symbol)
+			log.Println(msg)
+			ts.notify(SeverityWarning, msg)
+			return nil
+		},
+	}
+	cmd.Flags().BoolVarP(&yes, "yes", "y", false, "skip the confirmation prompt")
+	return cmd
+}
+
+// newCloseAllCommand builds the `close-all` command that flattens every managed
+// position, for fast exits during exchange incidents.
+func newCloseAllCommand() *cobra.Command {
+	var yes bool
+
+	cmd := &cobra.Command{
+		Use:   "close-all",
+		Short: "Cancel all open orders and close every position at market",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if !yes && !confirm("Close EVERY position at market? Type CLOSE ALL to confirm: ", "CLOSE ALL") {
+				return fmt.Errorf("close-all aborted")
+			}
+
+			ts, err := newTradingServiceFromEnv()
+			if err != nil {
+				return err
+			}
+			result, err := ts.closeAll()
+
+			msg := fmt.Sprintf("🚨 Emergency flatten: %s", result)
+			if err != nil {
+				msg += fmt.Sprintf("\n⚠️ Errors: %v", err)
+			}
+			ts.notify(SeverityCritical, msg)
+			return err
+		},
+	}
+	cmd.Flags().BoolVarP(&yes, "yes", "y", false, "skip the confirmation prompt")
+	return cmd
+}
+
+// newReportCommand builds the `report` command that prints position summaries.
+func newReportCommand() *cobra.Command {
+	var notify, daily bool
+
+	cmd := &cobra.Command{
+		Use:   "report",
+		Short: "Print a summary of every open position without modifying orders",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ts, err := newTradingServiceFromEnv()
+			if err != nil {
+				return err
+			}
+			if daily {
+				return ts.sendDailyReport()
+			}
+			positions, err := ts.snapshotPositions("")
+			if err != nil {
+				return err
+			}
+			if len(positions) == 0 {
+				fmt.Println("No open positions")
+				return nil
+			}
+
+			for _, data := range positions {
+				msg := ts.formatPositionMessage(data)
+				fmt.Println(msg)
+				if notify {
+					ts.notify(SeverityInfo, msg)
+				}
+			}
+			return nil
+		},
+	}
+	cmd.Flags().BoolVar(&notify, "notify", false, "also send the summaries to the notification channels")
+	cmd.Flags().BoolVar(&daily, "daily", false, "send the daily PnL digest for the past 24h")
+	return cmd
+}
+
+// newExportCommand builds the `export` command that writes the positions snapshot as CSV or JSON.
+func newExportCommand() *cobra.Command {
+	var format, output string
+
+	cmd := &cobra.Command{
+		Use:     "export [symbol]",
+		Short:   "Export entry, mark, SL/TP, risk/reward and potential P/L of open positions",
+		Example: "  futures-guard export --format csv --output positions.csv",
+		Args:    cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			format, err := parseExportFormat(format)
+			if err != nil {
+				return err
+			}
+			ts, err := newTradingServiceFromEnv()
+			if err != nil {
+				return err
+			}
+			symbol := ""
+			if len(args) == 1 {
+				symbol = strings.ToUpper(args[0])
+			}
+			positions, err := ts.snapshotPositions(symbol)
+			if err != nil {
+				return err
+			}
+
+			if output == "" || output == "-" {
+				return writePositionsExport(os.Stdout, format, positions)
+			}
+			file, err := os.Create(output)
+			if err != nil {
+				return fmt.Errorf("error creating %s: %w", output, err)
+			}
+			if err := writePositionsExport(file, format, positions); err != nil {
+				file.Close()
+				return fmt.Errorf("error writing %s: %w", output, err)
+			}
+			if err := file.Close(); err != nil {
+				return fmt.Errorf("error writing %s: %w", output, err)
+			}
+			log.Printf("Exported %d positions to %s", len(positions), output)
+			return nil
+		},
+	}
+	cmd.Flags().StringVarP(&format, "format", "f", exportFormatCSV, "output format: csv or json")
+	cmd.Flags().StringVarP(&output, "output", "o", "", "file to write (default stdout)")
+	return cmd
+}
+
+// newStatsCommand builds the `stats` command that analyzes the recorded closed trades.
+func newStatsCommand() *cobra.Command {
+	var from, to, tag string
+	var days int
+
+	cmd := &cobra.Command{
+		Use:     "stats [symbol]",
+		Short:   "Print win rate, expectancy, average R and profit factor of the recorded closed trades",
+		Example: "  futures-guard stats --days 30\n  futures-guard stats BTCUSDT --from 2025-01-01 --to 2025-03-31\n  futures-guard stats --tag trend",
+		Args:    cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			filter, err := parseStatsRange(from, to, days, time.Now())
+			if err != nil {
+				return err
+			}
+			if len(args) == 1 {
+				filter.Symbol = strings.ToUpper(args[0])
+			}
+			filter.Tag = normalizeTag(tag)
+
+			// Statistics only need the persisted state, not an exchange connection
+			config, err := loadConfig()
+			if err != nil {
+				return err
+			}
+			store, err := openStateStore(config)
+			if err != nil {
+				return err
+			}
+			state, err := store.Load()
+			if err != nil {
+				return err
+			}
+			total, bySymbol, byTag := computeStats(state.Trades, filter)
+			fmt.Print(formatStats(total, bySymbol, byTag, filter))
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&from, "from", "", "first day of the range, YYYY-MM-DD (UTC)")
+	cmd.Flags().StringVar(&to, "to", "", "last day of the range, YYYY-MM-DD (UTC)")
+	cmd.Flags().IntVar(&days, "days", 0, "only include trades closed in the last N days")
+	cmd.Flags().StringVar(&tag, "tag", "", "only include trades of this strategy tag (\"untagged\" for the others)")
+	return cmd
+}
+
+// newReplayCommand builds the `replay` command that feeds a recorded session back
+// through the guard and checks its orders against the recording.
+func newReplayCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:     "replay <session>",
+		Short:   "Replay a session recorded with RECORD_SESSION and compare the orders placed",
+		Example: "  futures-guard replay session.jsonl",
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			config, err := loadConfig()
+			if err != nil {
+				return err
+			}
+			result, err := replaySession(args[0], config)
+			if err != nil {
+				return err
+			}
+			fmt.Println(formatReplayResult(result))
+			for _, divergence := range result.Divergences {
+				fmt.Println("  " + divergence)
+			}
+			if len(result.Divergences) > 0 {
+				return fmt.Errorf("replay diverged from the recording in %d places", len(result.Divergences))
+			}
+			return nil
+		},
+	}
+}
+
+// newSizeCommand builds the `size` command for risk-based position sizing.
+func newSizeCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:     "size <symbol> <long|short> <risk%>",
+		Short:   "Compute the quantity that risks a share of equity at the default stop-loss",
+		Example: "  futures-guard size BTCUSDT long 1%",
+		Args:    cobra.ExactArgs(3),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ts, err := newTradingServiceFromEnv()
+			if err != nil {
+				return err
+			}
+			return runSizeCommand(ts, args)
+		},
+	}
+}
+
+// newOpenCommand builds the `open` command that enters a position together with
+// its protective SL/TP bracket.
+func newOpenCommand() *cobra.Command {
+	var (
+		quantity, limit float64
+		risk, side, tag string
+		yes             bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "open <symbol> <long|short>",
+		Short: "Open a position at market or limit with its SL/TP bracket in one batch",
+		Example: "  futures-guard open BTCUSDT long --risk 1%\n" +
+			"  futures-guard open ETHUSDT short --quantity 0.5 --limit 3450",
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			isLong, err := parseDirection(args[1])
+			if err != nil {
+				return err
+			}
+			req := entryRequest{
+				Symbol:       strings.ToUpper(args[0]),
+				PositionSide: strings.ToUpper(side),
+				IsLong:       isLong,
+				Quantity:     quantity,
+				LimitPrice:   limit,
+				Tag:          tag,
+			}
+			if risk != "" {
+				if req.RiskPct, err = parseRiskPercent(risk); err != nil {
+					return err
+				}
+			}
+			if (req.Quantity > 0) == (req.RiskPct > 0) {
+				return fmt.Errorf("exactly one of --quantity or --risk is required")
+			}
+			if !yes && !confirm(fmt.Sprintf("Open %s %s? Type the symbol to confirm: ", args[1], req.Symbol), req.Symbol) {
+				return fmt.Errorf("open of %s aborted", req.Symbol)
+			}
+
+			ts, err := newTradingServiceFromEnv()
+			if err != nil {
+				return err
+			}
+			data, err := ts.openPosition(req)
+			if err != nil {
+				return err
+			}
+
+			msg := formatEntry(data, limit > 0)
+			fmt.Println(msg)
+			ts.notify(SeverityInfo, msg)
+			return nil
+		},
+	}
+	cmd.Flags().Float64VarP(&quantity, "quantity", "q", 0, "position size")
+	cmd.Flags().StringVarP(&risk, "risk", "r", "", "size the position to risk this share of equity at the default stop, e.g. 1%")
+	cmd.Flags().Float64Var(&limit, "limit", 0, "limit entry price (market entry when omitted)")
+	cmd.Flags().StringVar(&side, "position-side", "BOTH", "position side in hedge mode: LONG or SHORT")
+	cmd.Flags().StringVar(&tag, "tag", "", "label the position with a strategy tag")
+	cmd.Flags().BoolVarP(&yes, "yes", "y", false, "skip the confirmation prompt")
+	return cmd
+}
+
+// confirm prompts on stdin and reports whether the user typed the expected answer.
+func confirm(prompt, expected string) bool {
+	fmt.Print(prompt)
+	answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(strings.TrimSpace(answer), expected)
+}
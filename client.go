@@ -0,0 +1,349 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	binance "github.com/adshao/go-binance/v2/futures"
+)
+
+// ExchangeClient is the Binance futures market data and account history the guard
+// reads besides the Exchange calls: candles, mark prices, funding, income, trades,
+// order history and the account. TradingService depends on this narrow interface
+// rather than on *binance.Client, so its decisions can run against memoryClient.
+type ExchangeClient interface {
+	// Klines returns the candles of symbol at interval opening between start and
+	// end, or the most recent ones when start is zero, at most limit of them.
+	Klines(ctx context.Context, symbol, interval string, start, end time.Time, limit int) ([]*binance.Kline, error)
+	// PremiumIndex returns the mark price and funding rates of symbol.
+	PremiumIndex(ctx context.Context, symbol string) ([]*binance.PremiumIndex, error)
+	// FundingRates returns the last limit settled funding rates of symbol.
+	FundingRates(ctx context.Context, symbol string, limit int) ([]*binance.FundingRate, error)
+	// IncomeHistory returns the account income matching query, oldest first.
+	IncomeHistory(ctx context.Context, query IncomeQuery) ([]*binance.IncomeHistory, error)
+	// AccountTrades returns the last limit trades of symbol.
+	AccountTrades(ctx context.Context, symbol string, limit int) ([]*binance.AccountTrade, error)
+	// Orders returns the last limit orders of symbol, open, filled or cancelled.
+	Orders(ctx context.Context, symbol string, limit int) ([]*binance.Order, error)
+	// Account returns the balances and positions of the account.
+	Account(ctx context.Context) (*binance.Account, error)
+	// Depth returns the top limit levels of each side of the order book of symbol.
+	Depth(ctx context.Context, symbol string, limit int) (*binance.DepthResponse, error)
+	// PositionRisk returns the risk of the positions of symbol, including their
+	// auto-deleverage quantile and maintenance margin.
+	PositionRisk(ctx context.Context, symbol string) ([]*binance.PositionRiskV3, error)
+	// AddPositionMargin adds amount of the margin asset to an isolated position.
+	AddPositionMargin(ctx context.Context, symbol, positionSide string, amount float64) error
+	// ChangeLeverage sets the leverage of symbol.
+	ChangeLeverage(ctx context.Context, symbol string, leverage int) error
+	// ChangeMarginType switches symbol to isolated or crossed margin.
+	ChangeMarginType(ctx context.Context, symbol, marginType string) error
+}
+
+// IncomeQuery selects account income entries. Empty fields do not filter.
+type IncomeQuery struct {
+	Symbol     string
+	IncomeType string
+	Start, End time.Time
+	Limit      int
+}
+
+// binanceClient implements ExchangeClient with a Binance futures client.
+type binanceClient struct {
+	client *binance.Client
+	config Config
+}
+
+// newBinanceClient wraps a Binance futures client.
+func newBinanceClient(client *binance.Client, config Config) *binanceClient {
+	return &binanceClient{client: client, config: config}
+}
+
+// signed returns the request options for signed requests.
+func (c *binanceClient) signed() []binance.RequestOption {
+	return []binance.RequestOption{recvWindowOption(c.config)}
+}
+
+// Klines implements ExchangeClient.
+func (c *binanceClient) Klines(ctx context.Context, symbol, interval string, start, end time.Time, limit int) ([]*binance.Kline, error) {
+	service := c.client.NewKlinesService().Symbol(symbol).Interval(interval).Limit(limit)
+	if !start.IsZero() {
+		service.StartTime(start.UnixMilli())
+	}
+	if !end.IsZero() {
+		service.EndTime(end.UnixMilli())
+	}
+	return service.Do(ctx)
+}
+
+// PremiumIndex implements ExchangeClient.
+func (c *binanceClient) PremiumIndex(ctx context.Context, symbol string) ([]*binance.PremiumIndex, error) {
+	return c.client.NewPremiumIndexService().Symbol(symbol).Do(ctx)
+}
+
+// FundingRates implements ExchangeClient.
+func (c *binanceClient) FundingRates(ctx context.Context, symbol string, limit int) ([]*binance.FundingRate, error) {
+	return c.client.NewFundingRateService().Symbol(symbol).Limit(limit).Do(ctx)
+}
+
+// IncomeHistory implements ExchangeClient.
+func (c *binanceClient) IncomeHistory(ctx context.Context, query IncomeQuery) ([]*binance.IncomeHistory, error) {
+	service := c.client.NewGetIncomeHistoryService()
+	if query.Symbol != "" {
+		service.Symbol(query.Symbol)
+	}
+	if query.IncomeType != "" {
+		service.IncomeType(query.IncomeType)
+	}
+	if !query.Start.IsZero() {
+		service.StartTime(query.Start.UnixMilli())
+	}
+	if !query.End.IsZero() {
+		service.EndTime(query.End.UnixMilli())
+	}
+	if query.Limit > 0 {
+		service.Limit(int64(query.Limit))
+	}
+	return service.Do(ctx, c.signed()...)
+}
+
+// AccountTrades implements ExchangeClient.
+func (c *binanceClient) AccountTrades(ctx context.Context, symbol string, limit int) ([]*binance.AccountTrade, error) {
+	return c.client.NewListAccountTradeService().Symbol(symbol).Limit(limit).Do(ctx, c.signed()...)
+}
+
+// Orders implements ExchangeClient.
+func (c *binanceClient) Orders(ctx context.Context, symbol string, limit int) ([]*binance.Order, error) {
+	// Without a time range the most recent orders are returned
+	return c.client.NewListOrdersService().Symbol(symbol).Limit(limit).Do(ctx, c.signed()...)
+}
+
+// Account implements ExchangeClient.
+func (c *binanceClient) Account(ctx context.Context) (*binance.Account, error) {
+	return c.client.NewGetAccountService().Do(ctx, c.signed()...)
+}
+
+// Depth implements ExchangeClient.
+func (c *binanceClient) Depth(ctx context.Context, symbol string, limit int) (*binance.DepthResponse, error) {
+	return c.client.NewDepthService().Symbol(symbol).Limit(limit).Do(ctx)
+}
+
+// PositionRisk implements ExchangeClient.
+func (c *binanceClient) PositionRisk(ctx context.Context, symbol string) ([]*binance.PositionRiskV3, error) {
+	return c.client.NewGetPositionRiskV3Service().Symbol(symbol).Do(ctx, c.signed()...)
+}
+
+// AddPositionMargin implements ExchangeClient.
+func (c *binanceClient) AddPositionMargin(ctx context.Context, symbol, positionSide string, amount float64) error {
+	return c.client.NewUpdatePositionMarginService().Symbol(symbol).
+		PositionSide(binance.PositionSideType(positionSide)).
+		Amount(strconv.FormatFloat(amount, 'f', -1, 64)).Type(1).Do(ctx, c.signed()...)
+}
+
+// ChangeLeverage implements ExchangeClient.
+func (c *binanceClient) ChangeLeverage(ctx context.Context, symbol string, leverage int) error {
+	_, err := c.client.NewChangeLeverageService().Symbol(symbol).Leverage(leverage).Do(ctx, c.signed()...)
+	return err
+}
+
+// ChangeMarginType implements ExchangeClient.
+func (c *binanceClient) ChangeMarginType(ctx context.Context, symbol, marginType string) error {
+	return c.client.NewChangeMarginTypeService().Symbol(symbol).
+		MarginType(binance.MarginType(strings.ToUpper(marginType))).Do(ctx, c.signed()...)
+}
+
+// memoryClient is an in-memory ExchangeClient serving fixed market data and
+// account history, for unit tests of the SL/TP decisions and offline replays.
+// Missing data is reported as an error, like an unknown symbol on Binance.
+type memoryClient struct {
+	mu sync.Mutex
+
+	Candles        map[string][]*binance.Kline // By symbol and interval, e.g. "BTCUSDT 1h"
+	PremiumIndexes map[string]*binance.PremiumIndex
+	FundingHistory map[string][]*binance.FundingRate
+	Income         []*binance.IncomeHistory
+	TradeHistory   map[string][]*binance.AccountTrade
+	OrderHistory   map[string][]*binance.Order
+	AccountInfo    *binance.Account
+	Books          map[string]*binance.DepthResponse
+	Risks          map[string][]*binance.PositionRiskV3
+
+	// Leverages and MarginTypes hold the settings changed through the client, and
+	// AddedMargin the margin added to isolated positions, by symbol.
+	Leverages   map[string]int
+	MarginTypes map[string]string
+	AddedMargin map[string]float64
+}
+
+// newMemoryClient returns an empty memoryClient.
+func newMemoryClient() *memoryClient {
+	return &memoryClient{
+		Candles:        make(map[string][]*binance.Kline),
+		PremiumIndexes: make(map[string]*binance.PremiumIndex),
+		FundingHistory: make(map[string][]*binance.FundingRate),
+		TradeHistory:   make(map[string][]*binance.AccountTrade),
+		OrderHistory:   make(map[string][]*binance.Order),
+		Books:          make(map[string]*binance.DepthResponse),
+		Risks:          make(map[string][]*binance.PositionRiskV3),
+		Leverages:      make(map[string]int),
+		MarginTypes:    make(map[string]string),
+		AddedMargin:    make(map[string]float64),
+	}
+}
+
+// candleKey identifies the candles of symbol at interval in memoryClient.Candles.
+func candleKey(symbol, interval string) string {
+	return symbol + " " + interval
+}
+
+// lastN returns the last n items of items, all of them when n is not positive.
+func lastN[T any](items []T, n int) []T {
+	if n > 0 && len(items) > n {
+		return items[len(items)-n:]
+	}
+	return items
+}
+
+// Klines implements ExchangeClient.
+func (c *memoryClient) Klines(ctx context.Context, symbol, interval string, start, end time.Time, limit int) ([]*binance.Kline, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	klines, ok := c.Candles[candleKey(symbol, interval)]
+	if !ok {
+		return nil, fmt.Errorf("no %s klines for %s", interval, symbol)
+	}
+	if start.IsZero() {
+		return lastN(klines, limit), nil
+	}
+	var selected []*binance.Kline
+	for _, k := range klines {
+		if k.OpenTime < start.UnixMilli() || (!end.IsZero() && k.OpenTime > end.UnixMilli()) {
+			continue
+		}
+		if limit > 0 && len(selected) == limit {
+			break
+		}
+		selected = append(selected, k)
+	}
+	return selected, nil
+}
+
+// PremiumIndex implements ExchangeClient.
+func (c *memoryClient) PremiumIndex(ctx context.Context, symbol string) ([]*binance.PremiumIndex, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	premium, ok := c.PremiumIndexes[symbol]
+	if !ok {
+		return nil, fmt.Errorf("no premium index for %s", symbol)
+	}
+	return []*binance.PremiumIndex{premium}, nil
+}
+
+// FundingRates implements ExchangeClient.
+func (c *memoryClient) FundingRates(ctx context.Context, symbol string, limit int) ([]*binance.FundingRate, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return lastN(c.FundingHistory[symbol], limit), nil
+}
+
+// IncomeHistory implements ExchangeClient.
+func (c *memoryClient) IncomeHistory(ctx context.Context, query IncomeQuery) ([]*binance.IncomeHistory, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var incomes []*binance.IncomeHistory
+	for _, income := range c.Income {
+		switch {
+		case query.Symbol != "" && income.Symbol != query.Symbol,
+			query.IncomeType != "" && income.IncomeType != query.IncomeType,
+			!query.Start.IsZero() && income.Time < query.Start.UnixMilli(),
+			!query.End.IsZero() && income.Time > query.End.UnixMilli():
+			continue
+		}
+		incomes = append(incomes, income)
+	}
+	sort.SliceStable(incomes, func(i, j int) bool { return incomes[i].Time < incomes[j].Time })
+	if query.Limit > 0 && len(incomes) > query.Limit {
+		incomes = incomes[:query.Limit]
+	}
+	return incomes, nil
+}
+
+// AccountTrades implements ExchangeClient.
+func (c *memoryClient) AccountTrades(ctx context.Context, symbol string, limit int) ([]*binance.AccountTrade, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return lastN(c.TradeHistory[symbol], limit), nil
+}
+
+// Orders implements ExchangeClient.
+func (c *memoryClient) Orders(ctx context.Context, symbol string, limit int) ([]*binance.Order, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return lastN(c.OrderHistory[symbol], limit), nil
+}
+
+// Account implements ExchangeClient.
+func (c *memoryClient) Account(ctx context.Context) (*binance.Account, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.AccountInfo == nil {
+		return nil, fmt.Errorf("no account information")
+	}
+	return c.AccountInfo, nil
+}
+
+// Depth implements ExchangeClient.
+func (c *memoryClient) Depth(ctx context.Context, symbol string, limit int) (*binance.DepthResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	book, ok := c.Books[symbol]
+	if !ok {
+		return nil, fmt.Errorf("no order book for %s", symbol)
+	}
+	bids, asks := book.Bids, book.Asks
+	if limit > 0 {
+		bids, asks = bids[:min(limit, len(bids))], asks[:min(limit, len(asks))]
+	}
+	return &binance.DepthResponse{LastUpdateID: book.LastUpdateID, Bids: bids, Asks: asks}, nil
+}
+
+// PositionRisk implements ExchangeClient.
+func (c *memoryClient) PositionRisk(ctx context.Context, symbol string) ([]*binance.PositionRiskV3, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.Risks[symbol], nil
+}
+
+// AddPositionMargin implements ExchangeClient.
+func (c *memoryClient) AddPositionMargin(ctx context.Context, symbol, positionSide string, amount float64) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.AddedMargin[symbol] += amount
+	return nil
+}
+
+// ChangeLeverage implements ExchangeClient.
+func (c *memoryClient) ChangeLeverage(ctx context.Context, symbol string, leverage int) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.Leverages[symbol] = leverage
+	return nil
+}
+
+// ChangeMarginType implements ExchangeClient.
+func (c *memoryClient) ChangeMarginType(ctx context.Context, symbol, marginType string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.MarginTypes[symbol] = marginType
+	return nil
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"strings"
+)
+
+// Client order IDs of the orders placed by the bot look like
+// fg-SL-BTCUSDT-LONG-3-9f2c41ab: the prefix, the order kind, the position, the
+// ladder stage and a random nonce. The readable part lets the bot recognize its
+// own orders after a restart; the nonce makes every placement unique, as Bybit
+// rejects an ID used before even when its order is gone. A retry reuses the ID of
+// the attempt it retries, so it adopts that order instead of opening a second one.
+const (
+	clientOrderPrefix = "fg"
+	clientOrderKindSL = "SL"
+	clientOrderKindTP = "TP"
+	// clientOrderKindClose marks the market orders reducing or closing a position.
+	clientOrderKindClose = "CL"
+
+	// clientOrderIDMaxLen is the longest client order ID Binance and Bybit accept.
+	clientOrderIDMaxLen = 36
+	// clientOrderIDMaxAlnumLen is the longest ID OKX accepts, without hyphens.
+	clientOrderIDMaxAlnumLen = 32
+	// clientOrderNonceLen is the number of random bytes ending each ID.
+	clientOrderNonceLen = 4
+)
+
+// clientOrderID returns a new client order ID for the kind order of data at its
+// current ladder stage.
+func (ts *TradingService) clientOrderID(kind string, data *PositionData) string {
+	stage := ts.ladderStage(data) + 1
+	return uniqueClientOrderID(fmt.Sprintf("%s-%s-%s-%s-%d", clientOrderPrefix, kind, data.Symbol, data.PositionSide, stage))
+}
+
+// uniqueClientOrderID appends a random nonce to base, shortening base so the ID
+// fits every exchange without losing the nonce.
+func uniqueClientOrderID(base string) string {
+	nonce := make([]byte, clientOrderNonceLen)
+	rand.Read(nonce)
+	suffix := hex.EncodeToString(nonce)
+	for len(base)+1+len(suffix) > clientOrderIDMaxLen ||
+		len(strings.ReplaceAll(base, "-", ""))+len(suffix) > clientOrderIDMaxAlnumLen {
+		base = base[:len(base)-1]
+	}
+	return strings.TrimSuffix(base, "-") + "-" + suffix
+}
+
+// isBotOrder reports whether order was placed by the bot, judging by its client
+// order ID. Hyphens are ignored because OKX only accepts alphanumeric IDs.
+func isBotOrder(order *Order) bool {
+	id := strings.ReplaceAll(order.ClientOrderID, "-", "")
+	return strings.HasPrefix(id, clientOrderPrefix+clientOrderKindSL) ||
+		strings.HasPrefix(id, clientOrderPrefix+clientOrderKindTP)
+}
+
+// ownsOrder reports whether the bot may cancel or replace order: any order by
+// default, only the orders it placed itself with PROTECT_ONLY_BOT_ORDERS.
+func (ts *TradingService) ownsOrder(order *Order) bool {
+	return !ts.config().ProtectOnlyBotOrders || isBotOrder(order)
+}
+
+// placeOrder submits req. When the placement fails, for example on a timeout after
+// the exchange accepted it, an open order already carrying req's client order ID
+// and price is adopted instead of reporting an error, so a retry never duplicates it.
+func (ts *TradingService) placeOrder(ctx context.Context, req OrderRequest) (*Order, error) {
+	order, err := ts.exchange().CreateOrder(ctx, req)
+	if err == nil || req.ClientOrderID == "" {
+		return order, err
+	}
+	if existing := ts.findClientOrder(ctx, req); existing != nil {
+		log.Printf("Order %s for %s is already open (%v), adopting it", req.ClientOrderID, req.Symbol, err)
+		return existing, nil
+	}
+	return nil, err
+}
+
+// findClientOrder returns the open order matching req's client order ID and price.
+func (ts *TradingService) findClientOrder(ctx context.Context, req OrderRequest) *Order {
+	// The failed attempt may have been placed after the cached listing
+	ts.orderCache().invalidate(req.Symbol)
+	openOrders, err := ts.exchange().OpenOrders(ctx, req.Symbol)
+	if err != nil {
+		return nil
+	}
+
+	id := strings.ReplaceAll(req.ClientOrderID, "-", "")
+	for _, order := range openOrders {
+		if strings.ReplaceAll(order.ClientOrderID, "-", "") == id &&
+			order.StopPrice == parseFloatOrZero(req.StopPrice) {
+			return order
+		}
+	}
+	return nil
+}
@@ -0,0 +1,295 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	binance "github.com/adshao/go-binance/v2/futures"
+)
+
+// Reasons a position that disappeared was closed for.
+const (
+	closeReasonStopLoss    = "stop loss hit"
+	closeReasonTakeProfit  = "take profit hit"
+	closeReasonLiquidation = "liquidated"
+	closeReasonADL         = "auto-deleveraged"
+	closeReasonBot         = "closed by the bot"
+	closeReasonManual      = "closed manually"
+	closeReasonUnknown     = "closed"
+)
+
+// Client order ID prefixes of the orders Binance places when it liquidates or
+// auto-deleverages a position.
+const (
+	liquidationClientPrefix = "autoclose-"
+	adlClientPrefix         = "adl_autoclose"
+)
+
+// closeHistoryLimit is the number of recent orders searched for the closing order.
+const closeHistoryLimit = 100
+
+// closeReport summarizes a position that is no longer open.
+type closeReport struct {
+	Position     *PositionData // Last processed snapshot
+	Reason       string
+	RealizedPnL  float64
+	Fees         float64 // Commissions and funding, negative when paid
+	Estimated    bool    // PnL estimated from the last mark price, without income history
+	Held         time.Duration
+	MaxProfitPct float64
+	MinProfitPct float64
+	Risk         float64 // Initial risk R, zero when unknown
+	Notes        []JournalNote
+}
+
+// recordPosition keeps the last snapshot of a processed position and its maximum
+// favorable and adverse excursions, for the report sent when it closes.
+func (ts *TradingService) recordPosition(data *PositionData) {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	ts.lastPositions[trackedKey(data.Symbol, data.PositionSide)] = data
+	st := ts.orderState(data.Symbol, data.PositionSide)
+	if st.OpenedAt.IsZero() {
+		st.OpenedAt = time.Now()
+		st.MaxProfitPct, st.MinProfitPct = data.CurrentProfitPct, data.CurrentProfitPct
+	}
+	if !data.OpenedAt.IsZero() && data.OpenedAt.Before(st.OpenedAt) {
+		st.OpenedAt = data.OpenedAt
+	}
+	recordExcursion(st, data.CurrentProfitPct)
+}
+
+// recordExcursion widens the recorded excursions of a position to profitPct.
+func recordExcursion(st *OrderState, profitPct float64) {
+	st.MaxProfitPct = math.Max(st.MaxProfitPct, profitPct)
+	st.MinProfitPct = math.Min(st.MinProfitPct, profitPct)
+}
+
+// detectClosedPositions reports the positions processed earlier that are no longer
+// among positions. Reports are built in the background, as they query the history.
+func (ts *TradingService) detectClosedPositions(positions []*Position) {
+	open := make(map[string]bool, len(positions))
+	for _, position := range positions {
+		if position.PositionAmt != 0 {
+			open[trackedKey(position.Symbol, position.PositionSide)] = true
+		}
+	}
+
+	ts.mu.Lock()
+	var reports []*closeReport
+	for key, data := range ts.lastPositions {
+		if open[key] {
+			continue
+		}
+		delete(ts.lastPositions, key)
+
+		report := &closeReport{Position: data}
+		if st, ok := ts.state.Orders[key]; ok && !st.OpenedAt.IsZero() {
+			report.Held = time.Since(st.OpenedAt)
+			report.MaxProfitPct, report.MinProfitPct = st.MaxProfitPct, st.MinProfitPct
+			st.OpenedAt, st.MaxProfitPct, st.MinProfitPct = time.Time{}, 0, 0
+		}
+		if st, ok := ts.state.Orders[key]; ok {
+			st.RiskDistance, st.PeakProfitPct, st.PeakEntryPrice = 0, 0, 0
+			st.ScaleOutStage, st.Confirmation = 0, StageConfirmation{}
+			st.Tag, st.TagChecked = "", false
+			report.Notes, st.Notes = st.Notes, nil
+		}
+		if st, ok := ts.state.Orders[key]; ok && st.InitialRisk > 0 {
+			report.Risk = st.InitialRisk
+		} else {
+			report.Risk = ts.initialRisk(data)
+		}
+		reports = append(reports, report)
+	}
+	ts.mu.Unlock()
+
+	for _, report := range reports {
+		go ts.sendCloseReport(report)
+	}
+}
+
+// sendCloseReport determines why and with what result a position closed and sends
+// the final report through the notification channels.
+func (ts *TradingService) sendCloseReport(report *closeReport) {
+	data := report.Position
+	since := time.Now().Add(-report.Held)
+	if report.Held == 0 {
+		since = time.Now().Add(-24 * time.Hour)
+	}
+
+	report.Reason = closeReasonUnknown
+	if ts.exchange().Name() == exchangeBinance {
+		if reason, err := ts.closeReason(data, since); err != nil {
+			log.Printf("Warning: %v", err)
+		} else {
+			report.Reason = reason
+		}
+		if err := ts.closeIncome(report, since); err != nil {
+			log.Printf("Warning: %v", err)
+			report.Estimated = true
+		}
+	} else {
+		report.Estimated = true
+	}
+	if report.Estimated {
+		report.RealizedPnL = positionPnL(data, data.MarkPrice)
+	}
+
+	ts.recordTrade(report)
+
+	msg := formatCloseReport(report)
+	log.Println(msg)
+	ts.notify(SeverityInfo, msg)
+	if report.Reason != closeReasonBot {
+		// The bot publishes its own closes as they happen
+		ts.publish(EventPositionClosed, data, Event{Price: data.MarkPrice, Reason: report.Reason})
+	}
+}
+
+// recordTrade appends the outcome of a closed position to the persisted trades.
+func (ts *TradingService) recordTrade(report *closeReport) {
+	data := report.Position
+	trade := &TradeRecord{
+		Symbol:       data.Symbol,
+		PositionSide: data.PositionSide,
+		IsLong:       data.IsLong,
+		ClosedAt:     time.Now().UTC(),
+		EntryPrice:   data.EntryPrice,
+		ExitPrice:    data.MarkPrice,
+		Quantity:     data.AbsAmt,
+		Leverage:     data.Leverage,
+		Reason:       report.Reason,
+		RealizedPnL:  report.RealizedPnL,
+		Fees:         report.Fees,
+		PnLAsset:     data.PnLAsset,
+		Estimated:    report.Estimated,
+		Risk:         report.Risk,
+		ExitStage:    ts.ladderStage(data),
+		MaxProfitPct: report.MaxProfitPct,
+		MinProfitPct: report.MinProfitPct,
+		Tag:          data.Tag,
+		Notes:        report.Notes,
+	}
+	if report.Held > 0 {
+		trade.OpenedAt = trade.ClosedAt.Add(-report.Held)
+	}
+
+	ts.mu.Lock()
+	ts.state.Trades = append(ts.state.Trades, trade)
+	ts.mu.Unlock()
+	ts.saveState()
+}
+
+// closeReason finds the latest filled order that closed the position and derives
+// the reason from its type and client order ID.
+func (ts *TradingService) closeReason(data *PositionData, since time.Time) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+
+	orders, err := ts.client().Orders(ctx, data.Symbol, closeHistoryLimit)
+	if err != nil {
+		return "", fmt.Errorf("error fetching order history for %s: %w", data.Symbol, err)
+	}
+
+	var closing *binance.Order
+	for _, order := range orders {
+		if order.Status != binance.OrderStatusTypeFilled || string(order.Side) != closeSide(data) ||
+			order.UpdateTime < since.UnixMilli() {
+			continue
+		}
+		if data.PositionSide != "BOTH" && string(order.PositionSide) != data.PositionSide {
+			continue
+		}
+		if closing == nil || order.UpdateTime > closing.UpdateTime {
+			closing = order
+		}
+	}
+	if closing == nil {
+		return closeReasonUnknown, nil
+	}
+
+	switch {
+	case strings.HasPrefix(closing.ClientOrderID, liquidationClientPrefix):
+		return closeReasonLiquidation, nil
+	case strings.HasPrefix(closing.ClientOrderID, adlClientPrefix):
+		return closeReasonADL, nil
+	case closing.OrigType == binance.OrderTypeStopMarket || closing.OrigType == binance.OrderTypeStop:
+		return closeReasonStopLoss, nil
+	case closing.OrigType == binance.OrderTypeTakeProfitMarket || closing.OrigType == binance.OrderTypeTakeProfit:
+		return closeReasonTakeProfit, nil
+	case strings.HasPrefix(strings.ReplaceAll(closing.ClientOrderID, "-", ""), clientOrderPrefix):
+		return closeReasonBot, nil
+	default:
+		return closeReasonManual, nil
+	}
+}
+
+// closeIncome adds up the realized PnL, commissions and funding of the position's
+// symbol since it opened.
+func (ts *TradingService) closeIncome(report *closeReport, since time.Time) error {
+	incomes, err := ts.getIncomeHistory(since, time.Now())
+	if err != nil {
+		return err
+	}
+	for _, income := range incomes {
+		if income.Symbol != report.Position.Symbol {
+			continue
+		}
+		amount, err := strconv.ParseFloat(income.Income, 64)
+		if err != nil {
+			continue
+		}
+		switch income.IncomeType {
+		case realizedPnLIncomeType:
+			report.RealizedPnL += amount
+		case commissionIncomeType, fundingIncomeType:
+			report.Fees += amount
+		}
+	}
+	return nil
+}
+
+// formatCloseReport creates the final report of a closed position.
+func formatCloseReport(report *closeReport) string {
+	data := report.Position
+	icon := "🏁"
+	if report.RealizedPnL+report.Fees < 0 {
+		icon = "🔻"
+	}
+
+	pnl := fmt.Sprintf("%.4f %s", report.RealizedPnL, data.PnLAsset)
+	if report.Estimated {
+		pnl += " (estimated at the last mark price)"
+	} else {
+		pnl += fmt.Sprintf(" (fees and funding %.4f)", report.Fees)
+	}
+	held := "unknown"
+	if report.Held > 0 {
+		held = report.Held.Round(time.Minute).String()
+	}
+
+	msg := fmt.Sprintf(`%s %s %s %s
+💰 Realized PnL: %s
+⏱ Held: %s
+📈 MFE: %.2f%% | 📉 MAE: %.2f%%
+Entry: %.8f | Last mark: %.8f`,
+		icon, data.Symbol, data.PositionSide, report.Reason,
+		pnl,
+		held,
+		report.MaxProfitPct, report.MinProfitPct,
+		data.EntryPrice, data.MarkPrice)
+	if data.Tag != "" {
+		msg += "\n🏷️ Strategy: " + data.Tag
+	}
+	for _, note := range report.Notes {
+		msg += fmt.Sprintf("\n📝 %s: %s", note.At.Format(time.DateTime), note.Text)
+	}
+	return msg
+}
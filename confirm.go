@@ -0,0 +1,130 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"futures-guard/ladder"
+)
+
+// StageConfirmation is the ladder stage confirmation of a position. The stages
+// are stored one past the stage, zero before any.
+type StageConfirmation struct {
+	EntryPrice    float64 `json:"entryPrice,omitempty"`
+	Stage         int     `json:"stage,omitempty"`
+	PendingStage  int     `json:"pendingStage,omitempty"`
+	PendingCycles int     `json:"pendingCycles,omitempty"`
+}
+
+// stageConfirming reports whether ladder stages must be confirmed before the
+// stop follows them.
+func (ts *TradingService) stageConfirming() bool {
+	return ts.config().LadderConfirmCycles > 0 || ts.config().LadderConfirmInterval != ""
+}
+
+// confirmLadderStage confirms the ladder stage of data once its profit has held
+// it for LadderConfirmCycles consecutive cycles, or the last closed
+// LadderConfirmInterval candle closed at or beyond its threshold, and copies the
+// confirmed stage to data so the stop stays at its lock until the next one is
+// confirmed. A single wick across a threshold then does not tighten the stop
+// for good. Like the peak profit, the confirmed stage belongs to the entry
+// price it was reached from.
+func (ts *TradingService) confirmLadderStage(data *PositionData) {
+	if !ts.stageConfirming() {
+		return
+	}
+	data.StageConfirming = true
+	stage := ts.ladderStage(data)
+
+	ts.mu.Lock()
+	st := ts.orderState(data.Symbol, data.PositionSide)
+	c := st.Confirmation
+	if c.EntryPrice != data.EntryPrice {
+		c = StageConfirmation{EntryPrice: data.EntryPrice}
+	}
+	confirmed := c.Stage - 1
+	pending := -1
+	if stage > confirmed {
+		// The stage held is the lowest one seen over the consecutive cycles
+		pending = stage
+		if c.PendingCycles > 0 {
+			pending = min(stage, c.PendingStage-1)
+		}
+		c.PendingStage, c.PendingCycles = pending+1, c.PendingCycles+1
+	} else {
+		c.PendingStage, c.PendingCycles = 0, 0
+	}
+	ts.mu.Unlock()
+
+	var reason string
+	switch {
+	case pending <= confirmed:
+	case ts.config().LadderConfirmCycles > 0 && c.PendingCycles >= ts.config().LadderConfirmCycles:
+		reason = fmt.Sprintf("held for %d cycles", c.PendingCycles)
+	case ts.config().LadderConfirmInterval != "":
+		candleStage, err := ts.closedCandleStage(data)
+		if err != nil {
+			log.Printf("Warning: Unable to confirm the ladder stage of %s: %v", data.Symbol, err)
+		} else if candleStage > confirmed {
+			pending = min(pending, candleStage)
+			reason = fmt.Sprintf("a %s candle closed beyond it", ts.config().LadderConfirmInterval)
+		}
+	}
+	if reason != "" {
+		confirmed = pending
+		c.Stage, c.PendingStage, c.PendingCycles = confirmed+1, 0, 0
+	}
+
+	ts.mu.Lock()
+	st = ts.orderState(data.Symbol, data.PositionSide)
+	changed := st.Confirmation != c
+	st.Confirmation = c
+	ts.mu.Unlock()
+
+	data.ConfirmedStage = confirmed
+	if reason != "" {
+		log.Printf("Confirmed ladder stage %d of %s %s: %s", confirmed, data.Symbol, data.PositionSide, reason)
+	} else if pending > confirmed {
+		log.Printf("DEBUG: Ladder stage %d of %s awaiting confirmation (%d cycles held)", pending, data.Symbol, c.PendingCycles)
+	}
+	if changed {
+		ts.saveState()
+	}
+}
+
+// closedCandleStage returns the ladder stage of data at the close of the last
+// closed LadderConfirmInterval candle.
+func (ts *TradingService) closedCandleStage(data *PositionData) (int, error) {
+	candles, err := ts.getKlines(data.Symbol, ts.config().LadderConfirmInterval, 2)
+	if err != nil {
+		return -1, err
+	}
+	// The last candle is usually still open
+	now := time.Now()
+	for i := len(candles) - 1; i >= 0; i-- {
+		if candles[i].CloseTime.After(now) {
+			continue
+		}
+		raw := ladder.RawStopPct(data.EntryPrice, candles[i].Close, data.IsLong)
+		// Count the fees and funding in RawProfitPct at the close too
+		raw += data.RawProfitPct - ladder.RawStopPct(data.EntryPrice, data.MarkPrice, data.IsLong)
+		return ts.profitStage(data.Symbol, ladder.Profit(ts.stopLadder(data.Symbol).Metric, ladderPosition(data), raw)), nil
+	}
+	return -1, fmt.Errorf("no closed %s candle of %s", ts.config().LadderConfirmInterval, data.Symbol)
+}
+
+// confirmedProfit caps profit, in the unit of the ladder of data, at the
+// threshold of its confirmed stage while stages must be confirmed, so the stop
+// does not follow a stage that has not been.
+func (ts *TradingService) confirmedProfit(data *PositionData, profit float64) float64 {
+	levels := ts.stopLadder(data.Symbol).Levels
+	if !data.StageConfirming || ts.profitStage(data.Symbol, profit) <= data.ConfirmedStage || data.ConfirmedStage >= len(levels) {
+		return profit
+	}
+	if data.ConfirmedStage < 0 {
+		// Below the first threshold, the default stop
+		return 0
+	}
+	return levels[data.ConfirmedStage].ProfitThreshold
+}
@@ -0,0 +1,272 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// ControlState is the runtime control state set through the CLI, the control API
+// and Telegram commands. It is persisted with the bot state, so order management
+// stays paused and the kill switch tripped across restarts.
+type ControlState struct {
+	// Paused leaves every order untouched and PausedSymbols those of the listed symbols.
+	Paused        bool     `json:"paused,omitempty"`
+	PausedSymbols []string `json:"pausedSymbols,omitempty"`
+	// ManualSymbols are managed by hand: their positions are still reported and
+	// alerted on, but the bot places, moves and cancels none of their orders.
+	ManualSymbols []string `json:"manualSymbols,omitempty"`
+	// KillSwitch is tripped by an emergency flatten and blocks new entries, scale-ins
+	// and pyramid adds until it is reset. Stops and targets are still managed.
+	KillSwitch       bool      `json:"killSwitch,omitempty"`
+	KillSwitchReason string    `json:"killSwitchReason,omitempty"`
+	UpdatedAt        time.Time `json:"updatedAt,omitzero"`
+}
+
+// String summarizes the control state for status replies.
+func (c ControlState) String() string {
+	var parts []string
+	if c.Paused {
+		parts = append(parts, "order management paused")
+	}
+	if len(c.PausedSymbols) > 0 {
+		parts = append(parts, "paused symbols: "+strings.Join(c.PausedSymbols, ", "))
+	}
+	if len(c.ManualSymbols) > 0 {
+		parts = append(parts, "under manual control: "+strings.Join(c.ManualSymbols, ", "))
+	}
+	if c.KillSwitch {
+		parts = append(parts, fmt.Sprintf("kill switch tripped (%s)", c.KillSwitchReason))
+	}
+	if len(parts) == 0 {
+		return "running, no pauses"
+	}
+	return strings.Join(parts, "; ")
+}
+
+// setSymbolPaused adds symbol to or removes it from the paused symbols.
+func (c *ControlState) setSymbolPaused(symbol string, paused bool) {
+	c.PausedSymbols = slices.DeleteFunc(c.PausedSymbols, func(s string) bool { return s == symbol })
+	if paused {
+		c.PausedSymbols = append(c.PausedSymbols, symbol)
+		slices.Sort(c.PausedSymbols)
+	}
+}
+
+// setSymbolManual puts symbol under manual control or hands it back to the bot.
+func (c *ControlState) setSymbolManual(symbol string, manual bool) {
+	c.ManualSymbols = slices.DeleteFunc(c.ManualSymbols, func(s string) bool { return s == symbol })
+	if manual {
+		c.ManualSymbols = append(c.ManualSymbols, symbol)
+		slices.Sort(c.ManualSymbols)
+	}
+}
+
+// control returns a copy of the control state.
+func (ts *TradingService) control() ControlState {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	control := ts.state.Control
+	control.PausedSymbols = slices.Clone(control.PausedSymbols)
+	control.ManualSymbols = slices.Clone(control.ManualSymbols)
+	return control
+}
+
+// updateControl applies change to the control state and persists it.
+func (ts *TradingService) updateControl(change func(c *ControlState)) ControlState {
+	ts.mu.Lock()
+	ts.syncControl()
+	change(&ts.state.Control)
+	ts.state.Control.UpdatedAt = time.Now()
+	ts.mu.Unlock()
+	ts.saveState()
+	return ts.control()
+}
+
+// syncControl adopts the control state persisted by another process, such as the
+// `control` command, when it changed after ours. The caller must hold ts.mu.
+func (ts *TradingService) syncControl() {
+	if ts.store == nil {
+		return
+	}
+	stored, err := ts.store.Load()
+	if err != nil {
+		log.Printf("Warning: Unable to read the persisted control state: %v", err)
+		return
+	}
+	if stored.Control.UpdatedAt.After(ts.state.Control.UpdatedAt) {
+		ts.state.Control = stored.Control
+		log.Printf("Control state changed by another process: %s", stored.Control)
+	}
+}
+
+// isPaused reports whether order management is paused.
+func (ts *TradingService) isPaused() bool {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	return ts.state.Control.Paused
+}
+
+// setPaused pauses or resumes order management.
+func (ts *TradingService) setPaused(paused bool) {
+	ts.updateControl(func(c *ControlState) { c.Paused = paused })
+}
+
+// isSymbolPaused reports whether the orders of symbol are left untouched.
+func (ts *TradingService) isSymbolPaused(symbol string) bool {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	return slices.Contains(ts.state.Control.PausedSymbols, symbol)
+}
+
+// setSymbolPaused pauses or resumes order management of symbol.
+func (ts *TradingService) setSymbolPaused(symbol string, paused bool) {
+	ts.updateControl(func(c *ControlState) { c.setSymbolPaused(symbol, paused) })
+}
+
+// isSymbolManual reports whether the orders of symbol are managed by hand.
+func (ts *TradingService) isSymbolManual(symbol string) bool {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	return slices.Contains(ts.state.Control.ManualSymbols, symbol)
+}
+
+// setSymbolManual puts symbol under manual control or hands it back to the bot.
+func (ts *TradingService) setSymbolManual(symbol string, manual bool) {
+	ts.updateControl(func(c *ControlState) { c.setSymbolManual(symbol, manual) })
+}
+
+// tripKillSwitch blocks new entries until the kill switch is reset.
+func (ts *TradingService) tripKillSwitch(reason string) {
+	ts.updateControl(func(c *ControlState) {
+		c.KillSwitch = true
+		c.KillSwitchReason = reason
+	})
+	ts.events.Publish(Event{Type: EventKillSwitch, Reason: reason})
+}
+
+// resetKillSwitch allows new entries again.
+func (ts *TradingService) resetKillSwitch() {
+	ts.updateControl(func(c *ControlState) {
+		c.KillSwitch = false
+		c.KillSwitchReason = ""
+	})
+}
+
+// entryBlocked returns why the bot may not add to a position of symbol, or nil.
+func (ts *TradingService) entryBlocked(symbol string) error {
+	control := ts.control()
+	switch {
+	case !ts.isLeader():
+		return errors.New("this instance is on standby; send orders to the leader")
+	case control.KillSwitch:
+		return fmt.Errorf("the kill switch is tripped (%s); reset it to allow new entries", control.KillSwitchReason)
+	case control.Paused:
+		return errors.New("order management is paused; new positions would be unprotected")
+	case slices.Contains(control.PausedSymbols, symbol):
+		return fmt.Errorf("order management of %s is paused; new positions would be unprotected", symbol)
+	case slices.Contains(control.ManualSymbols, symbol):
+		return fmt.Errorf("%s is under manual control; unlock it to let the bot trade it", symbol)
+	}
+	if ratio, low := ts.freeMarginBlocked(); low {
+		return fmt.Errorf("free margin is at %.2f%% of the margin balance, below FREE_MARGIN_ALERT_PERCENT", ratio)
+	}
+	return nil
+}
+
+// editControl applies change to the persisted control state, for the `control`
+// command, which does not run a trading service. A running daemon adopts the
+// change at its next cycle.
+func editControl(config Config, change func(c *ControlState)) (ControlState, error) {
+	store, err := openStateStore(config)
+	if err != nil {
+		return ControlState{}, err
+	}
+	state, err := store.Load()
+	if err != nil {
+		return ControlState{}, fmt.Errorf("error loading bot state: %w", err)
+	}
+	if change != nil {
+		change(&state.Control)
+		state.Control.UpdatedAt = time.Now()
+		if err := store.Save(state); err != nil {
+			return ControlState{}, err
+		}
+	}
+	return state.Control, nil
+}
+
+// newControlCommand builds the `control` command that inspects and changes the
+// persisted control state.
+func newControlCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "control",
+		Short: "Pause or resume order management, hand symbols to manual control and reset the kill switch",
+	}
+	cmd.AddCommand(
+		newControlSubcommand("status", "Show the pause and kill switch state", cobra.NoArgs, nil),
+		newControlSubcommand("pause [symbol...]", "Pause order management of every symbol or the given ones",
+			cobra.ArbitraryArgs, func(c *ControlState, symbols []string) { pauseControl(c, symbols, true) }),
+		newControlSubcommand("resume [symbol...]", "Resume order management of every symbol or the given ones",
+			cobra.ArbitraryArgs, func(c *ControlState, symbols []string) { pauseControl(c, symbols, false) }),
+		newControlSubcommand("manual <symbol...>", "Leave the orders of the given symbols to the trader until unlocked",
+			cobra.MinimumNArgs(1), func(c *ControlState, symbols []string) { manualControl(c, symbols, true) }),
+		newControlSubcommand("unlock <symbol...>", "Hand the given symbols back to the bot",
+			cobra.MinimumNArgs(1), func(c *ControlState, symbols []string) { manualControl(c, symbols, false) }),
+		newControlSubcommand("reset-kill-switch", "Allow new entries after an emergency flatten", cobra.NoArgs,
+			func(c *ControlState, _ []string) {
+				c.KillSwitch = false
+				c.KillSwitchReason = ""
+			}),
+	)
+	return cmd
+}
+
+// newControlSubcommand builds a `control` subcommand that applies change, when
+// set, and prints the resulting control state.
+func newControlSubcommand(use, short string, args cobra.PositionalArgs, change func(c *ControlState, args []string)) *cobra.Command {
+	return &cobra.Command{
+		Use:   use,
+		Short: short,
+		Args:  args,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var edit func(c *ControlState)
+			if change != nil {
+				edit = func(c *ControlState) { change(c, args) }
+			}
+			config, err := loadConfig()
+			if err != nil {
+				return err
+			}
+			control, err := editControl(config, edit)
+			if err != nil {
+				return err
+			}
+			fmt.Println(control)
+			return nil
+		},
+	}
+}
+
+// pauseControl pauses or resumes every symbol, or only the given ones.
+func pauseControl(c *ControlState, symbols []string, paused bool) {
+	if len(symbols) == 0 {
+		c.Paused = paused
+		return
+	}
+	for _, symbol := range symbols {
+		c.setSymbolPaused(strings.ToUpper(symbol), paused)
+	}
+}
+
+// manualControl puts the given symbols under manual control or unlocks them.
+func manualControl(c *ControlState, symbols []string, manual bool) {
+	for _, symbol := range symbols {
+		c.setSymbolManual(strings.ToUpper(symbol), manual)
+	}
+}
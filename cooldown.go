@@ -0,0 +1,22 @@
+package main
+
+import (
+	"time"
+)
+
+// updateCooldownRemaining returns how long the live SL and TP of data are still
+// held back after their last replacement, zero when ORDER_UPDATE_COOLDOWN allows
+// replacing them now.
+func (ts *TradingService) updateCooldownRemaining(data *PositionData) time.Duration {
+	if ts.config().OrderUpdateCooldown <= 0 {
+		return 0
+	}
+
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	st, ok := ts.state.Orders[trackedKey(data.Symbol, data.PositionSide)]
+	if !ok || st.UpdatedAt.IsZero() {
+		return 0
+	}
+	return max(time.Until(st.UpdatedAt.Add(ts.config().OrderUpdateCooldown)), 0)
+}
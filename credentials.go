@@ -0,0 +1,460 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"futures-guard/settings"
+)
+
+// Credential providers.
+const (
+	credentialProviderVault = "vault"
+	credentialProviderAWS   = "aws"
+)
+
+// defaultCredentialRefreshInterval is how often the provider is polled for rotated credentials.
+const defaultCredentialRefreshInterval = 5 * time.Minute
+
+// awsMetadataURL is the EC2 instance metadata endpoint used when no static AWS keys are set.
+const awsMetadataURL = "http://169.254.169.254/latest"
+
+// CredentialProvider fetches API credentials from a remote secret manager so they
+// never have to be written to disk. Fetch returns values keyed by env variable name.
+type CredentialProvider interface {
+	Name() string
+	Fetch(ctx context.Context) (map[string]string, error)
+}
+
+// providerCredentialsLoaded is set once the provider has been read at startup; later
+// changes are picked up by watchCredentials so the exchange client is rebuilt with them.
+var providerCredentialsLoaded bool
+
+// newCredentialProvider returns the provider src selects with CREDENTIAL_PROVIDER,
+// or nil when credentials come from the settings or the local secret store.
+func newCredentialProvider(src *settings.Settings) (CredentialProvider, error) {
+	httpClient := &http.Client{Timeout: defaultTimeout}
+
+	switch provider := strings.ToLower(strings.TrimSpace(src.String("CREDENTIAL_PROVIDER"))); provider {
+	case "":
+		return nil, nil
+	case credentialProviderVault:
+		p := &vaultProvider{
+			addr:       strings.TrimRight(src.String("VAULT_ADDR"), "/"),
+			token:      src.String("VAULT_TOKEN"),
+			namespace:  src.String("VAULT_NAMESPACE"),
+			path:       strings.Trim(src.String("VAULT_SECRET_PATH"), "/"),
+			httpClient: httpClient,
+		}
+		if p.addr == "" || p.token == "" || p.path == "" {
+			return nil, fmt.Errorf("vault credential provider needs VAULT_ADDR, VAULT_TOKEN and VAULT_SECRET_PATH")
+		}
+		return p, nil
+	case credentialProviderAWS:
+		region := src.String("AWS_REGION")
+		if region == "" {
+			region = src.String("AWS_DEFAULT_REGION")
+		}
+		p := &awsSecretsProvider{
+			region:   region,
+			secretID: src.String("AWS_SECRET_ID"),
+			static: awsCredentials{
+				AccessKeyID:     src.String("AWS_ACCESS_KEY_ID"),
+				SecretAccessKey: src.String("AWS_SECRET_ACCESS_KEY"),
+				Token:           src.String("AWS_SESSION_TOKEN"),
+			},
+			httpClient: httpClient,
+		}
+		if p.region == "" || p.secretID == "" {
+			return nil, fmt.Errorf("aws credential provider needs AWS_REGION and AWS_SECRET_ID")
+		}
+		return p, nil
+	default:
+		return nil, fmt.Errorf("unknown CREDENTIAL_PROVIDER %q (use %s or %s)", provider, credentialProviderVault, credentialProviderAWS)
+	}
+}
+
+// loadProviderCredentials reads the credentials from the configured provider once,
+// overriding the environment since the provider is the source of truth.
+func loadProviderCredentials(src *settings.Settings) {
+	if providerCredentialsLoaded {
+		return
+	}
+	provider, err := newCredentialProvider(src)
+	if err != nil {
+		src.Warn("CREDENTIAL_PROVIDER", err)
+		return
+	}
+	if provider == nil {
+		return
+	}
+	providerCredentialsLoaded = true
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+
+	if _, err := applyProviderCredentials(ctx, provider); err != nil {
+		src.Warn("CREDENTIAL_PROVIDER", err)
+	}
+}
+
+// applyProviderCredentials fetches the provider's credentials into the environment and
+// reports whether any value changed.
+func applyProviderCredentials(ctx context.Context, provider CredentialProvider) (bool, error) {
+	values, err := provider.Fetch(ctx)
+	if err != nil {
+		return false, fmt.Errorf("error fetching credentials from %s: %w", provider.Name(), err)
+	}
+
+	changed := false
+	for _, key := range secretKeys {
+		value, ok := values[key]
+		if !ok || value == "" || os.Getenv(key) == value {
+			continue
+		}
+		os.Setenv(key, value)
+		changed = true
+	}
+	return changed, nil
+}
+
+// watchCredentials polls the credential provider and signals rotations when a value
+// changes, until ctx is cancelled.
+func watchCredentials(ctx context.Context, provider CredentialProvider, interval time.Duration, rotations chan<- struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			fetchCtx, cancel := context.WithTimeout(ctx, defaultTimeout)
+			changed, err := applyProviderCredentials(fetchCtx, provider)
+			cancel()
+			if err != nil {
+				log.Printf("Warning: %v", err)
+				continue
+			}
+			if !changed {
+				continue
+			}
+			select {
+			case rotations <- struct{}{}:
+			default:
+				// A rotation is already pending
+			}
+		}
+	}
+}
+
+// Credentials are the exchange API keys.
+type Credentials struct {
+	BinanceAPIKey    string
+	BinanceAPISecret string
+	BybitAPIKey      string
+	BybitAPISecret   string
+	OKXAPIKey        string
+	OKXAPISecret     string
+	OKXPassphrase    string
+}
+
+// loadCredentials reads the exchange API keys from src.
+func loadCredentials(src *settings.Settings) Credentials {
+	return Credentials{
+		BinanceAPIKey:    src.String("BINANCE_API_KEY"),
+		BinanceAPISecret: src.String("BINANCE_API_SECRET"),
+		BybitAPIKey:      src.String("BYBIT_API_KEY"),
+		BybitAPISecret:   src.String("BYBIT_API_SECRET"),
+		OKXAPIKey:        src.String("OKX_API_KEY"),
+		OKXAPISecret:     src.String("OKX_API_SECRET"),
+		OKXPassphrase:    src.String("OKX_PASSPHRASE"),
+	}
+}
+
+// connectExchange connects to the exchange selected by config, replaced in tests.
+var connectExchange = setupExchange
+
+// rotateCredentials reconnects the exchange with the credentials now in the settings.
+// Requests already in flight finish on the previous connection. On failure it is kept.
+func (ts *TradingService) rotateCredentials() {
+	config := *ts.config()
+	src, err := loadSettings()
+	var exchange Exchange
+	if err == nil {
+		config.Credentials = loadCredentials(src)
+		exchange, err = connectExchange(config)
+	}
+	if err != nil {
+		msg := fmt.Sprintf("⚠️ Credential rotation detected but reconnecting to %s failed: %v", config.Exchange, err)
+		log.Println(msg)
+		ts.notify(SeverityWarning, msg)
+		return
+	}
+
+	client := ts.client()
+	if be, ok := exchange.(*binanceExchange); ok {
+		client = newBinanceClient(be.client, config)
+	}
+	ts.cfg.Store(&config)
+	ts.setExchange(exchange, client)
+
+	msg := fmt.Sprintf("🔑 Reconnected to %s with rotated API credentials", ts.config().Exchange)
+	log.Println(msg)
+	ts.notify(SeverityInfo, msg)
+}
+
+// vaultProvider reads credentials from a HashiCorp Vault KV secret (v1 or v2).
+type vaultProvider struct {
+	addr       string
+	token      string
+	namespace  string
+	path       string // e.g. secret/data/futures-guard for KV v2
+	httpClient *http.Client
+}
+
+// Name implements CredentialProvider.
+func (p *vaultProvider) Name() string {
+	return credentialProviderVault
+}
+
+// Fetch implements CredentialProvider.
+func (p *vaultProvider) Fetch(ctx context.Context) (map[string]string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.addr+"/v1/"+p.path, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Vault-Token", p.token)
+	if p.namespace != "" {
+		req.Header.Set("X-Vault-Namespace", p.namespace)
+	}
+
+	body, err := doCredentialRequest(p.httpClient, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp struct {
+		Data json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("error parsing Vault response: %w", err)
+	}
+
+	// KV v2 nests the secret under data.data; KV v1 returns it directly under data
+	var kv2 struct {
+		Data     map[string]any `json:"data"`
+		Metadata map[string]any `json:"metadata"`
+	}
+	if err := json.Unmarshal(resp.Data, &kv2); err == nil && kv2.Data != nil && kv2.Metadata != nil {
+		return stringValues(kv2.Data), nil
+	}
+	var kv1 map[string]any
+	if err := json.Unmarshal(resp.Data, &kv1); err != nil {
+		return nil, fmt.Errorf("error parsing Vault secret: %w", err)
+	}
+	return stringValues(kv1), nil
+}
+
+// awsSecretsProvider reads credentials from an AWS Secrets Manager secret whose value
+// is a JSON object of env variable names. Requests are signed with SigV4 using the
+// AWS_ACCESS_KEY_ID/AWS_SECRET_ACCESS_KEY/AWS_SESSION_TOKEN environment or, when
+// unset, the EC2 instance role.
+type awsSecretsProvider struct {
+	region     string
+	secretID   string
+	static     awsCredentials // Static keys, if configured
+	httpClient *http.Client
+}
+
+// awsCredentials are the keys used to sign an AWS request.
+type awsCredentials struct {
+	AccessKeyID     string `json:"AccessKeyId"`
+	SecretAccessKey string `json:"SecretAccessKey"`
+	Token           string `json:"Token"`
+}
+
+// Name implements CredentialProvider.
+func (p *awsSecretsProvider) Name() string {
+	return credentialProviderAWS
+}
+
+// Fetch implements CredentialProvider.
+func (p *awsSecretsProvider) Fetch(ctx context.Context) (map[string]string, error) {
+	creds, err := p.credentials(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	payload, err := json.Marshal(map[string]string{"SecretId": p.secretID})
+	if err != nil {
+		return nil, err
+	}
+	host := fmt.Sprintf("secretsmanager.%s.amazonaws.com", p.region)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, "https://"+host+"/", bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "secretsmanager.GetSecretValue")
+	signAWSRequest(req, payload, creds, p.region, "secretsmanager", time.Now().UTC())
+
+	body, err := doCredentialRequest(p.httpClient, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp struct {
+		SecretString string `json:"SecretString"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("error parsing Secrets Manager response: %w", err)
+	}
+	var values map[string]any
+	if err := json.Unmarshal([]byte(resp.SecretString), &values); err != nil {
+		return nil, fmt.Errorf("secret %s is not a JSON object: %w", p.secretID, err)
+	}
+	return stringValues(values), nil
+}
+
+// credentials returns the static AWS keys of the settings, falling back to the
+// EC2 instance role via IMDSv2.
+func (p *awsSecretsProvider) credentials(ctx context.Context) (awsCredentials, error) {
+	creds := p.static
+	if creds.AccessKeyID != "" && creds.SecretAccessKey != "" {
+		return creds, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, awsMetadataURL+"/api/token", nil)
+	if err != nil {
+		return creds, err
+	}
+	req.Header.Set("X-aws-ec2-metadata-token-ttl-seconds", "300")
+	token, err := doCredentialRequest(p.httpClient, req)
+	if err != nil {
+		return creds, fmt.Errorf("AWS credentials not configured and instance metadata unavailable: %w", err)
+	}
+
+	metadata := func(path string) ([]byte, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, awsMetadataURL+"/meta-data/iam/security-credentials/"+path, nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("X-aws-ec2-metadata-token", string(token))
+		return doCredentialRequest(p.httpClient, req)
+	}
+
+	role, err := metadata("")
+	if err != nil {
+		return creds, fmt.Errorf("error reading instance role: %w", err)
+	}
+	body, err := metadata(strings.TrimSpace(strings.SplitN(string(role), "\n", 2)[0]))
+	if err != nil {
+		return creds, fmt.Errorf("error reading instance role credentials: %w", err)
+	}
+	if err := json.Unmarshal(body, &creds); err != nil {
+		return creds, fmt.Errorf("error parsing instance role credentials: %w", err)
+	}
+	return creds, nil
+}
+
+// signAWSRequest adds an AWS Signature Version 4 Authorization header to req.
+func signAWSRequest(req *http.Request, payload []byte, creds awsCredentials, region, service string, now time.Time) {
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	payloadHash := sha256Hex(payload)
+
+	req.Header.Set("Host", req.URL.Host)
+	req.Header.Set("X-Amz-Date", amzDate)
+	if creds.Token != "" {
+		req.Header.Set("X-Amz-Security-Token", creds.Token)
+	}
+
+	// Canonical headers are lowercase, sorted and newline-terminated
+	var names []string
+	for name := range req.Header {
+		names = append(names, strings.ToLower(name))
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		value := req.Header.Get(name)
+		if name == "host" {
+			value = req.URL.Host
+		}
+		canonicalHeaders.WriteString(name + ":" + strings.TrimSpace(value) + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	path := req.URL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	canonicalRequest := strings.Join([]string{
+		req.Method, path, req.URL.RawQuery, canonicalHeaders.String(), signedHeaders, payloadHash,
+	}, "\n")
+
+	scope := strings.Join([]string{date, region, service, "aws4_request"}, "/")
+	stringToSign := strings.Join([]string{"AWS4-HMAC-SHA256", amzDate, scope, sha256Hex([]byte(canonicalRequest))}, "\n")
+
+	key := []byte("AWS4" + creds.SecretAccessKey)
+	for _, part := range []string{date, region, service, "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		creds.AccessKeyID, scope, signedHeaders, signature))
+}
+
+// doCredentialRequest sends req and returns the body of a successful response.
+func doCredentialRequest(client *http.Client, req *http.Request) ([]byte, error) {
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode/100 != 2 {
+		return nil, fmt.Errorf("%s %s returned %s: %s", req.Method, req.URL.Host, resp.Status, strings.TrimSpace(string(body)))
+	}
+	return body, nil
+}
+
+// stringValues keeps the string entries of a decoded JSON object.
+func stringValues(values map[string]any) map[string]string {
+	out := make(map[string]string, len(values))
+	for key, value := range values {
+		if s, ok := value.(string); ok {
+			out[key] = s
+		}
+	}
+	return out
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"sync"
+	"testing"
+)
+
+// TestRotateCredentialsWhileProcessing reconnects over and over while cycles
+// run, for go test -race to catch the exchange, client or order cache read while
+// it is replaced, then checks the next cycle goes through the new connection.
+func TestRotateCredentialsWhileProcessing(t *testing.T) {
+	position := &Position{Symbol: "BTCUSDT", PositionSide: "BOTH", PositionAmt: 1, EntryPrice: 100, MarkPrice: 104, Leverage: 10}
+	newExchange := func() *memoryExchange {
+		e := newMemoryExchange("BTCUSDT")
+		e.Open = []*Position{position}
+		return e
+	}
+	client := newMemoryClient()
+	ts := newTestTradingService(t, newExchange(), client, nil)
+
+	var latest *memoryExchange
+	defer func(connect func(Config) (Exchange, error)) { connectExchange = connect }(connectExchange)
+	connectExchange = func(Config) (Exchange, error) {
+		latest = newExchange()
+		return latest, nil
+	}
+
+	const rounds = 20
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for range rounds {
+			if err := ts.processPositions(); err != nil {
+				t.Errorf("processPositions: %v", err)
+			}
+		}
+	}()
+	for range rounds {
+		ts.rotateCredentials()
+	}
+	wg.Wait()
+
+	if ts.client() != client {
+		t.Error("client replaced by a non-Binance exchange")
+	}
+	if err := ts.processPositions(); err != nil {
+		t.Fatalf("processPositions: %v", err)
+	}
+	if len(latest.ordersOfType("BTCUSDT", orderTypeStopMarket)) != 1 {
+		t.Errorf("no stop placed through the rotated exchange, orders %v", latest.Orders)
+	}
+}
@@ -0,0 +1,214 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	binance "github.com/adshao/go-binance/v2/futures"
+)
+
+// Income history types aggregated by the daily report.
+const (
+	realizedPnLIncomeType = "REALIZED_PNL"
+	commissionIncomeType  = "COMMISSION"
+)
+
+// incomePageLimit is the maximum page size of the income history endpoint.
+const incomePageLimit = 1000
+
+// DailySummary aggregates account income over a reporting window.
+type DailySummary struct {
+	Start         time.Time
+	End           time.Time
+	RealizedPnL   float64
+	Fees          float64
+	Funding       float64
+	Wins          int
+	Losses        int
+	LargestWin    float64
+	LargestLoss   float64
+	LargestWinOn  string
+	LargestLossOn string
+	// ByTag is the net PnL of the recorded trades closed in the window per
+	// strategy tag, empty when none carries a tag.
+	ByTag map[string]float64
+}
+
+// NetPnL returns the realized PnL after fees and funding.
+func (s *DailySummary) NetPnL() float64 {
+	return s.RealizedPnL + s.Fees + s.Funding
+}
+
+// WinRate returns the share of winning closes in percent.
+func (s *DailySummary) WinRate() float64 {
+	if total := s.Wins + s.Losses; total > 0 {
+		return float64(s.Wins) / float64(total) * 100
+	}
+	return 0
+}
+
+// getIncomeHistory fetches all income entries between start and end, following pagination.
+func (ts *TradingService) getIncomeHistory(start, end time.Time) ([]*binance.IncomeHistory, error) {
+	if err := ts.requireBinance("the daily report"); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+
+	var incomes []*binance.IncomeHistory
+	query := IncomeQuery{Start: start, End: end, Limit: incomePageLimit}
+	for {
+		page, err := ts.client().IncomeHistory(ctx, query)
+		if err != nil {
+			return nil, fmt.Errorf("error fetching income history: %w", err)
+		}
+		incomes = append(incomes, page...)
+		if len(page) < incomePageLimit {
+			return incomes, nil
+		}
+		query.Start = time.UnixMilli(page[len(page)-1].Time + 1)
+	}
+}
+
+// buildDailySummary aggregates the income of the 24 hours ending at end.
+func (ts *TradingService) buildDailySummary(end time.Time) (*DailySummary, error) {
+	start := end.Add(-24 * time.Hour)
+	incomes, err := ts.getIncomeHistory(start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &DailySummary{Start: start, End: end}
+	for _, income := range incomes {
+		if income.Symbol != "" && !ts.isSymbolManaged(income.Symbol) {
+			continue
+		}
+		amount, err := strconv.ParseFloat(income.Income, 64)
+		if err != nil {
+			log.Printf("Warning: Skipping income %d with invalid amount %q", income.TranID, income.Income)
+			continue
+		}
+
+		switch income.IncomeType {
+		case realizedPnLIncomeType:
+			summary.RealizedPnL += amount
+			if amount > 0 {
+				summary.Wins++
+				if amount > summary.LargestWin {
+					summary.LargestWin, summary.LargestWinOn = amount, income.Symbol
+				}
+			} else if amount < 0 {
+				summary.Losses++
+				if amount < summary.LargestLoss {
+					summary.LargestLoss, summary.LargestLossOn = amount, income.Symbol
+				}
+			}
+		case commissionIncomeType:
+			summary.Fees += amount
+		case fundingIncomeType:
+			summary.Funding += amount
+		}
+	}
+
+	tagged := false
+	byTag := make(map[string]float64)
+	ts.mu.Lock()
+	for _, trade := range ts.state.Trades {
+		if trade.ClosedAt.Before(start) || !trade.ClosedAt.Before(end) {
+			continue
+		}
+		byTag[tagLabel(trade.Tag)] += trade.NetPnL()
+		tagged = tagged || trade.Tag != ""
+	}
+	ts.mu.Unlock()
+	if tagged {
+		summary.ByTag = byTag
+	}
+	return summary, nil
+}
+
+// formatDailySummary creates the daily digest message.
+func formatDailySummary(s *DailySummary) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "📅 Daily PnL Report (%s – %s UTC)\n",
+		s.Start.UTC().Format("Jan 02 15:04"), s.End.UTC().Format("Jan 02 15:04"))
+	fmt.Fprintf(&b, "💰 Realized PnL: %.2f USD\n", s.RealizedPnL)
+	fmt.Fprintf(&b, "🧾 Fees: %.2f USD\n", s.Fees)
+	fmt.Fprintf(&b, "⏱️ Funding: %.2f USD\n", s.Funding)
+	fmt.Fprintf(&b, "📈 Net: %.2f USD\n", s.NetPnL())
+	fmt.Fprintf(&b, "🎯 Win rate: %.1f%% (%d W / %d L)", s.WinRate(), s.Wins, s.Losses)
+	if s.Wins > 0 {
+		fmt.Fprintf(&b, "\n🏆 Largest win: %.2f USD (%s)", s.LargestWin, s.LargestWinOn)
+	}
+	if s.Losses > 0 {
+		fmt.Fprintf(&b, "\n💥 Largest loss: %.2f USD (%s)", s.LargestLoss, s.LargestLossOn)
+	}
+	if len(s.ByTag) > 0 {
+		tags := make([]string, 0, len(s.ByTag))
+		for tag := range s.ByTag {
+			tags = append(tags, tag)
+		}
+		sort.Strings(tags)
+		b.WriteString("\n🏷️ Net per strategy:")
+		for _, tag := range tags {
+			fmt.Fprintf(&b, "\n  %s: %.2f USD", tag, s.ByTag[tag])
+		}
+	}
+	return b.String()
+}
+
+// sendDailyReport builds the digest for the past 24 hours and sends it to the notifiers.
+func (ts *TradingService) sendDailyReport() error {
+	summary, err := ts.buildDailySummary(time.Now())
+	if err != nil {
+		return err
+	}
+	msg := formatDailySummary(summary)
+	fmt.Println(msg)
+	return ts.notifier.NotifyDigest(summary, msg)
+}
+
+// parseDailyTime parses a HH:MM time of day into an offset from midnight.
+func parseDailyTime(value string) (time.Duration, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(value))
+	if err != nil {
+		return 0, fmt.Errorf("invalid time of day %q, expected HH:MM: %w", value, err)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// nextDailyRun returns the next time after now at the given offset from UTC midnight.
+func nextDailyRun(now time.Time, offset time.Duration) time.Time {
+	now = now.UTC()
+	next := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC).Add(offset)
+	if !next.After(now) {
+		next = next.Add(24 * time.Hour)
+	}
+	return next
+}
+
+// runDailyReporter sends the daily digest at the configured UTC time until ctx is cancelled.
+func (ts *TradingService) runDailyReporter(ctx context.Context) {
+	for {
+		next := nextDailyRun(time.Now(), ts.config().DailyReportTime)
+		log.Printf("Next daily report scheduled for %s", next.Format(time.RFC3339))
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Until(next)):
+			if !ts.isLeader() {
+				continue
+			}
+			if err := ts.sendDailyReport(); err != nil {
+				log.Printf("Error sending daily report: %v", err)
+			}
+		}
+	}
+}
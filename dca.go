@@ -0,0 +1,274 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// clientOrderKindDCA marks the resting scale-in limit orders, which are neither
+// stops nor targets and are therefore never cancelled along with the bracket.
+const clientOrderKindDCA = "DCA"
+
+// isScaleInOrder reports whether order is a scale-in limit order placed by the bot.
+func isScaleInOrder(order *Order) bool {
+	id := strings.ReplaceAll(order.ClientOrderID, "-", "")
+	return strings.HasPrefix(id, clientOrderPrefix+clientOrderKindDCA)
+}
+
+// scaleInOrderID returns the client order ID of the add-th scale-in of a position.
+func scaleInOrderID(data *PositionData, add int) string {
+	id := fmt.Sprintf("%s-%s-%s-%s-%d", clientOrderPrefix, clientOrderKindDCA, data.Symbol, data.PositionSide, add)
+	if len(id) > clientOrderIDMaxLen {
+		id = id[:clientOrderIDMaxLen]
+	}
+	return id
+}
+
+// averageEntry returns the weighted average entry price of the current position and
+// the number of entry orders that built it, from the trade history. Partial exits
+// leave the average unchanged.
+func (ts *TradingService) averageEntry(data *PositionData) (float64, int, error) {
+	trades, err := ts.positionTrades(data, "DCA average entry")
+	if err != nil {
+		return 0, 0, err
+	}
+
+	var size, cost float64
+	entries := make(map[int64]bool)
+	for _, trade := range trades {
+		qty, err := signedTradeQuantity(trade)
+		if err != nil {
+			return 0, 0, err
+		}
+		price, err := strconv.ParseFloat(trade.Price, 64)
+		if err != nil {
+			return 0, 0, fmt.Errorf("error parsing trade price: %w", err)
+		}
+
+		if (qty > 0) == (data.PositionAmt > 0) {
+			size += math.Abs(qty)
+			cost += math.Abs(qty) * price
+			entries[trade.OrderID] = true
+		} else if size > 0 {
+			cost *= math.Max(size-math.Abs(qty), 0) / size
+			size = math.Max(size-math.Abs(qty), 0)
+		}
+	}
+
+	if size <= 0 || math.Abs(size-data.AbsAmt) > data.AbsAmt*1e-6 {
+		return 0, 0, fmt.Errorf("trade history of %s does not cover the whole position", data.Symbol)
+	}
+	return cost / size, len(entries), nil
+}
+
+// setEntryPrice re-bases the position's profit percentages on entryPrice.
+func setEntryPrice(data *PositionData, entryPrice float64) {
+	data.EntryPrice = entryPrice
+	if data.IsLong {
+		data.RawProfitPct = (data.MarkPrice - entryPrice) / entryPrice * 100
+	} else if data.IsShort {
+		data.RawProfitPct = (entryPrice - data.MarkPrice) / entryPrice * 100
+	}
+	data.CurrentProfitPct = data.RawProfitPct * data.Leverage
+}
+
+// checkScaleIn tracks positions built in several entries. On Binance the entry is
+// taken as the weighted average of the position's fills; when the position grew
+// since the last cycle it is flagged so its SL/TP are re-anchored on the new average
+// and size. Pyramiding relies on the same tracking. It returns how many adds the
+// position has had.
+func (ts *TradingService) checkScaleIn(data *PositionData) int {
+	if !ts.config().DCAEnabled && ts.config().PyramidFraction <= 0 {
+		return 0
+	}
+
+	entries := 0
+	if ts.exchange().Name() == exchangeBinance {
+		average, n, err := ts.averageEntry(data)
+		if err != nil {
+			log.Printf("Warning: %v", err)
+		} else {
+			if math.Abs(average-data.EntryPrice) > data.EntryPrice*1e-9 {
+				log.Printf("Using average entry %.4f for %s %s from %d entries (exchange reports %.4f)",
+					average, data.Symbol, data.PositionSide, n, data.EntryPrice)
+				setEntryPrice(data, average)
+			}
+			entries = n
+		}
+	}
+
+	ts.mu.Lock()
+	st := ts.orderState(data.Symbol, data.PositionSide)
+	previous := st.Quantity
+	data.ScaledIn = previous > 0 && data.AbsAmt > previous*(1+1e-9)
+	if data.ScaledIn {
+		st.Adds++
+	}
+	if entries > 0 {
+		// Pyramid adds are fills too, but do not use up DCA levels
+		st.Adds = max(entries-1-st.PyramidAdds, 0)
+	}
+	changed := st.Quantity != data.AbsAmt || data.ScaledIn
+	st.Quantity = data.AbsAmt
+	adds := st.Adds
+	ts.mu.Unlock()
+
+	if changed {
+		ts.saveState()
+	}
+	if data.ScaledIn {
+		msg := fmt.Sprintf("➕ %s %s scaled in from %v to %v, average entry %.4f (%d adds)",
+			data.Symbol, data.PositionSide, previous, data.AbsAmt, data.EntryPrice, adds)
+		log.Println(msg)
+		ts.notify(SeverityInfo, msg)
+	}
+	return adds
+}
+
+// placeScaleIn rests the next scale-in limit order of a position at the drawdown
+// level of DCA_LEVELS following its adds so far. Levels beyond the protective stop
+// are skipped, since the stop would close the position before the add fills, and
+// resting scale-ins for other levels are cancelled.
+func (ts *TradingService) placeScaleIn(data *PositionData, adds int) {
+	if !ts.config().DCAEnabled || ts.exchange().Name() == exchangeBinanceSpot || data.GridLow > 0 {
+		return
+	}
+	if ts.entryBlocked(data.Symbol) != nil {
+		return
+	}
+	levels := ts.config().DCALevels
+	maxAdds := ts.config().DCAMaxAdds
+	if maxAdds <= 0 || maxAdds > len(levels) {
+		maxAdds = len(levels)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+
+	openOrders, err := ts.exchange().OpenOrders(ctx, data.Symbol)
+	if err != nil {
+		log.Printf("Warning: Unable to list scale-in orders for %s: %v", data.Symbol, err)
+		return
+	}
+
+	var price float64
+	id := scaleInOrderID(data, adds+1)
+	if adds < maxAdds {
+		drawdown := levels[adds]
+		price = data.EntryPrice * (1 - drawdown/100)
+		if data.IsShort {
+			price = data.EntryPrice * (1 + drawdown/100)
+		}
+		if data.StopPrice > 0 && ((data.IsLong && price <= data.StopPrice) || (data.IsShort && price >= data.StopPrice)) {
+			log.Printf("Skipping scale-in of %s at %.4f beyond the stop at %.4f", data.Symbol, price, data.StopPrice)
+			price = 0
+		}
+	}
+
+	resting := false
+	for _, order := range openOrders {
+		if !isScaleInOrder(order) || !orderMatchesSide(order, data.PositionSide) {
+			continue
+		}
+		if price > 0 && strings.ReplaceAll(order.ClientOrderID, "-", "") == strings.ReplaceAll(id, "-", "") {
+			resting = true
+			continue
+		}
+		if err := ts.cancelOrder(ctx, order); err != nil {
+			log.Printf("Warning: %v", err)
+		}
+	}
+	if price <= 0 || resting {
+		return
+	}
+
+	precision, _ := ts.symbolPrecision(data.Symbol)
+	quantity := truncateToPrecision(data.AbsAmt*ts.config().DCASizeMultiplier, precision.QuantityPrecision)
+	if quantity <= 0 {
+		return
+	}
+
+	req := OrderRequest{
+		Symbol:        data.Symbol,
+		Side:          sideSell,
+		PositionSide:  data.PositionSide,
+		Type:          orderTypeLimit,
+		Quantity:      formatDecimal(quantity, precision.QuantityPrecision),
+		Price:         formatDecimal(price, precision.PricePrecision),
+		ClientOrderID: id,
+	}
+	if data.IsLong {
+		req.Side = sideBuy
+	}
+	if _, err := ts.placeOrder(ctx, req); err != nil {
+		log.Printf("Warning: Error placing scale-in %d for %s: %v", adds+1, data.Symbol, err)
+		return
+	}
+	log.Printf("Placed scale-in %d for %s %s: %s at %s", adds+1, data.Symbol, data.PositionSide, req.Quantity, req.Price)
+}
+
+// forgetScaleIns clears the tracked size of positions that are no longer open and
+// cancels their resting scale-in orders, which would otherwise open a new position.
+func (ts *TradingService) forgetScaleIns(positions []*Position) {
+	open := make(map[string]bool, len(positions))
+	for _, position := range positions {
+		if position.PositionAmt != 0 {
+			open[trackedKey(position.Symbol, position.PositionSide)] = true
+		}
+	}
+
+	var closed []*OrderState
+	ts.mu.Lock()
+	for key, st := range ts.state.Orders {
+		if st.Quantity > 0 && !open[key] {
+			closed = append(closed, &OrderState{Symbol: st.Symbol, PositionSide: st.PositionSide})
+			st.Quantity, st.Adds = 0, 0
+			st.InitialRisk, st.PyramidStage, st.PyramidAdds = 0, 0, 0
+		}
+	}
+	ts.mu.Unlock()
+	if len(closed) == 0 {
+		return
+	}
+	ts.saveState()
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+	for _, st := range closed {
+		openOrders, err := ts.exchange().OpenOrders(ctx, st.Symbol)
+		if err != nil {
+			log.Printf("Warning: Unable to list scale-in orders for %s: %v", st.Symbol, err)
+			continue
+		}
+		for _, order := range openOrders {
+			if !isScaleInOrder(order) || !orderMatchesSide(order, st.PositionSide) {
+				continue
+			}
+			if err := ts.cancelOrder(ctx, order); err != nil {
+				log.Printf("Warning: %v", err)
+				continue
+			}
+			log.Printf("Cancelled scale-in order %s of closed position %s", order.ID, trackedKey(st.Symbol, st.PositionSide))
+		}
+	}
+}
+
+// parseDCALevels parses comma-separated drawdown percentages such as "2,4,8",
+// sorted from the nearest level.
+func parseDCALevels(value string) ([]float64, error) {
+	var levels []float64
+	for _, item := range parseList(value) {
+		level, err := strconv.ParseFloat(item, 64)
+		if err != nil || level <= 0 || level >= 100 {
+			return nil, fmt.Errorf("invalid DCA level %q, want a percentage above 0 and below 100", item)
+		}
+		levels = append(levels, level)
+	}
+	sort.Float64s(levels)
+	return levels, nil
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"github.com/shopspring/decimal"
+
+	"futures-guard/ladder"
+)
+
+// Prices and quantities are kept as float64 in PositionData, but the stop and
+// target prices, potential profit and loss, and every price or quantity sent to an
+// exchange are computed in decimal. Binary floating point turns 0.29 truncated to
+// two decimals into 0.28 and makes a recomputed stop differ from the one already on
+// the book in the 15th digit, which caused needless order replacements and lot
+// size rejections.
+
+// hundred converts percentages to fractions.
+var hundred = decimal.NewFromInt(100)
+
+// toDecimal converts value using its shortest decimal representation, so 0.1
+// becomes exactly 0.1.
+func toDecimal(value float64) decimal.Decimal {
+	return decimal.NewFromFloat(value)
+}
+
+// roundToPrecision rounds value half away from zero to precision decimals.
+func roundToPrecision(value float64, precision int) float64 {
+	return toDecimal(value).Round(int32(precision)).InexactFloat64()
+}
+
+// truncateToPrecision rounds value towards zero to precision decimals, as order
+// quantities must never exceed the position they reduce.
+func truncateToPrecision(value float64, precision int) float64 {
+	return toDecimal(value).Truncate(int32(precision)).InexactFloat64()
+}
+
+// formatDecimal formats value with precision decimals, rounding half away from zero.
+func formatDecimal(value float64, precision int) string {
+	return toDecimal(value).StringFixed(int32(precision))
+}
+
+// scalePrice returns price moved by pct percent: above it when pct is positive,
+// below it when negative.
+func scalePrice(price, pct float64) float64 {
+	return ladder.ScalePrice(price, pct)
+}
+
+// tickSize returns the price step of precision decimals, e.g. 0.01 for 2.
+func tickSize(precision int) float64 {
+	return decimal.New(1, -int32(precision)).InexactFloat64()
+}
+
+// roundPrice rounds price to the price precision of symbol. Prices of unknown
+// symbols are returned unchanged.
+func (ts *TradingService) roundPrice(symbol string, price float64) float64 {
+	precision, ok := ts.symbolPrecision(symbol)
+	if !ok || price <= 0 {
+		return price
+	}
+	return roundToPrecision(price, precision.PricePrecision)
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+
+	spot "github.com/adshao/go-binance/v2"
+)
+
+// displayRateTTL is how long a conversion rate to the display currency is reused.
+const displayRateTTL = time.Minute
+
+// usdAssets are treated as interchangeable when no direct market exists between them.
+var usdAssets = map[string]bool{
+	"USD": true, "USDT": true, "USDC": true, "BUSD": true, "FDUSD": true,
+}
+
+// fiatAssets are the display currencies formatted with two decimals besides usdAssets.
+var fiatAssets = map[string]bool{
+	"EUR": true, "GBP": true, "JPY": true, "TRY": true, "BRL": true, "AUD": true,
+	"PLN": true, "UAH": true, "ZAR": true, "ARS": true, "MXN": true, "IDR": true,
+}
+
+// displayRate is a cached conversion rate.
+type displayRate struct {
+	rate      float64
+	fetchedAt time.Time
+}
+
+// displayRates converts PnL to the configured DISPLAY_CURRENCY using Binance spot prices.
+type displayRates struct {
+	mu     sync.Mutex
+	client *spot.Client
+	rates  map[string]displayRate // By "FROM/TO"
+}
+
+// rate returns how many units of to one unit of from is worth, from the spot price
+// of FROMTO or the inverse of TOFROM.
+func (r *displayRates) rate(from, to string) (float64, error) {
+	from, to = marketAsset(from), marketAsset(to)
+	if from == to {
+		return 1, nil
+	}
+	key := from + "/" + to
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if cached, ok := r.rates[key]; ok && time.Since(cached.fetchedAt) < displayRateTTL {
+		return cached.rate, nil
+	}
+	if r.client == nil {
+		r.client = spot.NewClient("", "")
+		r.rates = make(map[string]displayRate)
+	}
+
+	rate, err := r.fetch(from + to)
+	if err == nil && rate > 0 {
+		r.rates[key] = displayRate{rate: rate, fetchedAt: time.Now()}
+		return rate, nil
+	}
+	if inverse, err := r.fetch(to + from); err == nil && inverse > 0 {
+		r.rates[key] = displayRate{rate: 1 / inverse, fetchedAt: time.Now()}
+		return 1 / inverse, nil
+	}
+	if usdAssets[from] && usdAssets[to] {
+		return 1, nil
+	}
+	return 0, fmt.Errorf("no Binance spot market to convert %s to %s", from, to)
+}
+
+// fetch returns the last spot price of symbol.
+func (r *displayRates) fetch(symbol string) (float64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+
+	prices, err := r.client.NewListPricesService().Symbol(symbol).Do(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if len(prices) == 0 {
+		return 0, fmt.Errorf("no price for %s", symbol)
+	}
+	return strconv.ParseFloat(prices[0].Price, 64)
+}
+
+// marketAsset maps USD to USDT, the asset Binance spot markets quote USD prices in.
+func marketAsset(asset string) string {
+	if asset == "USD" {
+		return "USDT"
+	}
+	return asset
+}
+
+// applyDisplayCurrency sets the rate converting data's PnL to DISPLAY_CURRENCY. A
+// failed lookup only drops the converted amounts from reports.
+func (ts *TradingService) applyDisplayCurrency(data *PositionData) {
+	display := ts.config().DisplayCurrency
+	if display == "" || display == data.PnLAsset {
+		return
+	}
+	rate, err := ts.displayRates.rate(data.PnLAsset, display)
+	if err != nil {
+		log.Printf("Warning: %v", err)
+		return
+	}
+	data.DisplayCurrency = display
+	data.DisplayRate = rate
+}
+
+// formatPnL formats amount in asset, with two decimals for USD-like assets and
+// more for coin-denominated PnL.
+func formatPnL(amount float64, asset string) string {
+	if asset == "" {
+		asset = "USD"
+	}
+	if usdAssets[asset] || fiatAssets[asset] {
+		return fmt.Sprintf("%.2f %s", amount, asset)
+	}
+	return fmt.Sprintf("%.8f %s", amount, asset)
+}
+
+// formatPositionPnL formats a PnL amount of data in its settlement asset, followed by
+// the converted amount when a display currency is configured.
+func formatPositionPnL(data *PositionData, amount float64) string {
+	text := formatPnL(amount, data.PnLAsset)
+	if data.DisplayRate > 0 {
+		text += fmt.Sprintf(" (≈ %s)", formatPnL(amount*data.DisplayRate, data.DisplayCurrency))
+	}
+	return text
+}
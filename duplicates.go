@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+)
+
+// cancelDuplicateOrders keeps a single stop-loss and a single take-profit on the
+// position of data, cancelling the others, as left behind by a crashed run or a
+// placement that timed out. Of several orders that each close the whole position
+// the one that triggers first is kept, since it is the one protecting it; partial
+// orders, such as a take-profit ladder, are not duplicates.
+func (ts *TradingService) cancelDuplicateOrders(data *PositionData) {
+	if ts.config().ObserveOnly {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+
+	openOrders, err := ts.exchange().OpenOrders(ctx, data.Symbol)
+	if err != nil {
+		log.Printf("Warning: Unable to check for duplicate orders on %s: %v", data.Symbol, err)
+		return
+	}
+
+	var stops, takes []*Order
+	for _, order := range openOrders {
+		if !isProtectiveOrder(order) || !orderMatchesSide(order, data.PositionSide) || !ts.ownsOrder(order) {
+			continue
+		}
+		// Orders for part of the position are deliberate
+		if order.Quantity > 0 && order.Quantity < data.AbsAmt*(1-quantityTolerance) {
+			continue
+		}
+		if isStopLossOrder(order) {
+			stops = append(stops, order)
+		} else {
+			takes = append(takes, order)
+		}
+	}
+
+	var report []string
+	for _, orders := range [][]*Order{stops, takes} {
+		if len(orders) < 2 {
+			continue
+		}
+		keep := orders[0]
+		for _, order := range orders[1:] {
+			if triggersFirst(data, order, keep) {
+				keep = order
+			}
+		}
+		for _, order := range orders {
+			if order == keep {
+				continue
+			}
+			if err := ts.cancelOrder(ctx, order); err != nil {
+				log.Printf("Warning: %v", err)
+				continue
+			}
+			report = append(report, fmt.Sprintf("cancelled %s %s at %v, kept %s at %v",
+				order.Type, order.ID, order.StopPrice, keep.ID, keep.StopPrice))
+		}
+	}
+	if len(report) == 0 {
+		return
+	}
+
+	msg := fmt.Sprintf("🧹 Duplicate orders on %s (%s):\n- %s", data.Symbol, data.PositionSide, strings.Join(report, "\n- "))
+	log.Println(msg)
+	ts.notify(SeverityWarning, msg)
+}
+
+// triggersFirst reports whether order triggers before other as the price moves
+// towards it: the higher stop or the lower target of a long, and the reverse for
+// a short. Ties go to the most recently updated order.
+func triggersFirst(data *PositionData, order, other *Order) bool {
+	if order.StopPrice == other.StopPrice {
+		return order.UpdateTime.After(other.UpdateTime)
+	}
+	higher := order.StopPrice > other.StopPrice
+	if isStopLossOrder(order) {
+		return higher == data.IsLong
+	}
+	return higher != data.IsLong
+}
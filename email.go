@@ -0,0 +1,242 @@
+package main
+
+import (
+	"bytes"
+	"crypto/tls"
+	"fmt"
+	"html/template"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"futures-guard/settings"
+)
+
+// smtpImplicitTLSPort is the SMTP submission port that speaks TLS from the first
+// byte instead of upgrading with STARTTLS.
+const smtpImplicitTLSPort = 465
+
+// emailAlertTemplate lays out a notification as HTML.
+const emailAlertTemplate = `<!DOCTYPE html>
+<html><body style="font-family: sans-serif">
+<h2>{{.Subject}}</h2>
+<pre style="font-size: 14px">{{.Text}}</pre>
+<p style="color: #888">Sent by Futures Guard at {{.Sent.UTC.Format "2006-01-02 15:04"}} UTC</p>
+</body></html>`
+
+// emailDigestTemplate lays out the daily digest as HTML.
+const emailDigestTemplate = `<!DOCTYPE html>
+<html><body style="font-family: sans-serif">
+<h2>Daily PnL Report</h2>
+<p>{{.Start.UTC.Format "Jan 02 15:04"}} – {{.End.UTC.Format "Jan 02 15:04"}} UTC</p>
+<table cellpadding="6" style="border-collapse: collapse">
+<tr><td>Realized PnL</td><td align="right">{{usd .RealizedPnL}}</td></tr>
+<tr><td>Fees</td><td align="right">{{usd .Fees}}</td></tr>
+<tr><td>Funding</td><td align="right">{{usd .Funding}}</td></tr>
+<tr><td><b>Net</b></td><td align="right"><b style="color: {{if lt .NetPnL 0.0}}#c62828{{else}}#2e7d32{{end}}">{{usd .NetPnL}}</b></td></tr>
+<tr><td>Win rate</td><td align="right">{{printf "%.1f" .WinRate}}% ({{.Wins}} W / {{.Losses}} L)</td></tr>
+{{- if .Wins}}
+<tr><td>Largest win</td><td align="right">{{usd .LargestWin}} ({{.LargestWinOn}})</td></tr>
+{{- end}}
+{{- if .Losses}}
+<tr><td>Largest loss</td><td align="right">{{usd .LargestLoss}} ({{.LargestLossOn}})</td></tr>
+{{- end}}
+</table>
+{{- if .ByTag}}
+<h3>Net per strategy</h3>
+<table cellpadding="6" style="border-collapse: collapse">
+{{- range $tag, $net := .ByTag}}
+<tr><td>{{$tag}}</td><td align="right">{{usd $net}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+</body></html>`
+
+// emailFuncs are the functions available to the email templates.
+var emailFuncs = template.FuncMap{
+	"usd": func(v float64) string { return fmt.Sprintf("%.2f USD", v) },
+}
+
+// EmailNotifier sends notifications by email through an SMTP server, as plain
+// text with an HTML alternative.
+type EmailNotifier struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+	To       []string
+	alert    *template.Template
+	digest   *template.Template
+}
+
+// newEmailNotifier builds the email channel of config, with the digest template
+// of EMAIL_DIGEST_TEMPLATE when set.
+func newEmailNotifier(config Config) (*EmailNotifier, error) {
+	e := &EmailNotifier{
+		Host:     config.SMTPHost,
+		Port:     config.SMTPPort,
+		Username: config.SMTPUsername,
+		Password: config.SMTPPassword,
+		From:     config.EmailFrom,
+		To:       config.EmailTo,
+		alert:    template.Must(template.New("alert").Parse(emailAlertTemplate)),
+		digest:   template.Must(template.New("digest").Funcs(emailFuncs).Parse(emailDigestTemplate)),
+	}
+	if config.EmailDigestTemplate != "" {
+		content, err := os.ReadFile(config.EmailDigestTemplate)
+		if err != nil {
+			return e, fmt.Errorf("error reading EMAIL_DIGEST_TEMPLATE: %w", err)
+		}
+		digest, err := template.New("digest").Funcs(emailFuncs).Parse(string(content))
+		if err != nil {
+			return e, fmt.Errorf("error parsing EMAIL_DIGEST_TEMPLATE: %w", err)
+		}
+		e.digest = digest
+	}
+	return e, nil
+}
+
+// Name returns the channel name.
+func (e *EmailNotifier) Name() string { return "email" }
+
+// Send mails message, with its first line as the subject.
+func (e *EmailNotifier) Send(message string) error {
+	subject, _, _ := strings.Cut(message, "\n")
+	var html bytes.Buffer
+	err := e.alert.Execute(&html, struct {
+		Subject, Text string
+		Sent          time.Time
+	}{subject, message, time.Now()})
+	if err != nil {
+		return fmt.Errorf("error rendering email: %w", err)
+	}
+	return e.mail(subject, message, html.String())
+}
+
+// SendDigest mails the daily digest summary, with message as its plain text part.
+func (e *EmailNotifier) SendDigest(summary *DailySummary, message string) error {
+	var html bytes.Buffer
+	if err := e.digest.Execute(&html, summary); err != nil {
+		return fmt.Errorf("error rendering the daily digest email: %w", err)
+	}
+	subject := fmt.Sprintf("Daily PnL Report %s: %.2f USD", summary.End.UTC().Format("Jan 02"), summary.NetPnL())
+	return e.mail(subject, message, html.String())
+}
+
+// mail sends a multipart/alternative email with text and html bodies to every
+// recipient.
+func (e *EmailNotifier) mail(subject, text, html string) error {
+	var body bytes.Buffer
+	parts := multipart.NewWriter(&body)
+	for _, part := range []struct{ contentType, content string }{
+		{"text/plain; charset=UTF-8", text},
+		{"text/html; charset=UTF-8", html},
+	} {
+		w, err := parts.CreatePart(textproto.MIMEHeader{"Content-Type": {part.contentType}})
+		if err != nil {
+			return err
+		}
+		if _, err := w.Write([]byte(part.content)); err != nil {
+			return err
+		}
+	}
+	if err := parts.Close(); err != nil {
+		return err
+	}
+
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", e.From)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(e.To, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("UTF-8", subject))
+	fmt.Fprintf(&msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(&msg, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&msg, "Content-Type: multipart/alternative; boundary=%s\r\n\r\n", parts.Boundary())
+	msg.Write(body.Bytes())
+
+	return e.deliver(msg.Bytes())
+}
+
+// deliver hands msg to the SMTP server: over TLS from the start on port 465,
+// otherwise upgraded with STARTTLS when the server offers it.
+func (e *EmailNotifier) deliver(msg []byte) error {
+	addr := net.JoinHostPort(e.Host, strconv.Itoa(e.Port))
+	tlsConfig := &tls.Config{ServerName: e.Host}
+	dialer := &net.Dialer{Timeout: notifierTimeout}
+
+	var conn net.Conn
+	var err error
+	if e.Port == smtpImplicitTLSPort {
+		conn, err = tls.DialWithDialer(dialer, "tcp", addr, tlsConfig)
+	} else {
+		conn, err = dialer.Dial("tcp", addr)
+	}
+	if err != nil {
+		return fmt.Errorf("error connecting to %s: %w", addr, err)
+	}
+	conn.SetDeadline(time.Now().Add(notifierTimeout))
+	client, err := smtp.NewClient(conn, e.Host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok && e.Port != smtpImplicitTLSPort {
+		if err := client.StartTLS(tlsConfig); err != nil {
+			return err
+		}
+	}
+	if e.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", e.Username, e.Password, e.Host)); err != nil {
+			return err
+		}
+	}
+	if err := client.Mail(e.From); err != nil {
+		return err
+	}
+	for _, to := range e.To {
+		if err := client.Rcpt(to); err != nil {
+			return err
+		}
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+// loadEmailConfig reads the SMTP settings of the email channel from src.
+func loadEmailConfig(src *settings.Settings, config *Config) {
+	config.SMTPHost = src.String("SMTP_HOST")
+	config.SMTPPort = 587
+	config.SMTPPort = src.Int("SMTP_PORT", config.SMTPPort)
+	config.SMTPUsername = src.String("SMTP_USERNAME")
+	config.SMTPPassword = src.String("SMTP_PASSWORD")
+	config.EmailFrom = src.String("EMAIL_FROM")
+	if config.EmailFrom == "" {
+		config.EmailFrom = config.SMTPUsername
+	}
+	config.EmailTo = parseList(src.String("EMAIL_TO"))
+	config.EmailDigest = true
+	config.EmailDigest = src.Bool("EMAIL_DIGEST", config.EmailDigest)
+	config.EmailDigestTemplate = src.String("EMAIL_DIGEST_TEMPLATE")
+
+	if config.SMTPPort <= 0 {
+		src.Fail("SMTP_PORT", fmt.Errorf("must be positive, got %d", config.SMTPPort))
+		config.SMTPPort = 587
+	}
+}
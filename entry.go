@@ -0,0 +1,221 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+)
+
+// entryRequest describes a new position opened by the guard.
+type entryRequest struct {
+	Symbol       string
+	PositionSide string // BOTH in one-way mode
+	IsLong       bool
+	// Quantity is the position size; when zero the position is sized to risk
+	// RiskPct of equity at the default stop.
+	Quantity float64
+	RiskPct  float64
+	// LimitPrice places a limit entry instead of a market one when positive.
+	LimitPrice float64
+	// Tag labels the position with a strategy, subject to its position limit.
+	Tag string
+}
+
+// openPosition places an entry together with its SL/TP bracket in one batch
+// request, so the position is never unprotected after the entry fills. The stop
+// and target come from the configured strategies for the entry price.
+func (ts *TradingService) openPosition(req entryRequest) (*PositionData, error) {
+	if ts.exchange().Name() == exchangeBinanceSpot {
+		return nil, fmt.Errorf("opening positions is not supported on %s", exchangeBinanceSpot)
+	}
+	precision, ok := ts.symbolPrecision(req.Symbol)
+	if !ok {
+		return nil, fmt.Errorf("precision information not found for %s", req.Symbol)
+	}
+	if req.PositionSide == "" {
+		req.PositionSide = "BOTH"
+	}
+	if err := ts.entryBlocked(req.Symbol); err != nil {
+		return nil, err
+	}
+	req.Tag = normalizeTag(req.Tag)
+	if err := ts.tagLimitReached(req.Tag, req.Symbol, req.PositionSide); err != nil {
+		return nil, err
+	}
+
+	quantity, markPrice, leverage := req.Quantity, 0.0, 1.0
+	if quantity <= 0 {
+		if req.RiskPct <= 0 {
+			return nil, errors.New("a positive quantity or risk percentage is required")
+		}
+		size, err := ts.calculatePositionSize(req.Symbol, req.IsLong, req.RiskPct)
+		if err != nil {
+			return nil, err
+		}
+		quantity, markPrice, leverage = size.Quantity, size.EntryPrice, size.Leverage
+	} else {
+		var err error
+		if markPrice, err = ts.markPrice(req.Symbol); err != nil {
+			return nil, err
+		}
+	}
+	quantity = truncateToPrecision(quantity, precision.QuantityPrecision)
+	if quantity <= 0 {
+		return nil, fmt.Errorf("quantity for %s rounds to zero", req.Symbol)
+	}
+
+	entryPrice := markPrice
+	if req.LimitPrice > 0 {
+		entryPrice = req.LimitPrice
+	}
+	data := &PositionData{
+		Symbol:       req.Symbol,
+		PositionSide: req.PositionSide,
+		IsLong:       req.IsLong,
+		IsShort:      !req.IsLong,
+		EntryPrice:   entryPrice,
+		MarkPrice:    markPrice,
+		Leverage:     leverage,
+		AbsAmt:       quantity,
+		PositionAmt:  quantity,
+		Tag:          req.Tag,
+	}
+	if !req.IsLong {
+		data.PositionAmt = -quantity
+	}
+	data.StopPrice, _ = ts.applyRoundNumberNudge(data, ts.stopLossFor(data))
+	data.TakePrice = ts.takeProfitFor(data)
+	if ts.tpDeferred(data) {
+		// The first TP waits for TP_MIN_PROFIT or TP_MIN_AGE
+		data.TakePrice, data.RawTPPct, data.LeveragedTPPct = 0, 0, 0
+	}
+	if err := ts.calculateRiskMetrics(data); err != nil {
+		return nil, err
+	}
+
+	entry := OrderRequest{
+		Symbol:       req.Symbol,
+		Side:         sideSell,
+		PositionSide: req.PositionSide,
+		Type:         orderTypeMarket,
+		Quantity:     data.Quantity,
+	}
+	if req.IsLong {
+		entry.Side = sideBuy
+	}
+	if req.LimitPrice > 0 {
+		entry.Type = orderTypeLimit
+		entry.Price = formatDecimal(req.LimitPrice, precision.PricePrecision)
+	}
+	// Reduce-only legs can never open a reverse position if the entry does not fill
+	stop, take := ts.stopLossOrder(data), newTakeProfitOrder(data)
+	if err := checkReduceOnly(stop, data); err != nil {
+		return nil, err
+	}
+	stop.ClientOrderID = ts.clientOrderID(clientOrderKindSL, data)
+	take.ClientOrderID = ts.clientOrderID(clientOrderKindTP, data)
+
+	legs := []struct {
+		name  string
+		event EventType
+		price float64
+	}{
+		{"Stop Loss", EventSLMoved, data.StopPrice},
+		{"Take Profit", EventTPUpdated, data.TakePrice},
+	}
+	reqs := []OrderRequest{entry, stop, take}
+	if data.TakePrice <= 0 {
+		// Runners are opened with a stop only
+		legs, reqs = legs[:1], reqs[:2]
+	}
+
+	unlock := ts.lockSymbol(req.Symbol)
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+	orders, errs, err := ts.exchange().CreateOrders(ctx, reqs)
+	unlock()
+	if err != nil {
+		return nil, fmt.Errorf("error placing %s entry with bracket: %w", req.Symbol, err)
+	}
+
+	if errs[0] != nil || orders[0] == nil {
+		// Without an entry the bracket legs have nothing to protect
+		ts.cancelPlaced(ctx, orders[1:])
+		return nil, fmt.Errorf("error placing %s entry: %v", req.Symbol, errs[0])
+	}
+	if req.Tag != "" {
+		ts.setPositionTag(req.Symbol, req.PositionSide, req.Tag)
+	}
+
+	var failed []string
+	for i, leg := range legs {
+		if order := orders[i+1]; errs[i+1] == nil && order != nil {
+			ts.publish(leg.event, data, Event{Price: leg.price, OrderID: order.ID})
+			continue
+		}
+		ts.publish(EventOrderRejected, data, Event{Price: leg.price, Reason: leg.name, Error: fmt.Sprint(errs[i+1])})
+		failed = append(failed, leg.name)
+	}
+	if len(failed) == 0 {
+		return data, nil
+	}
+
+	if entry.Type == orderTypeLimit {
+		// A resting entry can still be withdrawn before it fills unprotected
+		ts.cancelPlaced(ctx, orders)
+		return nil, fmt.Errorf("bracket for %s failed for %v; entry cancelled", req.Symbol, failed)
+	}
+	// The market entry has filled: let the ladder protect it right away
+	log.Printf("Warning: Bracket for %s failed for %v, protecting it with the ladder", req.Symbol, failed)
+	ts.refreshSymbol(req.Symbol)
+	return data, nil
+}
+
+// cancelPlaced cancels the orders that were placed, skipping failed ones.
+func (ts *TradingService) cancelPlaced(ctx context.Context, orders []*Order) {
+	for _, order := range orders {
+		if order == nil {
+			continue
+		}
+		if err := ts.cancelOrder(ctx, order); err != nil {
+			log.Printf("Warning: %v", err)
+		}
+	}
+}
+
+// markPrice returns the current mark price of symbol from Binance market data.
+func (ts *TradingService) markPrice(symbol string) (float64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+
+	premium, err := ts.client().PremiumIndex(ctx, symbol)
+	if err != nil || len(premium) == 0 {
+		return 0, fmt.Errorf("error fetching mark price for %s: %v", symbol, err)
+	}
+	price, err := strconv.ParseFloat(premium[0].MarkPrice, 64)
+	if err != nil {
+		return 0, fmt.Errorf("error parsing mark price: %w", err)
+	}
+	return price, nil
+}
+
+// formatEntry creates a summary of an opened position and its bracket.
+func formatEntry(data *PositionData, limit bool) string {
+	kind := "market"
+	if limit {
+		kind = "limit"
+	}
+	side := "🔴 SHORT"
+	if data.IsLong {
+		side = "🟢 LONG"
+	}
+	take := data.TakePriceStr
+	if data.TakePrice <= 0 {
+		take = "none"
+	}
+	return fmt.Sprintf("🚀 Opened %s %s (%s) with a %s entry at %.8f\n📦 Quantity: %s  🛑 SL: %s  🎯 TP: %s",
+		data.Symbol, side, data.PositionSide, kind, data.EntryPrice,
+		data.Quantity, data.StopPriceStr, take)
+}
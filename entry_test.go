@@ -0,0 +1,131 @@
+package main
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	binance "github.com/adshao/go-binance/v2/futures"
+)
+
+func TestOpenPosition(t *testing.T) {
+	rejectTake := func(req OrderRequest) error {
+		if req.Type == orderTypeTakeProfitMarket {
+			return errors.New("order would immediately trigger")
+		}
+		return nil
+	}
+	tests := []struct {
+		name   string
+		req    entryRequest
+		adjust func(*Config)
+		reject func(OrderRequest) error
+
+		wantErr       bool
+		wantOpen      []string // Types of the orders left open, in placement order
+		wantSL        float64
+		wantTP        float64
+		wantCancelled []string
+	}{
+		{
+			name:     "market long with bracket",
+			req:      entryRequest{Symbol: "BTCUSDT", IsLong: true, Quantity: 0.5},
+			wantOpen: []string{orderTypeMarket, orderTypeStopMarket, orderTypeTakeProfitMarket},
+			wantSL:   98, wantTP: 150,
+		},
+		{
+			name:     "limit short with bracket",
+			req:      entryRequest{Symbol: "BTCUSDT", Quantity: 0.5, LimitPrice: 101},
+			wantOpen: []string{orderTypeLimit, orderTypeStopMarket, orderTypeTakeProfitMarket},
+			wantSL:   103.02, wantTP: 50.5,
+		},
+		{
+			name:     "runner opened with a stop only",
+			req:      entryRequest{Symbol: "BTCUSDT", IsLong: true, Quantity: 0.5},
+			adjust:   func(c *Config) { c.TPMinProfit = 10 },
+			wantOpen: []string{orderTypeMarket, orderTypeStopMarket},
+			wantSL:   98,
+		},
+		{
+			name:     "market entry kept when its target fails",
+			req:      entryRequest{Symbol: "BTCUSDT", IsLong: true, Quantity: 0.5},
+			reject:   rejectTake,
+			wantOpen: []string{orderTypeMarket, orderTypeStopMarket},
+			wantSL:   98,
+		},
+		{
+			name:          "limit entry cancelled when its target fails",
+			req:           entryRequest{Symbol: "BTCUSDT", IsLong: true, Quantity: 0.5, LimitPrice: 99},
+			reject:        rejectTake,
+			wantErr:       true,
+			wantCancelled: []string{orderTypeLimit, orderTypeStopMarket},
+		},
+		{
+			name: "bracket cancelled when the entry fails",
+			req:  entryRequest{Symbol: "BTCUSDT", IsLong: true, Quantity: 0.5},
+			reject: func(req OrderRequest) error {
+				if req.Type == orderTypeMarket {
+					return errors.New("margin is insufficient")
+				}
+				return nil
+			},
+			wantErr:       true,
+			wantCancelled: []string{orderTypeStopMarket, orderTypeTakeProfitMarket},
+		},
+		{
+			name:    "quantity rounding to zero",
+			req:     entryRequest{Symbol: "BTCUSDT", IsLong: true, Quantity: 0.0004},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exchange := newMemoryExchange("BTCUSDT")
+			client := newMemoryClient()
+			client.PremiumIndexes["BTCUSDT"] = &binance.PremiumIndex{Symbol: "BTCUSDT", MarkPrice: "100"}
+			ts := newTestTradingService(t, exchange, client, func(c *Config) {
+				orderTestConfig(c)
+				if tt.adjust != nil {
+					tt.adjust(c)
+				}
+			})
+			exchange.Reject = tt.reject
+
+			_, err := ts.openPosition(tt.req)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("openPosition error %v, want error %v", err, tt.wantErr)
+			}
+
+			var open []string
+			for _, order := range exchange.Orders {
+				open = append(open, order.Type)
+				switch order.Type {
+				case orderTypeStopMarket:
+					if order.StopPrice != tt.wantSL {
+						t.Errorf("stop at %v, want %v", order.StopPrice, tt.wantSL)
+					}
+				case orderTypeTakeProfitMarket:
+					if order.StopPrice != tt.wantTP {
+						t.Errorf("target at %v, want %v", order.StopPrice, tt.wantTP)
+					}
+				case orderTypeMarket, orderTypeLimit:
+					if order.Quantity != tt.req.Quantity {
+						t.Errorf("entry of %v, want %v", order.Quantity, tt.req.Quantity)
+					}
+				}
+			}
+			if !slices.Equal(open, tt.wantOpen) {
+				t.Errorf("open orders %v, want %v", open, tt.wantOpen)
+			}
+
+			var cancelled []string
+			for _, order := range exchange.Cancelled {
+				cancelled = append(cancelled, order.Type)
+			}
+			if !slices.Equal(cancelled, tt.wantCancelled) {
+				t.Errorf("cancelled %v, want %v", cancelled, tt.wantCancelled)
+			}
+		})
+	}
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+	"github.com/nats-io/nats.go"
+)
+
+// defaultEventTopic is the MQTT topic or NATS subject prefix of published events
+// unless EVENT_TOPIC is set.
+const defaultEventTopic = "futures-guard/events"
+
+// eventBroker publishes events to an MQTT topic or a NATS subject, one per event
+// type: <topic>/sl_moved on MQTT and <topic>.sl_moved on NATS, where slashes in
+// the topic become dots.
+type eventBroker struct {
+	send   func(topic string, payload []byte) error
+	topic  string
+	sep    string
+	events []EventType // empty publishes every event
+}
+
+// eventBrokerSchemes are the EVENT_BROKER_URL schemes of NATS, then of MQTT.
+var eventBrokerSchemes = []string{"nats", "tls", "mqtt", "mqtts", "tcp", "ssl", "ws", "wss"}
+
+// checkEventBrokerURL reports whether value is a NATS or MQTT URL. The error
+// leaves out the URL, which may carry the broker credentials.
+func checkEventBrokerURL(value string) error {
+	u, err := url.Parse(value)
+	if err != nil {
+		return errors.New("not a valid URL")
+	}
+	if !slices.Contains(eventBrokerSchemes, u.Scheme) {
+		return fmt.Errorf("unsupported scheme %q (use mqtt, mqtts or nats)", u.Scheme)
+	}
+	return nil
+}
+
+// newEventBroker connects to the broker at EventBrokerURL, or returns nil when
+// none is set. The connection is retried in the background, so an unreachable
+// broker at startup does not stop the bot.
+func newEventBroker(config Config) *eventBroker {
+	if config.EventBrokerURL == "" {
+		return nil
+	}
+	u, err := url.Parse(config.EventBrokerURL)
+	if err != nil {
+		log.Printf("Warning: Invalid EVENT_BROKER_URL: %v", err)
+		return nil
+	}
+
+	b := &eventBroker{topic: config.EventTopic, events: config.EventBrokerEvents}
+	switch u.Scheme {
+	case "nats", "tls":
+		conn, err := nats.Connect(config.EventBrokerURL, nats.Name("futures-guard"),
+			nats.RetryOnFailedConnect(true), nats.MaxReconnects(-1))
+		if err != nil {
+			log.Printf("Warning: Error connecting to NATS: %v", err)
+			return nil
+		}
+		b.send = conn.Publish
+		b.topic = strings.ReplaceAll(strings.Trim(b.topic, "/"), "/", ".")
+		b.sep = "."
+	case "mqtt", "mqtts", "tcp", "ssl", "ws", "wss":
+		// Client IDs must be unique per broker connection
+		hostname, _ := os.Hostname()
+		opts := mqtt.NewClientOptions().AddBroker(config.EventBrokerURL).
+			SetClientID(fmt.Sprintf("futures-guard-%s-%d", hostname, os.Getpid())).
+			SetAutoReconnect(true).SetConnectRetry(true)
+		if u.User != nil {
+			password, _ := u.User.Password()
+			opts.SetUsername(u.User.Username()).SetPassword(password)
+		}
+		client := mqtt.NewClient(opts)
+		client.Connect()
+		b.send = func(topic string, payload []byte) error {
+			token := client.Publish(topic, 1, false, payload)
+			if !token.WaitTimeout(defaultTimeout) {
+				return fmt.Errorf("timed out publishing to %s", topic)
+			}
+			return token.Error()
+		}
+		b.topic = strings.TrimSuffix(b.topic, "/")
+		b.sep = "/"
+	default:
+		log.Printf("Warning: Unsupported EVENT_BROKER_URL scheme %q (use mqtt, mqtts or nats)", u.Scheme)
+		return nil
+	}
+	return b
+}
+
+// handle publishes e in the background so a slow broker never delays order management.
+func (b *eventBroker) handle(e Event) {
+	if len(b.events) > 0 && !slices.Contains(b.events, e.Type) {
+		return
+	}
+
+	payload, err := json.Marshal(e)
+	if err != nil {
+		log.Printf("Warning: Error encoding broker event: %v", err)
+		return
+	}
+	topic := b.topic + b.sep + string(e.Type)
+	go func() {
+		if err := b.send(topic, payload); err != nil {
+			log.Printf("Warning: Error publishing %s event to %s: %v", e.Type, topic, err)
+		}
+	}()
+}
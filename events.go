@@ -0,0 +1,174 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+)
+
+// EventType identifies what happened to a position or its orders.
+type EventType string
+
+// Event types published by the trading service.
+const (
+	EventPositionSnapshot EventType = "position_snapshot"
+	EventThresholdCrossed EventType = "threshold_crossed"
+	EventSLMoved          EventType = "sl_moved"
+	EventTPUpdated        EventType = "tp_updated"
+	EventTPHit            EventType = "tp_hit"
+	EventOrderRejected    EventType = "order_rejected"
+	EventPositionClosed   EventType = "position_closed"
+	EventError            EventType = "error"
+	EventKillSwitch       EventType = "kill_switch"
+)
+
+// Event describes a change to a position or its protective orders.
+type Event struct {
+	Type         EventType `json:"type"`
+	Time         time.Time `json:"time"`
+	Symbol       string    `json:"symbol"`
+	PositionSide string    `json:"position_side"`
+	Stage        int       `json:"stage,omitempty"`
+	Price        float64   `json:"price,omitempty"`
+	OrderID      OrderID   `json:"order_id,omitempty"`
+	Reason       string    `json:"reason,omitempty"`
+	Error        string    `json:"error,omitempty"`
+
+	// Position is the full position snapshot of position_snapshot events.
+	Position *PositionData `json:"position,omitempty"`
+}
+
+// String returns a one-line description of the event for logs and notifications.
+func (e Event) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %s %s", e.Type, e.Symbol, e.PositionSide)
+	if e.Type == EventThresholdCrossed {
+		fmt.Fprintf(&b, " stage=%d", e.Stage)
+	}
+	if e.Price > 0 {
+		fmt.Fprintf(&b, " price=%v", e.Price)
+	}
+	if e.OrderID != "" {
+		fmt.Fprintf(&b, " order=%s", e.OrderID)
+	}
+	if e.Reason != "" {
+		fmt.Fprintf(&b, " reason=%q", e.Reason)
+	}
+	if e.Error != "" {
+		fmt.Fprintf(&b, " error=%q", e.Error)
+	}
+	return b.String()
+}
+
+// EventHandler receives published events.
+type EventHandler func(Event)
+
+// EventBus delivers published events to their subscribers synchronously, in
+// subscription order.
+type EventBus struct {
+	mu       sync.RWMutex
+	handlers map[EventType][]EventHandler
+	all      []EventHandler
+}
+
+// newEventBus returns an event bus without subscribers.
+func newEventBus() *EventBus {
+	return &EventBus{handlers: make(map[EventType][]EventHandler)}
+}
+
+// Subscribe registers handler for the given event types, or for every event
+// when no type is given.
+func (b *EventBus) Subscribe(handler EventHandler, types ...EventType) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if len(types) == 0 {
+		b.all = append(b.all, handler)
+		return
+	}
+	for _, t := range types {
+		b.handlers[t] = append(b.handlers[t], handler)
+	}
+}
+
+// Publish delivers event to its subscribers, stamping its time when unset.
+func (b *EventBus) Publish(event Event) {
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+
+	b.mu.RLock()
+	handlers := append(append([]EventHandler(nil), b.all...), b.handlers[event.Type]...)
+	b.mu.RUnlock()
+
+	for _, handler := range handlers {
+		handler(event)
+	}
+}
+
+// publish sends an event about data's position to the event bus.
+func (ts *TradingService) publish(eventType EventType, data *PositionData, event Event) {
+	event.Type = eventType
+	event.Symbol = data.Symbol
+	event.PositionSide = data.PositionSide
+	ts.events.Publish(event)
+}
+
+// subscribeEvents wires the built-in subscribers: logging, metrics, state
+// persistence, notifications, outbound webhooks, the MQTT or NATS broker and the
+// InfluxDB time series.
+func (ts *TradingService) subscribeEvents() {
+	ts.events.Subscribe(func(e Event) {
+		// Snapshots are already printed as position summaries
+		if e.Type != EventPositionSnapshot {
+			log.Printf("Event: %s", e)
+		}
+	})
+	ts.events.Subscribe(ts.countEvent)
+	ts.events.Subscribe(ts.persistEvent, EventSLMoved, EventTPUpdated)
+	ts.events.Subscribe(func(e Event) {
+		// A position left without its stop pages even during quiet hours
+		severity := SeverityWarning
+		if e.Reason == "Stop Loss" {
+			severity = SeverityCritical
+		}
+		ts.notify(severity, fmt.Sprintf("⛔ %s order for %s (%s) rejected: %s", e.Reason, e.Symbol, e.PositionSide, e.Error))
+	}, EventOrderRejected)
+	if webhook := newWebhookNotifier(*ts.config()); webhook != nil {
+		ts.events.Subscribe(webhook.handle)
+	}
+	if broker := newEventBroker(*ts.config()); broker != nil {
+		ts.events.Subscribe(broker.handle)
+	}
+	if influx := newInfluxWriter(*ts.config()); influx != nil {
+		ts.events.Subscribe(influx.handle, EventPositionSnapshot)
+	}
+}
+
+// countEvent counts published events by type for the health endpoints.
+func (ts *TradingService) countEvent(e Event) {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	if ts.health.events == nil {
+		ts.health.events = make(map[EventType]int64)
+	}
+	ts.health.events[e.Type]++
+}
+
+// persistEvent records the SL/TP orders the bot placed in the persisted state.
+func (ts *TradingService) persistEvent(e Event) {
+	ts.mu.Lock()
+	st := ts.orderState(e.Symbol, e.PositionSide)
+	if e.Type == EventSLMoved {
+		st.StopOrderID = e.OrderID
+		st.StopPrice = e.Price
+	} else {
+		st.TakeOrderID = e.OrderID
+		st.TakePrice = e.Price
+	}
+	st.UpdatedAt = e.Time
+	ts.mu.Unlock()
+
+	ts.saveState()
+}
@@ -0,0 +1,236 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"futures-guard/settings"
+)
+
+// Supported exchanges.
+const (
+	exchangeBinance      = "binance"
+	exchangeBinanceCoinM = "binance-coinm"
+	exchangeBinanceSpot  = "binance-spot"
+	exchangeBybit        = "bybit"
+	exchangeOKX          = "okx"
+)
+
+// Order types used by the guard, named after their Binance equivalents.
+const (
+	orderTypeMarket           = "MARKET"
+	orderTypeLimit            = "LIMIT"
+	orderTypeStop             = "STOP" // Stop-limit
+	orderTypeStopMarket       = "STOP_MARKET"
+	orderTypeTakeProfitMarket = "TAKE_PROFIT_MARKET"
+)
+
+// Order sides.
+const (
+	sideBuy  = "BUY"
+	sideSell = "SELL"
+)
+
+// Exchange abstracts the venue-specific calls the guard needs to protect positions.
+// Implementations translate to and from the exchange's own API; position sides use
+// the Binance convention of BOTH for one-way mode and LONG/SHORT in hedge mode.
+type Exchange interface {
+	// Name returns the exchange identifier, e.g. "binance".
+	Name() string
+	// ServerTime returns the exchange's current time.
+	ServerTime(ctx context.Context) (time.Time, error)
+	// SymbolPrecisions returns the price and quantity precision of every tradable symbol.
+	SymbolPrecisions(ctx context.Context) (map[string]SymbolPrecision, error)
+	// Positions returns the positions of symbol, or of every symbol when empty.
+	Positions(ctx context.Context, symbol string) ([]*Position, error)
+	// OpenOrders returns the open orders of symbol, or of every symbol when empty.
+	OpenOrders(ctx context.Context, symbol string) ([]*Order, error)
+	// CreateOrder places a single order.
+	CreateOrder(ctx context.Context, req OrderRequest) (*Order, error)
+	// CreateOrders places several orders together, reporting a result or an error per
+	// request in submission order.
+	CreateOrders(ctx context.Context, reqs []OrderRequest) ([]*Order, []error, error)
+	// CancelOrder cancels an open order.
+	CancelOrder(ctx context.Context, order *Order) error
+}
+
+// Position is a venue-neutral futures position.
+type Position struct {
+	Symbol           string
+	PositionSide     string
+	PositionAmt      float64 // Negative for one-way shorts
+	EntryPrice       float64
+	MarkPrice        float64
+	Leverage         float64
+	LiquidationPrice float64
+
+	// MarginType is isolated or crossed, empty when the exchange does not report it.
+	MarginType string
+
+	// Inverse (COIN-M) contracts: quote value of one contract and the asset PnL
+	// is settled in. ContractSize is zero for linear contracts.
+	ContractSize float64
+	MarginAsset  string
+}
+
+// Order is a venue-neutral open order.
+type Order struct {
+	ID            OrderID
+	ClientOrderID string
+	Symbol        string
+	Type          string
+	Side          string
+	PositionSide  string
+	StopPrice     float64
+	Price         float64 // Limit price, zero for market and stop-market orders
+	Quantity      float64 // Zero for closePosition orders or when the exchange does not report it
+	UpdateTime    time.Time
+}
+
+// OrderRequest describes an order to place. Quantity and StopPrice are already
+// formatted to the symbol's precision.
+type OrderRequest struct {
+	Symbol       string
+	Side         string
+	PositionSide string // BOTH for one-way mode
+	Type         string
+	Quantity     string
+	StopPrice    string
+	Price        string // Limit price of LIMIT orders
+	ReduceOnly   bool
+	// ClientOrderID is the ID the order is placed under, empty to let the
+	// exchange assign one.
+	ClientOrderID string
+	// ClosePosition closes the whole position when triggered, without a quantity.
+	// Only Binance USDⓈ-M supports it; other exchanges use Quantity.
+	ClosePosition bool
+}
+
+// OrderID identifies an exchange order. Binance and OKX IDs are numeric while Bybit uses UUIDs.
+type OrderID string
+
+// UnmarshalJSON accepts both string IDs and the numeric IDs of older state files.
+func (id *OrderID) UnmarshalJSON(b []byte) error {
+	if bytes.HasPrefix(b, []byte(`"`)) {
+		var s string
+		if err := json.Unmarshal(b, &s); err != nil {
+			return err
+		}
+		*id = OrderID(s)
+		return nil
+	}
+	var n json.Number
+	if err := json.Unmarshal(b, &n); err != nil {
+		return err
+	}
+	*id = OrderID(n.String())
+	return nil
+}
+
+// setupExchange connects to the exchange selected by config.
+func setupExchange(config Config) (Exchange, error) {
+	switch config.Exchange {
+	case exchangeBinanceCoinM:
+		return setupBinanceCoinMExchange(config)
+	case exchangeBinanceSpot:
+		return setupSpotExchange(config)
+	case exchangeBybit:
+		return setupBybitExchange(config)
+	case exchangeOKX:
+		return setupOKXExchange(config)
+	default:
+		client, err := setupBinanceClient(config)
+		if err != nil {
+			return nil, err
+		}
+		return newBinanceExchange(client, config), nil
+	}
+}
+
+// restrictToExchange disables the features that rely on Binance-only data when
+// another exchange is selected, warning about those src enabled.
+func restrictToExchange(src *settings.Settings, config *Config) {
+	if config.Exchange == exchangeBinance {
+		return
+	}
+
+	disable := func(name string, enabled bool) {
+		if enabled {
+			src.Warn(name, fmt.Errorf("only supported on Binance, disabling it on %s", config.Exchange))
+		}
+	}
+	disable("MARK_PRICE_STREAM", config.MarkPriceStream)
+	disable("FUNDING_INCLUDE_IN_PROFIT", config.FundingIncludeInProfit)
+	disable("FUNDING_EXTREME_RATE", config.FundingExtremeRate > 0)
+	disable("FEES_INCLUDE_IN_PROFIT", config.FeesIncludeInProfit)
+	disable("MAX_HOLDING_TIME", config.MaxHoldingTime > 0 || len(config.MaxHoldingTimeOverrides) > 0)
+	disable("DAILY_REPORT", config.DailyReport)
+	disable("ADL_ALERT_QUANTILE", config.ADLAlertQuantile > 0)
+	disable("MARGIN_RATIO_ALERT_PERCENT", config.MarginRatioAlertPct > 0)
+	disable("LEVERAGE_TARGET", config.LeverageTarget > 0 || len(config.LeverageTargetOverrides) > 0)
+	disable("MARGIN_TYPE_TARGET", config.MarginTypeTarget != "" || len(config.MarginTypeTargetOverrides) > 0)
+
+	config.MarkPriceStream = false
+	config.FundingIncludeInProfit = false
+	config.FundingExtremeRate = 0
+	config.FeesIncludeInProfit = false
+	config.MaxHoldingTime = 0
+	config.MaxHoldingTimeOverrides = nil
+	config.DailyReport = false
+	config.ADLAlertQuantile = 0
+	config.MarginRatioAlertPct = 0
+	config.LeverageTarget = 0
+	config.LeverageTargetOverrides = nil
+	config.MarginTypeTarget = ""
+	config.MarginTypeTargetOverrides = nil
+}
+
+// requireBinance returns an error when feature is used with another exchange.
+func (ts *TradingService) requireBinance(feature string) error {
+	if ts.exchange() != nil && ts.exchange().Name() != exchangeBinance {
+		return fmt.Errorf("%s is only supported on Binance", feature)
+	}
+	return nil
+}
+
+// parseExchange normalizes the configured exchange.
+func parseExchange(value string) (string, error) {
+	switch exchange := strings.ToLower(strings.TrimSpace(value)); exchange {
+	case exchangeBinance, exchangeBinanceCoinM, exchangeBinanceSpot, exchangeBybit, exchangeOKX:
+		return exchange, nil
+	default:
+		return "", fmt.Errorf("want %s, %s, %s, %s or %s", exchangeBinance, exchangeBinanceCoinM, exchangeBinanceSpot, exchangeBybit, exchangeOKX)
+	}
+}
+
+// parsePrecision returns the number of decimals of a step such as "0.001".
+func parsePrecision(step string) int {
+	step = strings.TrimRight(step, "0")
+	if i := strings.IndexByte(step, '.'); i >= 0 {
+		return len(step) - i - 1
+	}
+	return 0
+}
+
+// parseFloatOrZero parses s, treating empty or malformed values, NaN and
+// infinities as zero.
+func parseFloatOrZero(s string) float64 {
+	val, err := strconv.ParseFloat(s, 64)
+	if err != nil || !finite(val) {
+		return 0
+	}
+	return val
+}
+
+// closeSide returns the order side that closes a position.
+func closeSide(data *PositionData) string {
+	if data.IsLong {
+		return sideSell
+	}
+	return sideBuy
+}
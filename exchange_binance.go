@@ -0,0 +1,251 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	binance "github.com/adshao/go-binance/v2/futures"
+)
+
+// binanceExchange implements Exchange for Binance USDⓈ-M futures.
+type binanceExchange struct {
+	client *binance.Client
+	config Config
+}
+
+// newBinanceExchange wraps a connected Binance futures client.
+func newBinanceExchange(client *binance.Client, config Config) *binanceExchange {
+	return &binanceExchange{client: client, config: config}
+}
+
+// Name implements Exchange.
+func (e *binanceExchange) Name() string {
+	return exchangeBinance
+}
+
+// signed returns the request options for signed requests.
+func (e *binanceExchange) signed() []binance.RequestOption {
+	return []binance.RequestOption{recvWindowOption(e.config)}
+}
+
+// SyncTime implements timeSyncer.
+func (e *binanceExchange) SyncTime(ctx context.Context) error {
+	return syncServerTime(ctx, e.client)
+}
+
+// ServerTime implements Exchange.
+func (e *binanceExchange) ServerTime(ctx context.Context) (time.Time, error) {
+	serverTime, err := e.client.NewServerTimeService().Do(ctx)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.UnixMilli(serverTime), nil
+}
+
+// SymbolPrecisions implements Exchange.
+func (e *binanceExchange) SymbolPrecisions(ctx context.Context) (map[string]SymbolPrecision, error) {
+	exchangeInfo, err := e.client.NewExchangeInfoService().Do(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	symbolInfo := make(map[string]SymbolPrecision, len(exchangeInfo.Symbols))
+	for _, info := range exchangeInfo.Symbols {
+		precision := SymbolPrecision{
+			PricePrecision:    info.PricePrecision,
+			QuantityPrecision: info.QuantityPrecision,
+			SettleAsset:       info.MarginAsset,
+		}
+		if filter := info.LotSizeFilter(); filter != nil {
+			precision.MinQty = parseFloatOrZero(filter.MinQuantity)
+		}
+		if filter := info.MinNotionalFilter(); filter != nil {
+			precision.MinNotional = parseFloatOrZero(filter.Notional)
+		}
+		symbolInfo[info.Symbol] = precision
+	}
+	return symbolInfo, nil
+}
+
+// Positions implements Exchange.
+func (e *binanceExchange) Positions(ctx context.Context, symbol string) ([]*Position, error) {
+	service := e.client.NewGetPositionRiskService()
+	if symbol != "" {
+		service = service.Symbol(symbol)
+	}
+	risks, err := service.Do(ctx, e.signed()...)
+	if err != nil {
+		return nil, err
+	}
+
+	positions := make([]*Position, 0, len(risks))
+	for _, risk := range risks {
+		position, err := binancePosition(risk)
+		if err != nil {
+			return nil, err
+		}
+		positions = append(positions, position)
+	}
+	return positions, nil
+}
+
+// binancePosition converts a Binance position, whose numbers are strings.
+func binancePosition(risk *binance.PositionRisk) (*Position, error) {
+	posAmt, err := strconv.ParseFloat(risk.PositionAmt, 64)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing position amount: %w", err)
+	}
+
+	entryPrice, err := strconv.ParseFloat(risk.EntryPrice, 64)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing entry price: %w", err)
+	}
+
+	markPrice, err := strconv.ParseFloat(risk.MarkPrice, 64)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing mark price: %w", err)
+	}
+
+	leverage, err := strconv.ParseFloat(risk.Leverage, 64)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing leverage: %w", err)
+	}
+
+	return &Position{
+		Symbol:       risk.Symbol,
+		PositionSide: risk.PositionSide,
+		PositionAmt:  posAmt,
+		EntryPrice:   entryPrice,
+		MarkPrice:    markPrice,
+		Leverage:     leverage,
+		// Liquidation price may be empty or zero when the position cannot be liquidated
+		LiquidationPrice: parseFloatOrZero(risk.LiquidationPrice),
+		MarginType:       binanceMarginType(risk.MarginType),
+	}, nil
+}
+
+// OpenOrders implements Exchange.
+func (e *binanceExchange) OpenOrders(ctx context.Context, symbol string) ([]*Order, error) {
+	service := e.client.NewListOpenOrdersService()
+	if symbol != "" {
+		service = service.Symbol(symbol)
+	}
+	orders, err := service.Do(ctx, e.signed()...)
+	if err != nil {
+		return nil, err
+	}
+
+	openOrders := make([]*Order, 0, len(orders))
+	for _, order := range orders {
+		openOrders = append(openOrders, binanceOrder(order))
+	}
+	return openOrders, nil
+}
+
+// binanceOrder converts a Binance order.
+func binanceOrder(order *binance.Order) *Order {
+	return &Order{
+		ID:            OrderID(strconv.FormatInt(order.OrderID, 10)),
+		ClientOrderID: order.ClientOrderID,
+		Symbol:        order.Symbol,
+		Type:          string(order.Type),
+		Side:          string(order.Side),
+		PositionSide:  string(order.PositionSide),
+		StopPrice:     parseFloatOrZero(order.StopPrice),
+		Price:         parseFloatOrZero(order.Price),
+		Quantity:      parseFloatOrZero(order.OrigQuantity),
+		UpdateTime:    time.UnixMilli(order.UpdateTime),
+	}
+}
+
+// newOrderService builds the create order request for req.
+func (e *binanceExchange) newOrderService(req OrderRequest) *binance.CreateOrderService {
+	service := e.client.NewCreateOrderService().
+		Symbol(req.Symbol).
+		Side(binance.SideType(req.Side)).
+		Type(binance.OrderType(req.Type)).
+		Quantity(req.Quantity).
+		NewOrderResponseType(binance.NewOrderRespTypeRESULT)
+
+	if req.StopPrice != "" {
+		service = service.StopPrice(req.StopPrice).TimeInForce(binance.TimeInForceTypeGTC)
+	}
+	if req.Price != "" {
+		service = service.Price(req.Price).TimeInForce(binance.TimeInForceTypeGTC)
+	}
+	if req.ClientOrderID != "" {
+		service = service.NewClientOrderID(req.ClientOrderID)
+	}
+	if req.ClosePosition {
+		// closePosition orders take no quantity and cannot be combined with reduceOnly
+		service = service.Quantity("").ClosePosition(true)
+	}
+
+	// reduceOnly is rejected in hedge mode, where the position side already implies it
+	if req.PositionSide != "" && req.PositionSide != "BOTH" {
+		service = service.PositionSide(binance.PositionSideType(req.PositionSide))
+	} else if req.ReduceOnly && !req.ClosePosition {
+		service = service.ReduceOnly(true)
+	}
+	return service
+}
+
+// CreateOrder implements Exchange.
+func (e *binanceExchange) CreateOrder(ctx context.Context, req OrderRequest) (*Order, error) {
+	res, err := e.newOrderService(req).Do(ctx, e.signed()...)
+	if err != nil {
+		return nil, err
+	}
+	return &Order{
+		ID:            OrderID(strconv.FormatInt(res.OrderID, 10)),
+		ClientOrderID: res.ClientOrderID,
+		Symbol:        res.Symbol,
+		Type:          string(res.Type),
+		Side:          string(res.Side),
+		PositionSide:  string(res.PositionSide),
+		StopPrice:     parseFloatOrZero(res.StopPrice),
+		Price:         parseFloatOrZero(res.Price),
+		UpdateTime:    time.UnixMilli(res.UpdateTime),
+	}, nil
+}
+
+// CreateOrders implements Exchange using the batch order endpoint.
+func (e *binanceExchange) CreateOrders(ctx context.Context, reqs []OrderRequest) ([]*Order, []error, error) {
+	services := make([]*binance.CreateOrderService, 0, len(reqs))
+	for _, req := range reqs {
+		services = append(services, e.newOrderService(req))
+	}
+
+	res, err := e.client.NewCreateBatchOrdersService().OrderList(services).Do(ctx, e.signed()...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Successful orders are listed without gaps; line them up with their requests
+	orders := make([]*Order, len(reqs))
+	errs := make([]error, len(reqs))
+	next := 0
+	for i := range reqs {
+		if i < len(res.Errors) && res.Errors[i] != nil {
+			errs[i] = res.Errors[i]
+			continue
+		}
+		if next < len(res.Orders) {
+			orders[i] = binanceOrder(res.Orders[next])
+			next++
+		}
+	}
+	return orders, errs, nil
+}
+
+// CancelOrder implements Exchange.
+func (e *binanceExchange) CancelOrder(ctx context.Context, order *Order) error {
+	orderID, err := strconv.ParseInt(string(order.ID), 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid Binance order ID %q: %w", order.ID, err)
+	}
+	_, err = e.client.NewCancelOrderService().Symbol(order.Symbol).OrderID(orderID).Do(ctx, e.signed()...)
+	return err
+}
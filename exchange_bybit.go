@@ -0,0 +1,512 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Bybit v5 API endpoints.
+const (
+	bybitBaseURL        = "https://api.bybit.com"
+	bybitTestnetBaseURL = "https://api-testnet.bybit.com"
+
+	// bybitCategory selects USDT perpetual contracts.
+	bybitCategory = "linear"
+	// bybitSettleCoin limits position and order queries without a symbol to USDT contracts.
+	bybitSettleCoin = "USDT"
+)
+
+// bybitTimestampErrorCode is returned when a request timestamp is outside the recvWindow.
+const bybitTimestampErrorCode = 10002
+
+// Bybit trigger directions for conditional orders.
+const (
+	bybitTriggerRise = 1
+	bybitTriggerFall = 2
+)
+
+// bybitExchange implements Exchange for Bybit USDT perpetuals using the v5 REST API.
+type bybitExchange struct {
+	apiKey     string
+	apiSecret  string
+	baseURL    string
+	recvWindow time.Duration
+	httpClient *http.Client
+
+	mu         sync.Mutex
+	timeOffset time.Duration
+}
+
+// bybitResponse is the envelope of every Bybit v5 response.
+type bybitResponse struct {
+	RetCode int             `json:"retCode"`
+	RetMsg  string          `json:"retMsg"`
+	Result  json.RawMessage `json:"result"`
+}
+
+// bybitError is an error reported by the Bybit API.
+type bybitError struct {
+	Code    int
+	Message string
+}
+
+func (e *bybitError) Error() string {
+	return fmt.Sprintf("<BybitError> code=%d, msg=%s", e.Code, e.Message)
+}
+
+// setupBybitExchange initializes and validates the Bybit API client.
+func setupBybitExchange(config Config) (*bybitExchange, error) {
+	apiKey := config.Credentials.BybitAPIKey
+	apiSecret := config.Credentials.BybitAPISecret
+
+	if apiKey == "" || apiSecret == "" {
+		return nil, fmt.Errorf("bybit API credentials not configured")
+	}
+
+	baseURL := bybitBaseURL
+	if config.BybitTestnet {
+		baseURL = bybitTestnetBaseURL
+	}
+
+	e := &bybitExchange{
+		apiKey:     apiKey,
+		apiSecret:  apiSecret,
+		baseURL:    baseURL,
+		recvWindow: config.RecvWindow,
+		httpClient: &http.Client{Timeout: defaultTimeout},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+
+	// Calibrate the clock offset before the first signed request
+	if config.TimeSync {
+		if err := e.SyncTime(ctx); err != nil {
+			log.Printf("Warning: %v", err)
+		}
+	}
+
+	// Validate API connection
+	if _, err := e.Positions(ctx, ""); err != nil {
+		return nil, fmt.Errorf("failed to connect to Bybit API: %w", err)
+	}
+	return e, nil
+}
+
+// Name implements Exchange.
+func (e *bybitExchange) Name() string {
+	return exchangeBybit
+}
+
+// SyncTime implements timeSyncer, setting the local clock offset from the Bybit server time.
+func (e *bybitExchange) SyncTime(ctx context.Context) error {
+	serverTime, err := e.ServerTime(ctx)
+	if err != nil {
+		return fmt.Errorf("error synchronizing Bybit server time: %w", err)
+	}
+	offset := time.Until(serverTime)
+
+	e.mu.Lock()
+	e.timeOffset = offset
+	e.mu.Unlock()
+
+	log.Printf("Synchronized with Bybit server time (local clock offset %dms)", -offset.Milliseconds())
+	return nil
+}
+
+// timestamp returns the current Bybit server time in milliseconds.
+func (e *bybitExchange) timestamp() string {
+	e.mu.Lock()
+	offset := e.timeOffset
+	e.mu.Unlock()
+	return strconv.FormatInt(time.Now().Add(offset).UnixMilli(), 10)
+}
+
+// do sends a request and decodes the result into out. GET parameters go in the
+// query string and POST parameters in a JSON body; signed requests carry the
+// HMAC-SHA256 signature headers.
+func (e *bybitExchange) do(ctx context.Context, method, path string, params map[string]any, signed bool, out any) error {
+	var query, payload string
+	var body io.Reader
+	if method == http.MethodGet {
+		values := url.Values{}
+		for key, val := range params {
+			values.Set(key, fmt.Sprint(val))
+		}
+		query = values.Encode()
+		payload = query
+	} else {
+		raw, err := json.Marshal(params)
+		if err != nil {
+			return fmt.Errorf("error encoding Bybit request: %w", err)
+		}
+		payload = string(raw)
+		body = bytes.NewReader(raw)
+	}
+
+	fullURL := e.baseURL + path
+	if query != "" {
+		fullURL += "?" + query
+	}
+	req, err := http.NewRequestWithContext(ctx, method, fullURL, body)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	if signed {
+		timestamp := e.timestamp()
+		recvWindow := strconv.FormatInt(e.recvWindow.Milliseconds(), 10)
+		mac := hmac.New(sha256.New, []byte(e.apiSecret))
+		mac.Write([]byte(timestamp + e.apiKey + recvWindow + payload))
+
+		req.Header.Set("X-BAPI-API-KEY", e.apiKey)
+		req.Header.Set("X-BAPI-TIMESTAMP", timestamp)
+		req.Header.Set("X-BAPI-RECV-WINDOW", recvWindow)
+		req.Header.Set("X-BAPI-SIGN", hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	resp, err := e.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("bybit %s %s returned HTTP %d: %s", method, path, resp.StatusCode, raw)
+	}
+
+	var envelope bybitResponse
+	if err := json.Unmarshal(raw, &envelope); err != nil {
+		return fmt.Errorf("error decoding Bybit response: %w", err)
+	}
+	if envelope.RetCode != 0 {
+		if envelope.RetCode == bybitTimestampErrorCode {
+			log.Println("Warning: Bybit rejected a request timestamp, resynchronizing server time")
+			go func() {
+				if err := e.SyncTime(context.Background()); err != nil {
+					log.Printf("Warning: %v", err)
+				}
+			}()
+		}
+		return &bybitError{Code: envelope.RetCode, Message: envelope.RetMsg}
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.Unmarshal(envelope.Result, out); err != nil {
+		return fmt.Errorf("error decoding Bybit result: %w", err)
+	}
+	return nil
+}
+
+// ServerTime implements Exchange.
+func (e *bybitExchange) ServerTime(ctx context.Context) (time.Time, error) {
+	var result struct {
+		TimeNano string `json:"timeNano"`
+	}
+	if err := e.do(ctx, http.MethodGet, "/v5/market/time", nil, false, &result); err != nil {
+		return time.Time{}, err
+	}
+	nanos, err := strconv.ParseInt(result.TimeNano, 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("error parsing Bybit server time: %w", err)
+	}
+	return time.Unix(0, nanos), nil
+}
+
+// SymbolPrecisions implements Exchange, deriving precisions from the tick size and quantity step.
+func (e *bybitExchange) SymbolPrecisions(ctx context.Context) (map[string]SymbolPrecision, error) {
+	symbolInfo := make(map[string]SymbolPrecision)
+	cursor := ""
+	for {
+		params := map[string]any{"category": bybitCategory, "limit": 1000}
+		if cursor != "" {
+			params["cursor"] = cursor
+		}
+
+		var result struct {
+			List []struct {
+				Symbol      string `json:"symbol"`
+				PriceFilter struct {
+					TickSize string `json:"tickSize"`
+				} `json:"priceFilter"`
+				LotSizeFilter struct {
+					QtyStep          string `json:"qtyStep"`
+					MinOrderQty      string `json:"minOrderQty"`
+					MinNotionalValue string `json:"minNotionalValue"`
+				} `json:"lotSizeFilter"`
+			} `json:"list"`
+			NextPageCursor string `json:"nextPageCursor"`
+		}
+		if err := e.do(ctx, http.MethodGet, "/v5/market/instruments-info", params, false, &result); err != nil {
+			return nil, err
+		}
+
+		for _, info := range result.List {
+			symbolInfo[info.Symbol] = SymbolPrecision{
+				PricePrecision:    parsePrecision(info.PriceFilter.TickSize),
+				QuantityPrecision: parsePrecision(info.LotSizeFilter.QtyStep),
+				MinQty:            parseFloatOrZero(info.LotSizeFilter.MinOrderQty),
+				MinNotional:       parseFloatOrZero(info.LotSizeFilter.MinNotionalValue),
+				SettleAsset:       bybitSettleCoin,
+			}
+		}
+		if result.NextPageCursor == "" || len(result.List) == 0 {
+			return symbolInfo, nil
+		}
+		cursor = result.NextPageCursor
+	}
+}
+
+// scopeParams returns the parameters selecting symbol, or every USDT contract when empty.
+func scopeParams(symbol string) map[string]any {
+	params := map[string]any{"category": bybitCategory}
+	if symbol != "" {
+		params["symbol"] = symbol
+	} else {
+		params["settleCoin"] = bybitSettleCoin
+	}
+	return params
+}
+
+// bybitPositionSide maps a Bybit position index to a Binance-style position side.
+func bybitPositionSide(positionIdx int) string {
+	switch positionIdx {
+	case 1:
+		return "LONG"
+	case 2:
+		return "SHORT"
+	default:
+		return "BOTH"
+	}
+}
+
+// bybitPositionIdx maps a Binance-style position side to a Bybit position index.
+func bybitPositionIdx(positionSide string) int {
+	switch positionSide {
+	case "LONG":
+		return 1
+	case "SHORT":
+		return 2
+	default:
+		return 0
+	}
+}
+
+// bybitSide maps a Bybit order side to BUY/SELL.
+func bybitSide(side string) string {
+	if side == "Sell" {
+		return sideSell
+	}
+	return sideBuy
+}
+
+// Positions implements Exchange.
+func (e *bybitExchange) Positions(ctx context.Context, symbol string) ([]*Position, error) {
+	var positions []*Position
+	params := scopeParams(symbol)
+	params["limit"] = 200
+	for {
+		var result struct {
+			List []struct {
+				Symbol      string `json:"symbol"`
+				Side        string `json:"side"`
+				Size        string `json:"size"`
+				AvgPrice    string `json:"avgPrice"`
+				MarkPrice   string `json:"markPrice"`
+				Leverage    string `json:"leverage"`
+				LiqPrice    string `json:"liqPrice"`
+				PositionIdx int    `json:"positionIdx"`
+			} `json:"list"`
+			NextPageCursor string `json:"nextPageCursor"`
+		}
+		if err := e.do(ctx, http.MethodGet, "/v5/position/list", params, true, &result); err != nil {
+			return nil, err
+		}
+
+		for _, p := range result.List {
+			amt := parseFloatOrZero(p.Size)
+			if p.Side == "Sell" {
+				amt = -amt
+			}
+			positions = append(positions, &Position{
+				Symbol:           p.Symbol,
+				PositionSide:     bybitPositionSide(p.PositionIdx),
+				PositionAmt:      amt,
+				EntryPrice:       parseFloatOrZero(p.AvgPrice),
+				MarkPrice:        parseFloatOrZero(p.MarkPrice),
+				Leverage:         parseFloatOrZero(p.Leverage),
+				LiquidationPrice: parseFloatOrZero(p.LiqPrice),
+			})
+		}
+		if result.NextPageCursor == "" || len(result.List) == 0 {
+			return positions, nil
+		}
+		params["cursor"] = result.NextPageCursor
+	}
+}
+
+// OpenOrders implements Exchange. Conditional reduce-only market orders are
+// reported as STOP_MARKET or TAKE_PROFIT_MARKET depending on whether they trigger
+// against or in favor of the position they close.
+func (e *bybitExchange) OpenOrders(ctx context.Context, symbol string) ([]*Order, error) {
+	var orders []*Order
+	params := scopeParams(symbol)
+	params["limit"] = 50
+	for {
+		var result struct {
+			List []struct {
+				OrderID          string `json:"orderId"`
+				OrderLinkID      string `json:"orderLinkId"`
+				Symbol           string `json:"symbol"`
+				Side             string `json:"side"`
+				OrderType        string `json:"orderType"`
+				Price            string `json:"price"`
+				Qty              string `json:"qty"`
+				TriggerPrice     string `json:"triggerPrice"`
+				TriggerDirection int    `json:"triggerDirection"`
+				ReduceOnly       bool   `json:"reduceOnly"`
+				PositionIdx      int    `json:"positionIdx"`
+				UpdatedTime      string `json:"updatedTime"`
+			} `json:"list"`
+			NextPageCursor string `json:"nextPageCursor"`
+		}
+		if err := e.do(ctx, http.MethodGet, "/v5/order/realtime", params, true, &result); err != nil {
+			return nil, err
+		}
+
+		for _, o := range result.List {
+			order := &Order{
+				ID:            OrderID(o.OrderID),
+				ClientOrderID: o.OrderLinkID,
+				Symbol:        o.Symbol,
+				Type:          strings.ToUpper(o.OrderType),
+				Side:          bybitSide(o.Side),
+				PositionSide:  bybitPositionSide(o.PositionIdx),
+				StopPrice:     parseFloatOrZero(o.TriggerPrice),
+				Price:         parseFloatOrZero(o.Price),
+				Quantity:      parseFloatOrZero(o.Qty),
+			}
+			if millis, err := strconv.ParseInt(o.UpdatedTime, 10, 64); err == nil {
+				order.UpdateTime = time.UnixMilli(millis)
+			}
+			// A sell closing a long stops out when the price falls; a buy closing a short when it rises
+			stops := (order.Side == sideSell && o.TriggerDirection == bybitTriggerFall) ||
+				(order.Side == sideBuy && o.TriggerDirection == bybitTriggerRise)
+			if order.StopPrice > 0 && o.OrderType == "Market" {
+				order.Type = orderTypeTakeProfitMarket
+				if stops {
+					order.Type = orderTypeStopMarket
+				}
+			} else if order.StopPrice > 0 && o.ReduceOnly && stops {
+				order.Type = orderTypeStop
+			}
+			orders = append(orders, order)
+		}
+		if result.NextPageCursor == "" || len(result.List) == 0 {
+			return orders, nil
+		}
+		params["cursor"] = result.NextPageCursor
+	}
+}
+
+// orderParams translates req into Bybit order parameters.
+func (e *bybitExchange) orderParams(req OrderRequest) map[string]any {
+	side := "Buy"
+	if req.Side == sideSell {
+		side = "Sell"
+	}
+	params := map[string]any{
+		"category":    bybitCategory,
+		"symbol":      req.Symbol,
+		"side":        side,
+		"orderType":   "Market",
+		"qty":         req.Quantity,
+		"positionIdx": bybitPositionIdx(req.PositionSide),
+	}
+	if req.ClientOrderID != "" {
+		params["orderLinkId"] = req.ClientOrderID
+	}
+
+	if req.Type == orderTypeStopMarket || req.Type == orderTypeStop || req.Type == orderTypeTakeProfitMarket {
+		// A closing sell stops when the price falls and takes profit when it rises
+		direction := bybitTriggerRise
+		if (req.Side == sideSell) == (req.Type != orderTypeTakeProfitMarket) {
+			direction = bybitTriggerFall
+		}
+		params["triggerPrice"] = req.StopPrice
+		params["triggerDirection"] = direction
+		params["triggerBy"] = "MarkPrice"
+		params["reduceOnly"] = true
+		params["closeOnTrigger"] = req.Type == orderTypeStopMarket
+	} else if req.ReduceOnly {
+		params["reduceOnly"] = true
+	}
+	if req.Type == orderTypeLimit || req.Type == orderTypeStop {
+		params["orderType"] = "Limit"
+		params["price"] = req.Price
+		params["timeInForce"] = "GTC"
+	}
+	return params
+}
+
+// CreateOrder implements Exchange.
+func (e *bybitExchange) CreateOrder(ctx context.Context, req OrderRequest) (*Order, error) {
+	var result struct {
+		OrderID string `json:"orderId"`
+	}
+	if err := e.do(ctx, http.MethodPost, "/v5/order/create", e.orderParams(req), true, &result); err != nil {
+		return nil, err
+	}
+	return &Order{
+		ID:            OrderID(result.OrderID),
+		ClientOrderID: req.ClientOrderID,
+		Symbol:        req.Symbol,
+		Type:          req.Type,
+		Side:          req.Side,
+		PositionSide:  req.PositionSide,
+		StopPrice:     parseFloatOrZero(req.StopPrice),
+		Price:         parseFloatOrZero(req.Price),
+		UpdateTime:    time.Now(),
+	}, nil
+}
+
+// CreateOrders implements Exchange by placing the orders one after another.
+func (e *bybitExchange) CreateOrders(ctx context.Context, reqs []OrderRequest) ([]*Order, []error, error) {
+	orders := make([]*Order, len(reqs))
+	errs := make([]error, len(reqs))
+	for i, req := range reqs {
+		orders[i], errs[i] = e.CreateOrder(ctx, req)
+	}
+	return orders, errs, nil
+}
+
+// CancelOrder implements Exchange.
+func (e *bybitExchange) CancelOrder(ctx context.Context, order *Order) error {
+	params := map[string]any{
+		"category": bybitCategory,
+		"symbol":   order.Symbol,
+		"orderId":  string(order.ID),
+	}
+	return e.do(ctx, http.MethodPost, "/v5/order/cancel", params, true, nil)
+}
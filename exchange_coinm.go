@@ -0,0 +1,279 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/adshao/go-binance/v2/delivery"
+	binance "github.com/adshao/go-binance/v2/futures"
+)
+
+// coinMContract holds the details needed to value an inverse contract.
+type coinMContract struct {
+	contractSize float64 // Quote (USD) value of one contract
+	marginAsset  string
+}
+
+// binanceCoinMExchange implements Exchange for Binance COIN-margined delivery and
+// perpetual futures. Quantities are contract counts and positions report their
+// contract size and margin asset, so PnL is computed in the base asset.
+type binanceCoinMExchange struct {
+	client *delivery.Client
+	config Config
+
+	mu        sync.Mutex
+	contracts map[string]coinMContract // By symbol
+}
+
+// setupBinanceCoinMExchange initializes and validates the Binance COIN-M API client.
+func setupBinanceCoinMExchange(config Config) (*binanceCoinMExchange, error) {
+	apiKey := config.Credentials.BinanceAPIKey
+	apiSecret := config.Credentials.BinanceAPISecret
+
+	if apiKey == "" || apiSecret == "" {
+		return nil, fmt.Errorf("binance API credentials not configured")
+	}
+
+	e := &binanceCoinMExchange{
+		client:    delivery.NewClient(apiKey, apiSecret),
+		config:    config,
+		contracts: make(map[string]coinMContract),
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+
+	// Calibrate the clock offset before the first signed request
+	if config.TimeSync {
+		if err := e.SyncTime(ctx); err != nil {
+			log.Printf("Warning: %v", err)
+		}
+	}
+
+	// Validate API connection
+	if _, err := e.client.NewGetAccountService().Do(ctx, e.signed()...); err != nil {
+		return nil, fmt.Errorf("failed to connect to Binance COIN-M API: %w", err)
+	}
+	return e, nil
+}
+
+// Name implements Exchange.
+func (e *binanceCoinMExchange) Name() string {
+	return exchangeBinanceCoinM
+}
+
+// signed returns the request options for signed requests.
+func (e *binanceCoinMExchange) signed() []delivery.RequestOption {
+	window := e.config.RecvWindow
+	if window > maxRecvWindow {
+		window = maxRecvWindow
+	}
+	return []delivery.RequestOption{delivery.WithRecvWindow(window.Milliseconds())}
+}
+
+// SyncTime implements timeSyncer.
+func (e *binanceCoinMExchange) SyncTime(ctx context.Context) error {
+	offset, err := e.client.NewSetServerTimeService().Do(ctx)
+	if err != nil {
+		return fmt.Errorf("error synchronizing COIN-M server time: %w", err)
+	}
+	log.Printf("Synchronized with Binance COIN-M server time (local clock offset %dms)", offset)
+	return nil
+}
+
+// ServerTime implements Exchange.
+func (e *binanceCoinMExchange) ServerTime(ctx context.Context) (time.Time, error) {
+	serverTime, err := e.client.NewServerTimeService().Do(ctx)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.UnixMilli(serverTime), nil
+}
+
+// SymbolPrecisions implements Exchange. Quantity precision is that of the contract count.
+func (e *binanceCoinMExchange) SymbolPrecisions(ctx context.Context) (map[string]SymbolPrecision, error) {
+	exchangeInfo, err := e.client.NewExchangeInfoService().Do(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	symbolInfo := make(map[string]SymbolPrecision, len(exchangeInfo.Symbols))
+	contracts := make(map[string]coinMContract, len(exchangeInfo.Symbols))
+	for _, info := range exchangeInfo.Symbols {
+		precision := SymbolPrecision{
+			PricePrecision:    info.PricePrecision,
+			QuantityPrecision: info.QuantityPrecision,
+			SettleAsset:       info.MarginAsset,
+		}
+		if filter := info.LotSizeFilter(); filter != nil {
+			precision.MinQty = parseFloatOrZero(filter.MinQuantity)
+		}
+		symbolInfo[info.Symbol] = precision
+		contracts[info.Symbol] = coinMContract{
+			contractSize: float64(info.ContractSize),
+			marginAsset:  info.MarginAsset,
+		}
+	}
+
+	e.mu.Lock()
+	e.contracts = contracts
+	e.mu.Unlock()
+	return symbolInfo, nil
+}
+
+// contract returns the contract details of symbol, loading the exchange info on first use.
+func (e *binanceCoinMExchange) contract(ctx context.Context, symbol string) (coinMContract, error) {
+	e.mu.Lock()
+	contract, ok := e.contracts[symbol]
+	loaded := len(e.contracts) > 0
+	e.mu.Unlock()
+	if ok {
+		return contract, nil
+	}
+
+	if !loaded {
+		if _, err := e.SymbolPrecisions(ctx); err != nil {
+			return coinMContract{}, err
+		}
+		e.mu.Lock()
+		contract, ok = e.contracts[symbol]
+		e.mu.Unlock()
+		if ok {
+			return contract, nil
+		}
+	}
+	return coinMContract{}, fmt.Errorf("unknown COIN-M contract %s", symbol)
+}
+
+// Positions implements Exchange. The position risk endpoint only filters by pair,
+// so symbol is matched locally.
+func (e *binanceCoinMExchange) Positions(ctx context.Context, symbol string) ([]*Position, error) {
+	risks, err := e.client.NewGetPositionRiskService().Do(ctx, e.signed()...)
+	if err != nil {
+		return nil, err
+	}
+
+	positions := make([]*Position, 0, len(risks))
+	for _, risk := range risks {
+		if symbol != "" && risk.Symbol != symbol {
+			continue
+		}
+		position, err := binancePosition(&binance.PositionRisk{
+			Symbol:           risk.Symbol,
+			PositionSide:     risk.PositionSide,
+			PositionAmt:      risk.PositionAmt,
+			EntryPrice:       risk.EntryPrice,
+			MarkPrice:        risk.MarkPrice,
+			Leverage:         risk.Leverage,
+			LiquidationPrice: risk.LiquidationPrice,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		contract, err := e.contract(ctx, risk.Symbol)
+		if err != nil {
+			return nil, err
+		}
+		position.ContractSize = contract.contractSize
+		position.MarginAsset = contract.marginAsset
+		positions = append(positions, position)
+	}
+	return positions, nil
+}
+
+// OpenOrders implements Exchange.
+func (e *binanceCoinMExchange) OpenOrders(ctx context.Context, symbol string) ([]*Order, error) {
+	service := e.client.NewListOpenOrdersService()
+	if symbol != "" {
+		service = service.Symbol(symbol)
+	}
+	orders, err := service.Do(ctx, e.signed()...)
+	if err != nil {
+		return nil, err
+	}
+
+	openOrders := make([]*Order, 0, len(orders))
+	for _, order := range orders {
+		openOrders = append(openOrders, &Order{
+			ID:            OrderID(strconv.FormatInt(order.OrderID, 10)),
+			ClientOrderID: order.ClientOrderID,
+			Symbol:        order.Symbol,
+			Type:          string(order.Type),
+			Side:          string(order.Side),
+			PositionSide:  string(order.PositionSide),
+			StopPrice:     parseFloatOrZero(order.StopPrice),
+			Price:         parseFloatOrZero(order.Price),
+			Quantity:      parseFloatOrZero(order.OrigQuantity),
+			UpdateTime:    time.UnixMilli(order.UpdateTime),
+		})
+	}
+	return openOrders, nil
+}
+
+// CreateOrder implements Exchange. Quantity is a number of contracts.
+func (e *binanceCoinMExchange) CreateOrder(ctx context.Context, req OrderRequest) (*Order, error) {
+	service := e.client.NewCreateOrderService().
+		Symbol(req.Symbol).
+		Side(delivery.SideType(req.Side)).
+		Type(delivery.OrderType(req.Type)).
+		Quantity(req.Quantity).
+		NewOrderResponseType(delivery.NewOrderRespTypeRESULT)
+
+	if req.StopPrice != "" {
+		service = service.StopPrice(req.StopPrice).TimeInForce(delivery.TimeInForceTypeGTC)
+	}
+	if req.Price != "" {
+		service = service.Price(req.Price).TimeInForce(delivery.TimeInForceTypeGTC)
+	}
+	if req.ClientOrderID != "" {
+		service = service.NewClientOrderID(req.ClientOrderID)
+	}
+
+	// reduceOnly is rejected in hedge mode, where the position side already implies it
+	if req.PositionSide != "" && req.PositionSide != "BOTH" {
+		service = service.PositionSide(delivery.PositionSideType(req.PositionSide))
+	} else if req.ReduceOnly {
+		service = service.ReduceOnly(true)
+	}
+
+	res, err := service.Do(ctx, e.signed()...)
+	if err != nil {
+		return nil, err
+	}
+	return &Order{
+		ID:            OrderID(strconv.FormatInt(res.OrderID, 10)),
+		ClientOrderID: res.ClientOrderID,
+		Symbol:        res.Symbol,
+		Type:          string(res.Type),
+		Side:          string(res.Side),
+		PositionSide:  string(res.PositionSide),
+		StopPrice:     parseFloatOrZero(res.StopPrice),
+		Price:         parseFloatOrZero(res.Price),
+		UpdateTime:    time.UnixMilli(res.UpdateTime),
+	}, nil
+}
+
+// CreateOrders implements Exchange by placing the orders one after another.
+func (e *binanceCoinMExchange) CreateOrders(ctx context.Context, reqs []OrderRequest) ([]*Order, []error, error) {
+	orders := make([]*Order, len(reqs))
+	errs := make([]error, len(reqs))
+	for i, req := range reqs {
+		orders[i], errs[i] = e.CreateOrder(ctx, req)
+	}
+	return orders, errs, nil
+}
+
+// CancelOrder implements Exchange.
+func (e *binanceCoinMExchange) CancelOrder(ctx context.Context, order *Order) error {
+	orderID, err := strconv.ParseInt(string(order.ID), 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid Binance order ID %q: %w", order.ID, err)
+	}
+	_, err = e.client.NewCancelOrderService().Symbol(order.Symbol).OrderID(orderID).Do(ctx, e.signed()...)
+	return err
+}
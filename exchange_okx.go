@@ -0,0 +1,611 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// OKX v5 API settings.
+const (
+	okxBaseURL = "https://www.okx.com"
+
+	// okxInstType selects perpetual swaps.
+	okxInstType = "SWAP"
+	// okxInstSuffix limits the guard to USDT-margined swaps, e.g. BTC-USDT-SWAP.
+	okxInstSuffix = "-USDT-SWAP"
+	// okxDefaultTdMode is the trade mode used before a position's margin mode is known.
+	okxDefaultTdMode = "cross"
+	// okxPageLimit is the page size of paginated OKX queries.
+	okxPageLimit = 100
+)
+
+// okxTimestampErrorCode is returned when a request timestamp has expired.
+const okxTimestampErrorCode = "50102"
+
+// okxInstrument holds the contract details needed to convert between contracts and base units.
+type okxInstrument struct {
+	instID string
+	ctVal  float64
+}
+
+// okxExchange implements Exchange for OKX USDT perpetual swaps using the v5 REST API.
+// Symbols are exposed in Binance form (BTCUSDT) and quantities in base units rather
+// than contracts, so the guard logic works unchanged.
+type okxExchange struct {
+	apiKey     string
+	apiSecret  string
+	passphrase string
+	demo       bool
+	httpClient *http.Client
+
+	mu          sync.Mutex
+	timeOffset  time.Duration
+	instruments map[string]okxInstrument // By symbol
+	tdModes     map[string]string        // Margin mode by instrument ID
+}
+
+// okxResponse is the envelope of every OKX v5 response.
+type okxResponse struct {
+	Code string          `json:"code"`
+	Msg  string          `json:"msg"`
+	Data json.RawMessage `json:"data"`
+}
+
+// okxError is an error reported by the OKX API.
+type okxError struct {
+	Code    string
+	Message string
+}
+
+func (e *okxError) Error() string {
+	return fmt.Sprintf("<OKXError> code=%s, msg=%s", e.Code, e.Message)
+}
+
+// okxOrderResult is the per-order result of OKX order placement and cancellation.
+type okxOrderResult struct {
+	OrdID  string `json:"ordId"`
+	AlgoID string `json:"algoId"`
+	SCode  string `json:"sCode"`
+	SMsg   string `json:"sMsg"`
+}
+
+// setupOKXExchange initializes and validates the OKX API client.
+func setupOKXExchange(config Config) (*okxExchange, error) {
+	apiKey := config.Credentials.OKXAPIKey
+	apiSecret := config.Credentials.OKXAPISecret
+	passphrase := config.Credentials.OKXPassphrase
+
+	if apiKey == "" || apiSecret == "" || passphrase == "" {
+		return nil, fmt.Errorf("OKX API credentials not configured")
+	}
+
+	e := &okxExchange{
+		apiKey:      apiKey,
+		apiSecret:   apiSecret,
+		passphrase:  passphrase,
+		demo:        config.OKXDemo,
+		httpClient:  &http.Client{Timeout: defaultTimeout},
+		instruments: make(map[string]okxInstrument),
+		tdModes:     make(map[string]string),
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+
+	// Calibrate the clock offset before the first signed request
+	if config.TimeSync {
+		if err := e.SyncTime(ctx); err != nil {
+			log.Printf("Warning: %v", err)
+		}
+	}
+
+	// Validate API connection
+	if _, err := e.Positions(ctx, ""); err != nil {
+		return nil, fmt.Errorf("failed to connect to OKX API: %w", err)
+	}
+	return e, nil
+}
+
+// Name implements Exchange.
+func (e *okxExchange) Name() string {
+	return exchangeOKX
+}
+
+// SyncTime implements timeSyncer, setting the local clock offset from the OKX server time.
+func (e *okxExchange) SyncTime(ctx context.Context) error {
+	serverTime, err := e.ServerTime(ctx)
+	if err != nil {
+		return fmt.Errorf("error synchronizing OKX server time: %w", err)
+	}
+	offset := time.Until(serverTime)
+
+	e.mu.Lock()
+	e.timeOffset = offset
+	e.mu.Unlock()
+
+	log.Printf("Synchronized with OKX server time (local clock offset %dms)", -offset.Milliseconds())
+	return nil
+}
+
+// timestamp returns the current OKX server time in the ISO format its signature expects.
+func (e *okxExchange) timestamp() string {
+	e.mu.Lock()
+	offset := e.timeOffset
+	e.mu.Unlock()
+	return time.Now().Add(offset).UTC().Format("2006-01-02T15:04:05.000Z")
+}
+
+// do sends a request and decodes the data array into out. GET parameters go in the
+// query string and POST payloads in a JSON body; signed requests carry the
+// base64 HMAC-SHA256 signature headers.
+func (e *okxExchange) do(ctx context.Context, method, path string, params map[string]string, payload any, signed bool, out any) error {
+	requestPath := path
+	if len(params) > 0 {
+		values := url.Values{}
+		for key, val := range params {
+			values.Set(key, val)
+		}
+		requestPath += "?" + values.Encode()
+	}
+
+	var bodyStr string
+	var body io.Reader
+	if payload != nil {
+		raw, err := json.Marshal(payload)
+		if err != nil {
+			return fmt.Errorf("error encoding OKX request: %w", err)
+		}
+		bodyStr = string(raw)
+		body = bytes.NewReader(raw)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, okxBaseURL+requestPath, body)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if e.demo {
+		req.Header.Set("x-simulated-trading", "1")
+	}
+
+	if signed {
+		timestamp := e.timestamp()
+		mac := hmac.New(sha256.New, []byte(e.apiSecret))
+		mac.Write([]byte(timestamp + method + requestPath + bodyStr))
+
+		req.Header.Set("OK-ACCESS-KEY", e.apiKey)
+		req.Header.Set("OK-ACCESS-SIGN", base64.StdEncoding.EncodeToString(mac.Sum(nil)))
+		req.Header.Set("OK-ACCESS-TIMESTAMP", timestamp)
+		req.Header.Set("OK-ACCESS-PASSPHRASE", e.passphrase)
+	}
+
+	resp, err := e.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	var envelope okxResponse
+	if err := json.Unmarshal(raw, &envelope); err != nil {
+		return fmt.Errorf("OKX %s %s returned HTTP %d: %s", method, path, resp.StatusCode, raw)
+	}
+	if envelope.Code != "0" {
+		if envelope.Code == okxTimestampErrorCode {
+			log.Println("Warning: OKX rejected a request timestamp, resynchronizing server time")
+			go func() {
+				if err := e.SyncTime(context.Background()); err != nil {
+					log.Printf("Warning: %v", err)
+				}
+			}()
+		}
+		return &okxError{Code: envelope.Code, Message: envelope.Msg}
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.Unmarshal(envelope.Data, out); err != nil {
+		return fmt.Errorf("error decoding OKX result: %w", err)
+	}
+	return nil
+}
+
+// ServerTime implements Exchange.
+func (e *okxExchange) ServerTime(ctx context.Context) (time.Time, error) {
+	var data []struct {
+		Ts string `json:"ts"`
+	}
+	if err := e.do(ctx, http.MethodGet, "/api/v5/public/time", nil, nil, false, &data); err != nil {
+		return time.Time{}, err
+	}
+	if len(data) == 0 {
+		return time.Time{}, fmt.Errorf("empty OKX server time response")
+	}
+	millis, err := strconv.ParseInt(data[0].Ts, 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("error parsing OKX server time: %w", err)
+	}
+	return time.UnixMilli(millis), nil
+}
+
+// okxSymbol converts an instrument ID such as BTC-USDT-SWAP to BTCUSDT.
+func okxSymbol(instID string) string {
+	return strings.ReplaceAll(strings.TrimSuffix(instID, "-SWAP"), "-", "")
+}
+
+// SymbolPrecisions implements Exchange. Quantity precision is expressed in base
+// units, i.e. the lot size multiplied by the contract value.
+func (e *okxExchange) SymbolPrecisions(ctx context.Context) (map[string]SymbolPrecision, error) {
+	var data []struct {
+		InstID string `json:"instId"`
+		TickSz string `json:"tickSz"`
+		LotSz  string `json:"lotSz"`
+		MinSz  string `json:"minSz"`
+		CtVal  string `json:"ctVal"`
+	}
+	params := map[string]string{"instType": okxInstType}
+	if err := e.do(ctx, http.MethodGet, "/api/v5/public/instruments", params, nil, false, &data); err != nil {
+		return nil, err
+	}
+
+	symbolInfo := make(map[string]SymbolPrecision)
+	instruments := make(map[string]okxInstrument)
+	for _, inst := range data {
+		if !strings.HasSuffix(inst.InstID, okxInstSuffix) {
+			continue
+		}
+		ctVal := parseFloatOrZero(inst.CtVal)
+		if ctVal <= 0 {
+			continue
+		}
+		symbol := okxSymbol(inst.InstID)
+		instruments[symbol] = okxInstrument{instID: inst.InstID, ctVal: ctVal}
+
+		baseStep := strconv.FormatFloat(parseFloatOrZero(inst.LotSz)*ctVal, 'f', -1, 64)
+		symbolInfo[symbol] = SymbolPrecision{
+			PricePrecision:    parsePrecision(inst.TickSz),
+			QuantityPrecision: parsePrecision(baseStep),
+			MinQty:            parseFloatOrZero(inst.MinSz) * ctVal,
+			SettleAsset:       "USDT",
+		}
+	}
+
+	e.mu.Lock()
+	e.instruments = instruments
+	e.mu.Unlock()
+	return symbolInfo, nil
+}
+
+// instrument returns the contract details of symbol, loading the instrument list on first use.
+func (e *okxExchange) instrument(ctx context.Context, symbol string) (okxInstrument, error) {
+	e.mu.Lock()
+	inst, ok := e.instruments[symbol]
+	loaded := len(e.instruments) > 0
+	e.mu.Unlock()
+	if ok {
+		return inst, nil
+	}
+
+	if !loaded {
+		if _, err := e.SymbolPrecisions(ctx); err != nil {
+			return okxInstrument{}, err
+		}
+		e.mu.Lock()
+		inst, ok = e.instruments[symbol]
+		e.mu.Unlock()
+		if ok {
+			return inst, nil
+		}
+	}
+	return okxInstrument{}, fmt.Errorf("unknown OKX instrument for %s", symbol)
+}
+
+// instrumentParams returns the query selecting symbol, or every swap when empty.
+func (e *okxExchange) instrumentParams(ctx context.Context, symbol string) (map[string]string, error) {
+	params := map[string]string{"instType": okxInstType}
+	if symbol != "" {
+		inst, err := e.instrument(ctx, symbol)
+		if err != nil {
+			return nil, err
+		}
+		params["instId"] = inst.instID
+	}
+	return params, nil
+}
+
+// okxPositionSide maps an OKX posSide to a Binance-style position side.
+func okxPositionSide(posSide string) string {
+	switch posSide {
+	case "long":
+		return "LONG"
+	case "short":
+		return "SHORT"
+	default:
+		return "BOTH"
+	}
+}
+
+// okxPosSide maps a Binance-style position side to an OKX posSide.
+func okxPosSide(positionSide string) string {
+	switch positionSide {
+	case "LONG":
+		return "long"
+	case "SHORT":
+		return "short"
+	default:
+		return "net"
+	}
+}
+
+// Positions implements Exchange, converting contracts to base units.
+func (e *okxExchange) Positions(ctx context.Context, symbol string) ([]*Position, error) {
+	params, err := e.instrumentParams(ctx, symbol)
+	if err != nil {
+		return nil, err
+	}
+
+	var data []struct {
+		InstID  string `json:"instId"`
+		PosSide string `json:"posSide"`
+		Pos     string `json:"pos"`
+		AvgPx   string `json:"avgPx"`
+		MarkPx  string `json:"markPx"`
+		Lever   string `json:"lever"`
+		LiqPx   string `json:"liqPx"`
+		MgnMode string `json:"mgnMode"`
+	}
+	if err := e.do(ctx, http.MethodGet, "/api/v5/account/positions", params, nil, true, &data); err != nil {
+		return nil, err
+	}
+
+	var positions []*Position
+	for _, p := range data {
+		if !strings.HasSuffix(p.InstID, okxInstSuffix) {
+			continue
+		}
+		symbol := okxSymbol(p.InstID)
+		inst, err := e.instrument(ctx, symbol)
+		if err != nil {
+			return nil, err
+		}
+
+		e.mu.Lock()
+		e.tdModes[p.InstID] = p.MgnMode
+		e.mu.Unlock()
+
+		// Hedge-mode shorts report a positive size; make them negative like one-way shorts
+		amt := parseFloatOrZero(p.Pos) * inst.ctVal
+		if p.PosSide == "short" {
+			amt = -math.Abs(amt)
+		}
+		positions = append(positions, &Position{
+			Symbol:           symbol,
+			PositionSide:     okxPositionSide(p.PosSide),
+			PositionAmt:      amt,
+			EntryPrice:       parseFloatOrZero(p.AvgPx),
+			MarkPrice:        parseFloatOrZero(p.MarkPx),
+			Leverage:         parseFloatOrZero(p.Lever),
+			LiquidationPrice: parseFloatOrZero(p.LiqPx),
+		})
+	}
+	return positions, nil
+}
+
+// OpenOrders implements Exchange. Only conditional algo orders are listed, reported
+// as STOP_MARKET or TAKE_PROFIT_MARKET by their stop-loss or take-profit trigger.
+func (e *okxExchange) OpenOrders(ctx context.Context, symbol string) ([]*Order, error) {
+	params, err := e.instrumentParams(ctx, symbol)
+	if err != nil {
+		return nil, err
+	}
+	params["ordType"] = "conditional"
+	params["limit"] = strconv.Itoa(okxPageLimit)
+
+	var orders []*Order
+	for {
+		var data []struct {
+			AlgoID      string `json:"algoId"`
+			AlgoClOrdID string `json:"algoClOrdId"`
+			InstID      string `json:"instId"`
+			Side        string `json:"side"`
+			PosSide     string `json:"posSide"`
+			SlTriggerPx string `json:"slTriggerPx"`
+			SlOrdPx     string `json:"slOrdPx"`
+			Sz          string `json:"sz"`
+			TpTriggerPx string `json:"tpTriggerPx"`
+			UTime       string `json:"uTime"`
+		}
+		if err := e.do(ctx, http.MethodGet, "/api/v5/trade/orders-algo-pending", params, nil, true, &data); err != nil {
+			return nil, err
+		}
+
+		for _, o := range data {
+			order := &Order{
+				ID:            OrderID(o.AlgoID),
+				ClientOrderID: o.AlgoClOrdID,
+				Symbol:        okxSymbol(o.InstID),
+				Side:          strings.ToUpper(o.Side),
+				PositionSide:  okxPositionSide(o.PosSide),
+			}
+			// Sizes are in contracts
+			if inst, err := e.instrument(ctx, order.Symbol); err == nil {
+				order.Quantity = parseFloatOrZero(o.Sz) * inst.ctVal
+			}
+			if sl := parseFloatOrZero(o.SlTriggerPx); sl > 0 {
+				order.Type = orderTypeStopMarket
+				order.StopPrice = sl
+				if price := parseFloatOrZero(o.SlOrdPx); price > 0 {
+					order.Type = orderTypeStop
+					order.Price = price
+				}
+			} else {
+				order.Type = orderTypeTakeProfitMarket
+				order.StopPrice = parseFloatOrZero(o.TpTriggerPx)
+			}
+			if millis, err := strconv.ParseInt(o.UTime, 10, 64); err == nil {
+				order.UpdateTime = time.UnixMilli(millis)
+			}
+			orders = append(orders, order)
+		}
+
+		if len(data) < okxPageLimit {
+			return orders, nil
+		}
+		params["after"] = data[len(data)-1].AlgoID
+	}
+}
+
+// orderPayload translates req into an OKX order or algo order payload.
+func (e *okxExchange) orderPayload(ctx context.Context, req OrderRequest) (okxInstrument, map[string]any, error) {
+	inst, err := e.instrument(ctx, req.Symbol)
+	if err != nil {
+		return inst, nil, err
+	}
+
+	qty, err := strconv.ParseFloat(req.Quantity, 64)
+	if err != nil {
+		return inst, nil, fmt.Errorf("error parsing quantity %q: %w", req.Quantity, err)
+	}
+	contracts := toDecimal(qty).Div(toDecimal(inst.ctVal)).String()
+
+	e.mu.Lock()
+	tdMode := e.tdModes[inst.instID]
+	e.mu.Unlock()
+	if tdMode == "" {
+		tdMode = okxDefaultTdMode
+	}
+
+	payload := map[string]any{
+		"instId":  inst.instID,
+		"tdMode":  tdMode,
+		"side":    strings.ToLower(req.Side),
+		"posSide": okxPosSide(req.PositionSide),
+		"sz":      contracts,
+	}
+	// OKX client IDs are alphanumeric only, up to 32 characters
+	clientID := strings.ReplaceAll(req.ClientOrderID, "-", "")
+	if len(clientID) > clientOrderIDMaxAlnumLen {
+		clientID = clientID[:clientOrderIDMaxAlnumLen]
+	}
+
+	switch req.Type {
+	case orderTypeStopMarket, orderTypeStop:
+		// An order price of -1 executes at market once triggered
+		payload["ordType"] = "conditional"
+		payload["slTriggerPx"] = req.StopPrice
+		payload["slOrdPx"] = "-1"
+		if req.Type == orderTypeStop {
+			payload["slOrdPx"] = req.Price
+		}
+		payload["slTriggerPxType"] = "mark"
+		payload["reduceOnly"] = true
+		if clientID != "" {
+			payload["algoClOrdId"] = clientID
+		}
+	case orderTypeTakeProfitMarket:
+		payload["ordType"] = "conditional"
+		payload["tpTriggerPx"] = req.StopPrice
+		payload["tpOrdPx"] = "-1"
+		payload["tpTriggerPxType"] = "mark"
+		payload["reduceOnly"] = true
+		if clientID != "" {
+			payload["algoClOrdId"] = clientID
+		}
+	case orderTypeLimit:
+		payload["ordType"] = "limit"
+		payload["px"] = req.Price
+	default:
+		payload["ordType"] = "market"
+		if req.ReduceOnly {
+			payload["reduceOnly"] = true
+		}
+	}
+	return inst, payload, nil
+}
+
+// CreateOrder implements Exchange. Stops and targets are placed as algo orders.
+func (e *okxExchange) CreateOrder(ctx context.Context, req OrderRequest) (*Order, error) {
+	_, payload, err := e.orderPayload(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	path := "/api/v5/trade/order"
+	if payload["ordType"] == "conditional" {
+		path = "/api/v5/trade/order-algo"
+	}
+
+	var data []okxOrderResult
+	if err := e.do(ctx, http.MethodPost, path, nil, payload, true, &data); err != nil {
+		return nil, err
+	}
+	if len(data) == 0 {
+		return nil, fmt.Errorf("empty OKX order response")
+	}
+	if data[0].SCode != "" && data[0].SCode != "0" {
+		return nil, &okxError{Code: data[0].SCode, Message: data[0].SMsg}
+	}
+
+	id := data[0].AlgoID
+	if id == "" {
+		id = data[0].OrdID
+	}
+	return &Order{
+		ID:            OrderID(id),
+		ClientOrderID: req.ClientOrderID,
+		Symbol:        req.Symbol,
+		Type:          req.Type,
+		Side:          req.Side,
+		PositionSide:  req.PositionSide,
+		StopPrice:     parseFloatOrZero(req.StopPrice),
+		UpdateTime:    time.Now(),
+	}, nil
+}
+
+// CreateOrders implements Exchange by placing the orders one after another.
+func (e *okxExchange) CreateOrders(ctx context.Context, reqs []OrderRequest) ([]*Order, []error, error) {
+	orders := make([]*Order, len(reqs))
+	errs := make([]error, len(reqs))
+	for i, req := range reqs {
+		orders[i], errs[i] = e.CreateOrder(ctx, req)
+	}
+	return orders, errs, nil
+}
+
+// CancelOrder implements Exchange, cancelling an algo order.
+func (e *okxExchange) CancelOrder(ctx context.Context, order *Order) error {
+	inst, err := e.instrument(ctx, order.Symbol)
+	if err != nil {
+		return err
+	}
+
+	payload := []map[string]string{{"algoId": string(order.ID), "instId": inst.instID}}
+	var data []okxOrderResult
+	if err := e.do(ctx, http.MethodPost, "/api/v5/trade/cancel-algos", nil, payload, true, &data); err != nil {
+		return err
+	}
+	if len(data) > 0 && data[0].SCode != "" && data[0].SCode != "0" {
+		return &okxError{Code: data[0].SCode, Message: data[0].SMsg}
+	}
+	return nil
+}
@@ -0,0 +1,713 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	spot "github.com/adshao/go-binance/v2"
+	"github.com/adshao/go-binance/v2/common"
+)
+
+// Spot protection settings.
+const (
+	// spotDustNotional is the quote value below which a holding is ignored.
+	spotDustNotional = 10.0
+	// spotTradeLookback is the number of recent trades used to estimate the entry price.
+	spotTradeLookback = 1000
+	// unknownOrderErrorCode is returned when cancelling an order that no longer exists.
+	unknownOrderErrorCode = -2011
+)
+
+// spotBracket remembers the last requested legs of a symbol's OCO, so that replacing
+// one leg, which cancels the whole list, can place the other again.
+type spotBracket struct {
+	side      string
+	quantity  string
+	stopPrice string
+	takePrice string
+
+	stopClientID string
+	takeClientID string
+}
+
+// set records the price and client order ID of a stop or target request.
+func (b *spotBracket) set(req OrderRequest) {
+	if req.Type == orderTypeStopMarket {
+		b.stopPrice = req.StopPrice
+		b.stopClientID = req.ClientOrderID
+	} else {
+		b.takePrice = req.StopPrice
+		b.takeClientID = req.ClientOrderID
+	}
+}
+
+// spotExchange implements Exchange for Binance spot holdings, or cross margin
+// balances when SPOT_MARGIN is set. Each holding of a base asset against the quote
+// asset is a position; stops and targets are placed together as one OCO order made
+// of a STOP_LOSS_LIMIT leg and a LIMIT_MAKER leg, reported as STOP_MARKET and
+// TAKE_PROFIT_MARKET.
+type spotExchange struct {
+	client *spot.Client
+	config Config
+
+	mu         sync.Mutex
+	symbols    map[string]spot.Symbol // By symbol
+	precisions map[string]SymbolPrecision
+	orderLists map[OrderID]int64 // OCO list of each open order
+	brackets   map[string]*spotBracket
+}
+
+// setupSpotExchange initializes and validates the Binance spot API client.
+func setupSpotExchange(config Config) (*spotExchange, error) {
+	apiKey := config.Credentials.BinanceAPIKey
+	apiSecret := config.Credentials.BinanceAPISecret
+
+	if apiKey == "" || apiSecret == "" {
+		return nil, fmt.Errorf("binance API credentials not configured")
+	}
+
+	e := &spotExchange{
+		client:     spot.NewClient(apiKey, apiSecret),
+		config:     config,
+		symbols:    make(map[string]spot.Symbol),
+		precisions: make(map[string]SymbolPrecision),
+		orderLists: make(map[OrderID]int64),
+		brackets:   make(map[string]*spotBracket),
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+
+	// Calibrate the clock offset before the first signed request
+	if config.TimeSync {
+		if err := e.SyncTime(ctx); err != nil {
+			log.Printf("Warning: %v", err)
+		}
+	}
+
+	// Validate API connection
+	if _, err := e.balances(ctx); err != nil {
+		return nil, fmt.Errorf("failed to connect to Binance spot API: %w", err)
+	}
+	return e, nil
+}
+
+// Name implements Exchange.
+func (e *spotExchange) Name() string {
+	return exchangeBinanceSpot
+}
+
+// signed returns the request options for signed requests.
+func (e *spotExchange) signed() []spot.RequestOption {
+	window := e.config.RecvWindow
+	if window > maxRecvWindow {
+		window = maxRecvWindow
+	}
+	return []spot.RequestOption{spot.WithRecvWindow(window.Milliseconds())}
+}
+
+// SyncTime implements timeSyncer.
+func (e *spotExchange) SyncTime(ctx context.Context) error {
+	offset, err := e.client.NewSetServerTimeService().Do(ctx)
+	if err != nil {
+		return fmt.Errorf("error synchronizing spot server time: %w", err)
+	}
+	log.Printf("Synchronized with Binance spot server time (local clock offset %dms)", offset)
+	return nil
+}
+
+// ServerTime implements Exchange.
+func (e *spotExchange) ServerTime(ctx context.Context) (time.Time, error) {
+	serverTime, err := e.client.NewServerTimeService().Do(ctx)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.UnixMilli(serverTime), nil
+}
+
+// SymbolPrecisions implements Exchange for the trading symbols quoted in the
+// configured quote asset.
+func (e *spotExchange) SymbolPrecisions(ctx context.Context) (map[string]SymbolPrecision, error) {
+	exchangeInfo, err := e.client.NewExchangeInfoService().Do(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	symbols := make(map[string]spot.Symbol)
+	symbolInfo := make(map[string]SymbolPrecision)
+	for _, info := range exchangeInfo.Symbols {
+		if info.QuoteAsset != e.config.SpotQuoteAsset || info.Status != "TRADING" || !info.OcoAllowed {
+			continue
+		}
+		precision := SymbolPrecision{SettleAsset: info.QuoteAsset}
+		if filter := info.PriceFilter(); filter != nil {
+			precision.PricePrecision = parsePrecision(filter.TickSize)
+		}
+		if filter := info.LotSizeFilter(); filter != nil {
+			precision.QuantityPrecision = parsePrecision(filter.StepSize)
+			precision.MinQty = parseFloatOrZero(filter.MinQuantity)
+		}
+		if filter := info.NotionalFilter(); filter != nil {
+			precision.MinNotional = parseFloatOrZero(filter.MinNotional)
+		}
+		symbols[info.Symbol] = info
+		symbolInfo[info.Symbol] = precision
+	}
+
+	e.mu.Lock()
+	e.symbols = symbols
+	e.precisions = symbolInfo
+	e.mu.Unlock()
+	return symbolInfo, nil
+}
+
+// symbolFor returns the symbol trading asset against the quote asset, loading the
+// exchange info on first use.
+func (e *spotExchange) symbolFor(ctx context.Context, asset string) (string, bool, error) {
+	e.mu.Lock()
+	loaded := len(e.symbols) > 0
+	e.mu.Unlock()
+	if !loaded {
+		if _, err := e.SymbolPrecisions(ctx); err != nil {
+			return "", false, err
+		}
+	}
+
+	symbol := asset + e.config.SpotQuoteAsset
+	e.mu.Lock()
+	_, ok := e.symbols[symbol]
+	e.mu.Unlock()
+	return symbol, ok, nil
+}
+
+// balances returns the net balance of every asset: free plus locked on spot, or the
+// net asset after borrowing on margin, which is negative for shorts.
+func (e *spotExchange) balances(ctx context.Context) (map[string]float64, error) {
+	balances := make(map[string]float64)
+	if e.config.SpotMargin {
+		account, err := e.client.NewGetMarginAccountService().Do(ctx, e.signed()...)
+		if err != nil {
+			return nil, err
+		}
+		for _, asset := range account.UserAssets {
+			if amount := parseFloatOrZero(asset.NetAsset); amount != 0 {
+				balances[asset.Asset] = amount
+			}
+		}
+		return balances, nil
+	}
+
+	account, err := e.client.NewGetAccountService().OmitZeroBalances(true).Do(ctx, e.signed()...)
+	if err != nil {
+		return nil, err
+	}
+	for _, balance := range account.Balances {
+		if amount := parseFloatOrZero(balance.Free) + parseFloatOrZero(balance.Locked); amount != 0 {
+			balances[balance.Asset] = amount
+		}
+	}
+	return balances, nil
+}
+
+// prices returns the last price of every symbol.
+func (e *spotExchange) prices(ctx context.Context) (map[string]float64, error) {
+	list, err := e.client.NewListPricesService().Do(ctx)
+	if err != nil {
+		return nil, err
+	}
+	prices := make(map[string]float64, len(list))
+	for _, price := range list {
+		prices[price.Symbol] = parseFloatOrZero(price.Price)
+	}
+	return prices, nil
+}
+
+// entryPrice estimates the average entry price of a holding from the most recent
+// trades on its side that add up to amount. It falls back to fallback when the
+// trade history does not cover the holding, e.g. for deposited coins.
+func (e *spotExchange) entryPrice(ctx context.Context, symbol string, amount, fallback float64) float64 {
+	var trades []*spot.TradeV3
+	var err error
+	if e.config.SpotMargin {
+		trades, err = e.client.NewListMarginTradesService().Symbol(symbol).Limit(spotTradeLookback).Do(ctx, e.signed()...)
+	} else {
+		trades, err = e.client.NewListTradesService().Symbol(symbol).Limit(spotTradeLookback).Do(ctx, e.signed()...)
+	}
+	if err != nil {
+		log.Printf("Warning: error fetching trades for %s: %v", symbol, err)
+		return fallback
+	}
+
+	// Walk back from the newest trade until the holding is covered
+	remaining := math.Abs(amount)
+	var cost, filled float64
+	for i := len(trades) - 1; i >= 0 && remaining > 0; i-- {
+		trade := trades[i]
+		if trade.IsBuyer != (amount > 0) {
+			continue
+		}
+		qty := math.Min(parseFloatOrZero(trade.Quantity), remaining)
+		cost += qty * parseFloatOrZero(trade.Price)
+		filled += qty
+		remaining -= qty
+	}
+	if filled == 0 {
+		log.Printf("Warning: no trades found for %s, using the current price as entry", symbol)
+		return fallback
+	}
+	return cost / filled
+}
+
+// Positions implements Exchange. Holdings worth less than spotDustNotional are ignored.
+func (e *spotExchange) Positions(ctx context.Context, symbol string) ([]*Position, error) {
+	balances, err := e.balances(ctx)
+	if err != nil {
+		return nil, err
+	}
+	prices, err := e.prices(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var positions []*Position
+	held := make(map[string]bool)
+	for asset, amount := range balances {
+		if asset == e.config.SpotQuoteAsset {
+			continue
+		}
+		assetSymbol, ok, err := e.symbolFor(ctx, asset)
+		if err != nil {
+			return nil, err
+		}
+		if !ok || (symbol != "" && assetSymbol != symbol) {
+			continue
+		}
+		price := prices[assetSymbol]
+		if math.Abs(amount)*price < spotDustNotional {
+			continue
+		}
+
+		held[assetSymbol] = true
+		positions = append(positions, &Position{
+			Symbol:       assetSymbol,
+			PositionSide: "BOTH",
+			PositionAmt:  amount,
+			EntryPrice:   e.entryPrice(ctx, assetSymbol, amount, price),
+			MarkPrice:    price,
+			Leverage:     1,
+		})
+	}
+
+	// Forget the legs of holdings that are gone
+	e.mu.Lock()
+	for bracketSymbol := range e.brackets {
+		if (symbol == "" || bracketSymbol == symbol) && !held[bracketSymbol] {
+			delete(e.brackets, bracketSymbol)
+		}
+	}
+	e.mu.Unlock()
+	return positions, nil
+}
+
+// OpenOrders implements Exchange, reporting stop-loss legs as STOP_MARKET and
+// limit legs as TAKE_PROFIT_MARKET orders triggering at their limit price.
+func (e *spotExchange) OpenOrders(ctx context.Context, symbol string) ([]*Order, error) {
+	var orders []*spot.Order
+	var err error
+	if e.config.SpotMargin {
+		service := e.client.NewListMarginOpenOrdersService()
+		if symbol != "" {
+			service = service.Symbol(symbol)
+		}
+		orders, err = service.Do(ctx, e.signed()...)
+	} else {
+		service := e.client.NewListOpenOrdersService()
+		if symbol != "" {
+			service = service.Symbol(symbol)
+		}
+		orders, err = service.Do(ctx, e.signed()...)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	openOrders := make([]*Order, 0, len(orders))
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, order := range orders {
+		converted := &Order{
+			ID:            OrderID(strconv.FormatInt(order.OrderID, 10)),
+			ClientOrderID: order.ClientOrderID,
+			Symbol:        order.Symbol,
+			Type:          string(order.Type),
+			Side:          string(order.Side),
+			PositionSide:  "BOTH",
+			StopPrice:     parseFloatOrZero(order.StopPrice),
+			Price:         parseFloatOrZero(order.Price),
+			UpdateTime:    time.UnixMilli(order.UpdateTime),
+		}
+
+		bracket := e.bracket(order.Symbol, string(order.Side), order.OrigQuantity)
+		switch order.Type {
+		case spot.OrderTypeStopLoss, spot.OrderTypeStopLossLimit:
+			converted.Type = orderTypeStopMarket
+			bracket.stopPrice = order.StopPrice
+			bracket.stopClientID = order.ClientOrderID
+		case spot.OrderTypeLimitMaker, spot.OrderTypeTakeProfit, spot.OrderTypeTakeProfitLimit:
+			converted.Type = orderTypeTakeProfitMarket
+			if converted.StopPrice == 0 {
+				converted.StopPrice = parseFloatOrZero(order.Price)
+			}
+			bracket.takePrice = strconv.FormatFloat(converted.StopPrice, 'f', -1, 64)
+			bracket.takeClientID = order.ClientOrderID
+		}
+
+		if order.OrderListId >= 0 {
+			e.orderLists[converted.ID] = order.OrderListId
+		}
+		openOrders = append(openOrders, converted)
+	}
+	return openOrders, nil
+}
+
+// bracket returns the remembered legs of symbol, creating them if needed. The
+// caller must hold e.mu.
+func (e *spotExchange) bracket(symbol, side, quantity string) *spotBracket {
+	bracket, ok := e.brackets[symbol]
+	if !ok || bracket.side != side {
+		bracket = &spotBracket{side: side}
+		e.brackets[symbol] = bracket
+	}
+	bracket.quantity = quantity
+	return bracket
+}
+
+// stopLimitPrice offsets stopPrice by SPOT_STOP_LIMIT_OFFSET so the limit order
+// placed when the stop triggers still fills in a fast market.
+func (e *spotExchange) stopLimitPrice(symbol, side, stopPrice string) string {
+	offset := e.config.SpotStopLimitOffset
+	if side == sideSell {
+		offset = -offset
+	}
+	stop := scalePrice(parseFloatOrZero(stopPrice), offset)
+
+	e.mu.Lock()
+	precision := e.precisions[symbol].PricePrecision
+	e.mu.Unlock()
+	return formatDecimal(stop, precision)
+}
+
+// CreateOrder implements Exchange. A stop or target is merged with the remembered
+// other leg of the symbol into one OCO order, replacing any order still holding
+// the balance.
+func (e *spotExchange) CreateOrder(ctx context.Context, req OrderRequest) (*Order, error) {
+	if req.Type == orderTypeLimit {
+		return nil, fmt.Errorf("limit orders are not supported on %s", exchangeBinanceSpot)
+	}
+	if req.Type != orderTypeStopMarket && req.Type != orderTypeTakeProfitMarket {
+		return e.createMarketOrder(ctx, req)
+	}
+
+	e.mu.Lock()
+	bracket := e.bracket(req.Symbol, req.Side, req.Quantity)
+	bracket.set(req)
+	legs := *bracket
+	e.mu.Unlock()
+
+	orders, err := e.placeBracket(ctx, req.Symbol, legs)
+	if err != nil {
+		return nil, err
+	}
+	for _, order := range orders {
+		if order.Type == req.Type {
+			return order, nil
+		}
+	}
+	return nil, fmt.Errorf("no %s leg placed for %s", req.Type, req.Symbol)
+}
+
+// CreateOrders implements Exchange, placing a stop and target pair as one OCO order.
+func (e *spotExchange) CreateOrders(ctx context.Context, reqs []OrderRequest) ([]*Order, []error, error) {
+	orders := make([]*Order, len(reqs))
+	errs := make([]error, len(reqs))
+
+	if len(reqs) == 2 && reqs[0].Symbol == reqs[1].Symbol && reqs[0].Type != reqs[1].Type &&
+		reqs[0].Type != orderTypeMarket && reqs[1].Type != orderTypeMarket {
+		e.mu.Lock()
+		bracket := e.bracket(reqs[0].Symbol, reqs[0].Side, reqs[0].Quantity)
+		for _, req := range reqs {
+			bracket.set(req)
+		}
+		legs := *bracket
+		e.mu.Unlock()
+
+		placed, err := e.placeBracket(ctx, reqs[0].Symbol, legs)
+		for i, req := range reqs {
+			errs[i] = err
+			for _, order := range placed {
+				if order.Type == req.Type {
+					orders[i], errs[i] = order, nil
+				}
+			}
+		}
+		return orders, errs, nil
+	}
+
+	for i, req := range reqs {
+		orders[i], errs[i] = e.CreateOrder(ctx, req)
+	}
+	return orders, errs, nil
+}
+
+// placeBracket cancels the symbol's open protective orders on the bracket's side and
+// places the bracket: an OCO when both legs are known, otherwise the single leg.
+func (e *spotExchange) placeBracket(ctx context.Context, symbol string, legs spotBracket) ([]*Order, error) {
+	openOrders, err := e.OpenOrders(ctx, symbol)
+	if err != nil {
+		return nil, err
+	}
+	for _, order := range openOrders {
+		if order.Side == legs.side && (order.Type == orderTypeStopMarket || order.Type == orderTypeTakeProfitMarket) {
+			if err := e.CancelOrder(ctx, order); err != nil {
+				log.Printf("Warning: error cancelling order %s for %s: %v", order.ID, symbol, err)
+			}
+		}
+	}
+
+	if legs.stopPrice != "" && legs.takePrice != "" {
+		return e.createOCO(ctx, symbol, legs)
+	}
+
+	service := e.newOrder(symbol, legs.side, legs.quantity)
+	order := &Order{Symbol: symbol, Side: legs.side, PositionSide: "BOTH", UpdateTime: time.Now()}
+	if legs.stopPrice != "" {
+		service.orderType = spot.OrderTypeStopLossLimit
+		service.stopPrice = legs.stopPrice
+		service.price = e.stopLimitPrice(symbol, legs.side, legs.stopPrice)
+		service.clientID = legs.stopClientID
+		order.Type = orderTypeStopMarket
+		order.StopPrice = parseFloatOrZero(legs.stopPrice)
+	} else {
+		service.orderType = spot.OrderTypeLimitMaker
+		service.price = legs.takePrice
+		service.clientID = legs.takeClientID
+		order.Type = orderTypeTakeProfitMarket
+		order.StopPrice = parseFloatOrZero(legs.takePrice)
+	}
+	order.ClientOrderID = service.clientID
+
+	res, err := e.submit(ctx, service)
+	if err != nil {
+		return nil, err
+	}
+	order.ID = OrderID(strconv.FormatInt(res.OrderID, 10))
+	return []*Order{order}, nil
+}
+
+// createOCO places both legs of the bracket as one OCO order.
+func (e *spotExchange) createOCO(ctx context.Context, symbol string, legs spotBracket) ([]*Order, error) {
+	stopLimit := e.stopLimitPrice(symbol, legs.side, legs.stopPrice)
+
+	var listID int64
+	type report struct {
+		orderID   int64
+		orderType spot.OrderType
+	}
+	var reports []report
+	if e.config.SpotMargin {
+		service := e.client.NewCreateMarginOCOService().
+			Symbol(symbol).
+			Side(spot.SideType(legs.side)).
+			Quantity(legs.quantity).
+			Price(legs.takePrice).
+			StopPrice(legs.stopPrice).
+			StopLimitPrice(stopLimit).
+			StopLimitTimeInForce(spot.TimeInForceTypeGTC)
+		if legs.side == sideBuy {
+			service = service.SideEffectType(spot.SideEffectTypeAutoRepay)
+		}
+		if legs.stopClientID != "" {
+			service = service.StopClientOrderID(legs.stopClientID)
+		}
+		if legs.takeClientID != "" {
+			service = service.LimitClientOrderID(legs.takeClientID)
+		}
+		res, err := service.Do(ctx, e.signed()...)
+		if err != nil {
+			return nil, fmt.Errorf("error placing OCO for %s: %w", symbol, err)
+		}
+		listID = res.OrderListID
+		for _, r := range res.OrderReports {
+			reports = append(reports, report{r.OrderID, r.Type})
+		}
+	} else {
+		service := e.client.NewCreateOCOService().
+			Symbol(symbol).
+			Side(spot.SideType(legs.side)).
+			Quantity(legs.quantity).
+			Price(legs.takePrice).
+			StopPrice(legs.stopPrice).
+			StopLimitPrice(stopLimit).
+			StopLimitTimeInForce(spot.TimeInForceTypeGTC)
+		if legs.stopClientID != "" {
+			service = service.StopClientOrderID(legs.stopClientID)
+		}
+		if legs.takeClientID != "" {
+			service = service.LimitClientOrderID(legs.takeClientID)
+		}
+		res, err := service.Do(ctx, e.signed()...)
+		if err != nil {
+			return nil, fmt.Errorf("error placing OCO for %s: %w", symbol, err)
+		}
+		listID = res.OrderListID
+		for _, r := range res.OrderReports {
+			reports = append(reports, report{r.OrderID, r.Type})
+		}
+	}
+
+	orders := make([]*Order, 0, len(reports))
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, r := range reports {
+		order := &Order{
+			ID:           OrderID(strconv.FormatInt(r.orderID, 10)),
+			Symbol:       symbol,
+			Side:         legs.side,
+			PositionSide: "BOTH",
+			UpdateTime:   time.Now(),
+		}
+		if r.orderType == spot.OrderTypeLimitMaker {
+			order.Type = orderTypeTakeProfitMarket
+			order.StopPrice = parseFloatOrZero(legs.takePrice)
+			order.ClientOrderID = legs.takeClientID
+		} else {
+			order.Type = orderTypeStopMarket
+			order.StopPrice = parseFloatOrZero(legs.stopPrice)
+			order.ClientOrderID = legs.stopClientID
+		}
+		e.orderLists[order.ID] = listID
+		orders = append(orders, order)
+	}
+	return orders, nil
+}
+
+// spotOrder collects the parameters of a single spot or margin order.
+type spotOrder struct {
+	symbol    string
+	side      string
+	orderType spot.OrderType
+	quantity  string
+	price     string
+	stopPrice string
+	clientID  string
+}
+
+// newOrder starts a single order for symbol.
+func (e *spotExchange) newOrder(symbol, side, quantity string) *spotOrder {
+	return &spotOrder{symbol: symbol, side: side, quantity: quantity}
+}
+
+// submit places a single order on the spot or margin account.
+func (e *spotExchange) submit(ctx context.Context, o *spotOrder) (*spot.CreateOrderResponse, error) {
+	if e.config.SpotMargin {
+		service := e.client.NewCreateMarginOrderService().
+			Symbol(o.symbol).
+			Side(spot.SideType(o.side)).
+			Type(o.orderType).
+			Quantity(o.quantity)
+		if o.price != "" {
+			service = service.Price(o.price)
+		}
+		if o.stopPrice != "" {
+			service = service.StopPrice(o.stopPrice)
+		}
+		if o.orderType == spot.OrderTypeStopLossLimit {
+			service = service.TimeInForce(spot.TimeInForceTypeGTC)
+		}
+		if o.clientID != "" {
+			service = service.NewClientOrderID(o.clientID)
+		}
+		if o.side == sideBuy {
+			service = service.SideEffectType(spot.SideEffectTypeAutoRepay)
+		}
+		return service.Do(ctx, e.signed()...)
+	}
+
+	service := e.client.NewCreateOrderService().
+		Symbol(o.symbol).
+		Side(spot.SideType(o.side)).
+		Type(o.orderType).
+		Quantity(o.quantity)
+	if o.price != "" {
+		service = service.Price(o.price)
+	}
+	if o.stopPrice != "" {
+		service = service.StopPrice(o.stopPrice)
+	}
+	if o.orderType == spot.OrderTypeStopLossLimit {
+		service = service.TimeInForce(spot.TimeInForceTypeGTC)
+	}
+	if o.clientID != "" {
+		service = service.NewClientOrderID(o.clientID)
+	}
+	return service.Do(ctx, e.signed()...)
+}
+
+// createMarketOrder places a market order, used to reduce holdings.
+func (e *spotExchange) createMarketOrder(ctx context.Context, req OrderRequest) (*Order, error) {
+	o := e.newOrder(req.Symbol, req.Side, req.Quantity)
+	o.orderType = spot.OrderTypeMarket
+
+	res, err := e.submit(ctx, o)
+	if err != nil {
+		return nil, err
+	}
+	return &Order{
+		ID:           OrderID(strconv.FormatInt(res.OrderID, 10)),
+		Symbol:       req.Symbol,
+		Type:         req.Type,
+		Side:         req.Side,
+		PositionSide: "BOTH",
+		UpdateTime:   time.UnixMilli(res.TransactTime),
+	}, nil
+}
+
+// CancelOrder implements Exchange. Cancelling an OCO leg cancels the whole list.
+func (e *spotExchange) CancelOrder(ctx context.Context, order *Order) error {
+	e.mu.Lock()
+	listID, inList := e.orderLists[order.ID]
+	delete(e.orderLists, order.ID)
+	e.mu.Unlock()
+
+	if inList {
+		var err error
+		if e.config.SpotMargin {
+			_, err = e.client.NewCancelMarginOCOService().Symbol(order.Symbol).OrderListID(listID).Do(ctx, e.signed()...)
+		} else {
+			_, err = e.client.NewCancelOCOService().Symbol(order.Symbol).OrderListID(listID).Do(ctx, e.signed()...)
+		}
+		// The other leg is cancelled with the list and may already be gone
+		var apiErr *common.APIError
+		if errors.As(err, &apiErr) && apiErr.Code == unknownOrderErrorCode {
+			return nil
+		}
+		return err
+	}
+
+	orderID, err := strconv.ParseInt(string(order.ID), 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid Binance order ID %q: %w", order.ID, err)
+	}
+	if e.config.SpotMargin {
+		_, err = e.client.NewCancelMarginOrderService().Symbol(order.Symbol).OrderID(orderID).Do(ctx, e.signed()...)
+	} else {
+		_, err = e.client.NewCancelOrderService().Symbol(order.Symbol).OrderID(orderID).Do(ctx, e.signed()...)
+	}
+	return err
+}
@@ -0,0 +1,243 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/adshao/go-binance/v2/common"
+)
+
+// memoryExchange is an in-memory Exchange holding fixed positions and the orders
+// placed through it, for tests driving the guard without a venue.
+type memoryExchange struct {
+	mu sync.Mutex
+
+	Precisions map[string]SymbolPrecision
+	Open       []*Position
+	Orders     []*Order
+
+	// Cancelled holds the orders cancelled through the exchange, in order.
+	Cancelled []*Order
+	// Reject, when set, fails the placements it returns an error for.
+	Reject func(req OrderRequest) error
+	nextID int
+	// clientIDs holds every client order ID placed, which like Bybit the
+	// exchange never accepts again, even once its order is cancelled or filled.
+	clientIDs map[string]bool
+}
+
+// newMemoryExchange returns a memoryExchange trading symbols at two price and
+// three quantity decimals.
+func newMemoryExchange(symbols ...string) *memoryExchange {
+	e := &memoryExchange{Precisions: make(map[string]SymbolPrecision)}
+	for _, symbol := range symbols {
+		e.Precisions[symbol] = SymbolPrecision{PricePrecision: 2, QuantityPrecision: 3, SettleAsset: "USDT"}
+	}
+	return e
+}
+
+// Name implements Exchange.
+func (e *memoryExchange) Name() string { return exchangeBinance }
+
+// ServerTime implements Exchange.
+func (e *memoryExchange) ServerTime(ctx context.Context) (time.Time, error) {
+	return time.Now(), nil
+}
+
+// SymbolPrecisions implements Exchange.
+func (e *memoryExchange) SymbolPrecisions(ctx context.Context) (map[string]SymbolPrecision, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	precisions := make(map[string]SymbolPrecision, len(e.Precisions))
+	for symbol, p := range e.Precisions {
+		precisions[symbol] = p
+	}
+	return precisions, nil
+}
+
+// Positions implements Exchange.
+func (e *memoryExchange) Positions(ctx context.Context, symbol string) ([]*Position, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	var positions []*Position
+	for _, p := range e.Open {
+		if symbol == "" || p.Symbol == symbol {
+			copied := *p
+			positions = append(positions, &copied)
+		}
+	}
+	return positions, nil
+}
+
+// OpenOrders implements Exchange.
+func (e *memoryExchange) OpenOrders(ctx context.Context, symbol string) ([]*Order, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	var orders []*Order
+	for _, o := range e.Orders {
+		if symbol == "" || o.Symbol == symbol {
+			copied := *o
+			orders = append(orders, &copied)
+		}
+	}
+	return orders, nil
+}
+
+// CreateOrder implements Exchange.
+func (e *memoryExchange) CreateOrder(ctx context.Context, req OrderRequest) (*Order, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.place(req)
+}
+
+// CreateOrders implements Exchange.
+func (e *memoryExchange) CreateOrders(ctx context.Context, reqs []OrderRequest) ([]*Order, []error, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	orders := make([]*Order, len(reqs))
+	errs := make([]error, len(reqs))
+	for i, req := range reqs {
+		orders[i], errs[i] = e.place(req)
+	}
+	return orders, errs, nil
+}
+
+// place adds the order req describes. The caller holds e.mu.
+func (e *memoryExchange) place(req OrderRequest) (*Order, error) {
+	if _, ok := e.Precisions[req.Symbol]; !ok {
+		return nil, fmt.Errorf("invalid symbol %s", req.Symbol)
+	}
+	if e.Reject != nil {
+		if err := e.Reject(req); err != nil {
+			return nil, err
+		}
+	}
+	if e.triggersImmediately(req) {
+		return nil, &common.APIError{Code: immediateTriggerErrorCode, Message: "Order would immediately trigger."}
+	}
+	if req.ClientOrderID != "" {
+		if e.clientIDs[req.ClientOrderID] {
+			return nil, fmt.Errorf("duplicate client order ID %s", req.ClientOrderID)
+		}
+		if e.clientIDs == nil {
+			e.clientIDs = make(map[string]bool)
+		}
+		e.clientIDs[req.ClientOrderID] = true
+	}
+	order := &Order{
+		ClientOrderID: req.ClientOrderID,
+		Symbol:        req.Symbol,
+		Type:          req.Type,
+		Side:          req.Side,
+		PositionSide:  req.PositionSide,
+		UpdateTime:    time.Now(),
+	}
+	var err error
+	if order.StopPrice, err = parseOptionalFloat(req.StopPrice); err != nil {
+		return nil, fmt.Errorf("invalid stop price %q", req.StopPrice)
+	}
+	if order.Price, err = parseOptionalFloat(req.Price); err != nil {
+		return nil, fmt.Errorf("invalid price %q", req.Price)
+	}
+	if order.Quantity, err = parseOptionalFloat(req.Quantity); err != nil {
+		return nil, fmt.Errorf("invalid quantity %q", req.Quantity)
+	}
+	e.nextID++
+	order.ID = OrderID(strconv.Itoa(e.nextID))
+	e.Orders = append(e.Orders, order)
+	copied := *order
+	return &copied, nil
+}
+
+// triggersImmediately reports whether req is a stop or target the mark price of
+// an open position has already passed, which Binance rejects. The caller holds
+// e.mu.
+func (e *memoryExchange) triggersImmediately(req OrderRequest) bool {
+	stop, err := parseOptionalFloat(req.StopPrice)
+	if err != nil || stop <= 0 {
+		return false
+	}
+	for _, p := range e.Open {
+		if p.Symbol != req.Symbol || p.MarkPrice <= 0 {
+			continue
+		}
+		// A sell stop triggers at or below the mark price, a sell target at or above
+		falling := req.Side == sideSell
+		if req.Type == orderTypeTakeProfitMarket {
+			falling = !falling
+		}
+		switch req.Type {
+		case orderTypeStopMarket, orderTypeStop, orderTypeTakeProfitMarket:
+			if falling && stop >= p.MarkPrice || !falling && stop <= p.MarkPrice {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// parseOptionalFloat parses s, zero when empty.
+func parseOptionalFloat(s string) (float64, error) {
+	if s == "" {
+		return 0, nil
+	}
+	return strconv.ParseFloat(s, 64)
+}
+
+// CancelOrder implements Exchange.
+func (e *memoryExchange) CancelOrder(ctx context.Context, order *Order) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	i := slices.IndexFunc(e.Orders, func(o *Order) bool { return o.ID == order.ID })
+	if i < 0 {
+		return fmt.Errorf("unknown order %s", order.ID)
+	}
+	e.Cancelled = append(e.Cancelled, e.Orders[i])
+	e.Orders = slices.Delete(e.Orders, i, i+1)
+	return nil
+}
+
+// ordersOfType returns the open orders of symbol of type typ.
+func (e *memoryExchange) ordersOfType(symbol, typ string) []*Order {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	var orders []*Order
+	for _, o := range e.Orders {
+		if o.Symbol == symbol && o.Type == typ {
+			orders = append(orders, o)
+		}
+	}
+	return orders
+}
+
+// newTestTradingService returns a TradingService over exchange and client with
+// its state and an empty config file in a temporary directory and adjust changing
+// the config loaded from the environment.
+func newTestTradingService(t testing.TB, exchange Exchange, client ExchangeClient, adjust func(*Config)) *TradingService {
+	t.Helper()
+	dir := t.TempDir()
+	t.Setenv("CONFIG_FILE", dir+"/.env")
+	t.Setenv("STATE_FILE", dir+"/state.json")
+	if err := os.WriteFile(configFile(), nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	config, err := loadConfig()
+	if err != nil {
+		t.Fatalf("loadConfig: %v", err)
+	}
+	config.APIRateLimit = 0 // Nothing to pace in memory
+	if adjust != nil {
+		adjust(&config)
+	}
+	ts, err := NewTradingService(exchange, client, config)
+	if err != nil {
+		t.Fatalf("NewTradingService: %v", err)
+	}
+	return ts
+}
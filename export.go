@@ -0,0 +1,132 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Export formats.
+const (
+	exportFormatCSV  = "csv"
+	exportFormatJSON = "json"
+)
+
+// positionExport is the flat, spreadsheet-friendly form of a PositionData.
+type positionExport struct {
+	Time               time.Time `json:"time"`
+	Symbol             string    `json:"symbol"`
+	Side               string    `json:"side"`
+	PositionSide       string    `json:"position_side"`
+	Quantity           float64   `json:"quantity"`
+	Leverage           float64   `json:"leverage"`
+	EntryPrice         float64   `json:"entry_price"`
+	MarkPrice          float64   `json:"mark_price"`
+	ProfitPct          float64   `json:"profit_pct"`
+	StopLoss           float64   `json:"stop_loss"`
+	StopLossPct        float64   `json:"stop_loss_pct"`
+	TakeProfit         float64   `json:"take_profit"`
+	TakeProfitPct      float64   `json:"take_profit_pct"`
+	RiskReward         float64   `json:"risk_reward"`
+	PotentialProfit    float64   `json:"potential_profit"`
+	PotentialLoss      float64   `json:"potential_loss"`
+	PnLAsset           string    `json:"pnl_asset"`
+	LiquidationPrice   float64   `json:"liquidation_price"`
+	LiquidationDistPct float64   `json:"liquidation_distance_pct"`
+	Tag                string    `json:"tag"`
+	Notes              string    `json:"notes"`
+}
+
+// positionExportHeader is the CSV header, matching the JSON field names.
+var positionExportHeader = []string{
+	"time", "symbol", "side", "position_side", "quantity", "leverage",
+	"entry_price", "mark_price", "profit_pct", "stop_loss", "stop_loss_pct",
+	"take_profit", "take_profit_pct", "risk_reward", "potential_profit",
+	"potential_loss", "pnl_asset", "liquidation_price", "liquidation_distance_pct", "tag", "notes",
+}
+
+// parseExportFormat validates an export format name.
+func parseExportFormat(value string) (string, error) {
+	switch format := strings.ToLower(strings.TrimSpace(value)); format {
+	case "", exportFormatJSON:
+		return exportFormatJSON, nil
+	case exportFormatCSV:
+		return exportFormatCSV, nil
+	default:
+		return "", fmt.Errorf("unknown export format %q (use %s or %s)", value, exportFormatCSV, exportFormatJSON)
+	}
+}
+
+// newPositionExport flattens data, stamping it with now.
+func newPositionExport(data *PositionData, now time.Time) positionExport {
+	side := "SHORT"
+	if data.IsLong {
+		side = "LONG"
+	}
+	pnlAsset := data.PnLAsset
+	if pnlAsset == "" {
+		pnlAsset = "USD"
+	}
+	return positionExport{
+		Time:               now.UTC(),
+		Symbol:             data.Symbol,
+		Side:               side,
+		PositionSide:       data.PositionSide,
+		Quantity:           data.AbsAmt,
+		Leverage:           data.Leverage,
+		EntryPrice:         data.EntryPrice,
+		MarkPrice:          data.MarkPrice,
+		ProfitPct:          data.CurrentProfitPct,
+		StopLoss:           data.StopPrice,
+		StopLossPct:        data.LeveragedSLPct,
+		TakeProfit:         data.TakePrice,
+		TakeProfitPct:      data.LeveragedTPPct,
+		RiskReward:         data.RiskReward,
+		PotentialProfit:    data.PotentialProfit,
+		PotentialLoss:      data.PotentialLoss,
+		PnLAsset:           pnlAsset,
+		LiquidationPrice:   data.LiquidationPrice,
+		LiquidationDistPct: data.LiquidationDistPct,
+		Tag:                data.Tag,
+		Notes:              joinNotes(data.Notes),
+	}
+}
+
+// writePositionsExport writes positions to w in format.
+func writePositionsExport(w io.Writer, format string, positions []*PositionData) error {
+	now := time.Now()
+	rows := make([]positionExport, 0, len(positions))
+	for _, data := range positions {
+		rows = append(rows, newPositionExport(data, now))
+	}
+
+	if format == exportFormatJSON {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(rows)
+	}
+
+	f := func(v float64) string { return strconv.FormatFloat(v, 'f', -1, 64) }
+	cw := csv.NewWriter(w)
+	if err := cw.Write(positionExportHeader); err != nil {
+		return err
+	}
+	for _, row := range rows {
+		record := []string{
+			row.Time.Format(time.RFC3339), row.Symbol, row.Side, row.PositionSide,
+			f(row.Quantity), f(row.Leverage), f(row.EntryPrice), f(row.MarkPrice),
+			f(row.ProfitPct), f(row.StopLoss), f(row.StopLossPct), f(row.TakeProfit),
+			f(row.TakeProfitPct), f(row.RiskReward), f(row.PotentialProfit),
+			f(row.PotentialLoss), row.PnLAsset, f(row.LiquidationPrice), f(row.LiquidationDistPct), row.Tag, row.Notes,
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+)
+
+// positionFees returns the commissions and funding of the current position since it
+// opened, in its settlement asset and negative when paid. Commissions come from the
+// position's own trades; those paid in another asset, such as BNB, are left out.
+// Funding comes from the income history of the symbol, which does not tell the
+// sides of a hedge-mode symbol apart.
+func (ts *TradingService) positionFees(data *PositionData) (float64, error) {
+	trades, err := ts.positionTrades(data, "fee attribution")
+	if err != nil {
+		return 0, err
+	}
+
+	asset := data.PnLAsset
+	if asset == "" {
+		precision, _ := ts.symbolPrecision(data.Symbol)
+		asset = precision.SettleAsset
+	}
+	var fees float64
+	for _, trade := range trades {
+		if trade.CommissionAsset != asset {
+			continue
+		}
+		commission, err := strconv.ParseFloat(trade.Commission, 64)
+		if err != nil {
+			return 0, fmt.Errorf("error parsing trade commission: %w", err)
+		}
+		fees -= commission
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+
+	incomes, err := ts.client().IncomeHistory(ctx, IncomeQuery{
+		Symbol:     data.Symbol,
+		IncomeType: fundingIncomeType,
+		Start:      time.UnixMilli(trades[0].Time),
+		Limit:      incomePageLimit,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("error fetching funding history for %s: %w", data.Symbol, err)
+	}
+	for _, income := range incomes {
+		amount, err := strconv.ParseFloat(income.Income, 64)
+		if err != nil {
+			return 0, fmt.Errorf("error parsing funding income: %w", err)
+		}
+		fees += amount
+	}
+	return fees, nil
+}
+
+// applyFees folds the commissions and funding of a position into its profit, so the
+// ladder stages follow net rather than gross profit.
+func (ts *TradingService) applyFees(data *PositionData) {
+	if !ts.config().FeesIncludeInProfit {
+		return
+	}
+
+	fees, err := ts.positionFees(data)
+	if err != nil {
+		log.Printf("Warning: %v", err)
+		return
+	}
+	notional := data.EntryPrice * data.AbsAmt
+	if notional <= 0 {
+		return
+	}
+	data.Fees = fees
+	feesPct := fees / notional * 100
+	data.RawProfitPct += feesPct
+	data.CurrentProfitPct = data.RawProfitPct * data.Leverage
+	log.Printf("DEBUG: Included %.4f fees and funding (%.4f%%) in %s profit", fees, feesPct, data.Symbol)
+}
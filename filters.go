@@ -0,0 +1,84 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"path"
+	"slices"
+	"strings"
+)
+
+// parseSymbolList splits a comma-separated list of symbol patterns from config.
+func parseSymbolList(value string) ([]string, error) {
+	var patterns []string
+	for _, item := range strings.Split(value, ",") {
+		item = strings.ToUpper(strings.TrimSpace(item))
+		if item == "" {
+			continue
+		}
+		if _, err := path.Match(item, ""); err != nil {
+			return nil, fmt.Errorf("invalid symbol pattern %q: %w", item, err)
+		}
+		patterns = append(patterns, item)
+	}
+	return patterns, nil
+}
+
+// matchesSymbolPattern reports whether symbol matches any of the patterns.
+// Patterns support shell-style wildcards, e.g. "*USDT" or "BTC*".
+func matchesSymbolPattern(symbol string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, symbol); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// isSymbolManaged reports whether the bot should manage positions for symbol.
+// The blacklist takes precedence over the whitelist; an empty whitelist means
+// every symbol is managed.
+func (ts *TradingService) isSymbolManaged(symbol string) bool {
+	if matchesSymbolPattern(symbol, ts.config().SymbolBlacklist) {
+		return false
+	}
+	if len(ts.config().SymbolWhitelist) > 0 {
+		return matchesSymbolPattern(symbol, ts.config().SymbolWhitelist)
+	}
+	return true
+}
+
+// parseSymbolOverrides parses per-symbol settings of the form "BTCUSDT=48h,ETHUSDT=12h".
+func parseSymbolOverrides(value string) (map[string]string, error) {
+	overrides := make(map[string]string)
+	for _, item := range strings.Split(value, ",") {
+		if strings.TrimSpace(item) == "" {
+			continue
+		}
+		symbol, setting, ok := strings.Cut(item, "=")
+		if symbol = strings.ToUpper(strings.TrimSpace(symbol)); !ok || symbol == "" {
+			return nil, fmt.Errorf("malformed symbol override %q, want SYMBOL=VALUE", strings.TrimSpace(item))
+		}
+		overrides[symbol] = strings.TrimSpace(setting)
+	}
+	return overrides, nil
+}
+
+// parseOverrides parses per-symbol settings of the form "BTCUSDT=20,DOGEUSDT=5",
+// each value with fn. The first invalid value fails the whole setting, checked in
+// symbol order so the error is stable.
+func parseOverrides[T any](value string, fn func(string) (T, error)) (map[string]T, error) {
+	settings, err := parseSymbolOverrides(value)
+	if err != nil {
+		return nil, err
+	}
+	overrides := make(map[string]T, len(settings))
+	for _, symbol := range slices.Sorted(maps.Keys(settings)) {
+		v, err := fn(settings[symbol])
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", symbol, err)
+		}
+		overrides[symbol] = v
+	}
+	return overrides, nil
+}
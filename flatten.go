@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+)
+
+// flattenResult summarizes an emergency flatten.
+type flattenResult struct {
+	Cancelled int
+	Closed    []string
+}
+
+// String returns a one-line summary of the flatten.
+func (r flattenResult) String() string {
+	if len(r.Closed) == 0 {
+		return fmt.Sprintf("cancelled %d orders, no open positions", r.Cancelled)
+	}
+	return fmt.Sprintf("cancelled %d orders, closed %s", r.Cancelled, strings.Join(r.Closed, ", "))
+}
+
+// closeAll cancels every open order and closes every position of the managed
+// symbols at market. It keeps going past individual failures, which matters most
+// during exchange incidents, and reports them together. The kill switch is tripped
+// first, so no entry, scale-in or pyramid add reopens a position until it is reset.
+func (ts *TradingService) closeAll() (flattenResult, error) {
+	var result flattenResult
+	var errs []error
+
+	ts.tripKillSwitch("emergency flatten")
+
+	// Act on live orders rather than those cached by the last cycle
+	ts.orderCache().invalidate("")
+
+	// Each request gets its own timeout so a slow one cannot starve the rest
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	openOrders, err := ts.exchange().OpenOrders(ctx, "")
+	cancel()
+	if err != nil {
+		errs = append(errs, fmt.Errorf("error fetching open orders: %w", err))
+	}
+	for _, order := range openOrders {
+		if !ts.isSymbolManaged(order.Symbol) {
+			continue
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+		err := ts.cancelOrder(ctx, order)
+		cancel()
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		result.Cancelled++
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), defaultTimeout)
+	positions, err := ts.exchange().Positions(ctx, "")
+	cancel()
+	if err != nil {
+		return result, errors.Join(append(errs, fmt.Errorf("error getting positions: %w", err))...)
+	}
+	for _, position := range positions {
+		if !ts.isSymbolManaged(position.Symbol) {
+			continue
+		}
+		data, err := newPositionData(position)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if data == nil {
+			continue
+		}
+
+		unlock := ts.lockPosition(data.Symbol, data.PositionSide)
+		err = ts.reducePosition(data, 100, "emergency flatten")
+		unlock()
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		result.Closed = append(result.Closed, fmt.Sprintf("%s %s", data.Symbol, data.PositionSide))
+	}
+
+	log.Printf("Emergency flatten: %s", result)
+	return result, errors.Join(errs...)
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+)
+
+// freeMarginGuard tracks whether the free margin of the account is below
+// FREE_MARGIN_ALERT_PERCENT of its margin balance.
+type freeMarginGuard struct {
+	low   bool
+	ratio float64 // free margin as a percentage of the margin balance
+}
+
+// checkFreeMargin compares the available balance of the account with its total
+// margin balance and alerts when the free share drops below
+// FREE_MARGIN_ALERT_PERCENT, and again once it has recovered. Account balances
+// are only read on Binance USDⓈ-M.
+func (ts *TradingService) checkFreeMargin() {
+	if ts.config().FreeMarginAlertPct <= 0 || ts.exchange().Name() != exchangeBinance {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+
+	account, err := ts.client().Account(ctx)
+	if err != nil {
+		log.Printf("Warning: Unable to check the free margin: %v", err)
+		return
+	}
+	available := parseFloatOrZero(account.AvailableBalance)
+	balance := parseFloatOrZero(account.TotalMarginBalance)
+	if balance <= 0 {
+		return
+	}
+	ratio := available / balance * 100
+	low := ratio < ts.config().FreeMarginAlertPct
+
+	ts.mu.Lock()
+	guard := &ts.freeMargin
+	changed := low != guard.low
+	guard.low, guard.ratio = low, ratio
+	ts.mu.Unlock()
+	if !changed {
+		return
+	}
+
+	if !low {
+		msg := fmt.Sprintf("✅ Free margin recovered to %.2f%% of the margin balance (floor %.2f%%)",
+			ratio, ts.config().FreeMarginAlertPct)
+		log.Println(msg)
+		ts.notify(SeverityInfo, msg)
+		return
+	}
+	msg := fmt.Sprintf("⚠️ Free margin is down to %.2f%% of the margin balance (%s of %s, floor %.2f%%)",
+		ratio, formatPnL(available, "USDT"), formatPnL(balance, "USDT"), ts.config().FreeMarginAlertPct)
+	if ts.config().FreeMarginBlockAdds {
+		msg += ", blocking entries, scale-ins and pyramid adds"
+	}
+	log.Println(msg)
+	ts.notify(SeverityWarning, msg)
+}
+
+// freeMarginBlocked returns the last free margin ratio and whether adds are
+// blocked because it is below its floor.
+func (ts *TradingService) freeMarginBlocked() (float64, bool) {
+	if !ts.config().FreeMarginBlockAdds || ts.config().FreeMarginAlertPct <= 0 {
+		return 0, false
+	}
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	return ts.freeMargin.ratio, ts.freeMargin.low
+}
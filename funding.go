@@ -0,0 +1,187 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Funding guard actions.
+const (
+	fundingActionWarn    = "warn"
+	fundingActionTighten = "tighten"
+	fundingActionClose   = "close"
+)
+
+// fundingIncomeType is the income history type for funding payments.
+const fundingIncomeType = "FUNDING_FEE"
+
+// FundingInfo holds funding data for a symbol. Rates are expressed in percent per funding interval.
+type FundingInfo struct {
+	LastRate        float64
+	PredictedRate   float64
+	NextFundingTime time.Time
+}
+
+// getFundingInfo fetches the last settled and the predicted funding rate for a symbol.
+func (ts *TradingService) getFundingInfo(symbol string) (*FundingInfo, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+
+	info := &FundingInfo{}
+
+	history, err := ts.client().FundingRates(ctx, symbol, 1)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching funding rate for %s: %w", symbol, err)
+	}
+	if len(history) > 0 {
+		rate, err := strconv.ParseFloat(history[0].FundingRate, 64)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing funding rate: %w", err)
+		}
+		info.LastRate = rate * 100
+	}
+
+	premium, err := ts.client().PremiumIndex(ctx, symbol)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching premium index for %s: %w", symbol, err)
+	}
+	if len(premium) > 0 {
+		rate, err := strconv.ParseFloat(premium[0].LastFundingRate, 64)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing predicted funding rate: %w", err)
+		}
+		info.PredictedRate = rate * 100
+		info.NextFundingTime = time.UnixMilli(premium[0].NextFundingTime)
+	}
+
+	return info, nil
+}
+
+// getAccruedFunding sums the funding paid or received for a symbol over the configured lookback.
+// Positive values mean funding was received.
+func (ts *TradingService) getAccruedFunding(symbol string) (float64, error) {
+	if err := ts.requireBinance("accrued funding"); err != nil {
+		return 0, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+
+	incomes, err := ts.client().IncomeHistory(ctx, IncomeQuery{
+		Symbol:     symbol,
+		IncomeType: fundingIncomeType,
+		Start:      time.Now().Add(-ts.config().FundingLookback),
+		Limit:      1000,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("error fetching funding history for %s: %w", symbol, err)
+	}
+
+	var total float64
+	for _, income := range incomes {
+		amount, err := strconv.ParseFloat(income.Income, 64)
+		if err != nil {
+			return 0, fmt.Errorf("error parsing funding income: %w", err)
+		}
+		total += amount
+	}
+	return total, nil
+}
+
+// paysFunding reports whether the position pays funding at the given rate.
+// Longs pay when the rate is positive, shorts pay when it is negative.
+func paysFunding(data *PositionData, rate float64) bool {
+	return (data.IsLong && rate > 0) || (data.IsShort && rate < 0)
+}
+
+// applyFunding loads funding data for a position, optionally folds accrued funding
+// into the profit percentages, and handles positions paying extreme funding.
+func (ts *TradingService) applyFunding(data *PositionData) {
+	if !ts.config().FundingIncludeInProfit && ts.config().FundingExtremeRate <= 0 {
+		return
+	}
+
+	info, err := ts.getFundingInfo(data.Symbol)
+	if err != nil {
+		log.Printf("Warning: %v", err)
+		return
+	}
+	data.FundingRate = info.LastRate
+	data.PredictedFundingRate = info.PredictedRate
+
+	// FEES_INCLUDE_IN_PROFIT counts the funding of the position itself
+	if ts.config().FundingIncludeInProfit && !ts.config().FeesIncludeInProfit {
+		accrued, err := ts.getAccruedFunding(data.Symbol)
+		if err != nil {
+			log.Printf("Warning: %v", err)
+		} else if notional := data.EntryPrice * data.AbsAmt; notional > 0 {
+			data.AccruedFunding = accrued
+			fundingPct := accrued / notional * 100
+			data.RawProfitPct += fundingPct
+			data.CurrentProfitPct = data.RawProfitPct * data.Leverage
+			log.Printf("DEBUG: Included %.4f USD funding (%.4f%%) in %s profit",
+				accrued, fundingPct, data.Symbol)
+		}
+	}
+
+	if ts.config().FundingExtremeRate <= 0 {
+		return
+	}
+
+	// Use the worse of the last and predicted rate for the side the position is on
+	rate := data.PredictedFundingRate
+	if paysFunding(data, data.FundingRate) && math.Abs(data.FundingRate) > math.Abs(rate) {
+		rate = data.FundingRate
+	}
+	if !paysFunding(data, rate) || math.Abs(rate) < ts.config().FundingExtremeRate {
+		return
+	}
+	data.ExtremeFunding = true
+
+	msg := fmt.Sprintf("💸 %s %s is paying extreme funding: %.4f%% (threshold %.4f%%, next funding %s)",
+		data.Symbol, data.PositionSide, rate, ts.config().FundingExtremeRate,
+		info.NextFundingTime.UTC().Format(time.RFC3339))
+	log.Println(msg)
+	ts.notify(SeverityWarning, msg)
+
+	if ts.config().FundingAction == fundingActionClose {
+		if err := ts.reducePosition(data, 100, "extreme funding"); err != nil {
+			log.Printf("Warning: %v", err)
+		}
+	}
+}
+
+// applyFundingGuard tightens stopPrice for positions paying extreme funding by moving
+// it halfway towards the mark price. It never loosens the calculated stop.
+func (ts *TradingService) applyFundingGuard(data *PositionData, stopPrice float64) float64 {
+	if !data.ExtremeFunding || ts.config().FundingAction != fundingActionTighten {
+		return stopPrice
+	}
+
+	guardPrice := (stopPrice + data.MarkPrice) / 2
+	if (data.IsLong && guardPrice <= stopPrice) || (data.IsShort && guardPrice >= stopPrice) {
+		return stopPrice
+	}
+
+	log.Printf("Tightening SL for %s from %.8f to %.8f due to extreme funding",
+		data.Symbol, stopPrice, guardPrice)
+
+	data.RawSLPct = rawStopLossPct(data, guardPrice)
+	data.LeveragedSLPct = data.RawSLPct * data.Leverage
+	return guardPrice
+}
+
+// parseFundingAction normalizes the configured funding guard action.
+func parseFundingAction(value string) (string, error) {
+	switch action := strings.ToLower(strings.TrimSpace(value)); action {
+	case fundingActionWarn, fundingActionTighten, fundingActionClose:
+		return action, nil
+	default:
+		return "", fmt.Errorf("want %s, %s or %s", fundingActionWarn, fundingActionTighten, fundingActionClose)
+	}
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"strings"
+)
+
+// Grid guard defaults.
+const (
+	defaultGridStopBufferPct = 2.0
+	defaultGridRecenterPct   = 0.5
+)
+
+// isGridOrder reports whether order is a resting limit order of a grid bot, judging
+// by the client order ID prefixes of GRID_ORDER_PREFIXES.
+func (ts *TradingService) isGridOrder(order *Order) bool {
+	if order.Type != orderTypeLimit || order.Price <= 0 {
+		return false
+	}
+	for _, prefix := range ts.config().GridOrderPrefixes {
+		if strings.HasPrefix(order.ClientOrderID, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkGrid records the price range spanned by the grid bot's open orders on the
+// position's symbol, which switches the position to the grid guard.
+func (ts *TradingService) checkGrid(data *PositionData) {
+	if len(ts.config().GridOrderPrefixes) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+
+	openOrders, err := ts.exchange().OpenOrders(ctx, data.Symbol)
+	if err != nil {
+		log.Printf("Warning: Unable to list grid orders for %s: %v", data.Symbol, err)
+		return
+	}
+
+	for _, order := range openOrders {
+		if !ts.isGridOrder(order) || !orderMatchesSide(order, data.PositionSide) {
+			continue
+		}
+		if data.GridLow == 0 || order.Price < data.GridLow {
+			data.GridLow = order.Price
+		}
+		data.GridHigh = math.Max(data.GridHigh, order.Price)
+	}
+}
+
+// gridStop returns the protective stop GRID_STOP_BUFFER_PERCENT outside the grid
+// range: below its lowest order for longs, above its highest for shorts.
+func (ts *TradingService) gridStop(data *PositionData) float64 {
+	buffer := ts.config().GridStopBufferPct / 100
+	if data.IsLong {
+		return data.GridLow * (1 - buffer)
+	}
+	return data.GridHigh * (1 + buffer)
+}
+
+// updateGridStop protects a position built by a grid bot with a single stop outside
+// the grid range instead of the ladder, leaving take profits to the grid. The stop
+// follows the range in both directions, but is only replaced once it has moved
+// more than GRID_RECENTER_PERCENT, so every grid fill does not re-place it.
+func (ts *TradingService) updateGridStop(data *PositionData) error {
+	currentSL, err := ts.getCurrentStopLoss(data.Symbol, data.PositionSide)
+	if err != nil {
+		log.Printf("Warning: Unable to get current stop loss: %v", err)
+	}
+	if currentTP, err := ts.getCurrentTakeProfit(data.Symbol, data.PositionSide); err == nil {
+		data.TakePrice = currentTP
+	}
+
+	stop := ts.gridStop(data)
+	if (data.IsLong && stop >= data.MarkPrice) || (data.IsShort && stop <= data.MarkPrice) {
+		log.Printf("Warning: Grid stop %.8f for %s is past the mark price %.8f, keeping the current stop",
+			stop, data.Symbol, data.MarkPrice)
+		stop = currentSL
+	} else if currentSL > 0 && math.Abs(stop-currentSL) < currentSL*ts.config().GridRecenterPct/100 {
+		stop = currentSL
+	}
+
+	data.StopPrice = stop
+	data.RawSLPct = rawStopLossPct(data, stop)
+	data.LeveragedSLPct = data.RawSLPct * data.Leverage
+	data.CurrentSLPct = math.Abs(data.LeveragedSLPct)
+	if err := ts.calculateRiskMetrics(data); err != nil {
+		return err
+	}
+	if stop <= 0 || stop == currentSL || !ts.checkOrderSize(data) {
+		log.Printf("No changes needed for %s grid stop (range %.8f-%.8f)", data.Symbol, data.GridLow, data.GridHigh)
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+
+	openOrders, err := ts.exchange().OpenOrders(ctx, data.Symbol)
+	if err != nil {
+		return fmt.Errorf("error fetching open orders for %s: %w", data.Symbol, err)
+	}
+	for _, order := range openOrders {
+		if isStopLossOrder(order) && orderMatchesSide(order, data.PositionSide) && ts.ownsOrder(order) {
+			if err := ts.exchange().CancelOrder(ctx, order); err != nil {
+				log.Printf("Error canceling SL order %s for %s: %v", order.ID, data.Symbol, err)
+			}
+		}
+	}
+
+	log.Printf("Re-centering grid stop for %s from %.8f to %.8f (range %.8f-%.8f)",
+		data.Symbol, currentSL, stop, data.GridLow, data.GridHigh)
+	return ts.createStopLossOrder(data)
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"futures-guard/guardpb"
+)
+
+// grpcReadMethods are the gRPC methods a standby instance still serves.
+var grpcReadMethods = map[string]bool{
+	guardpb.Guard_ListPositions_FullMethodName: true,
+	guardpb.Guard_GetControl_FullMethodName:    true,
+}
+
+// grpcServer implements the Guard gRPC service on top of the trading service.
+type grpcServer struct {
+	guardpb.UnimplementedGuardServer
+	ts *TradingService
+}
+
+// serveGRPC runs the gRPC control interface on GRPCAddr until ctx is cancelled.
+func (ts *TradingService) serveGRPC(ctx context.Context) {
+	if ts.config().APIToken == "" {
+		log.Println("Warning: API_TOKEN is empty; not starting the gRPC control interface")
+		return
+	}
+	listener, err := net.Listen("tcp", ts.config().GRPCAddr)
+	if err != nil {
+		log.Printf("Error running gRPC control interface: %v", err)
+		return
+	}
+
+	server := grpc.NewServer(grpc.ChainUnaryInterceptor(ts.grpcAuthenticate, ts.grpcRequireLeader))
+	guardpb.RegisterGuardServer(server, &grpcServer{ts: ts})
+
+	go func() {
+		<-ctx.Done()
+		server.GracefulStop()
+	}()
+
+	log.Printf("gRPC control interface listening on %s", ts.config().GRPCAddr)
+	if err := server.Serve(listener); err != nil && !errors.Is(err, grpc.ErrServerStopped) {
+		log.Printf("Error running gRPC control interface: %v", err)
+	}
+}
+
+// grpcAuthenticate rejects calls that do not carry the configured API token in
+// their authorization metadata, as "Bearer <token>".
+func (ts *TradingService) grpcAuthenticate(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	var token string
+	if values := md.Get("authorization"); len(values) > 0 {
+		token = strings.TrimPrefix(values[0], "Bearer ")
+	}
+	if subtle.ConstantTimeCompare([]byte(token), []byte(ts.config().APIToken)) != 1 {
+		return nil, status.Error(codes.Unauthenticated, "invalid or missing API token")
+	}
+	return handler(ctx, req)
+}
+
+// grpcRequireLeader rejects calls that change orders or the control state while
+// this instance is on standby.
+func (ts *TradingService) grpcRequireLeader(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	if !grpcReadMethods[info.FullMethod] && !ts.isLeader() {
+		return nil, status.Error(codes.Unavailable, "this instance is on standby; send the request to the leader")
+	}
+	return handler(ctx, req)
+}
+
+// ListPositions returns the open positions with their live SL/TP orders.
+func (s *grpcServer) ListPositions(ctx context.Context, req *guardpb.ListPositionsRequest) (*guardpb.ListPositionsResponse, error) {
+	positions, err := s.ts.snapshotPositions(strings.ToUpper(req.GetSymbol()))
+	if err != nil {
+		return nil, status.Error(codes.Unavailable, err.Error())
+	}
+	resp := &guardpb.ListPositionsResponse{}
+	for _, data := range positions {
+		resp.Positions = append(resp.Positions, positionProto(data))
+	}
+	return resp, nil
+}
+
+// SetStopLoss replaces the stop-loss of a position with a manual price.
+func (s *grpcServer) SetStopLoss(ctx context.Context, req *guardpb.SetStopLossRequest) (*guardpb.Position, error) {
+	if req.GetPrice() <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "price must be positive")
+	}
+	symbol := strings.ToUpper(req.GetSymbol())
+	data, err := s.ts.setStopLoss(symbol, strings.ToUpper(req.GetSide()), req.GetPrice())
+	if err != nil {
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	}
+
+	msg := fmt.Sprintf("✋ Stop-loss for %s (%s) set to %s via gRPC", symbol, data.PositionSide, data.StopPriceStr)
+	log.Println(msg)
+	s.ts.notify(SeverityWarning, msg)
+	return positionProto(data), nil
+}
+
+// SetPaused pauses or resumes order management of every symbol or of one.
+func (s *grpcServer) SetPaused(ctx context.Context, req *guardpb.SetPausedRequest) (*guardpb.ControlState, error) {
+	if symbol := strings.ToUpper(req.GetSymbol()); symbol != "" {
+		s.ts.setSymbolPaused(symbol, req.GetPaused())
+	} else {
+		s.ts.setPaused(req.GetPaused())
+	}
+	return controlProto(s.ts.control()), nil
+}
+
+// GetControl returns the pause and kill switch state.
+func (s *grpcServer) GetControl(ctx context.Context, req *guardpb.GetControlRequest) (*guardpb.ControlState, error) {
+	return controlProto(s.ts.control()), nil
+}
+
+// ResetKillSwitch allows new entries after an emergency flatten.
+func (s *grpcServer) ResetKillSwitch(ctx context.Context, req *guardpb.ResetKillSwitchRequest) (*guardpb.ControlState, error) {
+	s.ts.resetKillSwitch()
+	return controlProto(s.ts.control()), nil
+}
+
+// Flatten closes one symbol, or every managed position when none is given.
+func (s *grpcServer) Flatten(ctx context.Context, req *guardpb.FlattenRequest) (*guardpb.FlattenResponse, error) {
+	if symbol := strings.ToUpper(req.GetSymbol()); symbol != "" {
+		if err := s.ts.closeSymbol(symbol); err != nil {
+			return nil, status.Error(codes.Unavailable, err.Error())
+		}
+		msg := fmt.Sprintf("🚪 Closed %s positions and cancelled its orders via gRPC", symbol)
+		log.Println(msg)
+		s.ts.notify(SeverityWarning, msg)
+		return &guardpb.FlattenResponse{Summary: msg}, nil
+	}
+
+	result, err := s.ts.closeAll()
+	msg := fmt.Sprintf("🚨 Emergency flatten via gRPC: %s", result)
+	if err != nil {
+		msg += fmt.Sprintf("\n⚠️ Errors: %v", err)
+	}
+	s.ts.notify(SeverityCritical, msg)
+	if err != nil {
+		return nil, status.Error(codes.Unavailable, msg)
+	}
+	return &guardpb.FlattenResponse{Summary: result.String()}, nil
+}
+
+// positionProto converts a position to its gRPC message.
+func positionProto(data *PositionData) *guardpb.Position {
+	return &guardpb.Position{
+		Symbol:           data.Symbol,
+		PositionSide:     data.PositionSide,
+		IsLong:           data.IsLong,
+		EntryPrice:       data.EntryPrice,
+		MarkPrice:        data.MarkPrice,
+		Quantity:         data.AbsAmt,
+		Leverage:         data.Leverage,
+		ProfitPct:        data.CurrentProfitPct,
+		StopPrice:        data.StopPrice,
+		TakePrice:        data.TakePrice,
+		RiskReward:       data.RiskReward,
+		PotentialProfit:  data.PotentialProfit,
+		PotentialLoss:    data.PotentialLoss,
+		PnlAsset:         data.PnLAsset,
+		LiquidationPrice: data.LiquidationPrice,
+	}
+}
+
+// controlProto converts the control state to its gRPC message.
+func controlProto(control ControlState) *guardpb.ControlState {
+	return &guardpb.ControlState{
+		Paused:           control.Paused,
+		PausedSymbols:    control.PausedSymbols,
+		KillSwitch:       control.KillSwitch,
+		KillSwitchReason: control.KillSwitchReason,
+	}
+}
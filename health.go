@@ -0,0 +1,174 @@
+package main
+
+import (
+	"context"
+	"log"
+	"maps"
+	"net/http"
+	"slices"
+	"time"
+)
+
+// defaultHealthMaxTimeDrift is the local clock drift versus exchange server time
+// tolerated by the readiness check when HEALTH_MAX_TIME_DRIFT is not set.
+const defaultHealthMaxTimeDrift = time.Second
+
+// streamStaleAfter is how long the mark price stream may stay silent before it is
+// reported unhealthy. Binance pushes mark prices every few seconds.
+const streamStaleAfter = time.Minute
+
+// healthState records the outcome of processing cycles and stream activity.
+type healthState struct {
+	startedAt         time.Time
+	lastCycleAt       time.Time
+	lastCycleErr      string
+	streamConnected   bool
+	lastStreamEventAt time.Time
+	events            map[EventType]int64 // published events by type
+}
+
+// HealthReport is the body served by /healthz and /readyz.
+type HealthReport struct {
+	Status          string              `json:"status"`
+	Problems        []string            `json:"problems,omitempty"`
+	StartedAt       time.Time           `json:"started_at"`
+	LastCycleAt     time.Time           `json:"last_cycle_at,omitzero"`
+	LastCycleError  string              `json:"last_cycle_error,omitempty"`
+	Paused          bool                `json:"paused"`
+	PausedSymbols   []string            `json:"paused_symbols,omitempty"`
+	ManualSymbols   []string            `json:"manual_symbols,omitempty"`
+	KillSwitch      bool                `json:"kill_switch"`
+	Standby         bool                `json:"standby,omitempty"`
+	ExchangeOK      *bool               `json:"exchange_ok,omitempty"`
+	TimeDriftMillis *int64              `json:"time_drift_ms,omitempty"`
+	StreamEnabled   bool                `json:"stream_enabled"`
+	StreamConnected bool                `json:"stream_connected"`
+	LastStreamEvent time.Time           `json:"last_stream_event_at,omitzero"`
+	Events          map[EventType]int64 `json:"events,omitempty"`
+}
+
+// recordCycle records the outcome of fetching positions for a processing cycle.
+func (ts *TradingService) recordCycle(err error) {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	if err != nil {
+		ts.health.lastCycleErr = err.Error()
+		return
+	}
+	ts.health.lastCycleAt = time.Now()
+	ts.health.lastCycleErr = ""
+}
+
+// recordStream records whether the mark price stream is connected.
+func (ts *TradingService) recordStream(connected bool) {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	ts.health.streamConnected = connected
+}
+
+// baseHealthReport snapshots the recorded health state and flags a stale cycle or stream.
+func (ts *TradingService) baseHealthReport() *HealthReport {
+	ts.mu.Lock()
+	health := ts.health
+	health.events = maps.Clone(ts.health.events)
+	control := ts.state.Control
+	control.PausedSymbols = slices.Clone(control.PausedSymbols)
+	control.ManualSymbols = slices.Clone(control.ManualSymbols)
+	ts.mu.Unlock()
+
+	report := &HealthReport{
+		StartedAt:       health.startedAt,
+		LastCycleAt:     health.lastCycleAt,
+		LastCycleError:  health.lastCycleErr,
+		Paused:          control.Paused,
+		PausedSymbols:   control.PausedSymbols,
+		ManualSymbols:   control.ManualSymbols,
+		KillSwitch:      control.KillSwitch,
+		Standby:         !ts.isLeader(),
+		StreamEnabled:   ts.config().MarkPriceStream,
+		StreamConnected: health.streamConnected,
+		LastStreamEvent: health.lastStreamEventAt,
+		Events:          health.events,
+	}
+
+	// Allow a few missed cycles before declaring the loop stuck
+	staleAfter := 3 * ts.config().RunInterval
+	lastActivity := health.lastCycleAt
+	if lastActivity.IsZero() {
+		lastActivity = health.startedAt
+	}
+	if staleAfter > 0 && time.Since(lastActivity) > staleAfter {
+		report.Problems = append(report.Problems, "no successful processing cycle within "+staleAfter.String())
+	}
+
+	if ts.config().MarkPriceStream {
+		if !health.streamConnected {
+			report.Problems = append(report.Problems, "mark price stream disconnected")
+		} else if time.Since(health.lastStreamEventAt) > streamStaleAfter {
+			report.Problems = append(report.Problems, "no mark price event within "+streamStaleAfter.String())
+		}
+	}
+	return report
+}
+
+// readinessReport extends the health report with live exchange connectivity and
+// clock drift checks, and requires at least one successful cycle.
+func (ts *TradingService) readinessReport() *HealthReport {
+	report := ts.baseHealthReport()
+	if report.LastCycleAt.IsZero() {
+		report.Problems = append(report.Problems, "no processing cycle completed yet")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+
+	exchangeOK := true
+	before := time.Now()
+	serverTime, err := ts.exchange().ServerTime(ctx)
+	if err != nil {
+		exchangeOK = false
+		report.Problems = append(report.Problems, ts.exchange().Name()+" unreachable: "+err.Error())
+	} else {
+		// Compare against the midpoint of the request to discount latency
+		after := time.Now()
+		local := before.Add(after.Sub(before) / 2).UnixMilli()
+		drift := local - serverTime.UnixMilli()
+		report.TimeDriftMillis = &drift
+
+		if time.Duration(abs64(drift))*time.Millisecond > ts.config().HealthMaxTimeDrift {
+			report.Problems = append(report.Problems, "clock drift exceeds "+ts.config().HealthMaxTimeDrift.String())
+		}
+	}
+	report.ExchangeOK = &exchangeOK
+	return report
+}
+
+// handleHealthz serves the liveness probe: the processing loop and stream are alive.
+func (ts *TradingService) handleHealthz(w http.ResponseWriter, r *http.Request) {
+	writeHealth(w, ts.baseHealthReport())
+}
+
+// handleReadyz serves the readiness probe: the guard can reach the exchange and manage orders.
+func (ts *TradingService) handleReadyz(w http.ResponseWriter, r *http.Request) {
+	writeHealth(w, ts.readinessReport())
+}
+
+// writeHealth writes report with 200 when healthy and 503 otherwise.
+func writeHealth(w http.ResponseWriter, report *HealthReport) {
+	status := http.StatusOK
+	report.Status = "ok"
+	if len(report.Problems) > 0 {
+		status = http.StatusServiceUnavailable
+		report.Status = "unhealthy"
+		log.Printf("Warning: Health check failed: %v", report.Problems)
+	}
+	writeJSON(w, status, report)
+}
+
+// abs64 returns the absolute value of n.
+func abs64(n int64) int64 {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
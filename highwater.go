@@ -0,0 +1,58 @@
+package main
+
+import (
+	"log"
+	"math"
+
+	"futures-guard/ladder"
+)
+
+// recordPeakProfit raises the peak profit of the position of data to its current
+// raw profit, persisting new highs, and copies it to data. The peak belongs to the
+// entry price it was reached from, so it starts over when a scale-in moves the
+// entry or the position was closed and reopened while the bot was not watching.
+func (ts *TradingService) recordPeakProfit(data *PositionData) {
+	ts.mu.Lock()
+	st := ts.orderState(data.Symbol, data.PositionSide)
+	raised := st.PeakEntryPrice != data.EntryPrice || data.RawProfitPct > st.PeakProfitPct
+	if st.PeakEntryPrice != data.EntryPrice {
+		st.PeakProfitPct, st.PeakEntryPrice = data.RawProfitPct, data.EntryPrice
+	}
+	st.PeakProfitPct = math.Max(st.PeakProfitPct, data.RawProfitPct)
+	data.PeakProfitPct = st.PeakProfitPct
+	ts.mu.Unlock()
+
+	if raised {
+		ts.saveState()
+	}
+}
+
+// recordPeakMark raises the peak profit of the tracked position of key to
+// rawProfitPct seen on the mark price stream. ts.mu must be held.
+func (ts *TradingService) recordPeakMark(key string, pos *trackedPosition, rawProfitPct float64) {
+	if st, ok := ts.state.Orders[key]; ok && st.PeakEntryPrice == pos.EntryPrice {
+		st.PeakProfitPct = math.Max(st.PeakProfitPct, rawProfitPct)
+	}
+}
+
+// highWaterStop returns the ladder stop for the peak profit of data when
+// LADDER_HIGH_WATER_MARK is enabled and the peak is above the current profit, so
+// a pullback keeps the lock of the stage already reached, across restarts too.
+// A peak stop the mark price has already passed is not placeable, and the stop
+// for the current profit is used instead.
+func (ts *TradingService) highWaterStop(data *PositionData) (float64, bool) {
+	if !ts.config().LadderHighWaterMark || data.PeakProfitPct <= data.RawProfitPct {
+		return 0, false
+	}
+
+	peak := ts.confirmedProfit(data, ladder.Profit(ts.stopLadder(data.Symbol).Metric, ladderPosition(data), data.PeakProfitPct))
+	stopPrice := ts.ladderStop(data, peak)
+	if (data.IsLong && stopPrice >= data.MarkPrice) || (data.IsShort && stopPrice <= data.MarkPrice) {
+		log.Printf("Warning: High-water SL %.8f of %s is past the mark price %.8f, using the current profit",
+			stopPrice, data.Symbol, data.MarkPrice)
+		return 0, false
+	}
+	log.Printf("DEBUG: Using the peak profit %.2f%s of %s for its SL (now %.2f%s)",
+		peak, ts.profitUnit(data.Symbol), data.Symbol, ts.ladderProfit(data), ts.profitUnit(data.Symbol))
+	return stopPrice, true
+}
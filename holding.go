@@ -0,0 +1,178 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	binance "github.com/adshao/go-binance/v2/futures"
+)
+
+// Max holding time actions.
+const (
+	holdingActionClose     = "close"
+	holdingActionBreakeven = "breakeven"
+)
+
+// tradeHistoryLimit is the maximum number of trades fetched to find a position's opening time.
+const tradeHistoryLimit = 1000
+
+// maxHoldingTime returns the maximum holding time for symbol, honoring per-symbol overrides.
+func (ts *TradingService) maxHoldingTime(symbol string) time.Duration {
+	if d, ok := ts.config().MaxHoldingTimeOverrides[symbol]; ok {
+		return d
+	}
+	return ts.config().MaxHoldingTime
+}
+
+// positionTrades returns the trades of the current position, from the one that
+// opened it onwards, by walking the trade history backwards until the traded
+// quantity adds up to the position size. When the position is older than the
+// available history, all of it is returned.
+func (ts *TradingService) positionTrades(data *PositionData, feature string) ([]*binance.AccountTrade, error) {
+	if err := ts.requireBinance(feature); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+
+	trades, err := ts.client().AccountTrades(ctx, data.Symbol, tradeHistoryLimit)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching trade history for %s: %w", data.Symbol, err)
+	}
+
+	sideTrades := make([]*binance.AccountTrade, 0, len(trades))
+	for _, trade := range trades {
+		if data.PositionSide == "BOTH" || string(trade.PositionSide) == data.PositionSide {
+			sideTrades = append(sideTrades, trade)
+		}
+	}
+	if len(sideTrades) == 0 {
+		return nil, fmt.Errorf("no trade history found for %s", data.Symbol)
+	}
+
+	remaining := data.PositionAmt
+	for i := len(sideTrades) - 1; i >= 0; i-- {
+		qty, err := signedTradeQuantity(sideTrades[i])
+		if err != nil {
+			return nil, err
+		}
+		remaining -= qty
+
+		// Once the trades account for the whole position, this trade opened it
+		if math.Abs(remaining) < data.AbsAmt*1e-9 || (data.PositionAmt > 0) != (remaining > 0) {
+			return sideTrades[i:], nil
+		}
+	}
+	return sideTrades, nil
+}
+
+// signedTradeQuantity returns the quantity of trade, negative for sells.
+func signedTradeQuantity(trade *binance.AccountTrade) (float64, error) {
+	qty, err := strconv.ParseFloat(trade.Quantity, 64)
+	if err != nil {
+		return 0, fmt.Errorf("error parsing trade quantity: %w", err)
+	}
+	if trade.Side == binance.SideTypeSell {
+		qty = -qty
+	}
+	return qty, nil
+}
+
+// getPositionOpenTime determines when the current position was opened. For
+// positions older than the trade history the oldest trade is a lower bound.
+func (ts *TradingService) getPositionOpenTime(data *PositionData) (time.Time, error) {
+	trades, err := ts.positionTrades(data, "max holding time")
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.UnixMilli(trades[0].Time), nil
+}
+
+// checkHoldingTime applies the max-holding-time rule: positions open longer than the
+// configured duration and still below the minimum profit are closed at market or
+// flagged so their stop is tightened to breakeven.
+func (ts *TradingService) checkHoldingTime(data *PositionData) {
+	maxHolding := ts.maxHoldingTime(data.Symbol)
+	if maxHolding <= 0 {
+		return
+	}
+
+	openedAt, err := ts.getPositionOpenTime(data)
+	if err != nil {
+		log.Printf("Warning: %v", err)
+		return
+	}
+	data.OpenedAt = openedAt
+
+	held := time.Since(openedAt)
+	if held < maxHolding || data.CurrentProfitPct >= ts.config().MaxHoldingMinProfit {
+		return
+	}
+
+	msg := fmt.Sprintf("⌛ %s %s held for %s (max %s) with %.2f%% profit (min %.2f%%), applying %s",
+		data.Symbol, data.PositionSide, held.Round(time.Minute), maxHolding,
+		data.CurrentProfitPct, ts.config().MaxHoldingMinProfit, ts.config().MaxHoldingAction)
+	log.Println(msg)
+	ts.notify(SeverityWarning, msg)
+
+	switch ts.config().MaxHoldingAction {
+	case holdingActionClose:
+		if err := ts.reducePosition(data, 100, "max holding time"); err != nil {
+			log.Printf("Warning: %v", err)
+		}
+	case holdingActionBreakeven:
+		data.HoldingExpired = true
+	}
+}
+
+// applyHoldingGuard moves the stop to breakeven for positions past their maximum
+// holding time. Breakeven is only used when it is tighter than stopPrice and still
+// on the valid side of the mark price.
+func (ts *TradingService) applyHoldingGuard(data *PositionData, stopPrice float64) float64 {
+	if !data.HoldingExpired {
+		return stopPrice
+	}
+	return moveStopToBreakeven(data, stopPrice, "max holding time")
+}
+
+// moveStopToBreakeven returns the entry price as the stop when it is tighter than
+// stopPrice and still on the valid side of the mark price, and stopPrice otherwise.
+func moveStopToBreakeven(data *PositionData, stopPrice float64, reason string) float64 {
+	breakeven := data.EntryPrice
+	if (data.IsLong && breakeven >= data.MarkPrice) || (data.IsShort && breakeven <= data.MarkPrice) {
+		log.Printf("Cannot move SL for %s to breakeven %.8f: mark price %.8f is on the wrong side",
+			data.Symbol, breakeven, data.MarkPrice)
+		return stopPrice
+	}
+	if (data.IsLong && breakeven <= stopPrice) || (data.IsShort && breakeven >= stopPrice) {
+		return stopPrice
+	}
+
+	log.Printf("Moving SL for %s from %.8f to breakeven %.8f due to %s",
+		data.Symbol, stopPrice, breakeven, reason)
+
+	data.RawSLPct = rawStopLossPct(data, breakeven)
+	data.LeveragedSLPct = data.RawSLPct * data.Leverage
+	return breakeven
+}
+
+// parseHoldingAction normalizes the configured max holding time action.
+func parseHoldingAction(value string) (string, error) {
+	switch action := strings.ToLower(strings.TrimSpace(value)); action {
+	case holdingActionClose, holdingActionBreakeven:
+		return action, nil
+	default:
+		return "", fmt.Errorf("want %s or %s", holdingActionClose, holdingActionBreakeven)
+	}
+}
+
+// parseHoldingOverrides parses per-symbol max holding times such as "BTCUSDT=48h,ETHUSDT=12h".
+func parseHoldingOverrides(value string) (map[string]time.Duration, error) {
+	return parseOverrides(value, time.ParseDuration)
+}
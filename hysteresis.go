@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Default update hysteresis: a new stop must differ from the live one by 0.01% of
+// the entry price, a new target from the live one by 0.5% of its price.
+var (
+	defaultSLUpdateHysteresis = updateHysteresis{Value: 0.01}
+	defaultTPUpdateHysteresis = updateHysteresis{Value: 0.5}
+)
+
+// updateHysteresis is the smallest change for which a live SL or TP order is
+// replaced: a percentage of a reference price, or a number of price ticks.
+type updateHysteresis struct {
+	Value float64
+	Ticks bool // Value counts price ticks instead of percent
+}
+
+// String formats h the way it is configured, e.g. "0.5%" or "3t".
+func (h updateHysteresis) String() string {
+	value := strconv.FormatFloat(h.Value, 'f', -1, 64)
+	if h.Ticks {
+		return value + "t"
+	}
+	return value + "%"
+}
+
+// parseHysteresis parses a hysteresis such as "0.05%" or "3t". A bare number is a
+// percentage.
+func parseHysteresis(setting string) (updateHysteresis, error) {
+	value := strings.ToLower(strings.TrimSpace(setting))
+	var h updateHysteresis
+	switch {
+	case strings.HasSuffix(value, "ticks"):
+		value, h.Ticks = strings.TrimSuffix(value, "ticks"), true
+	case strings.HasSuffix(value, "t"):
+		value, h.Ticks = strings.TrimSuffix(value, "t"), true
+	default:
+		value = strings.TrimSuffix(value, "%")
+	}
+
+	v, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil || v < 0 {
+		return h, fmt.Errorf("invalid hysteresis %q (use a percentage such as 0.05%% or ticks such as 3t)", setting)
+	}
+	h.Value = v
+	return h, nil
+}
+
+// parseHysteresisOverrides parses per-symbol hysteresis of the form "BTCUSDT=2t,ETHUSDT=0.05%".
+func parseHysteresisOverrides(value string) (map[string]updateHysteresis, error) {
+	return parseOverrides(value, parseHysteresis)
+}
+
+// slUpdateThreshold returns the smallest stop move worth replacing the live stop
+// of data for, relative to its entry price.
+func (ts *TradingService) slUpdateThreshold(data *PositionData) float64 {
+	h := ts.config().SLUpdateHysteresis
+	if override, ok := ts.config().SLUpdateHysteresisOverrides[data.Symbol]; ok {
+		h = override
+	}
+	return ts.hysteresisDistance(h, data.Symbol, data.EntryPrice)
+}
+
+// tpUpdateThreshold returns the smallest target move worth replacing the live
+// target currentTP of symbol for.
+func (ts *TradingService) tpUpdateThreshold(symbol string, currentTP float64) float64 {
+	h := ts.config().TPUpdateHysteresis
+	if override, ok := ts.config().TPUpdateHysteresisOverrides[symbol]; ok {
+		h = override
+	}
+	return ts.hysteresisDistance(h, symbol, currentTP)
+}
+
+// hysteresisDistance returns the price change h stands for at price on symbol.
+// Ticks of a symbol without precision information count as zero.
+func (ts *TradingService) hysteresisDistance(h updateHysteresis, symbol string, price float64) float64 {
+	if !h.Ticks {
+		return price * h.Value / 100
+	}
+	precision, ok := ts.symbolPrecision(symbol)
+	if !ok {
+		return 0
+	}
+	return h.Value * tickSize(precision.PricePrecision)
+}
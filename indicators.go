@@ -0,0 +1,177 @@
+package main
+
+import "math"
+
+// trueRange returns the true range of a candle given the previous close.
+func trueRange(c Candle, prevClose float64) float64 {
+	return math.Max(c.High-c.Low, math.Max(math.Abs(c.High-prevClose), math.Abs(c.Low-prevClose)))
+}
+
+// averageTrueRange computes Wilder's ATR over period candles. It returns 0 when
+// there are not enough candles.
+func averageTrueRange(candles []Candle, period int) float64 {
+	if period <= 0 || len(candles) <= period {
+		return 0
+	}
+
+	var atr float64
+	for i := 1; i <= period; i++ {
+		atr += trueRange(candles[i], candles[i-1].Close)
+	}
+	atr /= float64(period)
+
+	for i := period + 1; i < len(candles); i++ {
+		atr = (atr*float64(period-1) + trueRange(candles[i], candles[i-1].Close)) / float64(period)
+	}
+	return atr
+}
+
+// highestHigh returns the highest high of the last n candles.
+func highestHigh(candles []Candle, n int) float64 {
+	if n > len(candles) {
+		n = len(candles)
+	}
+	high := 0.0
+	for _, c := range candles[len(candles)-n:] {
+		high = math.Max(high, c.High)
+	}
+	return high
+}
+
+// lowestLow returns the lowest low of the last n candles.
+func lowestLow(candles []Candle, n int) float64 {
+	if n > len(candles) {
+		n = len(candles)
+	}
+	low := math.Inf(1)
+	for _, c := range candles[len(candles)-n:] {
+		low = math.Min(low, c.Low)
+	}
+	if math.IsInf(low, 1) {
+		return 0
+	}
+	return low
+}
+
+// lastPivotLow returns the low of the most recent pivot low below price: a candle
+// whose low is under the lows of the n candles on either side. It returns 0 when
+// there is none.
+func lastPivotLow(candles []Candle, n int, price float64) float64 {
+	for i := len(candles) - 1 - n; i >= n; i-- {
+		low := candles[i].Low
+		if low >= price {
+			continue
+		}
+		pivot := true
+		for j := i - n; j <= i+n && pivot; j++ {
+			if j != i && candles[j].Low <= low {
+				pivot = false
+			}
+		}
+		if pivot {
+			return low
+		}
+	}
+	return 0
+}
+
+// lastPivotHigh returns the high of the most recent pivot high above price: a candle
+// whose high is over the highs of the n candles on either side. It returns 0 when
+// there is none.
+func lastPivotHigh(candles []Candle, n int, price float64) float64 {
+	for i := len(candles) - 1 - n; i >= n; i-- {
+		high := candles[i].High
+		if high <= price {
+			continue
+		}
+		pivot := true
+		for j := i - n; j <= i+n && pivot; j++ {
+			if j != i && candles[j].High >= high {
+				pivot = false
+			}
+		}
+		if pivot {
+			return high
+		}
+	}
+	return 0
+}
+
+// averageDirectionalIndex computes Wilder's ADX over period candles: how strongly
+// the price trends, in either direction, from 0 to 100. It returns 0 when there
+// are fewer than 2*period+1 candles.
+func averageDirectionalIndex(candles []Candle, period int) float64 {
+	if period <= 0 || len(candles) <= 2*period {
+		return 0
+	}
+
+	p := float64(period)
+	var tr, plusDM, minusDM, adx float64
+	for i := 1; i < len(candles); i++ {
+		up := candles[i].High - candles[i-1].High
+		down := candles[i-1].Low - candles[i].Low
+		var plus, minus float64
+		if up > down && up > 0 {
+			plus = up
+		}
+		if down > up && down > 0 {
+			minus = down
+		}
+		if i <= period {
+			tr += trueRange(candles[i], candles[i-1].Close)
+			plusDM += plus
+			minusDM += minus
+			if i < period {
+				continue
+			}
+		} else {
+			tr = tr - tr/p + trueRange(candles[i], candles[i-1].Close)
+			plusDM = plusDM - plusDM/p + plus
+			minusDM = minusDM - minusDM/p + minus
+		}
+
+		var dx float64
+		if sum := plusDM + minusDM; tr > 0 && sum > 0 {
+			// The DI ratio is the same whether or not both are divided by tr
+			dx = math.Abs(plusDM-minusDM) / sum * 100
+		}
+		// The first ADX averages the first period DX values, then it is smoothed
+		switch n := i - period + 1; {
+		case n < period:
+			adx += dx
+		case n == period:
+			adx = (adx + dx) / p
+		default:
+			adx = (adx*(p-1) + dx) / p
+		}
+	}
+	return adx
+}
+
+// emaSlope returns the slope of the period EMA of the closes over its last period
+// candles, in percent of the EMA per candle. It returns 0 when there are fewer
+// than 2*period candles.
+func emaSlope(candles []Candle, period int) float64 {
+	if period <= 0 || len(candles) < 2*period {
+		return 0
+	}
+
+	var ema float64
+	for _, c := range candles[:period] {
+		ema += c.Close
+	}
+	ema /= float64(period)
+
+	k := 2 / float64(period+1)
+	past := ema
+	for i := period; i < len(candles); i++ {
+		if i == len(candles)-period {
+			past = ema
+		}
+		ema = candles[i].Close*k + ema*(1-k)
+	}
+	if past <= 0 {
+		return 0
+	}
+	return (ema - past) / past * 100 / float64(period)
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// defaultInfluxMeasurement is the default measurement of the position time series.
+const defaultInfluxMeasurement = "futures_guard_position"
+
+// influxWriter writes a point per processed position to InfluxDB using the v2
+// line protocol write API, which VictoriaMetrics and InfluxDB 1.8+ also accept.
+type influxWriter struct {
+	endpoint    string
+	token       string
+	measurement string
+	exchange    string
+}
+
+// newInfluxWriter returns the writer configured in config, or nil when no
+// INFLUX_URL is set.
+func newInfluxWriter(config Config) *influxWriter {
+	if config.InfluxURL == "" {
+		return nil
+	}
+	query := url.Values{}
+	query.Set("org", config.InfluxOrg)
+	query.Set("bucket", config.InfluxBucket)
+	query.Set("precision", "ms")
+	return &influxWriter{
+		endpoint:    strings.TrimRight(config.InfluxURL, "/") + "/api/v2/write?" + query.Encode(),
+		token:       config.InfluxToken,
+		measurement: config.InfluxMeasurement,
+		exchange:    config.Exchange,
+	}
+}
+
+// handle writes the position of a snapshot event in the background so a slow
+// database never delays order management.
+func (w *influxWriter) handle(e Event) {
+	if e.Position == nil {
+		return
+	}
+	line := w.line(e)
+	go func() {
+		if err := w.write(line); err != nil {
+			log.Printf("Warning: Error writing %s to InfluxDB: %v", e.Symbol, err)
+		}
+	}()
+}
+
+// line formats the position of e as a line protocol point: the symbol, position
+// side and exchange as tags, prices, PnL and the ladder stage as fields.
+func (w *influxWriter) line(e Event) string {
+	data := e.Position
+	fields := []string{
+		"mark_price=" + formatInfluxFloat(data.MarkPrice),
+		"entry_price=" + formatInfluxFloat(data.EntryPrice),
+		"stop_loss=" + formatInfluxFloat(data.StopPrice),
+		"take_profit=" + formatInfluxFloat(data.TakePrice),
+		"unrealized_pnl=" + formatInfluxFloat(positionPnL(data, data.MarkPrice)),
+		"profit_pct=" + formatInfluxFloat(data.CurrentProfitPct),
+		"quantity=" + formatInfluxFloat(data.AbsAmt),
+		"stage=" + strconv.Itoa(e.Stage) + "i",
+	}
+	return fmt.Sprintf("%s,symbol=%s,position_side=%s,exchange=%s %s %d",
+		escapeInfluxKey(w.measurement), escapeInfluxKey(data.Symbol), escapeInfluxKey(data.PositionSide),
+		escapeInfluxKey(w.exchange), strings.Join(fields, ","), e.Time.UnixMilli())
+}
+
+// write sends line protocol points to the write endpoint.
+func (w *influxWriter) write(lines string) error {
+	req, err := http.NewRequest(http.MethodPost, w.endpoint, bytes.NewBufferString(lines))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	if w.token != "" {
+		req.Header.Set("Authorization", "Token "+w.token)
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("InfluxDB returned error code: %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// escapeInfluxKey escapes the characters line protocol reserves in measurement
+// names and tag values.
+func escapeInfluxKey(value string) string {
+	return strings.NewReplacer(",", `\,`, " ", `\ `, "=", `\=`).Replace(value)
+}
+
+// formatInfluxFloat formats a float field value.
+func formatInfluxFloat(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// JournalNote is a note written about a position by replying to one of its
+// Telegram messages.
+type JournalNote struct {
+	At   time.Time `json:"at"`
+	Text string    `json:"text"`
+}
+
+// joinNotes returns the texts of notes on a single line, for exports.
+func joinNotes(notes []JournalNote) string {
+	texts := make([]string, len(notes))
+	for i, note := range notes {
+		texts[i] = note.Text
+	}
+	return strings.Join(texts, " | ")
+}
+
+// positionNotes returns a copy of the notes recorded for a position.
+func (ts *TradingService) positionNotes(symbol, positionSide string) []JournalNote {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	if st, ok := ts.state.Orders[trackedKey(symbol, positionSide)]; ok {
+		return append([]JournalNote(nil), st.Notes...)
+	}
+	return nil
+}
+
+// addJournalNote stores text against the position the replied-to message is about
+// and returns the reply. An open position mentioned in the message takes the
+// note; once it has closed, the last recorded trade of its symbol does.
+func (ts *TradingService) addJournalNote(repliedTo, text string) string {
+	text = strings.TrimSpace(text)
+	words := make(map[string]bool)
+	for _, word := range strings.FieldsFunc(strings.ToUpper(repliedTo), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_'
+	}) {
+		words[word] = true
+	}
+	note := JournalNote{At: time.Now().UTC(), Text: text}
+
+	ts.mu.Lock()
+	var matches []*PositionData
+	for _, data := range ts.lastPositions {
+		if words[data.Symbol] {
+			matches = append(matches, data)
+		}
+	}
+	if len(matches) > 1 {
+		// Both sides of a hedge-mode symbol are open; the message names its side
+		var sided []*PositionData
+		for _, data := range matches {
+			if words[positionDirection(data)] {
+				sided = append(sided, data)
+			}
+		}
+		matches = sided
+	}
+	if len(matches) == 1 {
+		data := matches[0]
+		st := ts.orderState(data.Symbol, data.PositionSide)
+		st.Notes = append(st.Notes, note)
+		ts.mu.Unlock()
+		ts.saveState()
+		return fmt.Sprintf("📝 Note added to %s %s", data.Symbol, positionDirection(data))
+	}
+	if len(matches) > 1 {
+		ts.mu.Unlock()
+		return "⚠️ Both sides of the position are open; reply to a message that names LONG or SHORT"
+	}
+
+	for i := len(ts.state.Trades) - 1; i >= 0; i-- {
+		trade := ts.state.Trades[i]
+		if !words[trade.Symbol] {
+			continue
+		}
+		trade.Notes = append(trade.Notes, note)
+		ts.mu.Unlock()
+		ts.saveState()
+		return fmt.Sprintf("📝 Note added to the closed %s trade of %s", trade.Symbol, trade.ClosedAt.Format(time.DateTime))
+	}
+	ts.mu.Unlock()
+	return "⚠️ No position found in the message you replied to"
+}
+
+// positionDirection returns LONG or SHORT for data.
+func positionDirection(data *PositionData) string {
+	if data.IsLong {
+		return "LONG"
+	}
+	return "SHORT"
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	binance "github.com/adshao/go-binance/v2/futures"
+)
+
+// klinePageLimit is the maximum number of klines returned per request.
+const klinePageLimit = 1500
+
+// Candle is a parsed kline.
+type Candle struct {
+	OpenTime  time.Time
+	CloseTime time.Time
+	Open      float64
+	High      float64
+	Low       float64
+	Close     float64
+	Volume    float64
+}
+
+// parseCandle converts a kline into a Candle.
+func parseCandle(k *binance.Kline) (Candle, error) {
+	var c Candle
+	var err error
+	c.OpenTime = time.UnixMilli(k.OpenTime)
+	c.CloseTime = time.UnixMilli(k.CloseTime)
+	for _, field := range []struct {
+		value  string
+		target *float64
+	}{
+		{k.Open, &c.Open},
+		{k.High, &c.High},
+		{k.Low, &c.Low},
+		{k.Close, &c.Close},
+		{k.Volume, &c.Volume},
+	} {
+		if *field.target, err = strconv.ParseFloat(field.value, 64); err != nil {
+			return Candle{}, fmt.Errorf("error parsing kline value %q: %w", field.value, err)
+		}
+	}
+	return c, nil
+}
+
+// getKlines fetches the most recent limit candles of symbol at interval.
+func (ts *TradingService) getKlines(symbol, interval string, limit int) ([]Candle, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+
+	klines, err := ts.client().Klines(ctx, symbol, interval, time.Time{}, time.Time{}, limit)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching %s klines for %s: %w", interval, symbol, err)
+	}
+	return parseCandles(klines)
+}
+
+// getKlinesRange fetches every candle of symbol at interval between start and end, following pagination.
+func (ts *TradingService) getKlinesRange(symbol, interval string, start, end time.Time) ([]Candle, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+
+	var candles []Candle
+	for start.Before(end) {
+		klines, err := ts.client().Klines(ctx, symbol, interval, start, end, klinePageLimit)
+		if err != nil {
+			return nil, fmt.Errorf("error fetching %s klines for %s: %w", interval, symbol, err)
+		}
+		page, err := parseCandles(klines)
+		if err != nil {
+			return nil, err
+		}
+		candles = append(candles, page...)
+		if len(klines) < klinePageLimit {
+			break
+		}
+		start = time.UnixMilli(klines[len(klines)-1].CloseTime + 1)
+	}
+	return candles, nil
+}
+
+// parseCandles converts a list of klines into candles.
+func parseCandles(klines []*binance.Kline) ([]Candle, error) {
+	candles := make([]Candle, 0, len(klines))
+	for _, k := range klines {
+		c, err := parseCandle(k)
+		if err != nil {
+			return nil, err
+		}
+		candles = append(candles, c)
+	}
+	return candles, nil
+}
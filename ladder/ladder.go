@@ -0,0 +1,200 @@
+// Package ladder holds the stop-loss and take-profit math of the profit-threshold
+// ladder as pure functions, without logging, state or exchange access, so every
+// threshold boundary can be checked on its own.
+package ladder
+
+import (
+	"math"
+
+	"github.com/shopspring/decimal"
+)
+
+// Metric is the unit ladder thresholds and locks are expressed in.
+type Metric string
+
+// Profit metrics.
+const (
+	// Leveraged is the profit percentage times the leverage.
+	Leveraged Metric = "leveraged"
+	// Raw is the price move from entry in percent.
+	Raw Metric = "raw"
+	// USD is the absolute profit in the quote currency.
+	USD Metric = "usd"
+)
+
+// Level is a step of the ladder: once the profit reaches ProfitThreshold, the stop
+// locks StopLossValue, both in the unit of the ladder's Metric.
+type Level struct {
+	ProfitThreshold float64 // Profit threshold
+	StopLossValue   float64 // Corresponding stop-loss level
+}
+
+// Default returns the built-in ladder, in leveraged percent.
+func Default() []Level {
+	return []Level{
+		{300, 0},     // Initial stage, no SL adjustment yet
+		{450, 150},   // Start light capital protection
+		{600, 300},   // RR 1:1, begin locking in profits
+		{750, 450},   // Move SL higher but still leave room for breakout
+		{900, 600},   // RR 1.5:1, locking more profit
+		{1050, 750},  // Gradually increase the protection level
+		{1200, 900},  // Secure at least 900 in profit
+		{1350, 1050}, // Protect 1050 profit level
+		{1500, 1200}, // Lock in a solid 1200 profit
+	}
+}
+
+// Ladder is a sorted set of steps with the settings that turn them into prices.
+type Ladder struct {
+	Levels []Level
+	Metric Metric
+	// DefaultSL is the raw percentage of the stop from entry, against the
+	// position, below the first threshold.
+	DefaultSL float64
+	// Interpolate moves the lock linearly with the profit between two steps.
+	Interpolate bool
+}
+
+// Position is what the ladder needs to know about a position.
+type Position struct {
+	Entry    float64
+	Leverage float64
+	// Notional is the quote value of the position at its entry price.
+	Notional float64
+	Long     bool
+}
+
+var hundred = decimal.NewFromInt(100)
+
+// ScalePrice returns price moved by pct percent, in decimal so that prices such
+// as 0.29 are not distorted by binary floating point.
+func ScalePrice(price, pct float64) float64 {
+	factor := decimal.NewFromInt(1).Add(decimal.NewFromFloat(pct).Div(hundred))
+	return decimal.NewFromFloat(price).Mul(factor).InexactFloat64()
+}
+
+// Profit converts the raw profit percentage of p to the unit of metric.
+func Profit(metric Metric, p Position, rawProfitPct float64) float64 {
+	switch metric {
+	case Raw:
+		return rawProfitPct
+	case USD:
+		return rawProfitPct / 100 * p.Notional
+	default:
+		return rawProfitPct * p.Leverage
+	}
+}
+
+// LockPct converts a lock in the unit of metric to the raw percentage from the
+// entry of p it puts the stop at.
+func LockPct(metric Metric, p Position, lock float64) float64 {
+	switch metric {
+	case Raw:
+		return lock
+	case USD:
+		if p.Notional <= 0 {
+			return 0
+		}
+		return lock / p.Notional * 100
+	default:
+		return lock / p.Leverage
+	}
+}
+
+// Stage returns the index of the highest level reached by profit, or -1 when the
+// first threshold has not been reached.
+func Stage(levels []Level, profit float64) int {
+	stage := -1
+	for i, level := range levels {
+		if profit >= level.ProfitThreshold {
+			stage = i
+		} else {
+			break
+		}
+	}
+	return stage
+}
+
+// Interpolate returns the lock for profit on levels, linearly interpolated
+// between the last step reached and the next one. Past the last step its lock is
+// kept. profit must have reached the first step.
+func Interpolate(levels []Level, profit float64) float64 {
+	for i := 0; i < len(levels)-1; i++ {
+		from, to := levels[i], levels[i+1]
+		if profit < to.ProfitThreshold {
+			progress := (profit - from.ProfitThreshold) / (to.ProfitThreshold - from.ProfitThreshold)
+			return from.StopLossValue + progress*(to.StopLossValue-from.StopLossValue)
+		}
+	}
+	return levels[len(levels)-1].StopLossValue
+}
+
+// Lock returns the lock of the ladder for profit and whether a threshold was
+// reached; below the first threshold it is DefaultSL.
+func (l Ladder) Lock(profit float64) (float64, bool) {
+	if profit <= 0 {
+		return l.DefaultSL, false
+	}
+	stage := Stage(l.Levels, profit)
+	if stage < 0 {
+		return l.DefaultSL, false
+	}
+	if l.Interpolate {
+		return Interpolate(l.Levels, profit), true
+	}
+	return l.Levels[stage].StopLossValue, true
+}
+
+// Stop returns the stop price of p for profit, in the unit of the ladder's Metric,
+// with the lock it is at and whether a threshold was reached. Below the first
+// threshold the stop sits DefaultSL percent from entry against the position; from
+// there on it sits the lock beyond entry, and a zero lock is breakeven.
+func (l Ladder) Stop(p Position, profit float64) (float64, float64, bool) {
+	lock, reached := l.Lock(profit)
+	switch {
+	case lock == 0:
+		return p.Entry, lock, reached
+	case reached:
+		offset := LockPct(l.Metric, p, lock)
+		if !p.Long {
+			offset = -offset
+		}
+		return ScalePrice(p.Entry, offset), lock, reached
+	default:
+		offset := -lock
+		if !p.Long {
+			offset = lock
+		}
+		return ScalePrice(p.Entry, offset), lock, reached
+	}
+}
+
+// RawStopPct returns the unleveraged distance of stop from entry as a percentage:
+// positive when the stop locks in profit, negative when it is at a loss.
+func RawStopPct(entry, stop float64, long bool) float64 {
+	if long {
+		return (stop - entry) / entry * 100
+	}
+	return (entry - stop) / entry * 100
+}
+
+// TakeProfit returns the target tpPct percent beyond entry, or half a percent
+// beyond mark when the price has already passed it, so the target is never
+// placed on the wrong side of the market.
+func TakeProfit(entry, mark, tpPct float64, long bool) float64 {
+	if long {
+		if take := ScalePrice(entry, tpPct); take > mark {
+			return take
+		}
+		return ScalePrice(mark, 0.5)
+	}
+	if take := ScalePrice(entry, -tpPct); take < mark {
+		return take
+	}
+	return ScalePrice(mark, -0.5)
+}
+
+// RawTakePct returns the unleveraged distance of take from entry as a percentage.
+func RawTakePct(entry, take float64, long bool) float64 {
+	return math.Abs(RawStopPct(entry, take, long))
+}
@@ -0,0 +1,294 @@
+package ladder
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// position is a 10x long from 100 with 1000 of notional.
+var position = Position{Entry: 100, Leverage: 10, Notional: 1000, Long: true}
+
+// short is position on the short side.
+var short = Position{Entry: 100, Leverage: 10, Notional: 1000}
+
+// near reports whether a and b agree to well beyond any price precision.
+func near(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
+
+func TestProfit(t *testing.T) {
+	tests := []struct {
+		metric Metric
+		raw    float64
+		want   float64
+	}{
+		{Raw, 5, 5},
+		{Raw, -2, -2},
+		{Leveraged, 5, 50},
+		{Leveraged, -2, -20},
+		{USD, 5, 50},
+		{USD, -2, -20},
+		{"", 5, 50}, // Leveraged by default
+	}
+	for _, tt := range tests {
+		if got := Profit(tt.metric, position, tt.raw); !near(got, tt.want) {
+			t.Errorf("Profit(%q, %v) = %v, want %v", tt.metric, tt.raw, got, tt.want)
+		}
+	}
+}
+
+func TestLockPct(t *testing.T) {
+	tests := []struct {
+		metric Metric
+		p      Position
+		lock   float64
+		want   float64
+	}{
+		{Raw, position, 2, 2},
+		{Leveraged, position, 150, 15},
+		{USD, position, 20, 2},
+		{USD, Position{Entry: 100, Leverage: 10}, 20, 0}, // Without a notional
+	}
+	for _, tt := range tests {
+		if got := LockPct(tt.metric, tt.p, tt.lock); !near(got, tt.want) {
+			t.Errorf("LockPct(%q, %v) = %v, want %v", tt.metric, tt.lock, got, tt.want)
+		}
+	}
+}
+
+func TestStage(t *testing.T) {
+	tests := []struct {
+		profit float64
+		want   int
+	}{
+		{-50, -1},
+		{0, -1},
+		{299.99, -1},
+		{300, 0},
+		{449.99, 0},
+		{450, 1},
+		{600, 2},
+		{750, 3},
+		{900, 4},
+		{1050, 5},
+		{1200, 6},
+		{1349.99, 6},
+		{1350, 7},
+		{1500, 8},
+		{5000, 8},
+	}
+	for _, tt := range tests {
+		if got := Stage(Default(), tt.profit); got != tt.want {
+			t.Errorf("Stage(%v) = %d, want %d", tt.profit, got, tt.want)
+		}
+	}
+}
+
+func TestLock(t *testing.T) {
+	tests := []struct {
+		profit      float64
+		interpolate bool
+		want        float64
+		wantReached bool
+	}{
+		{-50, false, 2, false},
+		{0, false, 2, false},
+		{299, false, 2, false},
+		{300, false, 0, true},
+		{375, false, 0, true},
+		{450, false, 150, true},
+		{525, false, 150, true},
+		{1425, false, 1050, true},
+		{1500, false, 1200, true},
+		{2000, false, 1200, true},
+
+		{-50, true, 2, false},
+		{299, true, 2, false},
+		{300, true, 0, true},
+		{375, true, 75, true},
+		{450, true, 150, true},
+		{525, true, 225, true},
+		{1425, true, 1125, true},
+		{1500, true, 1200, true},
+		{2000, true, 1200, true},
+	}
+	for _, tt := range tests {
+		l := Ladder{Levels: Default(), Metric: Leveraged, DefaultSL: 2, Interpolate: tt.interpolate}
+		got, reached := l.Lock(tt.profit)
+		if !near(got, tt.want) || reached != tt.wantReached {
+			t.Errorf("Lock(%v) with interpolation %v = %v, %v, want %v, %v",
+				tt.profit, tt.interpolate, got, reached, tt.want, tt.wantReached)
+		}
+	}
+}
+
+func TestStop(t *testing.T) {
+	leveraged := Ladder{Levels: Default(), Metric: Leveraged, DefaultSL: 2}
+	raw := Ladder{Levels: Presets()["scalper"].Levels, Metric: Raw, DefaultSL: 1}
+	usd := Ladder{Levels: []Level{{50, 0}, {100, 30}}, Metric: USD, DefaultSL: 1.5}
+	interpolated := leveraged
+	interpolated.Interpolate = true
+
+	tests := []struct {
+		name   string
+		ladder Ladder
+		p      Position
+		profit float64
+		want   float64
+		lock   float64
+	}{
+		{"leveraged long below the first threshold", leveraged, position, 100, 98, 2},
+		{"leveraged long at a loss", leveraged, position, -100, 98, 2},
+		{"leveraged long at breakeven", leveraged, position, 300, 100, 0},
+		{"leveraged long at the second threshold", leveraged, position, 450, 115, 150},
+		{"leveraged long past the last threshold", leveraged, position, 2000, 220, 1200},
+		{"leveraged short below the first threshold", leveraged, short, 100, 102, 2},
+		{"leveraged short at breakeven", leveraged, short, 300, 100, 0},
+		{"leveraged short at the second threshold", leveraged, short, 450, 85, 150},
+		{"interpolated long between thresholds", interpolated, position, 525, 122.5, 225},
+		{"interpolated short between thresholds", interpolated, short, 525, 77.5, 225},
+
+		{"raw long below the first threshold", raw, position, 0.2, 99, 1},
+		{"raw long at breakeven", raw, position, 0.3, 100, 0},
+		{"raw long at the second threshold", raw, position, 0.5, 100.2, 0.2},
+		{"raw short below the first threshold", raw, short, 0.2, 101, 1},
+		{"raw short at the second threshold", raw, short, 0.5, 99.8, 0.2},
+
+		{"usd long below the first threshold", usd, position, 40, 98.5, 1.5},
+		{"usd long at breakeven", usd, position, 50, 100, 0},
+		{"usd long at the second threshold", usd, position, 100, 103, 30},
+		{"usd short below the first threshold", usd, short, 40, 101.5, 1.5},
+		{"usd short at the second threshold", usd, short, 100, 97, 30},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, lock, reached := tt.ladder.Stop(tt.p, tt.profit)
+			if !near(got, tt.want) || !near(lock, tt.lock) {
+				t.Errorf("Stop(%v) = %v at lock %v, want %v at lock %v", tt.profit, got, lock, tt.want, tt.lock)
+			}
+			// Below the first threshold the stop is at a loss, from there on never
+			if loss := RawStopPct(tt.p.Entry, got, tt.p.Long) < 0; loss == reached {
+				t.Errorf("Stop(%v) = %v with threshold reached %v", tt.profit, got, reached)
+			}
+		})
+	}
+}
+
+func TestTakeProfit(t *testing.T) {
+	tests := []struct {
+		name  string
+		mark  float64
+		tpPct float64
+		long  bool
+		want  float64
+	}{
+		{"long ahead of the mark", 101, 5, true, 105},
+		{"long passed by the mark", 106, 5, true, 106.53},
+		{"short ahead of the mark", 99, 5, false, 95},
+		{"short passed by the mark", 94, 5, false, 93.53},
+	}
+	for _, tt := range tests {
+		if got := TakeProfit(100, tt.mark, tt.tpPct, tt.long); !near(got, tt.want) {
+			t.Errorf("%s: TakeProfit = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestRawStopPct(t *testing.T) {
+	tests := []struct {
+		stop float64
+		long bool
+		want float64
+	}{
+		{98, true, -2},
+		{103, true, 3},
+		{98, false, 2},
+		{103, false, -3},
+	}
+	for _, tt := range tests {
+		if got := RawStopPct(100, tt.stop, tt.long); !near(got, tt.want) {
+			t.Errorf("RawStopPct(100, %v, %v) = %v, want %v", tt.stop, tt.long, got, tt.want)
+		}
+		if got := RawTakePct(100, tt.stop, tt.long); !near(got, math.Abs(tt.want)) {
+			t.Errorf("RawTakePct(100, %v, %v) = %v, want %v", tt.stop, tt.long, got, math.Abs(tt.want))
+		}
+	}
+}
+
+func TestScalePrice(t *testing.T) {
+	// Binary floating point would give 0.29869999999999997
+	if got := ScalePrice(0.29, 3); got != 0.2987 {
+		t.Errorf("ScalePrice(0.29, 3) = %v, want 0.2987", got)
+	}
+}
+
+// TestStopGolden walks the default ladder just below and just above each
+// threshold, for every leverage on both sides, and compares the stops with
+// testdata/stops.golden. One-way BOTH positions take their side from the sign
+// of their amount before they reach the ladder, which main tests. Run go test -update to rewrite it after a deliberate
+// change to the ladder math.
+func TestStopGolden(t *testing.T) {
+	const epsilon = 1e-6 // In leveraged percent
+	sides := []struct {
+		name string
+		long bool
+	}{
+		{"LONG", true},
+		{"SHORT", false},
+	}
+	l := Ladder{Levels: Default(), Metric: Leveraged, DefaultSL: 2}
+
+	var got bytes.Buffer
+	fmt.Fprintln(&got, "# leverage side level offset profit stage lock reached stop")
+	for _, leverage := range []float64{1, 2, 10, 50, 125} {
+		for _, side := range sides {
+			p := Position{Entry: 100, Leverage: leverage, Notional: 100, Long: side.long}
+			for i, level := range l.Levels {
+				for _, offset := range []float64{-epsilon, epsilon} {
+					raw := (level.ProfitThreshold + offset) / leverage
+					if !p.Long && raw >= 100 {
+						continue // A short never gains more than its entry price
+					}
+					profit := Profit(l.Metric, p, raw)
+					stop, lock, reached := l.Stop(p, profit)
+					fmt.Fprintf(&got, "%g %s %d %+g %.6f %d %s %t %s\n",
+						leverage, side.name, i, offset, profit, Stage(l.Levels, profit),
+						strconv.FormatFloat(lock, 'f', -1, 64), reached, strconv.FormatFloat(stop, 'f', -1, 64))
+				}
+			}
+		}
+	}
+
+	path := filepath.Join("testdata", "stops.golden")
+	if *update {
+		if err := os.WriteFile(path, got.Bytes(), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%v; run go test -update to create it", err)
+	}
+	gotLines, wantLines := strings.Split(got.String(), "\n"), strings.Split(string(want), "\n")
+	for i := range max(len(gotLines), len(wantLines)) {
+		var g, w string
+		if i < len(gotLines) {
+			g = gotLines[i]
+		}
+		if i < len(wantLines) {
+			w = wantLines[i]
+		}
+		if g != w {
+			t.Errorf("%s:%d:\n got %s\nwant %s", path, i+1, g, w)
+		}
+	}
+}
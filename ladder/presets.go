@@ -0,0 +1,30 @@
+package ladder
+
+// DefaultPreset is the name of the preset used when none is configured.
+const DefaultPreset = "high-leverage"
+
+// Preset is a named ladder with the metric its steps are expressed in.
+type Preset struct {
+	Metric Metric
+	Levels []Level
+}
+
+// Presets returns the built-in ladder presets by name.
+func Presets() map[string]Preset {
+	return map[string]Preset{
+		// The original table, for 50x and more: the first lock comes at 30% at 100x
+		DefaultPreset: {Metric: Leveraged, Levels: Default()},
+		// Leveraged steps for 3x-20x positions: breakeven at +30%, then trail
+		"low-leverage": {Metric: Leveraged, Levels: []Level{
+			{30, 0}, {50, 20}, {75, 40}, {100, 60}, {150, 100}, {200, 150}, {300, 230},
+		}},
+		// Tight price steps for short holds: breakeven after a 0.3% move
+		"scalper": {Metric: Raw, Levels: []Level{
+			{0.3, 0}, {0.5, 0.2}, {0.8, 0.4}, {1.2, 0.7}, {2, 1.3},
+		}},
+		// Wide price steps for multi-day holds: breakeven after a 3% move
+		"swing": {Metric: Raw, Levels: []Level{
+			{3, 0}, {5, 2}, {8, 4}, {12, 7}, {20, 13}, {30, 21},
+		}},
+	}
+}
@@ -0,0 +1,273 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// defaultLeaderLease is how long a leader keeps the lease without renewing it
+// unless LEADER_LEASE is set.
+const defaultLeaderLease = 30 * time.Second
+
+// leaderLease is a distributed lock held by one instance at a time that expires
+// unless its holder renews it.
+type leaderLease interface {
+	// Acquire takes or renews the lease for holder until ttl from now and reports
+	// whether holder now holds it.
+	Acquire(holder string, ttl time.Duration) (bool, error)
+	// Release gives the lease up if holder holds it.
+	Release(holder string) error
+}
+
+// leaseRecord is the content of a lease: its holder and when it expires.
+type leaseRecord struct {
+	Holder    string    `json:"holder"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// fileLeaderLease keeps the lease in a JSON file on storage shared by the
+// instances, such as a network volume. A lock file created exclusively guards
+// reading and replacing the lease.
+type fileLeaderLease struct {
+	path string
+}
+
+// newFileLeaderLease creates a lease backed by the file at path.
+func newFileLeaderLease(path string) *fileLeaderLease {
+	return &fileLeaderLease{path: path}
+}
+
+// Acquire takes the lease when it is free, expired or already held by holder.
+func (l *fileLeaderLease) Acquire(holder string, ttl time.Duration) (bool, error) {
+	unlock, err := l.lock(ttl)
+	if err != nil {
+		return false, err
+	}
+	defer unlock()
+
+	record, err := l.read()
+	if err != nil {
+		return false, err
+	}
+	now := time.Now()
+	if record.Holder != "" && record.Holder != holder && now.Before(record.ExpiresAt) {
+		return false, nil
+	}
+	if err := l.write(leaseRecord{Holder: holder, ExpiresAt: now.Add(ttl)}); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Release expires the lease right away if holder holds it.
+func (l *fileLeaderLease) Release(holder string) error {
+	unlock, err := l.lock(defaultLeaderLease)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	record, err := l.read()
+	if err != nil || record.Holder != holder {
+		return err
+	}
+	return l.write(leaseRecord{Holder: holder})
+}
+
+// lock creates the lock file of the lease and returns its removal function. A
+// lock file older than ttl was left behind by a crashed instance and is taken over.
+func (l *fileLeaderLease) lock(ttl time.Duration) (func(), error) {
+	path := l.path + ".lock"
+	for attempt := 0; ; attempt++ {
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+		if err == nil {
+			f.Close()
+			return func() { os.Remove(path) }, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, fmt.Errorf("error locking leader lease: %w", err)
+		}
+		info, statErr := os.Stat(path)
+		if attempt > 0 || statErr != nil || time.Since(info.ModTime()) < ttl {
+			return nil, fmt.Errorf("leader lease %s is locked by another instance", l.path)
+		}
+		os.Remove(path)
+	}
+}
+
+// read returns the current lease, empty when the lease file does not exist yet.
+func (l *fileLeaderLease) read() (leaseRecord, error) {
+	var record leaseRecord
+	data, err := os.ReadFile(l.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return record, nil
+	}
+	if err != nil {
+		return record, fmt.Errorf("error reading leader lease %s: %w", l.path, err)
+	}
+	if err := json.Unmarshal(data, &record); err != nil {
+		return record, fmt.Errorf("error parsing leader lease %s: %w", l.path, err)
+	}
+	return record, nil
+}
+
+// write replaces the lease file atomically via a temporary file and rename.
+func (l *fileLeaderLease) write(record leaseRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("error encoding leader lease: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(l.path), ".futures-guard-lease-*")
+	if err != nil {
+		return fmt.Errorf("error creating temporary lease file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("error writing leader lease: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("error writing leader lease: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), l.path); err != nil {
+		return fmt.Errorf("error replacing leader lease %s: %w", l.path, err)
+	}
+	return nil
+}
+
+// leaderElection tracks whether this instance holds the leader lease. Only the
+// leader manages orders; a standby takes over once the leader stops renewing.
+type leaderElection struct {
+	lease leaderLease
+	id    string
+	ttl   time.Duration
+
+	mu        sync.Mutex
+	leading   bool
+	expiresAt time.Time // End of the lease as last renewed by this instance
+}
+
+// newLeaderElection returns the leader election configured by LEADER_LOCK_FILE,
+// or nil when the instance runs alone.
+func newLeaderElection(config Config) *leaderElection {
+	if config.LeaderLockFile == "" {
+		return nil
+	}
+	id := config.LeaderID
+	if id == "" {
+		hostname, _ := os.Hostname()
+		id = fmt.Sprintf("%s-%d", hostname, os.Getpid())
+	}
+	return &leaderElection{
+		lease: newFileLeaderLease(config.LeaderLockFile),
+		id:    id,
+		ttl:   config.LeaderLease,
+	}
+}
+
+// campaign tries to take or renew the lease and reports whether leadership
+// changed. A leader that fails to reach the lease keeps leading until the lease
+// it last renewed expires.
+func (e *leaderElection) campaign() (changed bool) {
+	acquired, err := e.lease.Acquire(e.id, e.ttl)
+	if err != nil {
+		log.Printf("Warning: Leader election failed: %v", err)
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	now := time.Now()
+	leading := acquired || (err != nil && e.leading && now.Before(e.expiresAt))
+	if acquired {
+		e.expiresAt = now.Add(e.ttl)
+	}
+	changed = leading != e.leading
+	e.leading = leading
+	return changed
+}
+
+// isLeader reports whether this instance holds an unexpired lease.
+func (e *leaderElection) isLeader() bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.leading && time.Now().Before(e.expiresAt)
+}
+
+// resign releases the lease so a standby takes over without waiting for it to expire.
+func (e *leaderElection) resign() {
+	e.mu.Lock()
+	leading := e.leading
+	e.leading = false
+	e.mu.Unlock()
+	if !leading {
+		return
+	}
+	if err := e.lease.Release(e.id); err != nil {
+		log.Printf("Warning: Error releasing leader lease: %v", err)
+	}
+}
+
+// isLeader reports whether this instance may modify orders: it holds the leader
+// lease, or runs without leader election.
+func (ts *TradingService) isLeader() bool {
+	return ts.leader == nil || ts.leader.isLeader()
+}
+
+// runLeaderElection renews or contests the leader lease every third of its
+// duration until ctx is cancelled.
+func (ts *TradingService) runLeaderElection(ctx context.Context) {
+	ticker := time.NewTicker(ts.leader.ttl / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			ts.campaign()
+		}
+	}
+}
+
+// campaign runs one leader election round and handles a change of leadership. A
+// new leader reloads the state saved by the previous one and, with
+// RECONCILE_ON_STARTUP, repairs the live orders against it.
+func (ts *TradingService) campaign() {
+	if !ts.leader.campaign() {
+		return
+	}
+	if !ts.leader.isLeader() {
+		msg := fmt.Sprintf("⚠️ Instance %s lost the leader lease and is on standby", ts.leader.id)
+		log.Println(msg)
+		ts.notify(SeverityCritical, msg)
+		return
+	}
+
+	if ts.store != nil {
+		state, err := ts.store.Load()
+		if err != nil {
+			log.Printf("Warning: Unable to reload the bot state: %v", err)
+		} else {
+			ts.mu.Lock()
+			ts.state = state
+			ts.mu.Unlock()
+		}
+	}
+	msg := fmt.Sprintf("👑 Instance %s is now the leader and manages orders", ts.leader.id)
+	log.Println(msg)
+	ts.notify(SeverityWarning, msg)
+
+	if ts.config().ReconcileOnStartup && !ts.config().ObserveOnly {
+		if err := ts.reconcile(); err != nil {
+			log.Printf("Warning: State reconciliation failed: %v", err)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFileLeaderLease(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "leader.json")
+	a, b := newFileLeaderLease(path), newFileLeaderLease(path)
+	acquire := func(l *fileLeaderLease, holder string, want bool) {
+		t.Helper()
+		got, err := l.Acquire(holder, time.Minute)
+		if err != nil {
+			t.Fatalf("Acquire(%s): %v", holder, err)
+		}
+		if got != want {
+			t.Fatalf("Acquire(%s) = %v, want %v", holder, got, want)
+		}
+	}
+
+	acquire(a, "a", true)
+	acquire(b, "b", false)
+	acquire(a, "a", true) // Renewed by its holder
+
+	// Only the holder releases the lease
+	if err := b.Release("b"); err != nil {
+		t.Fatalf("Release(b): %v", err)
+	}
+	acquire(b, "b", false)
+	if err := a.Release("a"); err != nil {
+		t.Fatalf("Release(a): %v", err)
+	}
+	acquire(b, "b", true)
+
+	// A lease its holder stopped renewing expires
+	if err := b.write(leaseRecord{Holder: "b", ExpiresAt: time.Now().Add(-time.Second)}); err != nil {
+		t.Fatal(err)
+	}
+	acquire(a, "a", true)
+}
+
+func TestFileLeaderLeaseLock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "leader.json")
+	l := newFileLeaderLease(path)
+	if err := os.WriteFile(path+".lock", nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	// A lock held by another instance blocks the lease
+	if _, err := l.Acquire("a", time.Minute); err == nil {
+		t.Fatal("Acquire succeeded with the lease locked, want an error")
+	}
+
+	// A lock older than the lease was left behind by a crash and is taken over
+	stale := time.Now().Add(-2 * time.Minute)
+	if err := os.Chtimes(path+".lock", stale, stale); err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := l.Acquire("a", time.Minute); err != nil || !ok {
+		t.Fatalf("Acquire with a stale lock = %v, %v, want the lease", ok, err)
+	}
+	if _, err := os.Stat(path + ".lock"); !os.IsNotExist(err) {
+		t.Errorf("lock file left after Acquire: %v", err)
+	}
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/adshao/go-binance/v2/common"
+)
+
+// Leverage enforcement actions.
+const (
+	leverageActionWarn   = "warn"
+	leverageActionChange = "change"
+)
+
+// Margin types.
+const (
+	marginTypeIsolated = "isolated"
+	marginTypeCrossed  = "crossed"
+)
+
+// binanceErrNoMarginTypeChange is returned when a symbol already uses the
+// requested margin type.
+const binanceErrNoMarginTypeChange = -4046
+
+// binanceMarginType normalizes the margin type of a Binance position risk,
+// "isolated" or "cross".
+func binanceMarginType(value string) string {
+	switch strings.ToLower(value) {
+	case "isolated":
+		return marginTypeIsolated
+	case "cross", "crossed":
+		return marginTypeCrossed
+	default:
+		return ""
+	}
+}
+
+// leverageTarget returns the configured leverage of symbol, zero when not enforced.
+func (ts *TradingService) leverageTarget(symbol string) int {
+	if leverage, ok := ts.config().LeverageTargetOverrides[symbol]; ok {
+		return leverage
+	}
+	return ts.config().LeverageTarget
+}
+
+// marginTypeTarget returns the configured margin type of symbol, empty when not enforced.
+func (ts *TradingService) marginTypeTarget(symbol string) string {
+	if marginType, ok := ts.config().MarginTypeTargetOverrides[symbol]; ok {
+		return marginType
+	}
+	return ts.config().MarginTypeTarget
+}
+
+// checkLeverage compares the leverage and margin type of a position with the
+// configured ones. Mismatches are reported once until they change and, with
+// LEVERAGE_ACTION=change, corrected through the exchange. Binance refuses to change
+// the margin type of a symbol with an open position, so that change is only
+// reported as failed.
+func (ts *TradingService) checkLeverage(data *PositionData) {
+	leverage := ts.leverageTarget(data.Symbol)
+	marginType := ts.marginTypeTarget(data.Symbol)
+
+	var mismatches []string
+	if leverage > 0 && data.Leverage != float64(leverage) {
+		mismatches = append(mismatches, fmt.Sprintf("leverage %gx (target %dx)", data.Leverage, leverage))
+	}
+	if marginType != "" && data.MarginType != "" && data.MarginType != marginType {
+		mismatches = append(mismatches, fmt.Sprintf("%s margin (target %s)", data.MarginType, marginType))
+	}
+
+	key := trackedKey(data.Symbol, data.PositionSide)
+	mismatch := strings.Join(mismatches, ", ")
+	ts.mu.Lock()
+	reported := ts.leverageWarn[key] == mismatch
+	if mismatch == "" {
+		delete(ts.leverageWarn, key)
+	} else {
+		ts.leverageWarn[key] = mismatch
+	}
+	ts.mu.Unlock()
+	if mismatch == "" {
+		return
+	}
+
+	change := ts.config().LeverageAction == leverageActionChange && !ts.config().ObserveOnly
+	if !reported {
+		msg := fmt.Sprintf("🎚️ %s %s uses %s", data.Symbol, data.PositionSide, mismatch)
+		if change {
+			msg += ", changing it"
+		}
+		log.Println(msg)
+		ts.notify(SeverityWarning, msg)
+	}
+	if !change {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+
+	if leverage > 0 && data.Leverage != float64(leverage) {
+		if err := ts.client().ChangeLeverage(ctx, data.Symbol, leverage); err != nil {
+			ts.leverageChangeFailed(data, fmt.Sprintf("leverage to %dx", leverage), err, reported)
+		} else {
+			log.Printf("Changed %s leverage from %gx to %dx", data.Symbol, data.Leverage, leverage)
+			data.Leverage = float64(leverage)
+			data.CurrentProfitPct = data.RawProfitPct * data.Leverage
+		}
+	}
+	if marginType != "" && data.MarginType != "" && data.MarginType != marginType {
+		err := ts.client().ChangeMarginType(ctx, data.Symbol, marginType)
+		var apiErr *common.APIError
+		if errors.As(err, &apiErr) && apiErr.Code == binanceErrNoMarginTypeChange {
+			err = nil
+		}
+		if err != nil {
+			ts.leverageChangeFailed(data, marginType+" margin", err, reported)
+		} else {
+			log.Printf("Changed %s margin type from %s to %s", data.Symbol, data.MarginType, marginType)
+			data.MarginType = marginType
+		}
+	}
+}
+
+// leverageChangeFailed logs a failed leverage or margin type change, and notifies
+// it the first time the mismatch is seen.
+func (ts *TradingService) leverageChangeFailed(data *PositionData, setting string, err error, reported bool) {
+	msg := fmt.Sprintf("❌ Failed to change %s %s to %s: %v", data.Symbol, data.PositionSide, setting, err)
+	log.Println(msg)
+	if !reported {
+		ts.notify(SeverityWarning, msg)
+	}
+}
+
+// parseLeverageAction normalizes the configured leverage enforcement action.
+func parseLeverageAction(value string) (string, error) {
+	switch action := strings.ToLower(strings.TrimSpace(value)); action {
+	case leverageActionWarn, leverageActionChange:
+		return action, nil
+	default:
+		return "", fmt.Errorf("want %s or %s", leverageActionWarn, leverageActionChange)
+	}
+}
+
+// parseMarginType normalizes a configured margin type.
+func parseMarginType(value string) (string, error) {
+	switch marginType := strings.ToLower(strings.TrimSpace(value)); marginType {
+	case marginTypeIsolated:
+		return marginTypeIsolated, nil
+	case marginTypeCrossed, "cross":
+		return marginTypeCrossed, nil
+	default:
+		return "", fmt.Errorf("want %s or %s", marginTypeIsolated, marginTypeCrossed)
+	}
+}
+
+// parseLeverageOverrides parses per-symbol leverage such as "BTCUSDT=20,DOGEUSDT=5".
+func parseLeverageOverrides(value string) (map[string]int, error) {
+	return parseOverrides(value, func(setting string) (int, error) {
+		leverage, err := strconv.Atoi(setting)
+		if err != nil || leverage < 0 {
+			return 0, fmt.Errorf("invalid target leverage %q", setting)
+		}
+		return leverage, nil
+	})
+}
+
+// parseMarginTypeOverrides parses per-symbol margin types such as "BTCUSDT=crossed,DOGEUSDT=isolated".
+func parseMarginTypeOverrides(value string) (map[string]string, error) {
+	return parseOverrides(value, func(setting string) (string, error) {
+		marginType, err := parseMarginType(setting)
+		if err != nil {
+			return "", fmt.Errorf("unknown margin type %q: %w", setting, err)
+		}
+		return marginType, nil
+	})
+}
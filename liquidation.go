@@ -0,0 +1,167 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"strings"
+)
+
+// Liquidation guard actions.
+const (
+	liquidationActionWarn    = "warn"
+	liquidationActionTighten = "tighten"
+	liquidationActionReduce  = "reduce"
+)
+
+// liquidationDistancePct returns how far the mark price is from the liquidation
+// price as a percentage of the mark price. It returns -1 when the position has
+// no liquidation price (e.g. fully collateralized cross positions).
+func liquidationDistancePct(data *PositionData) float64 {
+	if data.LiquidationPrice <= 0 || data.MarkPrice <= 0 {
+		return -1
+	}
+	return math.Abs(data.MarkPrice-data.LiquidationPrice) / data.MarkPrice * 100
+}
+
+// checkLiquidationDistance warns when a position is within the configured distance
+// of its liquidation price and, when configured, reduces the position size. A
+// reduce barely moves the liquidation price of an isolated position, so it is
+// made once per approach: again only when the distance has halved since, or once
+// it has recovered beyond the guard and shrinks back within it.
+func (ts *TradingService) checkLiquidationDistance(data *PositionData) {
+	if ts.config().LiquidationGuardPct <= 0 {
+		return
+	}
+
+	data.LiquidationDistPct = liquidationDistancePct(data)
+	if data.LiquidationDistPct < 0 || data.LiquidationDistPct > ts.config().LiquidationGuardPct {
+		ts.mu.Lock()
+		if st, ok := ts.state.Orders[trackedKey(data.Symbol, data.PositionSide)]; ok {
+			st.LiquidationReduceDistPct = 0
+		}
+		ts.mu.Unlock()
+		return
+	}
+	data.NearLiquidation = true
+
+	msg := fmt.Sprintf("⚠️ %s %s is %.2f%% from liquidation (mark: %.8f, liquidation: %.8f)",
+		data.Symbol, data.PositionSide, data.LiquidationDistPct, data.MarkPrice, data.LiquidationPrice)
+	log.Println(msg)
+	ts.notify(SeverityCritical, msg)
+
+	if ts.config().LiquidationAction != liquidationActionReduce || data.Manual {
+		return
+	}
+	ts.mu.Lock()
+	last := ts.orderState(data.Symbol, data.PositionSide).LiquidationReduceDistPct
+	ts.mu.Unlock()
+	if last > 0 && data.LiquidationDistPct > last/2 {
+		log.Printf("Not reducing %s %s again: reduced %.2f%% from liquidation, now %.2f%%",
+			data.Symbol, data.PositionSide, last, data.LiquidationDistPct)
+		return
+	}
+	if err := ts.reducePosition(data, ts.config().LiquidationReducePct, "liquidation proximity"); err != nil {
+		log.Printf("Warning: %v", err)
+		return
+	}
+	ts.mu.Lock()
+	ts.orderState(data.Symbol, data.PositionSide).LiquidationReduceDistPct = data.LiquidationDistPct
+	ts.mu.Unlock()
+	ts.saveState()
+}
+
+// applyLiquidationGuard tightens stopPrice so that the stop triggers before the
+// position reaches liquidation. The guarded stop is placed halfway between the
+// mark price and the liquidation price; it only replaces stopPrice when it is
+// tighter than the calculated one.
+func (ts *TradingService) applyLiquidationGuard(data *PositionData, stopPrice float64) float64 {
+	if !data.NearLiquidation || ts.config().LiquidationAction != liquidationActionTighten {
+		return stopPrice
+	}
+
+	guardPrice := (data.MarkPrice + data.LiquidationPrice) / 2
+	if (data.IsLong && guardPrice <= stopPrice) || (data.IsShort && guardPrice >= stopPrice) {
+		return stopPrice
+	}
+
+	log.Printf("Tightening SL for %s from %.8f to %.8f to stay ahead of liquidation at %.8f",
+		data.Symbol, stopPrice, guardPrice, data.LiquidationPrice)
+
+	data.RawSLPct = rawStopLossPct(data, guardPrice)
+	data.LeveragedSLPct = data.RawSLPct * data.Leverage
+	return guardPrice
+}
+
+// reducePosition closes percent of the position at market with a reduce-only order
+// and updates the position data to reflect the remaining size. With OBSERVE_ONLY
+// the reduce is only logged and the position data left as it is.
+func (ts *TradingService) reducePosition(data *PositionData, percent float64, reason string) error {
+	if percent <= 0 || percent > 100 {
+		return fmt.Errorf("invalid reduce percentage %.2f for %s", percent, data.Symbol)
+	}
+
+	precision, ok := ts.symbolPrecision(data.Symbol)
+	if !ok {
+		return fmt.Errorf("precision information not found for %s", data.Symbol)
+	}
+
+	reduceAmt := truncateToPrecision(data.AbsAmt*percent/100, precision.QuantityPrecision)
+	if percent == 100 {
+		// Closing the whole position uses the exact size so no dust remains
+		reduceAmt = data.AbsAmt
+	}
+	if reduceAmt <= 0 {
+		return fmt.Errorf("reduce quantity for %s rounds to zero", data.Symbol)
+	}
+	quantity := formatDecimal(reduceAmt, precision.QuantityPrecision)
+
+	// The position stays whole, so the report keeps recommending its stop and target
+	if ts.config().ObserveOnly {
+		log.Printf("OBSERVE_ONLY: would reduce %s %s by %s (%.2f%%) due to %s",
+			data.Symbol, data.PositionSide, quantity, percent, reason)
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+
+	order := OrderRequest{
+		Symbol:       data.Symbol,
+		Side:         closeSide(data),
+		PositionSide: data.PositionSide,
+		Type:         orderTypeMarket,
+		Quantity:     quantity,
+		ReduceOnly:   true,
+		// Lets the close report tell the bot's own closes from manual ones
+		ClientOrderID: ts.clientOrderID(clientOrderKindClose, data),
+	}
+	if _, err := ts.exchange().CreateOrder(ctx, order); err != nil {
+		return fmt.Errorf("error reducing position %s by %s: %w", data.Symbol, quantity, err)
+	}
+
+	data.AbsAmt -= reduceAmt
+	if data.PositionAmt < 0 {
+		data.PositionAmt = -data.AbsAmt
+	} else {
+		data.PositionAmt = data.AbsAmt
+	}
+
+	log.Printf("Reduced %s %s by %s (%.2f%%) due to %s",
+		data.Symbol, data.PositionSide, quantity, percent, reason)
+	if data.AbsAmt == 0 {
+		ts.publish(EventPositionClosed, data, Event{Price: data.MarkPrice, Reason: reason})
+	}
+	return nil
+}
+
+// parseLiquidationAction normalizes the configured liquidation guard action.
+func parseLiquidationAction(value string) (string, error) {
+	switch action := strings.ToLower(strings.TrimSpace(value)); action {
+	case liquidationActionWarn, liquidationActionTighten, liquidationActionReduce:
+		return action, nil
+	default:
+		return "", fmt.Errorf("want %s, %s or %s", liquidationActionWarn, liquidationActionTighten, liquidationActionReduce)
+	}
+}
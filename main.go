@@ -4,18 +4,21 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"math"
-	"net/http"
-	"net/url"
 	"os"
-	"strconv"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	binance "github.com/adshao/go-binance/v2/futures"
-	"github.com/joho/godotenv"
+	"github.com/shopspring/decimal"
+
+	"futures-guard/ladder"
+	"futures-guard/settings"
 )
 
 // Configuration defaults for the trading bot.
@@ -23,6 +26,9 @@ const (
 	defaultSLPercentVal = 1.0
 	defaultTPPercentVal = 3.0
 	defaultSLFixedVal   = true
+
+	defaultLiquidationReducePct = 25.0
+	defaultFundingLookback      = 24 * time.Hour
 )
 
 // Config holds application configuration loaded from environment.
@@ -30,6 +36,437 @@ type Config struct {
 	DefaultSLPercent float64
 	TPPercent        float64
 	SLFixed          bool
+	SymbolWhitelist  []string
+	SymbolBlacklist  []string
+
+	// SLUpdateHysteresis and TPUpdateHysteresis are the smallest changes for which a
+	// live SL or TP is replaced, optionally per symbol, trading responsiveness for
+	// fewer order replacements.
+	SLUpdateHysteresis          updateHysteresis
+	TPUpdateHysteresis          updateHysteresis
+	SLUpdateHysteresisOverrides map[string]updateHysteresis
+	TPUpdateHysteresisOverrides map[string]updateHysteresis
+
+	// OrderUpdateCooldown is the minimum time between replacements of a position's
+	// SL or TP; zero disables it. Missing orders are always placed.
+	OrderUpdateCooldown time.Duration
+
+	// TPDisabled manages positions without a take-profit, leaving the exit to the
+	// trailing stop. TPDisabledSymbols limits it to matching symbols (empty means all).
+	TPDisabled        bool
+	TPDisabledSymbols []string
+
+	// TPMinProfit and TPMinAge defer the first take-profit of a position until it
+	// reaches TPMinProfit, in the unit of its ladder, or has been open for
+	// TPMinAge, whichever comes first. Zero disables either condition.
+	TPMinProfit float64
+	TPMinAge    time.Duration
+
+	// SLOrderType places stop-losses as stop-market orders or as stop-limit orders
+	// whose limit sits SLLimitOffset percent beyond the trigger. A triggered
+	// stop-limit still open after SLLimitTimeout is closed at market.
+	SLOrderType    string
+	SLLimitOffset  float64
+	SLLimitTimeout time.Duration
+
+	// SmallPositionAction handles positions below the symbol's minimum order
+	// quantity or notional: close (closePosition orders) or skip.
+	SmallPositionAction string
+
+	// Liquidation guard: distance (in % of mark price) that triggers the guard,
+	// the action to take, and the share of the position to close when reducing.
+	LiquidationGuardPct  float64
+	LiquidationAction    string
+	LiquidationReducePct float64
+
+	// Spread guard: stops within SpreadGuardDistance percent of the mark price are
+	// widened by SpreadBufferPct when the spread exceeds SpreadMaxPct or the top of
+	// the book they would fill against holds less than SpreadMinDepth in quote
+	// value. A zero buffer disables it.
+	SpreadGuardDistance float64
+	SpreadMaxPct        float64
+	SpreadMinDepth      float64
+	SpreadBufferPct     float64
+
+	// RoundNumberNudgeTicks moves stops within that many price ticks of a round
+	// number, such as 65000 or 3400, to that many ticks beyond it; 0 disables it.
+	RoundNumberNudgeTicks int
+
+	// Auto-deleverage and margin call alerts: positions whose ADL quantile reaches
+	// ADLAlertQuantile or whose isolated margin ratio reaches MarginRatioAlertPct
+	// trigger MarginRiskAction: warn, add_margin (MarginAddAmount of the margin
+	// asset, isolated positions only) or reduce (MarginReducePct of the position).
+	ADLAlertQuantile    int
+	MarginRatioAlertPct float64
+	MarginRiskAction    string
+	MarginAddAmount     float64
+	MarginReducePct     float64
+
+	// Funding: whether accrued funding counts towards profit, how far back to sum it,
+	// the per-interval rate (in %) considered extreme, and the action to take then.
+	FundingIncludeInProfit bool
+	FundingLookback        time.Duration
+	FundingExtremeRate     float64
+	FundingAction          string
+
+	// FeesIncludeInProfit folds the commissions and funding paid since a position
+	// opened into its profit and potential profit and loss, replacing the funding
+	// of FundingIncludeInProfit.
+	FeesIncludeInProfit bool
+
+	// RunInterval enables daemon mode, re-running the full REST cycle at this interval;
+	// zero processes positions once and exits. MarkPriceStream evaluates threshold
+	// crossings on every mark price tick between cycles.
+	RunInterval     time.Duration
+	MarkPriceStream bool
+
+	// ConfigReload polls the config file (CONFIG_FILE, default .env) every
+	// ConfigReloadInterval in daemon mode and applies changed SL/TP, ladder,
+	// strategy and symbol filter settings without a restart.
+	ConfigReload         bool
+	ConfigReloadInterval time.Duration
+
+	// MaxConcurrency bounds how many positions are processed in parallel and
+	// APIRateLimit paces exchange requests (per second, 0 disables pacing).
+	MaxConcurrency int
+	APIRateLimit   float64
+
+	// DisplayCurrency, when set, adds the PnL converted to this currency (e.g.
+	// EUR or USD) to reports, using Binance spot prices.
+	DisplayCurrency string
+
+	// ObserveOnly runs the full analysis and reporting but never places or cancels
+	// orders, for advisory use with read-only API keys.
+	ObserveOnly bool
+
+	// ProtectOnlyBotOrders restricts cancellations, and the lookup of current SL/TP
+	// orders, to orders carrying the bot's client order ID prefix so manual entries,
+	// grids and hedges are never touched.
+	ProtectOnlyBotOrders bool
+
+	// CredentialRefreshInterval is how often the CREDENTIAL_PROVIDER is polled in
+	// daemon mode; rotated API keys reconnect the exchange client.
+	CredentialRefreshInterval time.Duration
+
+	// StateFile is where the last placed SL/TP per position is persisted, and
+	// ReconcileOnStartup repairs live orders against it before the first cycle.
+	StateFile          string
+	ReconcileOnStartup bool
+
+	// StateBackend selects where the state lives: the StateFile, Redis at RedisURL
+	// under keys starting with RedisKeyPrefix, or the PostgreSQL database at
+	// PostgresURL. Both servers share the state between processes.
+	StateBackend   string
+	RedisURL       string
+	RedisKeyPrefix string
+	PostgresURL    string
+
+	// RecordSession is the file every exchange response and mark price tick the bot
+	// consumes is recorded to, for the replay command. Empty disables recording.
+	RecordSession string
+
+	// LeaderLockFile is the lease file on shared storage that redundant daemons
+	// contest; only the instance holding it manages orders. Empty runs alone.
+	// LeaderLease is how long the lease outlives its last renewal and LeaderID
+	// names this instance, the hostname and process ID by default.
+	LeaderLockFile string
+	LeaderLease    time.Duration
+	LeaderID       string
+
+	// StartupReport notifies an account summary when the daemon starts.
+	StartupReport bool
+
+	// DailyReport enables the daily PnL digest in daemon mode, sent at
+	// DailyReportTime (offset from midnight UTC).
+	DailyReport     bool
+	DailyReportTime time.Duration
+
+	// Notification channels and the minimum severity each one receives.
+	Notifiers           []string
+	NotifierMinSeverity map[string]Severity
+	TelegramBotToken    string
+	TelegramChatID      string
+	TelegramCommands    bool
+	DiscordWebhookURL   string
+	SlackWebhookURL     string
+
+	// TelegramUrgentChatID also receives critical notifications. During QuietHours
+	// only notifications of at least QuietMinSeverity are sent.
+	TelegramUrgentChatID string
+	QuietHours           []scheduleWindow
+	QuietMinSeverity     Severity
+
+	// Email channel: notifications are mailed through the SMTP server at
+	// SMTPHost:SMTPPort from EmailFrom to EmailTo. With EmailDigest the daily
+	// digest, laid out by the HTML template of EmailDigestTemplate, is mailed
+	// whatever the minimum severity of the channel.
+	SMTPHost            string
+	SMTPPort            int
+	SMTPUsername        string
+	SMTPPassword        string
+	EmailFrom           string
+	EmailTo             []string
+	EmailDigest         bool
+	EmailDigestTemplate string
+
+	// Push channels: Pushover messages go to PushoverUserKey through the
+	// application PushoverAppToken; ntfy messages are published to NtfyTopic on
+	// NtfyServer, with NtfyToken for protected topics.
+	PushoverAppToken string
+	PushoverUserKey  string
+	NtfyServer       string
+	NtfyTopic        string
+	NtfyToken        string
+
+	// Position summaries: labels of NotifyLocale (en, vi, zh or ru), overridden by
+	// the JSON labels of NotifyLocaleFile, and an optional Go template file
+	// NotifyTemplate replacing the built-in layout.
+	NotifyLocale     string
+	NotifyLocaleFile string
+	NotifyTemplate   string
+
+	// NotifyChart attaches a chart of the last ChartCandles candles at
+	// ChartInterval, with the protection levels drawn, to position summaries.
+	NotifyChart   bool
+	ChartInterval string
+	ChartCandles  int
+
+	// Outbound webhooks: events are POSTed as JSON to WebhookURLs, signed with
+	// WebhookSecret and filtered by WebhookEvents (empty sends every event).
+	WebhookURLs   []string
+	WebhookSecret string
+	WebhookEvents []EventType
+
+	// Event broker: events are published as JSON to EventTopic on the MQTT or
+	// NATS server at EventBrokerURL, filtered by EventBrokerEvents.
+	EventBrokerURL    string
+	EventTopic        string
+	EventBrokerEvents []EventType
+
+	// InfluxDB time series: a point per processed position is written to the
+	// InfluxBucket of InfluxOrg at InfluxURL, for charting in Grafana.
+	InfluxURL         string
+	InfluxToken       string
+	InfluxOrg         string
+	InfluxBucket      string
+	InfluxMeasurement string
+
+	// TradingViewSecret enables POST /webhooks/tradingview on the API server for
+	// alerts carrying this secret.
+	TradingViewSecret string
+
+	// NotifyOnlyOnChange suppresses position summaries unless the SL, TP or ladder
+	// stage changed; NotifyMinInterval rate-limits summaries per position.
+	NotifyOnlyOnChange bool
+	NotifyMinInterval  time.Duration
+
+	// Max holding time rule: positions held longer than MaxHoldingTime (or the
+	// per-symbol override) with less than MaxHoldingMinProfit leveraged profit
+	// are closed or moved to breakeven.
+	MaxHoldingTime          time.Duration
+	MaxHoldingTimeOverrides map[string]time.Duration
+	MaxHoldingMinProfit     float64
+	MaxHoldingAction        string
+
+	// Leverage and margin type enforcement: positions whose leverage differs from
+	// LeverageTarget or whose margin type differs from MarginTypeTarget (or their
+	// per-symbol overrides) are reported or changed according to LeverageAction.
+	LeverageTarget            int
+	LeverageTargetOverrides   map[string]int
+	MarginTypeTarget          string
+	MarginTypeTargetOverrides map[string]string
+	LeverageAction            string
+
+	// ScheduleWindows are weekly UTC windows that tighten stops to breakeven,
+	// flatten positions or pause order management.
+	ScheduleWindows []scheduleWindow
+
+	// Economic calendar: stops move to breakeven from CalendarLeadTime before
+	// until CalendarHoldTime after the watched releases of CalendarURL.
+	CalendarURL             string
+	CalendarEvents          []string
+	CalendarCountries       []string
+	CalendarLeadTime        time.Duration
+	CalendarHoldTime        time.Duration
+	CalendarRefreshInterval time.Duration
+
+	// Account PnL guard: when the total unrealized PnL retraces AccountPnLRetracePct
+	// from its session peak (once the peak reached AccountPnLMinPeak), stops are
+	// tightened one ladder stage or the AccountPnLCloseCount weakest positions closed.
+	AccountPnLRetracePct float64
+	AccountPnLMinPeak    float64
+	AccountPnLAction     string
+	AccountPnLCloseCount int
+
+	// Free margin floor: alert when the available balance falls below
+	// FreeMarginAlertPct of the total margin balance and, with FreeMarginBlockAdds,
+	// block entries, scale-ins and pyramid adds until it recovers.
+	FreeMarginAlertPct  float64
+	FreeMarginBlockAdds bool
+
+	// Sub-account top-up: with master account credentials, the USDⓈ-M margin ratio
+	// of each of SubAccountEmails is checked every SubAccountCheckInterval and,
+	// when it reaches SubAccountMarginRatioPct, SubAccountTopUpAmount USDT is moved
+	// from the SubAccountTopUpFrom wallet of the master account, at most
+	// SubAccountTopUpDailyCap a day and, with SubAccountTopUpConfirm, only once
+	// confirmed through Telegram.
+	SubAccountEmails         []string
+	SubAccountMarginRatioPct float64
+	SubAccountTopUpAmount    float64
+	SubAccountTopUpDailyCap  float64
+	SubAccountTopUpConfirm   bool
+	SubAccountTopUpFrom      string
+	SubAccountCheckInterval  time.Duration
+
+	// Strategy tags label each position with the strategy that opened it, from
+	// StrategyTags (STRATEGY_TAGS_FILE, keyed by symbol or symbol:side), the tag of
+	// a bot entry, or the client order ID of its opening order matching one of
+	// StrategyTagPrefixes. StrategyTagMaxPositions limits the open positions per tag.
+	StrategyTags            map[string]string
+	StrategyTagPrefixes     []tagPrefix
+	StrategyTagMaxPositions map[string]int
+
+	// Scale-in (DCA) management: with DCAEnabled, adds to a position re-anchor its
+	// SL/TP on the weighted average entry. DCALevels are the drawdowns (raw % from
+	// the average entry) at which the next scale-in limit order rests, each sized
+	// DCASizeMultiplier times the current position, up to DCAMaxAdds adds.
+	DCAEnabled        bool
+	DCALevels         []float64
+	DCASizeMultiplier float64
+	DCAMaxAdds        int
+
+	// Pyramiding: each time a new ladder stage is reached, PyramidFraction of the
+	// position is added at market, up to PyramidMaxAdds adds, with the combined stop
+	// capped so the loss never exceeds the original risk at DefaultSLPercent.
+	PyramidFraction float64
+	PyramidMaxAdds  int
+
+	// ScaleOutFraction of the position is closed at market each time a new ladder
+	// stage is reached, realizing profit as the stop locks more of it; 0 disables it.
+	ScaleOutFraction float64
+
+	// Grid guard: positions on symbols with open orders whose client order IDs start
+	// with one of GridOrderPrefixes get a single stop GridStopBufferPct outside the
+	// grid range, re-centered once the range has moved GridRecenterPct.
+	GridOrderPrefixes []string
+	GridStopBufferPct float64
+	GridRecenterPct   float64
+
+	// Stops rejected because the price is already beyond them are re-placed
+	// StopTriggerTicks price ticks from mark, or the position closed, according
+	// to StopTriggerAction.
+	StopTriggerAction string
+	StopTriggerTicks  int
+
+	// Strategies: SL/TP calculators selected globally or per symbol, and the
+	// kline and indicator settings used by the ATR, chandelier, swing and pivot strategies.
+	SLStrategy             string
+	TPStrategy             string
+	SLStrategyOverrides    map[string]string
+	TPStrategyOverrides    map[string]string
+	StrategyInterval       string
+	StrategyLookback       int
+	StrategyATRPeriod      int
+	StrategyATRMultiplier  float64
+	StrategyATRTPRatio     float64
+	StrategySwingBufferPct float64
+	StrategyPivotLookback  int
+	// RLadder holds the steps of the rmultiple strategy, sorted by profit.
+	RLadder []RLevel
+	// ProfitMetric is the unit of the ladder thresholds and locks: leveraged or
+	// raw percent, or USD profit. LadderLevels holds the ladder, sorted by profit.
+	ProfitMetric string
+	LadderLevels []StopLossLevel
+	// LadderPreset names the preset LadderLevels and ProfitMetric come from,
+	// empty when LADDER_LEVELS is set. LadderPresets holds the built-in and
+	// custom presets, and LadderPresetOverrides the preset of single symbols.
+	LadderPreset          string
+	LadderPresets         map[string]ladder.Preset
+	LadderPresetOverrides map[string]string
+	// LadderInterpolate moves the lock linearly with the profit between two
+	// ladder steps instead of jumping at each threshold.
+	LadderInterpolate bool
+	// LadderHighWaterMark bases the ladder on the peak profit of each position
+	// instead of its current profit.
+	LadderHighWaterMark bool
+	// LadderConfirmCycles and LadderConfirmInterval hold the stop at the lock of
+	// the last confirmed ladder stage until the profit has held the next one for
+	// that many consecutive cycles, or a closed candle of that interval is
+	// beyond its threshold. Either, or both, may be set.
+	LadderConfirmCycles   int
+	LadderConfirmInterval string
+	// TPRiskReward is the target of the rr take-profit strategy in multiples of
+	// the stop-loss distance, optionally per symbol.
+	TPRiskReward          float64
+	TPRiskRewardOverrides map[string]float64
+
+	// Volatility-scaled take-profit: TPPercent is multiplied by the ratio of the
+	// realized volatility of TPVolPeriod TPVolInterval returns to TPVolReference
+	// (per-symbol overrides allowed), clamped to [TPVolMinFactor, TPVolMaxFactor].
+	// TPVolScaleSymbols limits scaling to matching symbols (empty means all).
+	TPVolScale              bool
+	TPVolScaleSymbols       []string
+	TPVolInterval           string
+	TPVolPeriod             int
+	TPVolReference          float64
+	TPVolReferenceOverrides map[string]float64
+	TPVolMinFactor          float64
+	TPVolMaxFactor          float64
+
+	// Trend filter: with TrendFilter, the ladder of each symbol (or those
+	// matching TrendFilterSymbols) follows its trend, measured by TrendIndicator
+	// over TrendPeriod TrendInterval candles. At or above TrendStrong it uses the
+	// Strong preset of TrendLadders, at or below TrendChoppy the Choppy one,
+	// optionally per symbol with TrendLadderOverrides.
+	TrendFilter          bool
+	TrendFilterSymbols   []string
+	TrendIndicator       string
+	TrendInterval        string
+	TrendPeriod          int
+	TrendStrong          float64
+	TrendChoppy          float64
+	TrendLadders         trendLadders
+	TrendLadderOverrides map[string]trendLadders
+
+	// Control API: listen address of the HTTP control API (empty disables it)
+	// and the token every request must present. GRPCAddr serves the same
+	// operations over gRPC, with the same token.
+	APIAddr  string
+	APIToken string
+	GRPCAddr string
+
+	// Exchange selects the venue whose positions are guarded: binance,
+	// binance-coinm, binance-spot, bybit or okx.
+	// BybitTestnet points the Bybit client at its testnet and OKXDemo sends
+	// OKX requests to demo trading.
+	Exchange     string
+	BybitTestnet bool
+	OKXDemo      bool
+
+	// Credentials are the API keys of the exchange, left out of config dumps.
+	Credentials Credentials `json:"-"`
+
+	// Spot protection (EXCHANGE=binance-spot): guard cross margin balances instead
+	// of spot holdings, the quote asset holdings are valued in, and how far below
+	// (above for shorts) the stop the stop-limit price is set, in percent.
+	SpotMargin          bool
+	SpotQuoteAsset      string
+	SpotStopLimitOffset float64
+
+	// HealthMaxTimeDrift is the clock drift versus Binance server time above
+	// which the readiness probe fails.
+	HealthMaxTimeDrift time.Duration
+
+	// Time synchronization: TimeSync calibrates the local clock offset against
+	// Binance server time at startup, every TimeSyncInterval and on -1021
+	// timestamp errors; RecvWindow is sent with every signed request.
+	TimeSync         bool
+	TimeSyncInterval time.Duration
+	RecvWindow       time.Duration
+
+	// SymbolRefreshInterval is how often the daemon reloads symbol precisions and
+	// filters; zero disables it. Unknown symbols trigger a reload on demand.
+	SymbolRefreshInterval time.Duration
 	// Add other configuration values here
 }
 
@@ -37,6 +474,15 @@ type Config struct {
 type SymbolPrecision struct {
 	PricePrecision    int
 	QuantityPrecision int
+
+	// MinQty and MinNotional are the LOT_SIZE and MIN_NOTIONAL limits of an
+	// order, zero when the exchange does not report them.
+	MinQty      float64
+	MinNotional float64
+
+	// SettleAsset is the asset profit and loss are settled in, e.g. USDT,
+	// USDC or BTC for COIN-M contracts.
+	SettleAsset string
 }
 
 // PositionData contains all calculated data for a futures position.
@@ -52,6 +498,7 @@ type PositionData struct {
 	IsShort          bool
 	CurrentProfitPct float64
 	RawProfitPct     float64
+	PeakProfitPct    float64 // Highest RawProfitPct since the position opened
 	StopPrice        float64
 	TakePrice        float64
 	Quantity         string
@@ -65,116 +512,435 @@ type PositionData struct {
 	PotentialProfit  float64
 	PotentialLoss    float64
 	RiskReward       float64
+
+	// ContractSize is the quote value of one inverse contract (zero for linear
+	// contracts) and PnLAsset the asset potential profit and loss are quoted in.
+	// DisplayRate converts PnLAsset amounts to DisplayCurrency when configured.
+	ContractSize    float64
+	PnLAsset        string
+	DisplayCurrency string
+	DisplayRate     float64
+
+	// ClosePosition places the SL/TP as closePosition orders, for positions
+	// below the symbol's minimum order size.
+	ClosePosition bool
+
+	LiquidationPrice   float64
+	LiquidationDistPct float64
+	NearLiquidation    bool
+
+	// ADLQuantile is the auto-deleverage rank (0-4) and MarginRatio the isolated
+	// margin ratio in percent, both zero unless margin risk alerts are enabled.
+	ADLQuantile int
+	MarginRatio float64
+
+	// MarginType is isolated or crossed, empty when unknown.
+	MarginType string
+
+	FundingRate          float64
+	PredictedFundingRate float64
+	AccruedFunding       float64
+	ExtremeFunding       bool
+
+	// Fees are the commissions and funding since the position opened, negative
+	// when paid, when included in profit.
+	Fees float64
+
+	// ConfirmedStage is the highest ladder stage confirmed under
+	// LADDER_CONFIRM_*, -1 before the first. While StageConfirming the stop
+	// does not go past its lock.
+	ConfirmedStage  int
+	StageConfirming bool
+
+	OpenedAt       time.Time
+	HoldingExpired bool
+
+	// Tag is the strategy that opened the position, empty when unknown.
+	Tag string
+	// Notes are the journal notes of the position, filled for exports.
+	Notes []JournalNote
+	// Manual is set while the symbol is under manual control: the position is
+	// reported and alerted on, but none of its orders are changed.
+	Manual bool
+
+	// ScheduleTighten is set inside a tighten window of SCHEDULE_WINDOWS.
+	ScheduleTighten bool
+	// CalendarEvent names the economic release whose window the position is in.
+	CalendarEvent string
+	// RestoreLadder replaces a temporarily tightened stop with the ladder stop.
+	RestoreLadder bool
+	// AccountTighten is set while the account PnL guard is tightening stops.
+	AccountTighten bool
+	// ScaledIn is set when the position grew since the last cycle, so its SL/TP
+	// are re-anchored on the new average entry and size.
+	ScaledIn bool
+	// GridLow and GridHigh span the open orders of a grid bot on the symbol,
+	// zero when the position is not run by one.
+	GridLow  float64
+	GridHigh float64
 }
 
 // StopLossLevel defines a profit threshold and corresponding stop-loss level.
-type StopLossLevel struct {
-	ProfitThreshold float64 // Profit threshold
-	StopLossValue   float64 // Corresponding stop-loss level
-}
+type StopLossLevel = ladder.Level
 
 // TradingService handles all trading operations.
 type TradingService struct {
-	client     *binance.Client
-	config     Config
-	symbolInfo map[string]SymbolPrecision
-	stopLevels []StopLossLevel
+	conn       atomic.Pointer[connection] // Replaced as a whole on credential rotation
+	cfg        atomic.Pointer[Config]     // Replaced as a whole on hot reload, read through config
+	symbolInfo *symbolCache
+	trends     trendCache // Trend regime of each symbol under TREND_FILTER
+
+	mu            sync.Mutex
+	tracked       map[string]*trackedPosition
+	positionLocks map[string]*sync.Mutex
+	store         StateStore
+	state         *BotState
+	notifier      *MultiNotifier
+	health        healthState
+	displayRates  displayRates
+	scheduleState string // active schedule actions as last notified
+	calendar      economicCalendar
+	accountGuard  accountGuard
+	freeMargin    freeMarginGuard
+	subAccounts   subAccountGuard
+	events        *EventBus
+	lastPositions map[string]*PositionData // Last snapshot of each processed position
+	leverageWarn  map[string]string        // Last leverage or margin type mismatch reported per position
+	marginRisk    map[string]string        // Margin risk alert raised per position
+	recorder      *sessionRecorder         // Session recording, nil unless RECORD_SESSION is set
+	messages      *positionTemplate        // Position summary template
+	leader        *leaderElection          // Leader election in daemon mode, nil without LEADER_LOCK_FILE
+}
+
+// config returns the current configuration. A hot reload swaps in a new one
+// rather than changing it, so callers must not modify it and a caller that needs
+// several settings to agree reads them from one returned value.
+func (ts *TradingService) config() *Config {
+	return ts.cfg.Load()
+}
+
+// defaultStopLevels returns the built-in stop-loss ladder.
+func defaultStopLevels() []StopLossLevel {
+	return ladder.Default()
 }
 
 // NewTradingService creates and initializes a new trading service.
-func NewTradingService(client *binance.Client, config Config) (*TradingService, error) {
-	// Initialize stop-loss levels
-	stopLevels := []StopLossLevel{
-		{300, 0},     // Initial stage, no SL adjustment yet
-		{450, 150},   // Start light capital protection
-		{600, 300},   // RR 1:1, begin locking in profits
-		{750, 450},   // Move SL higher but still leave room for breakout
-		{900, 600},   // RR 1.5:1, locking more profit
-		{1050, 750},  // Gradually increase the protection level
-		{1200, 900},  // Secure at least 900 in profit
-		{1350, 1050}, // Protect 1050 profit level
-		{1500, 1200}, // Lock in a solid 1200 profit
+func NewTradingService(exchange Exchange, client ExchangeClient, config Config) (*TradingService, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+
+	// Load the state persisted by previous runs
+	store, err := openStateStore(config)
+	if err != nil {
+		return nil, err
+	}
+	state, err := store.Load()
+	if err != nil {
+		return nil, fmt.Errorf("error loading bot state: %w", err)
+	}
+
+	messages, err := newPositionTemplate(config)
+	if err != nil {
+		return nil, err
+	}
+
+	recorder, err := newSessionRecorder(config.RecordSession, config.Exchange, state)
+	if err != nil {
+		return nil, err
 	}
+	exchange = recordSession(exchange, recorder)
 
 	// Get symbol precision information
-	symbolInfo, err := getSymbolPrecisions(client)
+	symbolInfo, err := exchange.SymbolPrecisions(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("error getting exchange information: %w", err)
 	}
 
-	return &TradingService{
-		client:     client,
-		config:     config,
-		symbolInfo: symbolInfo,
-		stopLevels: stopLevels,
-	}, nil
+	ts := &TradingService{
+		symbolInfo: newSymbolCache(symbolInfo),
+
+		tracked:       make(map[string]*trackedPosition),
+		positionLocks: make(map[string]*sync.Mutex),
+		store:         store,
+		state:         state,
+		notifier:      newNotifier(config),
+		health:        healthState{startedAt: time.Now()},
+		events:        newEventBus(),
+		lastPositions: make(map[string]*PositionData),
+		leverageWarn:  make(map[string]string),
+		marginRisk:    make(map[string]string),
+		subAccounts:   newSubAccountGuard(),
+		recorder:      recorder,
+		messages:      messages,
+	}
+	ts.cfg.Store(&config)
+	ts.subscribeEvents()
+	ts.setExchange(exchange, client)
+	return ts, nil
+}
+
+// loadConfig loads the configuration with readConfig, logging its warnings.
+func loadConfig() (Config, error) {
+	config, warnings, err := readConfig()
+	for _, warning := range warnings {
+		log.Printf("Warning: %v", warning)
+	}
+	return config, err
 }
 
-// loadConfig loads configuration from environment variables with defaults.
-func loadConfig() Config {
-	// Load environment variables
-	if err := godotenv.Load(); err != nil {
-		log.Printf("Warning: Error loading .env file: %v", err)
+// readConfig reads the configuration from the --set flags, the environment and
+// the config file over the defaults. Every invalid or missing setting is reported
+// in the error; the config returned with it has the defaults in their place, so
+// config validate can go on checking the rest. The warnings are the settings
+// that load but are not used as written.
+func readConfig() (Config, []error, error) {
+	src, err := loadSettings()
+	if err != nil {
+		return Config{}, nil, err
 	}
+	loadSecrets(src)
+	loadProviderCredentials(src)
 
 	config := Config{
 		DefaultSLPercent: defaultSLPercentVal,
 		TPPercent:        defaultTPPercentVal,
 		SLFixed:          defaultSLFixedVal,
-	}
 
-	// Override with environment variables if present
-	if slStr := os.Getenv("DEFAULT_SL_PERCENT"); slStr != "" {
-		if val, err := strconv.ParseFloat(slStr, 64); err == nil {
-			config.DefaultSLPercent = val
-		}
-	}
+		SLUpdateHysteresis: defaultSLUpdateHysteresis,
+		TPUpdateHysteresis: defaultTPUpdateHysteresis,
 
-	if tpStr := os.Getenv("TP_PERCENT"); tpStr != "" {
-		if val, err := strconv.ParseFloat(tpStr, 64); err == nil {
-			config.TPPercent = val
-		}
-	}
+		SmallPositionAction: smallPositionClose,
 
-	if slFixedStr := os.Getenv("SL_FIXED"); slFixedStr != "" {
-		if val, err := strconv.ParseBool(slFixedStr); err == nil {
-			config.SLFixed = val
-		}
-	}
+		StartupReport: true,
 
-	return config
-}
+		LiquidationAction:    liquidationActionWarn,
+		LiquidationReducePct: defaultLiquidationReducePct,
 
-// sendTelegramMessage sends a notification to the configured Telegram chat.
-func sendTelegramMessage(message string) error {
-	botToken := os.Getenv("TELEGRAM_BOT_TOKEN")
-	chatID := os.Getenv("TELEGRAM_CHAT_ID")
+		SpreadGuardDistance: 0.5,
+		SpreadMaxPct:        0.05,
 
-	if botToken == "" || chatID == "" {
-		return fmt.Errorf("telegram configuration missing")
-	}
+		MarginRiskAction: marginRiskActionWarn,
+		MarginReducePct:  defaultLiquidationReducePct,
 
-	apiURL := fmt.Sprintf("https://api.telegram.org/bot%s/sendMessage", botToken)
+		FundingLookback: defaultFundingLookback,
+		FundingAction:   fundingActionWarn,
 
-	resp, err := http.PostForm(apiURL, url.Values{
-		"chat_id": {chatID},
-		"text":    {message},
-	})
-	if err != nil {
-		return err
+		Exchange:            exchangeBinance,
+		SpotQuoteAsset:      "USDT",
+		SpotStopLimitOffset: 0.5,
+
+		ConfigReloadInterval: defaultConfigReloadInterval,
+
+		CredentialRefreshInterval: defaultCredentialRefreshInterval,
+
+		MaxConcurrency: defaultMaxConcurrency,
+		APIRateLimit:   defaultAPIRateLimit,
+
+		StateFile:          defaultStateFile,
+		ReconcileOnStartup: true,
+		StateBackend:       stateBackendFile,
+		RedisKeyPrefix:     defaultRedisKeyPrefix,
+		LeaderLease:        defaultLeaderLease,
+
+		NotifyOnlyOnChange: true,
+
+		MaxHoldingAction: holdingActionBreakeven,
+		LeverageAction:   leverageActionWarn,
+
+		CalendarEvents:          parseList(defaultCalendarEvents),
+		CalendarCountries:       parseList(defaultCalendarCountries),
+		CalendarLeadTime:        defaultCalendarLeadTime,
+		CalendarHoldTime:        defaultCalendarHoldTime,
+		CalendarRefreshInterval: defaultCalendarRefreshInterval,
+
+		AccountPnLAction:     accountActionTighten,
+		AccountPnLCloseCount: 1,
+
+		DCASizeMultiplier: 1,
+		PyramidMaxAdds:    defaultPyramidMaxAdds,
+
+		GridStopBufferPct: defaultGridStopBufferPct,
+		GridRecenterPct:   defaultGridRecenterPct,
+
+		StopTriggerAction: stopTriggerActionRetry,
+		StopTriggerTicks:  defaultStopTriggerTicks,
+
+		HealthMaxTimeDrift: defaultHealthMaxTimeDrift,
+
+		TimeSync:         true,
+		TimeSyncInterval: defaultTimeSyncInterval,
+
+		SymbolRefreshInterval: defaultSymbolRefreshInterval,
+		RecvWindow:            defaultRecvWindow,
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("telegram API returned error code: %d", resp.StatusCode)
+	// Override with the settings present
+	config.Exchange = settings.Parse(src, "EXCHANGE", config.Exchange, parseExchange)
+	config.Credentials = loadCredentials(src)
+	config.BybitTestnet = src.Bool("BYBIT_TESTNET", config.BybitTestnet)
+	config.OKXDemo = src.Bool("OKX_DEMO", config.OKXDemo)
+	config.SpotMargin = src.Bool("SPOT_MARGIN", config.SpotMargin)
+	if quote := src.String("SPOT_QUOTE_ASSET"); quote != "" {
+		config.SpotQuoteAsset = strings.ToUpper(strings.TrimSpace(quote))
 	}
-	return nil
+	config.SpotStopLimitOffset = src.Float("SPOT_STOP_LIMIT_OFFSET", config.SpotStopLimitOffset)
+
+	config.DefaultSLPercent = src.Float("DEFAULT_SL_PERCENT", config.DefaultSLPercent)
+	config.TPPercent = src.Float("TP_PERCENT", config.TPPercent)
+	config.SLFixed = src.Bool("SL_FIXED", config.SLFixed)
+	config.SLUpdateHysteresis = settings.Parse(src, "SL_UPDATE_HYSTERESIS", config.SLUpdateHysteresis, parseHysteresis)
+	config.TPUpdateHysteresis = settings.Parse(src, "TP_UPDATE_HYSTERESIS", config.TPUpdateHysteresis, parseHysteresis)
+	config.SLUpdateHysteresisOverrides = settings.Parse(src, "SL_UPDATE_HYSTERESIS_OVERRIDES", nil, parseHysteresisOverrides)
+	config.TPUpdateHysteresisOverrides = settings.Parse(src, "TP_UPDATE_HYSTERESIS_OVERRIDES", nil, parseHysteresisOverrides)
+	config.OrderUpdateCooldown = src.Duration("ORDER_UPDATE_COOLDOWN", config.OrderUpdateCooldown)
+	config.TPDisabled = src.Bool("TP_DISABLED", config.TPDisabled)
+	config.TPDisabledSymbols = settings.Parse(src, "TP_DISABLED_SYMBOLS", nil, parseSymbolList)
+	config.TPMinProfit = src.Float("TP_MIN_PROFIT", config.TPMinProfit)
+	config.TPMinAge = src.Duration("TP_MIN_AGE", config.TPMinAge)
+
+	config.SymbolWhitelist = settings.Parse(src, "SYMBOL_WHITELIST", nil, parseSymbolList)
+	config.SymbolBlacklist = settings.Parse(src, "SYMBOL_BLACKLIST", nil, parseSymbolList)
+	config.SmallPositionAction = settings.Parse(src, "SMALL_POSITION_ACTION", config.SmallPositionAction, parseSmallPositionAction)
+
+	config.LiquidationGuardPct = src.Float("LIQUIDATION_GUARD_PERCENT", config.LiquidationGuardPct)
+	config.LiquidationAction = settings.Parse(src, "LIQUIDATION_ACTION", config.LiquidationAction, parseLiquidationAction)
+	config.LiquidationReducePct = src.Float("LIQUIDATION_REDUCE_PERCENT", config.LiquidationReducePct)
+	config.SpreadGuardDistance = src.Float("SPREAD_GUARD_DISTANCE", config.SpreadGuardDistance)
+	config.SpreadMaxPct = src.Float("SPREAD_MAX_PERCENT", config.SpreadMaxPct)
+	config.SpreadMinDepth = src.Float("SPREAD_MIN_DEPTH", config.SpreadMinDepth)
+	config.SpreadBufferPct = src.Float("SPREAD_BUFFER_PERCENT", config.SpreadBufferPct)
+	config.RoundNumberNudgeTicks = src.Int("ROUND_NUMBER_NUDGE_TICKS", config.RoundNumberNudgeTicks)
+
+	config.ADLAlertQuantile = src.Int("ADL_ALERT_QUANTILE", config.ADLAlertQuantile)
+	config.MarginRatioAlertPct = src.Float("MARGIN_RATIO_ALERT_PERCENT", config.MarginRatioAlertPct)
+	config.MarginRiskAction = settings.Parse(src, "MARGIN_RISK_ACTION", config.MarginRiskAction, parseMarginRiskAction)
+	config.MarginAddAmount = src.Float("MARGIN_ADD_AMOUNT", config.MarginAddAmount)
+	config.MarginReducePct = src.Float("MARGIN_REDUCE_PERCENT", config.MarginReducePct)
+
+	config.FundingIncludeInProfit = src.Bool("FUNDING_INCLUDE_IN_PROFIT", config.FundingIncludeInProfit)
+	config.FundingLookback = src.Duration("FUNDING_LOOKBACK", config.FundingLookback)
+	config.FundingExtremeRate = src.Float("FUNDING_EXTREME_RATE", config.FundingExtremeRate)
+	config.FundingAction = settings.Parse(src, "FUNDING_ACTION", config.FundingAction, parseFundingAction)
+	config.FeesIncludeInProfit = src.Bool("FEES_INCLUDE_IN_PROFIT", config.FeesIncludeInProfit)
+
+	config.RunInterval = src.Duration("RUN_INTERVAL", config.RunInterval)
+	config.ObserveOnly = src.Bool("OBSERVE_ONLY", config.ObserveOnly)
+	config.ProtectOnlyBotOrders = src.Bool("PROTECT_ONLY_BOT_ORDERS", config.ProtectOnlyBotOrders)
+	config.MaxConcurrency = src.Int("MAX_CONCURRENCY", config.MaxConcurrency)
+	config.APIRateLimit = src.Float("API_RATE_LIMIT", config.APIRateLimit)
+	config.DisplayCurrency = strings.ToUpper(strings.TrimSpace(src.String("DISPLAY_CURRENCY")))
+	config.ConfigReload = src.Bool("CONFIG_RELOAD", config.ConfigReload)
+	config.ConfigReloadInterval = src.Duration("CONFIG_RELOAD_INTERVAL", config.ConfigReloadInterval)
+	config.CredentialRefreshInterval = src.Duration("CREDENTIAL_REFRESH_INTERVAL", config.CredentialRefreshInterval)
+	config.MarkPriceStream = src.Bool("MARK_PRICE_STREAM", config.MarkPriceStream)
+
+	if stateFile := src.String("STATE_FILE"); stateFile != "" {
+		config.StateFile = stateFile
+	}
+	config.ReconcileOnStartup = src.Bool("RECONCILE_ON_STARTUP", config.ReconcileOnStartup)
+	config.StateBackend = settings.Parse(src, "STATE_BACKEND", config.StateBackend, parseStateBackend)
+	config.RedisURL = src.String("REDIS_URL")
+	if prefix := src.String("REDIS_KEY_PREFIX"); prefix != "" {
+		config.RedisKeyPrefix = prefix
+	}
+	config.PostgresURL = src.String("POSTGRES_URL")
+	// The shared backends need their server
+	switch config.StateBackend {
+	case stateBackendRedis:
+		src.Required("REDIS_URL")
+	case stateBackendPostgres:
+		src.Required("POSTGRES_URL")
+	}
+	config.RecordSession = src.String("RECORD_SESSION")
+	config.LeaderLockFile = src.String("LEADER_LOCK_FILE")
+	config.LeaderLease = src.Duration("LEADER_LEASE", config.LeaderLease)
+	config.LeaderID = src.String("LEADER_ID")
+	if config.LeaderLease <= 0 {
+		src.Fail("LEADER_LEASE", fmt.Errorf("must be positive, got %s", config.LeaderLease))
+	}
+
+	loadNotifierConfig(src, &config)
+	config.NotifyOnlyOnChange = src.Bool("NOTIFY_ONLY_ON_CHANGE", config.NotifyOnlyOnChange)
+	config.NotifyMinInterval = src.Duration("NOTIFY_MIN_INTERVAL", config.NotifyMinInterval)
+
+	config.ScheduleWindows = settings.Parse(src, "SCHEDULE_WINDOWS", nil, parseScheduleWindows)
+	config.CalendarURL = src.String("CALENDAR_URL")
+	if value, ok := src.Lookup("CALENDAR_EVENTS"); ok {
+		config.CalendarEvents = parseList(value)
+	}
+	if value, ok := src.Lookup("CALENDAR_COUNTRIES"); ok {
+		config.CalendarCountries = parseList(strings.ToUpper(value))
+	}
+	config.CalendarLeadTime = src.Duration("CALENDAR_LEAD_TIME", config.CalendarLeadTime)
+	config.CalendarHoldTime = src.Duration("CALENDAR_HOLD_TIME", config.CalendarHoldTime)
+	config.CalendarRefreshInterval = src.Duration("CALENDAR_REFRESH_INTERVAL", config.CalendarRefreshInterval)
+	config.AccountPnLRetracePct = src.Float("ACCOUNT_PNL_RETRACE_PERCENT", config.AccountPnLRetracePct)
+	config.AccountPnLMinPeak = src.Float("ACCOUNT_PNL_MIN_PEAK", config.AccountPnLMinPeak)
+	config.AccountPnLAction = settings.Parse(src, "ACCOUNT_PNL_ACTION", config.AccountPnLAction, parseAccountPnLAction)
+	config.AccountPnLCloseCount = src.Int("ACCOUNT_PNL_CLOSE_COUNT", config.AccountPnLCloseCount)
+	config.FreeMarginAlertPct = src.Float("FREE_MARGIN_ALERT_PERCENT", config.FreeMarginAlertPct)
+	config.FreeMarginBlockAdds = src.Bool("FREE_MARGIN_BLOCK_ADDS", config.FreeMarginBlockAdds)
+	config.DCAEnabled = src.Bool("DCA_ENABLED", config.DCAEnabled)
+	config.DCALevels = settings.Parse(src, "DCA_LEVELS", nil, parseDCALevels)
+	config.DCASizeMultiplier = src.Float("DCA_SIZE_MULTIPLIER", config.DCASizeMultiplier)
+	config.DCAMaxAdds = src.Int("DCA_MAX_ADDS", config.DCAMaxAdds)
+	config.PyramidFraction = src.Float("PYRAMID_FRACTION", config.PyramidFraction)
+	config.PyramidMaxAdds = src.Int("PYRAMID_MAX_ADDS", config.PyramidMaxAdds)
+	config.ScaleOutFraction = src.Float("SCALE_OUT_FRACTION", config.ScaleOutFraction)
+	if config.ScaleOutFraction < 0 || config.ScaleOutFraction >= 1 {
+		src.Fail("SCALE_OUT_FRACTION", fmt.Errorf("must be at least 0 and below 1, got %v", config.ScaleOutFraction))
+	} else if config.ScaleOutFraction > 0 && config.PyramidFraction > 0 {
+		src.Fail("SCALE_OUT_FRACTION", errors.New("cannot be combined with PYRAMID_FRACTION, both act on new ladder stages"))
+	}
+	config.GridOrderPrefixes = parseList(src.String("GRID_ORDER_PREFIXES"))
+	config.GridStopBufferPct = src.Float("GRID_STOP_BUFFER_PERCENT", config.GridStopBufferPct)
+	config.GridRecenterPct = src.Float("GRID_RECENTER_PERCENT", config.GridRecenterPct)
+	config.StopTriggerAction = settings.Parse(src, "STOP_TRIGGER_ACTION", config.StopTriggerAction, parseStopTriggerAction)
+	config.StopTriggerTicks = src.Int("STOP_TRIGGER_TICKS", config.StopTriggerTicks)
+	config.MaxHoldingTime = src.Duration("MAX_HOLDING_TIME", config.MaxHoldingTime)
+	config.MaxHoldingTimeOverrides = settings.Parse(src, "MAX_HOLDING_TIME_OVERRIDES", nil, parseHoldingOverrides)
+	config.MaxHoldingMinProfit = src.Float("MAX_HOLDING_MIN_PROFIT", config.MaxHoldingMinProfit)
+	config.MaxHoldingAction = settings.Parse(src, "MAX_HOLDING_ACTION", config.MaxHoldingAction, parseHoldingAction)
+	config.LeverageTarget = src.Int("LEVERAGE_TARGET", config.LeverageTarget)
+	config.LeverageTargetOverrides = settings.Parse(src, "LEVERAGE_TARGET_OVERRIDES", nil, parseLeverageOverrides)
+	config.MarginTypeTarget = settings.Parse(src, "MARGIN_TYPE_TARGET", config.MarginTypeTarget, parseMarginType)
+	config.MarginTypeTargetOverrides = settings.Parse(src, "MARGIN_TYPE_TARGET_OVERRIDES", nil, parseMarginTypeOverrides)
+	config.LeverageAction = settings.Parse(src, "LEVERAGE_ACTION", config.LeverageAction, parseLeverageAction)
+
+	loadStrategyConfig(src, &config)
+	loadRLadderConfig(src, &config)
+	loadProfitMetricConfig(src, &config)
+	loadVolatilityConfig(src, &config)
+	loadTrendConfig(src, &config)
+	loadRiskRewardConfig(src, &config)
+	loadStopLimitConfig(src, &config)
+	loadSubAccountConfig(src, &config)
+	loadStrategyTagConfig(src, &config)
+	loadChartConfig(src, &config)
+
+	config.APIAddr = src.String("API_ADDR")
+	config.APIToken = src.String("API_TOKEN")
+	config.GRPCAddr = src.String("GRPC_ADDR")
+	config.TradingViewSecret = src.String("TRADINGVIEW_SECRET")
+	config.HealthMaxTimeDrift = src.Duration("HEALTH_MAX_TIME_DRIFT", config.HealthMaxTimeDrift)
+
+	config.TimeSync = src.Bool("TIME_SYNC", config.TimeSync)
+	config.TimeSyncInterval = src.Duration("TIME_SYNC_INTERVAL", config.TimeSyncInterval)
+	config.SymbolRefreshInterval = src.Duration("SYMBOL_REFRESH_INTERVAL", config.SymbolRefreshInterval)
+	config.RecvWindow = src.Duration("RECV_WINDOW", config.RecvWindow)
+
+	config.StartupReport = src.Bool("STARTUP_REPORT", config.StartupReport)
+	config.DailyReport = src.Bool("DAILY_REPORT", config.DailyReport)
+	config.DailyReportTime = settings.Parse(src, "DAILY_REPORT_TIME", config.DailyReportTime, parseDailyTime)
+
+	restrictToExchange(src, &config)
+	return config, src.Warnings(), src.Err()
 }
 
 // setupBinanceClient initializes and validates the Binance API client.
-func setupBinanceClient() (*binance.Client, error) {
-	apiKey := os.Getenv("BINANCE_API_KEY")
-	apiSecret := os.Getenv("BINANCE_API_SECRET")
+func setupBinanceClient(config Config) (*binance.Client, error) {
+	apiKey := config.Credentials.BinanceAPIKey
+	apiSecret := config.Credentials.BinanceAPISecret
 
 	if apiKey == "" || apiSecret == "" {
 		return nil, fmt.Errorf("binance API credentials not configured")
@@ -182,8 +948,13 @@ func setupBinanceClient() (*binance.Client, error) {
 
 	client := binance.NewClient(apiKey, apiSecret)
 
+	// Calibrate the clock offset before the first signed request
+	if config.TimeSync {
+		enableTimeSync(client)
+	}
+
 	// Validate API connection
-	_, err := client.NewGetAccountService().Do(context.Background())
+	_, err := client.NewGetAccountService().Do(context.Background(), recvWindowOption(config))
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to Binance API: %w", err)
 	}
@@ -191,123 +962,30 @@ func setupBinanceClient() (*binance.Client, error) {
 	return client, nil
 }
 
-// getSymbolPrecisions retrieves precision information for all trading symbols.
-func getSymbolPrecisions(client *binance.Client) (map[string]SymbolPrecision, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
-	defer cancel()
-
-	exchangeInfo, err := client.NewExchangeInfoService().Do(ctx)
-	if err != nil {
-		return nil, err
-	}
-
-	symbolInfo := make(map[string]SymbolPrecision, len(exchangeInfo.Symbols))
-	for _, info := range exchangeInfo.Symbols {
-		symbolInfo[info.Symbol] = SymbolPrecision{
-			PricePrecision:    info.PricePrecision,
-			QuantityPrecision: info.QuantityPrecision,
-		}
-	}
-	return symbolInfo, nil
-}
-
 // Fixed calculateStopLoss function with precise calculations
 func (ts *TradingService) calculateStopLoss(data *PositionData) float64 {
-	// Determine current stop-loss percentage based on profit levels
-	currentSLPct := ts.config.DefaultSLPercent
-	log.Printf("DEBUG: Initial SL%% for %s set to %.2f%%", data.Symbol, currentSLPct)
-
-	// Only adjust stop-loss based on profit levels if we're in profit
-	// and hit at least the first threshold
-	if data.CurrentProfitPct > 0 {
-		thresholdReached := false
-		for _, level := range ts.stopLevels {
-			if data.CurrentProfitPct >= level.ProfitThreshold {
-				currentSLPct = level.StopLossValue
-				thresholdReached = true
-				log.Printf("DEBUG: Adjusted SL%% to %.2f%% based on profit threshold %.2f%%",
-					currentSLPct, level.ProfitThreshold)
-			} else {
-				break
-			}
-		}
-
-		// If no threshold reached but in profit, keep using the default SL percent
-		if !thresholdReached {
-			log.Printf("DEBUG: Using default SL%% of %.2f%% for position with profit %.2f%% (below first threshold)",
-				currentSLPct, data.CurrentProfitPct)
-		}
+	if stopPrice, ok := ts.highWaterStop(data); ok {
+		return stopPrice
 	}
-	data.CurrentSLPct = currentSLPct
-
-	// Calculate stop price based on position direction
-	var stopPrice float64
-
-	// IMPORTANT FIX: For positions below threshold, the SL calculation logic was incorrect
-	// For default behavior (below thresholds), we want:
-	// - Long positions: SL below entry price by DefaultSLPercent
-	// - Short positions: SL above entry price by DefaultSLPercent
-
-	if data.IsLong {
-		if currentSLPct == 0 {
-			// At breakeven
-			stopPrice = data.EntryPrice
-			log.Printf("DEBUG: Long SL calculation: Breakeven at entry=%.8f", data.EntryPrice)
-		} else if data.CurrentProfitPct >= ts.stopLevels[0].ProfitThreshold {
-			// We're above threshold - lock in profit at specified level above entry
-			profitPercentToSecure := currentSLPct / data.Leverage
-			stopPrice = data.EntryPrice * (1 + profitPercentToSecure/100)
-			log.Printf("DEBUG: Long SL calculation (above threshold): Entry=%.8f * (1 + %.4f/100) = %.8f",
-				data.EntryPrice, profitPercentToSecure, stopPrice)
-		} else {
-			// Default behavior - SL below entry by DefaultSLPercent
-			// Note: This is a fixed percentage of the entry price
-			rawSLPct := currentSLPct
-			stopPrice = data.EntryPrice * (1 - rawSLPct/100)
-			log.Printf("DEBUG: Long SL calculation (below threshold): Entry=%.8f * (1 - %.4f/100) = %.8f",
-				data.EntryPrice, rawSLPct, stopPrice)
-		}
+	return ts.ladderStop(data, ts.confirmedProfit(data, ts.ladderProfit(data)))
+}
+
+// ladderStop calculates the ladder stop of data for profit, in the unit of
+// PROFIT_METRIC, and fills in the reporting percentages.
+func (ts *TradingService) ladderStop(data *PositionData, profit float64) float64 {
+	unit := ts.profitUnit(data.Symbol)
+	stopPrice, lock, reached := ts.stopLadder(data.Symbol).Stop(ladderPosition(data), profit)
+	data.CurrentSLPct = lock
+	if reached {
+		log.Printf("DEBUG: SL lock of %s is %.2f%s at profit %.2f%s (stage %d)",
+			data.Symbol, lock, unit, profit, unit, ts.profitStage(data.Symbol, profit))
 	} else {
-		// For short positions
-		if currentSLPct == 0 {
-			// At breakeven
-			stopPrice = data.EntryPrice
-			log.Printf("DEBUG: Short SL calculation: Breakeven at entry=%.8f", data.EntryPrice)
-		} else if data.CurrentProfitPct >= ts.stopLevels[0].ProfitThreshold {
-			// We're above threshold - lock in profit at specified level below entry
-			profitPercentToSecure := currentSLPct / data.Leverage
-			stopPrice = data.EntryPrice * (1 - profitPercentToSecure/100)
-			log.Printf("DEBUG: Short SL calculation (above threshold): Entry=%.8f * (1 - %.4f/100) = %.8f",
-				data.EntryPrice, profitPercentToSecure, stopPrice)
-		} else {
-			// Default behavior - SL above entry by DefaultSLPercent
-			// THIS IS THE KEY FIX - for positions below threshold, we use the raw percentage
-			// directly (not divided by leverage) to calculate the stop price
-			rawSLPct := currentSLPct
-			stopPrice = data.EntryPrice * (1 + rawSLPct/100)
-			log.Printf("DEBUG: Short SL calculation (below threshold): Entry=%.8f * (1 + %.4f/100) = %.8f",
-				data.EntryPrice, rawSLPct, stopPrice)
-		}
+		log.Printf("DEBUG: Using default SL%% of %.2f%% for %s with profit %.2f%s (below first threshold)",
+			lock, data.Symbol, profit, unit)
 	}
 
 	// Calculate raw and leveraged percentages for reporting
-	if data.IsLong {
-		if stopPrice >= data.EntryPrice {
-			// SL is above entry (in profit)
-			data.RawSLPct = ((stopPrice - data.EntryPrice) / data.EntryPrice) * 100
-		} else {
-			// SL is below entry (at loss)
-			data.RawSLPct = -((data.EntryPrice - stopPrice) / data.EntryPrice) * 100
-		}
-	} else {
-		if stopPrice <= data.EntryPrice {
-			// SL is below entry (in profit)
-			data.RawSLPct = ((data.EntryPrice - stopPrice) / data.EntryPrice) * 100
-		} else {
-			// SL is above entry (at loss)
-			data.RawSLPct = -((stopPrice - data.EntryPrice) / data.EntryPrice) * 100
-		}
-	}
+	data.RawSLPct = rawStopLossPct(data, stopPrice)
 	data.LeveragedSLPct = data.RawSLPct * data.Leverage
 
 	log.Printf("DEBUG: Final SL for %s: price=%.8f, raw=%.2f%%, leveraged=%.2f%%",
@@ -316,28 +994,24 @@ func (ts *TradingService) calculateStopLoss(data *PositionData) float64 {
 	return stopPrice
 }
 
+// profitStage returns the index of the highest stop level of symbol reached by
+// profitPct, or -1 when the first threshold has not been reached.
+func (ts *TradingService) profitStage(symbol string, profitPct float64) int {
+	return ladder.Stage(ts.stopLadder(symbol).Levels, profitPct)
+}
+
+// rawStopLossPct returns the unleveraged distance of stopPrice from entry as a
+// percentage: positive when the stop locks in profit, negative when it is at a loss.
+func rawStopLossPct(data *PositionData, stopPrice float64) float64 {
+	return ladder.RawStopPct(data.EntryPrice, stopPrice, data.IsLong)
+}
+
 // calculateTakeProfit determines the take-profit price.
 func (ts *TradingService) calculateTakeProfit(data *PositionData) float64 {
-	var takePrice float64
-
-	if data.IsLong {
-		takePrice = data.EntryPrice * (1 + ts.config.TPPercent/100)
-		if takePrice <= data.MarkPrice {
-			takePrice = data.MarkPrice * 1.005 // Slightly above current price
-		}
-	} else {
-		takePrice = data.EntryPrice * (1 - ts.config.TPPercent/100)
-		if takePrice >= data.MarkPrice {
-			takePrice = data.MarkPrice * 0.995 // Slightly below current price
-		}
-	}
+	takePrice := ladder.TakeProfit(data.EntryPrice, data.MarkPrice, ts.takeProfitPercent(data), data.IsLong)
 
 	// Calculate raw and leveraged percentages for reporting
-	if data.IsLong {
-		data.RawTPPct = math.Abs(((takePrice - data.EntryPrice) / data.EntryPrice) * 100)
-	} else {
-		data.RawTPPct = math.Abs(((data.EntryPrice - takePrice) / data.EntryPrice) * 100)
-	}
+	data.RawTPPct = ladder.RawTakePct(data.EntryPrice, takePrice, data.IsLong)
 	data.LeveragedTPPct = data.RawTPPct * data.Leverage
 
 	return takePrice
@@ -349,32 +1023,20 @@ func (ts *TradingService) getCurrentStopLoss(symbol string, positionSide string)
 	defer cancel()
 
 	// Get all open orders for the symbol
-	openOrders, err := ts.client.NewListOpenOrdersService().Symbol(symbol).Do(ctx)
+	openOrders, err := ts.exchange().OpenOrders(ctx, symbol)
 	if err != nil {
 		return 0, fmt.Errorf("error fetching open orders for %s: %w", symbol, err)
 	}
 
 	// Find stop-loss order
 	for _, order := range openOrders {
-		// Check if this is a stop-loss order (STOP_MARKET)
-		if order.Type == "STOP_MARKET" {
-			// Check position side based on value
-			// Skip this check if positionSide is "BOTH"
-			if positionSide != "BOTH" {
-				// Convert both to comparable strings for safe comparison
-				orderPosSide := order.PositionSide
-				if (positionSide == "LONG" && orderPosSide != "LONG") ||
-					(positionSide == "SHORT" && orderPosSide != "SHORT") {
-					continue
-				}
+		// Check if this is a stop-loss order (STOP_MARKET or STOP)
+		if isStopLossOrder(order) {
+			// Only consider orders protecting this side of a hedge-mode position
+			if !orderMatchesSide(order, positionSide) || !ts.ownsOrder(order) {
+				continue
 			}
-
-			// Get the stop price
-			stopPrice, err := strconv.ParseFloat(order.StopPrice, 64)
-			if err != nil {
-				return 0, fmt.Errorf("error parsing stop price: %w", err)
-			}
-			return stopPrice, nil
+			return order.StopPrice, nil
 		}
 	}
 
@@ -382,159 +1044,258 @@ func (ts *TradingService) getCurrentStopLoss(symbol string, positionSide string)
 	return 0, nil
 }
 
-// cancelExistingOrders removes all open orders for a symbol.
-func (ts *TradingService) cancelExistingOrders(symbol string) error {
+// orderMatchesSide reports whether an order belongs to the given position side.
+// In one-way mode ("BOTH") or with an empty side every order matches; in hedge
+// mode only orders for the same LONG/SHORT side do, so one side never touches
+// the other side's orders.
+func orderMatchesSide(order *Order, positionSide string) bool {
+	if positionSide == "" || positionSide == "BOTH" {
+		return true
+	}
+	return order.PositionSide == positionSide
+}
+
+// cancelExistingOrders removes the open orders for a symbol that belong to
+// positionSide. An empty positionSide cancels the orders of every side. With
+// PROTECT_ONLY_BOT_ORDERS only the orders placed by the bot are cancelled.
+func (ts *TradingService) cancelExistingOrders(symbol string, positionSide string) error {
 	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
 	defer cancel()
 
-	openOrders, err := ts.client.NewListOpenOrdersService().Symbol(symbol).Do(ctx)
+	openOrders, err := ts.exchange().OpenOrders(ctx, symbol)
 	if err != nil {
 		return fmt.Errorf("error fetching open orders for %s: %w", symbol, err)
 	}
 
 	for _, order := range openOrders {
-		_, err := ts.client.NewCancelOrderService().Symbol(symbol).OrderID(order.OrderID).Do(ctx)
-		if err != nil {
-			log.Printf("Error canceling order %d for %s: %v", order.OrderID, symbol, err)
+		// Resting scale-ins and grid orders are managed separately from the bracket
+		if !orderMatchesSide(order, positionSide) || !ts.ownsOrder(order) || isScaleInOrder(order) || ts.isGridOrder(order) {
+			continue
+		}
+		if err := ts.exchange().CancelOrder(ctx, order); err != nil {
+			log.Printf("Error canceling order %s for %s: %v", order.ID, symbol, err)
 		}
 	}
 	return nil
 }
 
-// getOrderSideInfo determines the appropriate side and position side for orders.
-func getOrderSideInfo(positionSide string, posAmt float64) (binance.SideType, binance.PositionSideType) {
-	var closeSide binance.SideType
-	var positionSideForOrder binance.PositionSideType
+// needsStopLossOrder reports whether a stop-loss order should be placed for a position.
+func needsStopLossOrder(data *PositionData) bool {
+	return data.CurrentSLPct >= 0 && data.StopPrice > 0
+}
 
-	if positionSide == "BOTH" {
-		if posAmt > 0 {
-			closeSide = binance.SideTypeSell
-		} else {
-			closeSide = binance.SideTypeBuy
-		}
-		positionSideForOrder = ""
-	} else {
-		positionSideForOrder = binance.PositionSideType(positionSide)
-		if positionSide == "LONG" {
-			closeSide = binance.SideTypeSell
-		} else {
-			closeSide = binance.SideTypeBuy
-		}
+// takeProfitReached reports whether the mark price has already touched the take-profit level.
+func takeProfitReached(data *PositionData) bool {
+	return (data.IsLong && data.MarkPrice >= data.TakePrice) ||
+		(data.IsShort && data.MarkPrice <= data.TakePrice)
+}
+
+// newStopLossOrder builds the stop-loss order request for a position. Protective
+// orders are always reduce-only, so they can never open a reverse position.
+func newStopLossOrder(data *PositionData) OrderRequest {
+	return OrderRequest{
+		Symbol:        data.Symbol,
+		Side:          closeSide(data),
+		PositionSide:  data.PositionSide,
+		Type:          orderTypeStopMarket,
+		Quantity:      data.Quantity,
+		StopPrice:     data.StopPriceStr,
+		ReduceOnly:    true,
+		ClosePosition: data.ClosePosition,
 	}
+}
 
-	return closeSide, positionSideForOrder
+// newTakeProfitOrder builds the take-profit order request for a position.
+func newTakeProfitOrder(data *PositionData) OrderRequest {
+	return OrderRequest{
+		Symbol:        data.Symbol,
+		Side:          closeSide(data),
+		PositionSide:  data.PositionSide,
+		Type:          orderTypeTakeProfitMarket,
+		Quantity:      data.Quantity,
+		StopPrice:     data.TakePriceStr,
+		ReduceOnly:    true,
+		ClosePosition: data.ClosePosition,
+	}
 }
 
 // createStopLossOrder places a stop-loss order for a position.
 func (ts *TradingService) createStopLossOrder(data *PositionData) error {
-	if data.CurrentSLPct < 0 || data.StopPrice <= 0 {
+	return ts.placeStopLoss(data, ts.clientOrderID(clientOrderKindSL, data))
+}
+
+// placeStopLoss places the stop-loss order of a position under clientID.
+func (ts *TradingService) placeStopLoss(data *PositionData, clientID string) error {
+	if !needsStopLossOrder(data) {
 		return nil // No stop-loss needed
 	}
 
 	log.Printf("DEBUG SL: %s | entry: %.2f | stop: %.2f | SL%%: %.2f",
 		data.Symbol, data.EntryPrice, data.StopPrice, data.CurrentSLPct)
 
-	closeSide, positionSideForOrder := getOrderSideInfo(data.PositionSide, data.PositionAmt)
-
 	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
 	defer cancel()
 
-	// Create the stop-loss order
-	slOrderService := ts.client.NewCreateOrderService().
-		Symbol(data.Symbol).
-		Side(closeSide).
-		Type(binance.OrderTypeStopMarket).
-		Quantity(data.Quantity).
-		StopPrice(data.StopPriceStr).
-		TimeInForce(binance.TimeInForceTypeGTC)
-
-	if data.PositionSide != "BOTH" {
-		slOrderService = slOrderService.PositionSide(positionSideForOrder)
+	req := ts.stopLossOrder(data)
+	if err := checkReduceOnly(req, data); err != nil {
+		return fmt.Errorf("refusing Stop Loss order: %w", err)
+	}
+	req.ClientOrderID = clientID
+	order, err := ts.placeOrder(ctx, req)
+	if isImmediateTrigger(err) {
+		return ts.healStopLoss(data, err)
 	}
-
-	_, err := slOrderService.Do(ctx)
 	if err != nil {
+		ts.publish(EventOrderRejected, data, Event{Price: data.StopPrice, Reason: "Stop Loss", Error: err.Error()})
 		return fmt.Errorf("error setting Stop Loss order for %s: %w", data.Symbol, err)
 	}
+	ts.publish(EventSLMoved, data, Event{Price: data.StopPrice, OrderID: order.ID})
 	return nil
 }
 
 // createTakeProfitOrder places a take-profit order for a position.
 func (ts *TradingService) createTakeProfitOrder(data *PositionData) error {
+	return ts.placeTakeProfit(data, ts.clientOrderID(clientOrderKindTP, data))
+}
+
+// placeTakeProfit places the take-profit order of a position under clientID.
+func (ts *TradingService) placeTakeProfit(data *PositionData, clientID string) error {
+	// Positions without a take-profit keep only their stop
+	if data.TakePrice <= 0 {
+		return nil
+	}
+
 	// Check if TP has already been reached
-	if (data.IsLong && data.MarkPrice >= data.TakePrice) ||
-		(data.IsShort && data.MarkPrice <= data.TakePrice) {
+	if takeProfitReached(data) {
 		log.Printf("TP for %s (%s) already reached: current price = %.2f, TP price = %.2f",
 			data.Symbol, data.PositionSide, data.MarkPrice, data.TakePrice)
 		return nil
 	}
 
-	closeSide, positionSideForOrder := getOrderSideInfo(data.PositionSide, data.PositionAmt)
-
 	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
 	defer cancel()
 
-	// Create the take-profit order
-	tpOrderService := ts.client.NewCreateOrderService().
-		Symbol(data.Symbol).
-		Side(closeSide).
-		Type(binance.OrderTypeTakeProfitMarket).
-		Quantity(data.Quantity).
-		StopPrice(data.TakePriceStr).
-		TimeInForce(binance.TimeInForceTypeGTC)
-
-	if data.PositionSide != "BOTH" {
-		tpOrderService = tpOrderService.PositionSide(positionSideForOrder)
+	req := newTakeProfitOrder(data)
+	if err := checkReduceOnly(req, data); err != nil {
+		return fmt.Errorf("refusing Take Profit order: %w", err)
 	}
-
-	_, err := tpOrderService.Do(ctx)
+	req.ClientOrderID = clientID
+	order, err := ts.placeOrder(ctx, req)
 	if err != nil {
+		ts.publish(EventOrderRejected, data, Event{Price: data.TakePrice, Reason: "Take Profit", Error: err.Error()})
 		return fmt.Errorf("error setting Take Profit order for %s: %w", data.Symbol, err)
 	}
+	ts.publish(EventTPUpdated, data, Event{Price: data.TakePrice, OrderID: order.ID})
 	return nil
 }
 
-// formatPositionMessage creates a formatted position summary for logging and notification.
-func formatPositionMessage(data *PositionData) string {
-	// Determine icon based on position direction
-	sideIcon := "🔴 SHORT"
-	if data.IsLong {
-		sideIcon = "🟢 LONG"
+// removeTakeProfit cancels the take-profit orders the bot may manage on the
+// position's side.
+func (ts *TradingService) removeTakeProfit(data *PositionData) error {
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+
+	openOrders, err := ts.exchange().OpenOrders(ctx, data.Symbol)
+	if err != nil {
+		return fmt.Errorf("error fetching open orders for %s: %w", data.Symbol, err)
 	}
-	// Format the message
-	var slText string
-	if data.CurrentSLPct >= 0 && data.StopPrice > 0 {
-		slText = fmt.Sprintf("%.8f", data.StopPrice)
-	} else {
-		slText = "NONE"
+	for _, order := range openOrders {
+		if order.Type == orderTypeTakeProfitMarket && orderMatchesSide(order, data.PositionSide) && ts.ownsOrder(order) {
+			if err := ts.cancelOrder(ctx, order); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// createBracketOrders places the stop-loss and take-profit orders for a position
+// in a single batch request so both legs are submitted together. A leg the batch
+// fails to place is retried alone, so a rejected leg never leaves the position
+// half protected. It falls back to the single-order path when only one leg is
+// required.
+func (ts *TradingService) createBracketOrders(data *PositionData) error {
+	placeSL := needsStopLossOrder(data)
+	placeTP := data.TakePrice > 0 && !takeProfitReached(data)
+
+	// The batch endpoint always sends a quantity, which closePosition orders reject
+	if !placeSL || !placeTP || data.ClosePosition {
+		// A failed stop must not keep the target from being placed
+		var errs []error
+		if placeSL {
+			errs = append(errs, ts.createStopLossOrder(data))
+		}
+		errs = append(errs, ts.createTakeProfitOrder(data))
+		return errors.Join(errs...)
+	}
+
+	log.Printf("DEBUG SL: %s | entry: %.2f | stop: %.2f | SL%%: %.2f",
+		data.Symbol, data.EntryPrice, data.StopPrice, data.CurrentSLPct)
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+
+	reqs := []OrderRequest{ts.stopLossOrder(data), newTakeProfitOrder(data)}
+	for _, req := range reqs {
+		if err := checkReduceOnly(req, data); err != nil {
+			return fmt.Errorf("refusing SL/TP batch orders: %w", err)
+		}
+	}
+	reqs[0].ClientOrderID = ts.clientOrderID(clientOrderKindSL, data)
+	reqs[1].ClientOrderID = ts.clientOrderID(clientOrderKindTP, data)
+	orders, errs, err := ts.exchange().CreateOrders(ctx, reqs)
+	if err != nil {
+		return fmt.Errorf("error setting SL/TP batch orders for %s: %w", data.Symbol, err)
+	}
+
+	// The batch endpoint reports a result per leg, in submission order
+	legs := []struct {
+		name  string
+		event EventType
+		price float64
+		place func(*PositionData, string) error // Places the leg alone
+	}{
+		{"Stop Loss", EventSLMoved, data.StopPrice, ts.placeStopLoss},
+		{"Take Profit", EventTPUpdated, data.TakePrice, ts.placeTakeProfit},
+	}
+	var failed []error
+	for i, leg := range legs {
+		if errs[i] != nil && orders[i] == nil {
+			// A leg rejected as a duplicate may already be open from a timed-out attempt
+			if existing := ts.findClientOrder(ctx, reqs[i]); existing != nil {
+				orders[i], errs[i] = existing, nil
+			}
+		}
+		if i == 0 && isImmediateTrigger(errs[i]) {
+			// The price has moved past the stop since it was computed
+			if err := ts.healStopLoss(data, errs[i]); err != nil {
+				failed = append(failed, err)
+			}
+			continue
+		}
+		if errs[i] != nil || orders[i] == nil {
+			// The single-order path reports the outcome of the retry, which keeps
+			// the ID so it adopts the leg should the batch have placed it after all
+			log.Printf("Warning: %s leg of the batch for %s failed, retrying it alone: %v", leg.name, data.Symbol, errs[i])
+			if err := leg.place(data, reqs[i].ClientOrderID); err != nil {
+				failed = append(failed, err)
+			}
+			continue
+		}
+		ts.publish(leg.event, data, Event{Price: leg.price, OrderID: orders[i].ID})
+	}
+	return errors.Join(failed...)
+}
+
+// formatPositionMessage creates a formatted position summary for logging and
+// notification. A custom template that fails to render falls back to the built-in one.
+func (ts *TradingService) formatPositionMessage(data *PositionData) string {
+	msg, err := ts.messages.render(data)
+	if err != nil {
+		log.Printf("Warning: %v", err)
+		builtin, _ := newPositionTemplate(Config{})
+		msg, _ = builtin.render(data)
 	}
-	var potentialLossDisplay float64
-	if data.CurrentSLPct > 0 {
-		potentialLossDisplay = math.Abs(data.PotentialLoss)
-	} else {
-		potentialLossDisplay = math.Abs(data.PotentialLoss)
-	}
-
-	// Add negative sign to potential loss when RawSLPct is negative
-	if data.RawSLPct < 0 {
-		potentialLossDisplay = -potentialLossDisplay
-	}
-
-	// Format the message with higher precision for price values
-	msg := fmt.Sprintf(`📊 %s %s
-💵 Entry: %.8f  📉 Mark: %.8f
-💹 P/L: %.2f%% (%.2f%% x%d)
-🛑 SL: %s (%.2f%% / %.2f%% x%d)  
-🎯 TP: %.8f (%.2f%% / %.2f%% x%d)
-⚖️ Risk/Reward: %.2f
-💰 Potential Profit: %.2f USD
-💸 Potential Loss: %.2f USD`,
-		data.Symbol, sideIcon,
-		data.EntryPrice, data.MarkPrice,
-		data.CurrentProfitPct, data.RawProfitPct, int(data.Leverage),
-		slText, data.RawSLPct, data.LeveragedSLPct, int(data.Leverage),
-		data.TakePrice, data.RawTPPct, data.LeveragedTPPct, int(data.Leverage),
-		data.RiskReward, data.PotentialProfit, potentialLossDisplay)
 	return msg
 }
 
@@ -544,7 +1305,7 @@ func (ts *TradingService) getCurrentTakeProfit(symbol string, positionSide strin
 	defer cancel()
 
 	// Get all open orders for the symbol
-	openOrders, err := ts.client.NewListOpenOrdersService().Symbol(symbol).Do(ctx)
+	openOrders, err := ts.exchange().OpenOrders(ctx, symbol)
 	if err != nil {
 		return 0, fmt.Errorf("error fetching open orders for %s: %w", symbol, err)
 	}
@@ -552,24 +1313,14 @@ func (ts *TradingService) getCurrentTakeProfit(symbol string, positionSide strin
 	// Find take-profit order
 	for _, order := range openOrders {
 		// Check if this is a take-profit order (TAKE_PROFIT_MARKET)
-		if order.Type == "TAKE_PROFIT_MARKET" {
-			// Check position side based on value
-			// Skip this check if positionSide is "BOTH"
-			if positionSide != "BOTH" {
-				// Convert both to comparable strings for safe comparison
-				orderPosSide := order.PositionSide
-				if (positionSide == "LONG" && orderPosSide != "LONG") ||
-					(positionSide == "SHORT" && orderPosSide != "SHORT") {
-					continue
-				}
+		if order.Type == orderTypeTakeProfitMarket {
+			// Only consider orders protecting this side of a hedge-mode position
+			if !orderMatchesSide(order, positionSide) || !ts.ownsOrder(order) {
+				continue
 			}
 
-			// Get the stop price (which is actually the take-profit price in this case)
-			takePrice, err := strconv.ParseFloat(order.StopPrice, 64)
-			if err != nil {
-				return 0, fmt.Errorf("error parsing take profit price: %w", err)
-			}
-			return takePrice, nil
+			// The stop price is actually the take-profit price in this case
+			return order.StopPrice, nil
 		}
 	}
 
@@ -577,8 +1328,93 @@ func (ts *TradingService) getCurrentTakeProfit(symbol string, positionSide strin
 	return 0, nil
 }
 
+// calculateRiskMetrics formats the order values according to symbol precision and
+// computes the potential profit, potential loss and risk/reward of a position.
+func (ts *TradingService) calculateRiskMetrics(data *PositionData) error {
+	// Format values according to symbol precision
+	precision, ok := ts.symbolPrecision(data.Symbol)
+	if !ok {
+		return fmt.Errorf("precision information not found for %s", data.Symbol)
+	}
+
+	data.Quantity = formatDecimal(data.AbsAmt, precision.QuantityPrecision)
+	if data.PnLAsset == "" {
+		data.PnLAsset = precision.SettleAsset
+	}
+	ts.applyDisplayCurrency(data)
+	data.StopPriceStr = formatDecimal(data.StopPrice, precision.PricePrecision)
+	data.TakePriceStr = formatDecimal(data.TakePrice, precision.PricePrecision)
+
+	// Calculate potential profit and loss
+	data.PotentialProfit = positionPnL(data, data.TakePrice)
+
+	// FIXED: Calculate potential loss correctly based on stop price, regardless of CurrentSLPct
+	data.PotentialLoss = 0.0
+	if data.StopPrice > 0 {
+		data.PotentialLoss = positionPnL(data, data.StopPrice)
+		// If the calculation results in a positive value for what should be a loss, negate it
+		if data.PotentialLoss > 0 {
+			data.PotentialLoss = -data.PotentialLoss
+		}
+	}
+
+	// Commissions and funding already paid count against both outcomes
+	if data.Fees != 0 {
+		data.PotentialProfit += data.Fees
+		if data.PotentialLoss != 0 {
+			data.PotentialLoss += data.Fees
+		}
+	}
+
+	// Calculate risk-reward ratio
+	data.RiskReward = 0.0
+	if data.PotentialLoss != 0 {
+		data.RiskReward = math.Abs(data.PotentialProfit / data.PotentialLoss)
+	}
+	return nil
+}
+
+// finite reports whether none of values is NaN or infinite.
+func finite(values ...float64) bool {
+	for _, value := range values {
+		if math.IsNaN(value) || math.IsInf(value, 0) {
+			return false
+		}
+	}
+	return true
+}
+
+// positionPnL returns the profit of closing the position at exitPrice, in the quote
+// asset for linear contracts and in the margin asset for inverse contracts.
+func positionPnL(data *PositionData, exitPrice float64) float64 {
+	if exitPrice <= 0 || data.EntryPrice <= 0 {
+		return 0
+	}
+	entry, exit := toDecimal(data.EntryPrice), toDecimal(exitPrice)
+	var pnl decimal.Decimal
+	if data.ContractSize > 0 {
+		// Inverse contracts are worth a fixed quote amount, so PnL is in coins
+		one := decimal.NewFromInt(1)
+		pnl = toDecimal(data.AbsAmt).Mul(toDecimal(data.ContractSize)).Mul(one.Div(entry).Sub(one.Div(exit)))
+	} else {
+		pnl = exit.Sub(entry).Mul(toDecimal(data.AbsAmt))
+	}
+	if data.PositionAmt < 0 || data.IsShort {
+		pnl = pnl.Neg()
+	}
+	return pnl.InexactFloat64()
+}
+
 // updatePositionOrders cancels existing orders and creates new ones only if necessary
 func (ts *TradingService) updatePositionOrders(data *PositionData) error {
+	// Grid positions get a single stop outside the grid range instead of the ladder
+	if data.GridLow > 0 {
+		return ts.updateGridStop(data)
+	}
+
+	// A position protected by several stops or targets keeps one of each
+	ts.cancelDuplicateOrders(data)
+
 	// Get current stop loss and take profit from open orders
 	currentSL, err := ts.getCurrentStopLoss(data.Symbol, data.PositionSide)
 	if err != nil {
@@ -590,62 +1426,119 @@ func (ts *TradingService) updatePositionOrders(data *PositionData) error {
 		log.Printf("Warning: Unable to get current take profit: %v", err)
 	}
 
-	// Calculate new stop loss
-	newSL := ts.calculateStopLoss(data)
+	// Calculate new stop loss, keeping it ahead of liquidation when guarded
+	newSL := ts.stopLossFor(data)
+	slGuard := ""
+	for _, guard := range []struct {
+		name  string
+		apply func(*PositionData, float64) float64
+	}{
+		{"liquidation guard", ts.applyLiquidationGuard},
+		{"funding guard", ts.applyFundingGuard},
+		{"holding time guard", ts.applyHoldingGuard},
+		{"schedule", ts.applyScheduleGuard},
+		{"economic calendar", ts.applyCalendarGuard},
+		{"account PnL guard", ts.applyAccountGuard},
+		{"pyramid risk cap", ts.applyPyramidGuard},
+	} {
+		if sl := guard.apply(data, newSL); sl != newSL {
+			newSL, slGuard = sl, guard.name
+		}
+	}
+	// Keep stops off round numbers and give those close to the mark price room on thin order books
+	newSL, roundNote := ts.applyRoundNumberNudge(data, newSL)
+	newSL, spreadNote := ts.applySpreadGuard(data, newSL)
+
+	// Compare at the symbol's tick size, as the exchange stores the stop
+	newSL = ts.roundPrice(data.Symbol, newSL)
+	data.RawSLPct = rawStopLossPct(data, newSL)
+	data.LeveragedSLPct = data.RawSLPct * data.Leverage
 	// Store the newly calculated RawSLPct
 	newRawSLPct := data.RawSLPct
 
 	// Determine which profit threshold we're at
-	currentThreshold := -1
-	for i, level := range ts.stopLevels {
-		if data.CurrentProfitPct >= level.ProfitThreshold {
-			currentThreshold = i
-		} else {
-			break
-		}
+	currentThreshold := ts.ladderStage(data)
+	var confirmNote string
+	if data.StageConfirming && currentThreshold > data.ConfirmedStage {
+		// The stop stays at the confirmed stage until the next one is confirmed
+		confirmNote = fmt.Sprintf("stage %d awaiting confirmation", currentThreshold)
+		currentThreshold = data.ConfirmedStage
+	}
+
+	// Live orders replaced recently are kept until ORDER_UPDATE_COOLDOWN has passed
+	cooldown := ts.updateCooldownRemaining(data)
+
+	// A stop other than the one the bot placed last was moved by hand
+	if lastSL, ok := ts.adoptManualStop(data, currentSL); ok {
+		ts.audit(data, auditStopLoss, auditManual, lastSL, currentSL, "stop changed outside the bot")
 	}
 
 	// Determine if we need to update the stop loss
 	slNeedsUpdate := true
+	slReason := ts.stopReason(data, currentThreshold, slGuard)
+	for _, note := range []string{confirmNote, roundNote, spreadNote} {
+		if note != "" {
+			slReason += ", " + note
+		}
+	}
 	if currentSL > 0 {
 		// Calculate raw percentage of current SL
-		var currentRawSLPct float64
-		if data.IsLong {
-			if currentSL >= data.EntryPrice {
-				currentRawSLPct = ((currentSL - data.EntryPrice) / data.EntryPrice) * 100
-			} else {
-				currentRawSLPct = -((data.EntryPrice - currentSL) / data.EntryPrice) * 100
-			}
-		} else {
-			if currentSL <= data.EntryPrice {
-				currentRawSLPct = ((data.EntryPrice - currentSL) / data.EntryPrice) * 100
-			} else {
-				currentRawSLPct = -((currentSL - data.EntryPrice) / data.EntryPrice) * 100
-			}
-		}
+		currentRawSLPct := rawStopLossPct(data, currentSL)
 		currentLeveragedSLPct := currentRawSLPct * data.Leverage
 
 		// Calculate which threshold the current SL corresponds to
 		currentSLThreshold := -1
-		for i, level := range ts.stopLevels {
-			if math.Abs(currentRawSLPct-level.StopLossValue/data.Leverage) < 0.1 {
+		for i, level := range ts.stopLadder(data.Symbol).Levels {
+			lockPct := ts.lockPct(data, level.StopLossValue)
+			if ts.config().LadderInterpolate {
+				// An interpolated stop belongs to the last step whose lock it has reached
+				if currentRawSLPct < lockPct-0.1 {
+					break
+				}
+				currentSLThreshold = i
+				continue
+			}
+			if math.Abs(currentRawSLPct-lockPct) < 0.1 {
 				currentSLThreshold = i
 				break
 			}
 		}
 
 		priceDifference := math.Abs(currentSL - newSL)
-		slPriceThreshold := 0.0001 * data.EntryPrice
+		slPriceThreshold := ts.slUpdateThreshold(data)
 
 		log.Printf("DEBUG: Current SL threshold: %d, New threshold: %d, Current profit: %.2f%%",
 			currentSLThreshold, currentThreshold, data.CurrentProfitPct)
 
 		// FORCE UPDATE SL IF WE'VE CROSSED A NEW THRESHOLD
-		if currentThreshold > currentSLThreshold {
+		// Threshold crossings only force an update for the ladder strategy
+		isLadder := ts.stopLossStrategyFor(data.Symbol).Name() == strategyLadder
+		if data.ScaledIn {
+			// An add moves the average entry, so the ladder is re-anchored even if looser
+			data.StopPrice = newSL
+			slReason = "re-anchored after a scale-in"
+			log.Printf("Re-anchoring SL for %s from %.4f to %.4f after a scale-in",
+				data.Symbol, currentSL, newSL)
+		} else if data.RestoreLadder {
+			// A temporarily tightened stop goes back to the ladder, even if looser
+			data.StopPrice = newSL
+			slReason = "ladder restored after a temporary tightening"
+			log.Printf("Restoring SL for %s from %.4f to %.4f after a temporary tightening",
+				data.Symbol, currentSL, newSL)
+		} else if cooldown > 0 && currentSL != newSL {
+			// Choppy prices must not replace the stop on every cycle
+			data.StopPrice = currentSL
+			data.RawSLPct = currentRawSLPct
+			data.LeveragedSLPct = currentLeveragedSLPct
+			slNeedsUpdate = false
+			slReason = "update cooldown kept old SL"
+			log.Printf("Keeping SL for %s at %.4f for another %s of update cooldown (new %.4f)",
+				data.Symbol, currentSL, cooldown.Round(time.Second), newSL)
+		} else if isLadder && currentThreshold > currentSLThreshold {
 			// We've crossed a new threshold, definitely update
 			data.StopPrice = newSL
-			log.Printf("THRESHOLD CROSSED: Updating SL for %s from %.4f to %.4f (threshold %d -> %d)",
-				data.Symbol, currentSL, newSL, currentSLThreshold, currentThreshold)
+			ts.publish(EventThresholdCrossed, data, Event{Stage: currentThreshold, Price: newSL,
+				Reason: fmt.Sprintf("stage %d -> %d", currentSLThreshold, currentThreshold)})
 		} else if currentRawSLPct > newRawSLPct || priceDifference < slPriceThreshold {
 			// If current SL price is better or difference is too small, do not update
 			data.StopPrice = currentSL
@@ -653,6 +1546,11 @@ func (ts *TradingService) updatePositionOrders(data *PositionData) error {
 			data.RawSLPct = currentRawSLPct
 			data.LeveragedSLPct = currentLeveragedSLPct
 			slNeedsUpdate = false
+			if currentRawSLPct > newRawSLPct {
+				slReason = "current SL is tighter"
+			} else {
+				slReason = "hysteresis kept old SL"
+			}
 			log.Printf("Keeping SL for %s at %.4f (raw %.4f%% > new %.4f%% or diff %.6f < threshold %.6f)",
 				data.Symbol, currentSL, currentRawSLPct, newRawSLPct, priceDifference, slPriceThreshold)
 		} else {
@@ -668,13 +1566,25 @@ func (ts *TradingService) updatePositionOrders(data *PositionData) error {
 			data.Symbol, newSL, newRawSLPct)
 	}
 
+	switch {
+	case currentSL <= 0:
+		ts.audit(data, auditStopLoss, auditSet, 0, data.StopPrice, slReason)
+	case !slNeedsUpdate && currentSL != newSL:
+		ts.audit(data, auditStopLoss, auditKept, currentSL, newSL, slReason)
+	case slNeedsUpdate && currentSL != data.StopPrice:
+		ts.audit(data, auditStopLoss, auditMoved, currentSL, data.StopPrice, slReason)
+	}
+
 	// Calculate take profit
-	newTP := ts.calculateTakeProfit(data)
+	tpDisabled := ts.tpDisabled(data.Symbol)
+	newTP := ts.roundPrice(data.Symbol, ts.takeProfitFor(data))
 	data.TakePrice = newTP
 
+	// A position without a TP yet may have to earn it first
+	tpDeferred := !tpDisabled && currentTP <= 0 && ts.tpDeferred(data)
+
 	// Check if TP has already been reached
-	tpReached := (data.IsLong && data.MarkPrice >= data.TakePrice) ||
-		(data.IsShort && data.MarkPrice <= data.TakePrice)
+	tpReached := !tpDisabled && !tpDeferred && takeProfitReached(data)
 
 	// Debug logs for TP values
 	log.Printf("TP Debug for %s: Current TP = %.4f, New calculated TP = %.4f, Mark price = %.4f",
@@ -683,26 +1593,57 @@ func (ts *TradingService) updatePositionOrders(data *PositionData) error {
 	// Determine if we need to update the take profit
 	tpNeedsUpdate := false // Default to NOT updating
 
-	// First check if we don't have a TP yet
-	if currentTP <= 0 {
+	// Runners are left to the trailing stop, without a TP to maintain
+	if tpDisabled {
+		data.TakePrice = 0
+		if currentTP > 0 {
+			log.Printf("TP disabled for %s, removing the TP at %.4f", data.Symbol, currentTP)
+			ts.audit(data, auditTakeProfit, auditRemoved, currentTP, 0, "TP disabled")
+			if err := ts.removeTakeProfit(data); err != nil {
+				log.Printf("Warning: %v", err)
+			}
+		}
+	} else if tpDeferred {
+		data.TakePrice, data.RawTPPct, data.LeveragedTPPct = 0, 0, 0
+		ts.audit(data, auditTakeProfit, auditKept, 0, newTP, "deferred until TP_MIN_PROFIT or TP_MIN_AGE")
+		log.Printf("Deferring the first TP for %s until TP_MIN_PROFIT or TP_MIN_AGE is reached", data.Symbol)
+	} else if currentTP <= 0 {
 		// No current TP exists, we need to create one
 		tpNeedsUpdate = true
+		ts.audit(data, auditTakeProfit, auditSet, 0, newTP, ts.takeProfitStrategyFor(data.Symbol).Name()+" strategy")
 		log.Printf("No existing TP for %s, will create new TP at %.4f",
 			data.Symbol, newTP)
+	} else if data.ScaledIn {
+		// The TP order must cover the added size
+		tpNeedsUpdate = true
+		ts.audit(data, auditTakeProfit, auditMoved, currentTP, newTP, "re-anchored after a scale-in")
+		log.Printf("Re-anchoring TP for %s from %.4f to %.4f after a scale-in",
+			data.Symbol, currentTP, newTP)
+	} else if cooldown > 0 {
+		data.TakePrice = currentTP
+		if currentTP != newTP {
+			ts.audit(data, auditTakeProfit, auditKept, currentTP, newTP, "update cooldown kept old TP")
+		}
+		log.Printf("Keeping TP for %s at %.4f for another %s of update cooldown (new %.4f)",
+			data.Symbol, currentTP, cooldown.Round(time.Second), newTP)
 	} else {
 		// Calculate the difference between current and new TP as a percentage
 		tpDiffPercent := math.Abs((currentTP - newTP) / currentTP * 100)
 		log.Printf("TP difference for %s: %.4f%% (current: %.4f, new: %.4f)",
 			data.Symbol, tpDiffPercent, currentTP, newTP)
 
-		// Only update if the difference is significant (e.g., more than 0.5%)
-		if tpDiffPercent > 0.5 {
+		// Only update if the difference exceeds the TP update hysteresis
+		if math.Abs(currentTP-newTP) > ts.tpUpdateThreshold(data.Symbol, currentTP) {
 			tpNeedsUpdate = true
+			ts.audit(data, auditTakeProfit, auditMoved, currentTP, newTP, ts.takeProfitStrategyFor(data.Symbol).Name()+" strategy")
 			log.Printf("TP difference %.4f%% is significant, will update TP for %s from %.4f to %.4f",
 				tpDiffPercent, data.Symbol, currentTP, newTP)
 		} else {
 			// Keep the current TP if difference is small
 			data.TakePrice = currentTP
+			if currentTP != newTP {
+				ts.audit(data, auditTakeProfit, auditKept, currentTP, newTP, "hysteresis kept old TP")
+			}
 			log.Printf("Keeping current TP for %s at %.4f (difference %.4f%% is insignificant)",
 				data.Symbol, currentTP, tpDiffPercent)
 		}
@@ -712,6 +1653,7 @@ func (ts *TradingService) updatePositionOrders(data *PositionData) error {
 	if tpReached {
 		log.Printf("TP for %s (%s) already reached: current price = %.4f, TP price = %.4f",
 			data.Symbol, data.PositionSide, data.MarkPrice, data.TakePrice)
+		ts.publish(EventTPHit, data, Event{Price: data.TakePrice})
 		// If TP is reached but we have no TP order, we should still set one slightly above/below current price
 		if currentTP <= 0 {
 			tpNeedsUpdate = true
@@ -719,45 +1661,29 @@ func (ts *TradingService) updatePositionOrders(data *PositionData) error {
 		}
 	}
 
-	// Format values according to symbol precision
-	precision, ok := ts.symbolInfo[data.Symbol]
-	if !ok {
-		return fmt.Errorf("precision information not found for %s", data.Symbol)
+	// Orders sized for a larger position are replaced after a partial close, so a
+	// stale quantity can never flip the position when triggered
+	staleSL, staleTP := ts.oversizedProtection(data)
+	if staleSL && currentSL > 0 {
+		slNeedsUpdate = true
+	}
+	if staleTP && currentTP > 0 && !tpDisabled {
+		tpNeedsUpdate = true
 	}
 
-	quantityFormat := fmt.Sprintf("%%.%df", precision.QuantityPrecision)
-	priceFormat := fmt.Sprintf("%%.%df", precision.PricePrecision)
-
-	data.Quantity = fmt.Sprintf(quantityFormat, data.AbsAmt)
-	data.StopPriceStr = fmt.Sprintf(priceFormat, data.StopPrice)
-	data.TakePriceStr = fmt.Sprintf(priceFormat, data.TakePrice)
-
-	// Calculate potential profit and loss
-	data.PotentialProfit = (data.TakePrice - data.EntryPrice) * data.AbsAmt
-	if data.PositionAmt < 0 {
-		data.PotentialProfit = (data.EntryPrice - data.TakePrice) * data.AbsAmt
+	// A stop or target the math could not compute must never be sent
+	if !finite(data.StopPrice, data.TakePrice) || data.StopPrice < 0 || data.TakePrice < 0 {
+		return fmt.Errorf("invalid SL %g or TP %g computed for %s", data.StopPrice, data.TakePrice, data.Symbol)
 	}
 
-	// FIXED: Calculate potential loss correctly based on stop price, regardless of CurrentSLPct
-	data.PotentialLoss = 0.0
-	if data.StopPrice > 0 {
-		if data.IsLong {
-			// For long positions, loss is when price goes below entry
-			data.PotentialLoss = (data.StopPrice - data.EntryPrice) * data.AbsAmt
-		} else {
-			// For short positions, loss is when price goes above entry
-			data.PotentialLoss = (data.EntryPrice - data.StopPrice) * data.AbsAmt
-		}
-		// If the calculation results in a positive value for what should be a loss, negate it
-		if data.PotentialLoss > 0 {
-			data.PotentialLoss = -data.PotentialLoss
-		}
+	// Format prices and compute potential profit/loss
+	if err := ts.calculateRiskMetrics(data); err != nil {
+		return err
 	}
 
-	// Calculate risk-reward ratio
-	data.RiskReward = 0.0
-	if data.PotentialLoss != 0 {
-		data.RiskReward = math.Abs(data.PotentialProfit / data.PotentialLoss)
+	// Skip orders the exchange would reject for the position's size
+	if !ts.checkOrderSize(data) {
+		return nil
 	}
 
 	log.Printf("Order update status for %s: SL needs update: %v, TP needs update: %v",
@@ -765,24 +1691,15 @@ func (ts *TradingService) updatePositionOrders(data *PositionData) error {
 
 	// We'll handle SL and TP separately to avoid unnecessary cancellations
 	if slNeedsUpdate && tpNeedsUpdate {
-		// Both need updates, cancel all and recreate both
-		log.Printf("Both SL and TP need updates for %s, cancelling all orders", data.Symbol)
-		if err := ts.cancelExistingOrders(data.Symbol); err != nil {
-			log.Printf("Warning: %v", err)
-		}
-
-		// Create new SL order
-		if err := ts.createStopLossOrder(data); err != nil {
+		// Both need updates, cancel all of this side's orders and recreate both
+		log.Printf("Both SL and TP need updates for %s (%s), cancelling its orders", data.Symbol, data.PositionSide)
+		if err := ts.cancelExistingOrders(data.Symbol, data.PositionSide); err != nil {
 			log.Printf("Warning: %v", err)
-		} else {
-			log.Printf("Successfully created new SL order for %s at %s", data.Symbol, data.StopPriceStr)
 		}
 
-		// Create new TP order
-		if err := ts.createTakeProfitOrder(data); err != nil {
+		// Create new SL and TP orders together in one batch request
+		if err := ts.createBracketOrders(data); err != nil {
 			log.Printf("Warning: %v", err)
-		} else {
-			log.Printf("Successfully created new TP order for %s at %s", data.Symbol, data.TakePriceStr)
 		}
 	} else if slNeedsUpdate {
 		// Only SL needs update
@@ -792,7 +1709,7 @@ func (ts *TradingService) updatePositionOrders(data *PositionData) error {
 		ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
 		defer cancel()
 
-		openOrders, err := ts.client.NewListOpenOrdersService().Symbol(data.Symbol).Do(ctx)
+		openOrders, err := ts.exchange().OpenOrders(ctx, data.Symbol)
 		if err != nil {
 			log.Printf("Error fetching open orders for selective cancellation: %v", err)
 			return err
@@ -800,12 +1717,11 @@ func (ts *TradingService) updatePositionOrders(data *PositionData) error {
 
 		// Find and cancel only stop-loss orders
 		for _, order := range openOrders {
-			if order.Type == "STOP_MARKET" {
-				_, err := ts.client.NewCancelOrderService().Symbol(data.Symbol).OrderID(order.OrderID).Do(ctx)
-				if err != nil {
-					log.Printf("Error canceling SL order %d for %s: %v", order.OrderID, data.Symbol, err)
+			if isStopLossOrder(order) && orderMatchesSide(order, data.PositionSide) && ts.ownsOrder(order) {
+				if err := ts.exchange().CancelOrder(ctx, order); err != nil {
+					log.Printf("Error canceling SL order %s for %s: %v", order.ID, data.Symbol, err)
 				} else {
-					log.Printf("Successfully cancelled SL order %d for %s", order.OrderID, data.Symbol)
+					log.Printf("Successfully cancelled SL order %s for %s", order.ID, data.Symbol)
 				}
 			}
 		}
@@ -813,8 +1729,6 @@ func (ts *TradingService) updatePositionOrders(data *PositionData) error {
 		// Create new SL order
 		if err := ts.createStopLossOrder(data); err != nil {
 			log.Printf("Warning: %v", err)
-		} else {
-			log.Printf("Successfully created new SL order for %s at %s", data.Symbol, data.StopPriceStr)
 		}
 	} else if tpNeedsUpdate {
 		// Only TP needs update
@@ -824,7 +1738,7 @@ func (ts *TradingService) updatePositionOrders(data *PositionData) error {
 		ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
 		defer cancel()
 
-		openOrders, err := ts.client.NewListOpenOrdersService().Symbol(data.Symbol).Do(ctx)
+		openOrders, err := ts.exchange().OpenOrders(ctx, data.Symbol)
 		if err != nil {
 			log.Printf("Error fetching open orders for selective cancellation: %v", err)
 			return err
@@ -832,12 +1746,11 @@ func (ts *TradingService) updatePositionOrders(data *PositionData) error {
 
 		// Find and cancel only take-profit orders
 		for _, order := range openOrders {
-			if order.Type == "TAKE_PROFIT_MARKET" {
-				_, err := ts.client.NewCancelOrderService().Symbol(data.Symbol).OrderID(order.OrderID).Do(ctx)
-				if err != nil {
-					log.Printf("Error canceling TP order %d for %s: %v", order.OrderID, data.Symbol, err)
+			if order.Type == orderTypeTakeProfitMarket && orderMatchesSide(order, data.PositionSide) && ts.ownsOrder(order) {
+				if err := ts.exchange().CancelOrder(ctx, order); err != nil {
+					log.Printf("Error canceling TP order %s for %s: %v", order.ID, data.Symbol, err)
 				} else {
-					log.Printf("Successfully cancelled TP order %d for %s", order.OrderID, data.Symbol)
+					log.Printf("Successfully cancelled TP order %s for %s", order.ID, data.Symbol)
 				}
 			}
 		}
@@ -845,8 +1758,6 @@ func (ts *TradingService) updatePositionOrders(data *PositionData) error {
 		// Create new TP order
 		if err := ts.createTakeProfitOrder(data); err != nil {
 			log.Printf("Warning: %v", err)
-		} else {
-			log.Printf("Successfully created new TP order for %s at %s", data.Symbol, data.TakePriceStr)
 		}
 	} else {
 		log.Printf("No changes needed for %s orders", data.Symbol)
@@ -855,42 +1766,24 @@ func (ts *TradingService) updatePositionOrders(data *PositionData) error {
 	return nil
 }
 
-// processPosition handles a single position and manages its stop-loss and take-profit orders.
-func (ts *TradingService) processPosition(position *binance.PositionRisk) error {
+// newPositionData parses a position into PositionData with its profit percentages.
+// It returns nil for empty positions.
+func newPositionData(position *Position) (*PositionData, error) {
 	// Skip empty positions
-	posAmt, err := strconv.ParseFloat(position.PositionAmt, 64)
-	if err != nil {
-		return fmt.Errorf("error parsing position amount: %w", err)
-	}
-
+	posAmt := position.PositionAmt
 	if posAmt == 0 {
-		return nil
+		return nil, nil
 	}
 
 	// Extract position details
-	entryPrice, err := strconv.ParseFloat(position.EntryPrice, 64)
-	if err != nil {
-		return fmt.Errorf("error parsing entry price: %w", err)
-	}
-
-	markPrice, err := strconv.ParseFloat(position.MarkPrice, 64)
-	if err != nil {
-		return fmt.Errorf("error parsing mark price: %w", err)
-	}
-
-	leverage, err := strconv.ParseFloat(position.Leverage, 64)
-	if err != nil {
-		return fmt.Errorf("error parsing leverage: %w", err)
-	}
+	entryPrice := position.EntryPrice
+	markPrice := position.MarkPrice
+	leverage := position.Leverage
+	liquidationPrice := position.LiquidationPrice
 
 	symbol := position.Symbol
 	positionSide := position.PositionSide
 
-	// Check if we have precision info for this symbol
-	if _, ok := ts.symbolInfo[symbol]; !ok {
-		return fmt.Errorf("precision information not found for %s, skipping", symbol)
-	}
-
 	// Calculate position parameters
 	absAmt := math.Abs(posAmt)
 	isShort := (positionSide == "SHORT" || (positionSide == "BOTH" && posAmt < 0))
@@ -918,6 +1811,109 @@ func (ts *TradingService) processPosition(position *binance.PositionRisk) error
 		IsShort:          isShort,
 		CurrentProfitPct: leveragedProfitPct,
 		RawProfitPct:     rawProfitPct,
+		LiquidationPrice: liquidationPrice,
+		MarginType:       position.MarginType,
+		ContractSize:     position.ContractSize,
+		PnLAsset:         position.MarginAsset,
+	}
+	return data, nil
+}
+
+// checkPositionData rejects malformed exchange data, which must never reach the
+// order math.
+func checkPositionData(data *PositionData) error {
+	if !finite(data.PositionAmt, data.EntryPrice, data.MarkPrice, data.Leverage) ||
+		data.EntryPrice <= 0 || data.MarkPrice <= 0 || data.Leverage <= 0 {
+		return fmt.Errorf("invalid position data for %s %s: amount %g, entry %g, mark %g, leverage %g",
+			data.Symbol, data.PositionSide, data.PositionAmt, data.EntryPrice, data.MarkPrice, data.Leverage)
+	}
+	return nil
+}
+
+// processPosition handles a single position and manages its stop-loss and take-profit orders.
+func (ts *TradingService) processPosition(position *Position) error {
+	data, err := newPositionData(position)
+	if err != nil || data == nil {
+		return err
+	}
+	if err := checkPositionData(data); err != nil {
+		return err
+	}
+
+	// Serialize processing of a position between the REST cycle and stream-triggered refreshes
+	unlock := ts.lockPosition(data.Symbol, data.PositionSide)
+	defer unlock()
+
+	// Check if we have precision info for this symbol
+	if _, ok := ts.symbolPrecision(data.Symbol); !ok {
+		return fmt.Errorf("precision information not found for %s, skipping", data.Symbol)
+	}
+
+	// Label the position with the strategy that opened it
+	ts.tagPosition(data)
+
+	// Positions a trader manages by hand are only reported
+	if ts.isSymbolManual(data.Symbol) {
+		return ts.reportManualPosition(data)
+	}
+
+	// Re-base positions built in several entries on their average entry
+	adds := ts.checkScaleIn(data)
+
+	// Report or correct leverage and margin type that differ from the configured ones
+	ts.checkLeverage(data)
+
+	// Warn (and optionally reduce) when the position is close to liquidation
+	ts.checkLiquidationDistance(data)
+
+	// Alert on high auto-deleverage rank and isolated margin ratio
+	ts.checkMarginRisk(data)
+
+	// Fold funding into profit and handle positions paying extreme funding
+	ts.applyFunding(data)
+	if data.AbsAmt == 0 {
+		return nil
+	}
+
+	// Count commissions and funding towards profit
+	ts.applyFees(data)
+
+	// Exit or protect positions held past their maximum holding time
+	ts.checkHoldingTime(data)
+	if data.AbsAmt == 0 {
+		return nil
+	}
+
+	// Flatten or tighten inside scheduled windows
+	ts.checkSchedule(data)
+	if data.AbsAmt == 0 {
+		return nil
+	}
+
+	// Close at market when a triggered stop-limit has not filled
+	ts.checkStopLimitFill(data)
+	if data.AbsAmt == 0 {
+		return nil
+	}
+
+	// Pick the ladder for the symbol's current trend
+	ts.checkTrend(data)
+
+	// Hold the stop at the last ladder stage confirmed
+	ts.confirmLadderStage(data)
+
+	// Remember the peak profit so the ladder never trails back on a pullback
+	ts.recordPeakProfit(data)
+
+	// Move the stop to breakeven around watched economic releases
+	ts.checkCalendar(data)
+	data.AccountTighten = ts.accountRetracing()
+	ts.checkGrid(data)
+
+	// Realize part of the profit at each new ladder stage
+	ts.checkScaleOut(data)
+	if data.AbsAmt == 0 {
+		return nil
 	}
 
 	// Update orders (this will handle SL and TP checking and placement)
@@ -925,13 +1921,25 @@ func (ts *TradingService) processPosition(position *binance.PositionRisk) error
 		return fmt.Errorf("error updating orders: %w", err)
 	}
 
-	// Format and send position message
-	msg := formatPositionMessage(data)
-	fmt.Println(msg)
+	// Rest the next scale-in at its drawdown level
+	ts.placeScaleIn(data, adds)
+
+	// Add to winners as they reach new ladder stages
+	ts.checkPyramid(data)
 
-	if err := sendTelegramMessage(msg); err != nil {
-		log.Printf("Error sending Telegram message: %v", err)
+	// Remember the ladder stage so the mark price stream can detect the next crossing
+	ts.trackPosition(data)
+	ts.recordPosition(data)
+
+	// Format and send position message, deduplicated and rate limited per symbol
+	msg := ts.formatPositionMessage(data)
+	if ts.config().ObserveOnly {
+		msg = observeOnlyBanner + "\n" + msg
 	}
+	fmt.Println(msg)
+
+	ts.notifyPosition(data, msg)
+	ts.publish(EventPositionSnapshot, data, Event{Stage: ts.ladderStage(data), Position: data})
 
 	return nil
 }
@@ -940,28 +1948,93 @@ func (ts *TradingService) processPosition(position *binance.PositionRisk) error
 func (ts *TradingService) processPositions() error {
 	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
 	defer cancel()
+	ts.recorder.record(sessionKindCycle, "", nil, nil, nil)
 
 	// Get all positions
-	positions, err := ts.client.NewGetPositionRiskService().Do(ctx)
+	positions, err := ts.exchange().Positions(ctx, "")
+	ts.recordCycle(err)
 	if err != nil {
+		ts.events.Publish(Event{Type: EventError, Error: err.Error()})
 		return fmt.Errorf("error getting positions: %w", err)
 	}
 
-	// Process positions concurrently with a wait group
-	var wg sync.WaitGroup
+	// Standby instances only watch the leader lease
+	if !ts.isLeader() {
+		log.Println("Standby instance; skipping processing cycle")
+		return nil
+	}
+
+	// Start every cycle from fresh open orders
+	ts.orderCache().Reset()
+
+	// Report the positions that closed since the previous cycle
+	ts.detectClosedPositions(positions)
+
+	// Leave orders untouched while paused through the CLI, control API or Telegram
+	ts.mu.Lock()
+	ts.syncControl()
+	ts.mu.Unlock()
+	if ts.isPaused() {
+		log.Println("Order management paused; skipping processing cycle")
+		return nil
+	}
+
+	// Leave orders untouched during scheduled maintenance windows
+	now := time.Now()
+	ts.checkScheduleTransition(now)
+	if ts.scheduleActive(scheduleActionPause, now) {
+		log.Println("Inside a scheduled pause window; skipping processing cycle")
+		return nil
+	}
+
+	ts.refreshCalendar()
+	ts.forgetScaleIns(positions)
+
+	// Guard the total unrealized PnL before managing individual positions
+	closed := ts.checkAccountPnL(positions)
+	ts.checkFreeMargin()
+
+	// Rebuild the tracked set from the positions that are still open
+	ts.resetTracked()
+
+	// Process positions with a bounded pool of workers
+	workers := ts.config().MaxConcurrency
+	if workers <= 0 {
+		workers = 1
+	}
+	jobs := make(chan *Position)
 	errChan := make(chan error, len(positions))
 
-	for _, position := range positions {
+	var wg sync.WaitGroup
+	for range workers {
 		wg.Add(1)
-		go func(pos *binance.PositionRisk) {
+		go func() {
 			defer wg.Done()
-			if err := ts.processPosition(pos); err != nil {
-				errChan <- fmt.Errorf("error processing position %s: %w", pos.Symbol, err)
+			for pos := range jobs {
+				if err := ts.processPosition(pos); err != nil {
+					ts.events.Publish(Event{Type: EventError, Symbol: pos.Symbol, PositionSide: pos.PositionSide, Error: err.Error()})
+					errChan <- fmt.Errorf("error processing position %s: %w", pos.Symbol, err)
+				}
+			}
+		}()
+	}
+
+	for _, position := range positions {
+		// Skip symbols excluded by the whitelist/blacklist filters
+		if !ts.isSymbolManaged(position.Symbol) || closed[trackedKey(position.Symbol, position.PositionSide)] {
+			continue
+		}
+		if ts.isSymbolPaused(position.Symbol) {
+			if position.PositionAmt != 0 {
+				log.Printf("Order management of %s paused; skipping it", position.Symbol)
 			}
-		}(position)
+			continue
+		}
+		jobs <- position
 	}
 
-	// Wait for all goroutines to complete
+	// Wait for all workers to drain the queue
+	close(jobs)
 	wg.Wait()
 	close(errChan)
 
@@ -981,27 +2054,8 @@ const (
 func main() {
 	// Set up logging
 	log.SetFlags(log.LstdFlags | log.Lshortfile)
-	log.Println("Starting Binance Futures Guard Bot")
-
-	// Load configuration
-	config := loadConfig()
-
-	// Setup Binance client
-	client, err := setupBinanceClient()
-	if err != nil {
-		log.Fatalf("Error connecting to Binance API: %v", err)
-	}
-
-	// Create trading service
-	tradingService, err := NewTradingService(client, config)
-	if err != nil {
-		log.Fatalf("Error initializing trading service: %v", err)
-	}
 
-	// Process all positions
-	if err := tradingService.processPositions(); err != nil {
-		log.Fatalf("Error processing positions: %v", err)
+	if err := newRootCommand().Execute(); err != nil {
+		os.Exit(1)
 	}
-
-	log.Println("Processing complete")
 }
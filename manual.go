@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+)
+
+// manualBanner heads reports of positions whose symbol is under manual control.
+const manualBanner = "✋ MANUAL CONTROL: orders left to the trader until unlocked"
+
+// reportManualPosition reports a position of a symbol under manual control with
+// the stop and target on the book, whoever placed them, and raises its
+// liquidation and margin alerts. No order is placed, moved or cancelled and the
+// position is never reduced, so the bot does not fight the trader.
+func (ts *TradingService) reportManualPosition(data *PositionData) error {
+	data.Manual = true
+
+	// Alerts still go out; the configured actions are left to the trader
+	ts.checkLiquidationDistance(data)
+	ts.checkMarginRisk(data)
+	ts.applyFees(data)
+	ts.recordPeakProfit(data)
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+
+	openOrders, err := ts.exchange().OpenOrders(ctx, data.Symbol)
+	if err != nil {
+		return fmt.Errorf("error fetching open orders for %s: %w", data.Symbol, err)
+	}
+	for _, order := range openOrders {
+		if !orderMatchesSide(order, data.PositionSide) {
+			continue
+		}
+		switch {
+		case isStopLossOrder(order) && data.StopPrice == 0:
+			data.StopPrice = order.StopPrice
+		case order.Type == orderTypeTakeProfitMarket && data.TakePrice == 0:
+			data.TakePrice = order.StopPrice
+		}
+	}
+	if data.StopPrice > 0 {
+		data.RawSLPct = rawStopLossPct(data, data.StopPrice)
+		data.LeveragedSLPct = data.RawSLPct * data.Leverage
+		data.CurrentSLPct = math.Abs(data.LeveragedSLPct)
+	} else {
+		// No live stop; mark it so reports show NONE
+		data.CurrentSLPct = -1
+	}
+	if data.TakePrice > 0 {
+		data.RawTPPct = math.Abs((data.TakePrice - data.EntryPrice) / data.EntryPrice * 100)
+		data.LeveragedTPPct = data.RawTPPct * data.Leverage
+	}
+	if err := ts.calculateRiskMetrics(data); err != nil {
+		return err
+	}
+	if data.TakePrice <= 0 {
+		data.PotentialProfit = 0
+	}
+	ts.recordPosition(data)
+
+	msg := manualBanner + "\n" + ts.formatPositionMessage(data)
+	if data.StopPrice <= 0 {
+		msg += "\n⚠️ No stop-loss on the book"
+	}
+	fmt.Println(msg)
+
+	ts.notifyPosition(data, msg)
+	ts.publish(EventPositionSnapshot, data, Event{Stage: ts.ladderStage(data), Position: data})
+	return nil
+}
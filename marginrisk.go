@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+)
+
+// Margin risk actions.
+const (
+	marginRiskActionWarn      = "warn"
+	marginRiskActionAddMargin = "add_margin"
+	marginRiskActionReduce    = "reduce"
+)
+
+// checkMarginRisk reads the auto-deleverage quantile and isolated margin ratio of
+// a position and alerts when either reaches its threshold. The configured action
+// is taken once when an alert is raised, and again only after the position has
+// recovered and crossed a threshold anew.
+func (ts *TradingService) checkMarginRisk(data *PositionData) {
+	if ts.config().ADLAlertQuantile <= 0 && ts.config().MarginRatioAlertPct <= 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+
+	risks, err := ts.client().PositionRisk(ctx, data.Symbol)
+	if err != nil {
+		log.Printf("Warning: error fetching position risk for %s: %v", data.Symbol, err)
+		return
+	}
+	isolatedMargin := 0.0
+	for _, risk := range risks {
+		if risk.PositionSide != data.PositionSide {
+			continue
+		}
+		data.ADLQuantile = int(risk.Adl)
+		isolatedMargin = parseFloatOrZero(risk.IsolatedMargin)
+		if maintMargin := parseFloatOrZero(risk.MaintMargin); isolatedMargin > 0 {
+			data.MarginRatio = maintMargin / isolatedMargin * 100
+		}
+	}
+
+	var alerts []string
+	if ts.config().ADLAlertQuantile > 0 && data.ADLQuantile >= ts.config().ADLAlertQuantile {
+		alerts = append(alerts, fmt.Sprintf("ADL quantile %d (threshold %d)", data.ADLQuantile, ts.config().ADLAlertQuantile))
+	}
+	marginCall := ts.config().MarginRatioAlertPct > 0 && data.MarginRatio >= ts.config().MarginRatioAlertPct
+	if marginCall {
+		alerts = append(alerts, fmt.Sprintf("margin ratio %.2f%% (threshold %.2f%%)", data.MarginRatio, ts.config().MarginRatioAlertPct))
+	}
+
+	key := trackedKey(data.Symbol, data.PositionSide)
+	alert := strings.Join(alerts, ", ")
+	ts.mu.Lock()
+	raised := alert != "" && ts.marginRisk[key] == ""
+	if alert == "" {
+		delete(ts.marginRisk, key)
+	} else {
+		ts.marginRisk[key] = alert
+	}
+	ts.mu.Unlock()
+	if !raised {
+		return
+	}
+
+	msg := fmt.Sprintf("🚨 %s %s is at risk: %s", data.Symbol, data.PositionSide, alert)
+	log.Println(msg)
+	ts.notify(SeverityCritical, msg)
+	if data.Manual {
+		return
+	}
+
+	switch ts.config().MarginRiskAction {
+	case marginRiskActionAddMargin:
+		// Extra margin only helps isolated positions against a margin call
+		if !marginCall || isolatedMargin <= 0 || ts.config().MarginAddAmount <= 0 {
+			return
+		}
+		if ts.config().ObserveOnly {
+			log.Printf("OBSERVE_ONLY: would add %g margin to %s %s", ts.config().MarginAddAmount, data.Symbol, data.PositionSide)
+			return
+		}
+		if err := ts.client().AddPositionMargin(ctx, data.Symbol, data.PositionSide, ts.config().MarginAddAmount); err != nil {
+			log.Printf("Warning: error adding margin to %s %s: %v", data.Symbol, data.PositionSide, err)
+			ts.notify(SeverityCritical, fmt.Sprintf("❌ Failed to add margin to %s %s: %v", data.Symbol, data.PositionSide, err))
+			return
+		}
+		log.Printf("Added %g margin to %s %s", ts.config().MarginAddAmount, data.Symbol, data.PositionSide)
+	case marginRiskActionReduce:
+		if err := ts.reducePosition(data, ts.config().MarginReducePct, "margin risk"); err != nil {
+			log.Printf("Warning: %v", err)
+		}
+	}
+}
+
+// parseMarginRiskAction normalizes the configured margin risk action.
+func parseMarginRiskAction(value string) (string, error) {
+	switch action := strings.ToLower(strings.TrimSpace(value)); action {
+	case marginRiskActionWarn, marginRiskActionAddMargin, marginRiskActionReduce:
+		return action, nil
+	default:
+		return "", fmt.Errorf("want %s, %s or %s", marginRiskActionWarn, marginRiskActionAddMargin, marginRiskActionReduce)
+	}
+}
@@ -0,0 +1,201 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"math"
+	"os"
+	"slices"
+	"strings"
+	"text/template"
+)
+
+// defaultNotifyLocale is the language of position summaries unless NOTIFY_LOCALE is set.
+const defaultNotifyLocale = "en"
+
+// defaultPositionTemplate renders the position summary. Labels come from the locale
+// bundle through t, so the same template serves every language.
+const defaultPositionTemplate = `📊 {{.Symbol}} {{.Side}}
+💵 {{t "entry"}}: {{price .EntryPrice}}  📉 {{t "mark"}}: {{price .MarkPrice}}
+💹 {{t "pnl"}}: {{pct .CurrentProfitPct}}% ({{pct .RawProfitPct}}% x{{.Lev}})
+🛑 {{t "sl"}}: {{.StopText}} ({{pct .RawSLPct}}% / {{pct .LeveragedSLPct}}% x{{.Lev}})
+🎯 {{t "tp"}}: {{.TakeText}} ({{pct .RawTPPct}}% / {{pct .LeveragedTPPct}}% x{{.Lev}})
+⚖️ {{t "risk_reward"}}: {{pct .RiskReward}}
+💰 {{t "potential_profit"}}: {{.PnL .PotentialProfit}}
+💸 {{t "potential_loss"}}: {{.PnL .LossDisplay}}
+{{- if or .FundingRate .PredictedFundingRate}}
+⏱️ {{t "funding"}}: {{rate .FundingRate}}% ({{t "next"}} {{rate .PredictedFundingRate}}%, {{t "accrued"}} {{.Amount .AccruedFunding}})
+{{- end}}
+{{- if .Fees}}
+🧾 {{t "fees"}}: {{.Amount .Fees}} ({{t "fees_included"}})
+{{- end}}
+{{- if ge .LiquidationDist 0.0}}
+☠️ {{t "liquidation"}}: {{price .LiquidationPrice}} ({{printf (t "liquidation_distance") (pct .LiquidationDist)}})
+{{- end}}
+{{- if .Tag}}
+🏷️ {{t "tag"}}: {{.Tag}}
+{{- end}}`
+
+// localeBundles holds the labels of position summaries per language. Missing
+// labels fall back to English.
+var localeBundles = map[string]map[string]string{
+	"en": {
+		"long": "LONG", "short": "SHORT", "none": "NONE",
+		"entry": "Entry", "mark": "Mark", "pnl": "P/L", "sl": "SL", "tp": "TP",
+		"risk_reward": "Risk/Reward", "potential_profit": "Potential Profit", "potential_loss": "Potential Loss",
+		"funding": "Funding", "next": "next", "accrued": "accrued",
+		"fees": "Fees and funding", "fees_included": "included in P/L",
+		"liquidation": "Liquidation", "liquidation_distance": "%s%% away", "tag": "Strategy",
+	},
+	"vi": {
+		"long": "LONG", "short": "SHORT", "none": "KHÔNG CÓ",
+		"entry": "Giá vào", "mark": "Giá đánh dấu", "pnl": "Lãi/Lỗ", "sl": "SL", "tp": "TP",
+		"risk_reward": "Rủi ro/Lợi nhuận", "potential_profit": "Lợi nhuận dự kiến", "potential_loss": "Thua lỗ dự kiến",
+		"funding": "Funding", "next": "kỳ tới", "accrued": "tích lũy",
+		"fees": "Phí và funding", "fees_included": "đã tính vào Lãi/Lỗ",
+		"liquidation": "Thanh lý", "liquidation_distance": "cách %s%%", "tag": "Chiến lược",
+	},
+	"zh": {
+		"long": "多", "short": "空", "none": "无",
+		"entry": "开仓价", "mark": "标记价", "pnl": "盈亏", "sl": "止损", "tp": "止盈",
+		"risk_reward": "风险回报比", "potential_profit": "潜在盈利", "potential_loss": "潜在亏损",
+		"funding": "资金费率", "next": "下期", "accrued": "累计",
+		"fees": "手续费和资金费", "fees_included": "已计入盈亏",
+		"liquidation": "强平价", "liquidation_distance": "距离 %s%%", "tag": "策略",
+	},
+	"ru": {
+		"long": "ЛОНГ", "short": "ШОРТ", "none": "НЕТ",
+		"entry": "Вход", "mark": "Маркировка", "pnl": "П/У", "sl": "SL", "tp": "TP",
+		"risk_reward": "Риск/Прибыль", "potential_profit": "Потенциальная прибыль", "potential_loss": "Потенциальный убыток",
+		"funding": "Фандинг", "next": "след.", "accrued": "накоплено",
+		"fees": "Комиссии и фандинг", "fees_included": "учтено в П/У",
+		"liquidation": "Ликвидация", "liquidation_distance": "в %s%%", "tag": "Стратегия",
+	},
+}
+
+// positionTemplate renders position summaries with a Go template and a locale bundle.
+type positionTemplate struct {
+	tmpl   *template.Template
+	labels map[string]string
+}
+
+// positionView is the data a position template is executed with: the position
+// plus the values the summary derives from it.
+type positionView struct {
+	*PositionData
+	Side            string  // Direction icon and label, e.g. "🟢 LONG"
+	Lev             int     // Leverage as a whole number
+	StopText        string  // Stop price, or the "none" label without a stop
+	TakeText        string  // Take-profit price, or the "none" label without one
+	LossDisplay     float64 // Potential loss, negative when the stop is in profit
+	LiquidationDist float64 // Distance to liquidation in %, -1 without one
+}
+
+// PnL formats amount in the settlement asset of the position, with the display
+// currency equivalent when configured.
+func (v positionView) PnL(amount float64) string {
+	return formatPositionPnL(v.PositionData, amount)
+}
+
+// Amount formats amount in the settlement asset of the position.
+func (v positionView) Amount(amount float64) string {
+	return formatPnL(amount, v.PnLAsset)
+}
+
+// newPositionTemplate loads the position template of config: the template file
+// NOTIFY_TEMPLATE or the built-in one, with the labels of NOTIFY_LOCALE overridden
+// by NOTIFY_LOCALE_FILE.
+func newPositionTemplate(config Config) (*positionTemplate, error) {
+	labels := make(map[string]string)
+	for key, label := range localeBundles[defaultNotifyLocale] {
+		labels[key] = label
+	}
+	for key, label := range localeBundles[config.NotifyLocale] {
+		labels[key] = label
+	}
+	if config.NotifyLocaleFile != "" {
+		content, err := os.ReadFile(config.NotifyLocaleFile)
+		if err != nil {
+			return nil, fmt.Errorf("error reading locale file: %w", err)
+		}
+		var overrides map[string]string
+		if err := json.Unmarshal(content, &overrides); err != nil {
+			return nil, fmt.Errorf("error parsing locale file %s: %w", config.NotifyLocaleFile, err)
+		}
+		for key, label := range overrides {
+			labels[key] = label
+		}
+	}
+
+	text := defaultPositionTemplate
+	if config.NotifyTemplate != "" {
+		content, err := os.ReadFile(config.NotifyTemplate)
+		if err != nil {
+			return nil, fmt.Errorf("error reading notification template: %w", err)
+		}
+		text = strings.TrimRight(string(content), "\n")
+	}
+
+	t := &positionTemplate{labels: labels}
+	tmpl, err := template.New("position").Funcs(template.FuncMap{
+		"t":     t.label,
+		"price": func(v float64) string { return fmt.Sprintf("%.8f", v) },
+		"pct":   func(v float64) string { return fmt.Sprintf("%.2f", v) },
+		"rate":  func(v float64) string { return fmt.Sprintf("%.4f", v) },
+	}).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing notification template: %w", err)
+	}
+	t.tmpl = tmpl
+	return t, nil
+}
+
+// label returns the label of key, or key itself when no bundle defines it.
+func (t *positionTemplate) label(key string) string {
+	if label, ok := t.labels[key]; ok {
+		return label
+	}
+	return key
+}
+
+// render formats the summary of data.
+func (t *positionTemplate) render(data *PositionData) (string, error) {
+	view := positionView{
+		PositionData:    data,
+		Side:            "🔴 " + t.label("short"),
+		Lev:             int(data.Leverage),
+		StopText:        t.label("none"),
+		TakeText:        t.label("none"),
+		LossDisplay:     math.Abs(data.PotentialLoss),
+		LiquidationDist: liquidationDistancePct(data),
+	}
+	if data.IsLong {
+		view.Side = "🟢 " + t.label("long")
+	}
+	if data.CurrentSLPct >= 0 && data.StopPrice > 0 {
+		view.StopText = fmt.Sprintf("%.8f", data.StopPrice)
+	}
+	if data.TakePrice > 0 {
+		view.TakeText = fmt.Sprintf("%.8f", data.TakePrice)
+	}
+	// Add negative sign to potential loss when RawSLPct is negative
+	if data.RawSLPct < 0 {
+		view.LossDisplay = -view.LossDisplay
+	}
+
+	var b strings.Builder
+	if err := t.tmpl.Execute(&b, view); err != nil {
+		return "", fmt.Errorf("error rendering position message for %s: %w", data.Symbol, err)
+	}
+	return b.String(), nil
+}
+
+// parseNotifyLocale normalizes the configured notification locale.
+func parseNotifyLocale(value string) (string, error) {
+	locale := strings.ToLower(strings.TrimSpace(value))
+	if _, ok := localeBundles[locale]; ok {
+		return locale, nil
+	}
+	return "", fmt.Errorf("want one of %s", strings.Join(slices.Sorted(maps.Keys(localeBundles)), ", "))
+}
@@ -0,0 +1,37 @@
+-- Protective orders and ladder progress of the open positions
+CREATE TABLE IF NOT EXISTS positions (
+    key           text PRIMARY KEY,
+    symbol        text NOT NULL,
+    position_side text NOT NULL,
+    stop_price    double precision NOT NULL DEFAULT 0,
+    take_price    double precision NOT NULL DEFAULT 0,
+    stage         integer NOT NULL DEFAULT 0,
+    quantity      double precision NOT NULL DEFAULT 0,
+    opened_at     timestamptz,
+    updated_at    timestamptz NOT NULL,
+    data          jsonb NOT NULL
+);
+
+-- Closed positions, kept for the statistics
+CREATE TABLE IF NOT EXISTS trades (
+    symbol        text NOT NULL,
+    position_side text NOT NULL,
+    closed_at     timestamptz NOT NULL,
+    opened_at     timestamptz,
+    reason        text NOT NULL,
+    realized_pnl  double precision NOT NULL,
+    fees          double precision NOT NULL DEFAULT 0,
+    pnl_asset     text NOT NULL DEFAULT '',
+    data          jsonb NOT NULL,
+    PRIMARY KEY (symbol, position_side, closed_at)
+);
+
+CREATE INDEX IF NOT EXISTS trades_closed_at_idx ON trades (closed_at);
+
+-- Notification history and control state, a single row
+CREATE TABLE IF NOT EXISTS bot_state (
+    id         integer PRIMARY KEY CHECK (id = 1),
+    notices    jsonb NOT NULL,
+    control    jsonb NOT NULL,
+    updated_at timestamptz NOT NULL
+);
@@ -0,0 +1,2 @@
+-- Transfers to sub-accounts, kept for the daily top-up cap
+ALTER TABLE bot_state ADD COLUMN IF NOT EXISTS top_ups jsonb NOT NULL DEFAULT '[]';
@@ -0,0 +1,14 @@
+-- SL/TP decisions of the positions with their reasons
+CREATE TABLE IF NOT EXISTS audit_log (
+    time          timestamptz NOT NULL,
+    symbol        text NOT NULL,
+    position_side text NOT NULL,
+    order_kind    text NOT NULL,
+    action        text NOT NULL,
+    from_price    double precision NOT NULL DEFAULT 0,
+    to_price      double precision NOT NULL DEFAULT 0,
+    reason        text NOT NULL,
+    PRIMARY KEY (symbol, position_side, order_kind, time)
+);
+
+CREATE INDEX IF NOT EXISTS audit_log_time_idx ON audit_log (time);
@@ -0,0 +1,469 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"futures-guard/settings"
+)
+
+// notifierTimeout bounds a single notification request.
+const notifierTimeout = 10 * time.Second
+
+// Severity ranks notifications so channels can filter out routine messages.
+type Severity int
+
+// Notification severities, from routine to urgent.
+const (
+	SeverityInfo Severity = iota
+	SeverityWarning
+	SeverityCritical
+)
+
+// String returns the configuration name of the severity.
+func (s Severity) String() string {
+	switch s {
+	case SeverityWarning:
+		return "warning"
+	case SeverityCritical:
+		return "critical"
+	default:
+		return "info"
+	}
+}
+
+// parseSeverity converts a configured severity name into a Severity.
+func parseSeverity(value string) (Severity, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "", "info":
+		return SeverityInfo, nil
+	case "warning", "warn":
+		return SeverityWarning, nil
+	case "critical", "alert":
+		return SeverityCritical, nil
+	default:
+		return SeverityInfo, fmt.Errorf("unknown severity %q", value)
+	}
+}
+
+// channelMinSeverity is the default minimum severity of the channels that are
+// meant for alerts needing action rather than routine summaries.
+var channelMinSeverity = map[string]Severity{
+	"email":    SeverityCritical,
+	"pushover": SeverityCritical,
+	"ntfy":     SeverityCritical,
+}
+
+// Notifier delivers a message to a single notification channel.
+type Notifier interface {
+	Name() string
+	Send(message string) error
+}
+
+// ImageNotifier is a Notifier that can also send a message with a PNG image attached.
+type ImageNotifier interface {
+	SendImage(message string, image []byte) error
+}
+
+// DigestNotifier is a Notifier with its own layout for the daily digest.
+type DigestNotifier interface {
+	Notifier
+	SendDigest(summary *DailySummary, message string) error
+}
+
+// notifierChannel pairs a notifier with the minimum severity it receives, and
+// whether it receives the daily digest whatever that severity.
+type notifierChannel struct {
+	notifier    Notifier
+	minSeverity Severity
+	digest      bool
+}
+
+// MultiNotifier fans a notification out to every channel whose minimum severity it meets.
+type MultiNotifier struct {
+	channels []notifierChannel
+	quiet    quietHours
+}
+
+// Add registers a notifier that receives messages of at least minSeverity.
+func (m *MultiNotifier) Add(notifier Notifier, minSeverity Severity) {
+	m.channels = append(m.channels, notifierChannel{notifier: notifier, minSeverity: minSeverity})
+}
+
+// AddDigest registers a notifier that receives messages of at least minSeverity
+// and every daily digest.
+func (m *MultiNotifier) AddDigest(notifier DigestNotifier, minSeverity Severity) {
+	m.channels = append(m.channels, notifierChannel{notifier: notifier, minSeverity: minSeverity, digest: true})
+}
+
+// NotifyDigest sends the daily digest summary, formatted as message, to the
+// channels receiving info notifications or registered for the digest.
+func (m *MultiNotifier) NotifyDigest(summary *DailySummary, message string) error {
+	if m.quiet.suppresses(SeverityInfo, time.Now()) {
+		log.Printf("Quiet hours: suppressed %s notification", SeverityInfo)
+		return nil
+	}
+	var errs []error
+	for _, ch := range m.channels {
+		var err error
+		if sender, ok := ch.notifier.(DigestNotifier); ok && (ch.digest || ch.minSeverity <= SeverityInfo) {
+			err = sender.SendDigest(summary, message)
+		} else if ch.minSeverity <= SeverityInfo {
+			err = ch.notifier.Send(message)
+		} else {
+			continue
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", ch.notifier.Name(), err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Notify sends message to the matching channels and returns the combined delivery errors.
+func (m *MultiNotifier) Notify(severity Severity, message string) error {
+	return m.NotifyImage(severity, message, nil)
+}
+
+// NotifyImage sends message to the matching channels with image attached on
+// those that accept images, and returns the combined delivery errors.
+func (m *MultiNotifier) NotifyImage(severity Severity, message string, image []byte) error {
+	if m.quiet.suppresses(severity, time.Now()) {
+		log.Printf("Quiet hours: suppressed %s notification", severity)
+		return nil
+	}
+	var errs []error
+	for _, ch := range m.channels {
+		if severity < ch.minSeverity {
+			continue
+		}
+		var err error
+		if sender, ok := ch.notifier.(ImageNotifier); ok && image != nil {
+			err = sender.SendImage(message, image)
+		} else if sender, ok := ch.notifier.(SeverityNotifier); ok {
+			err = sender.SendSeverity(severity, message)
+		} else {
+			err = ch.notifier.Send(message)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", ch.notifier.Name(), err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// notifierNames are the notification channels NOTIFIERS selects from.
+var notifierNames = []string{"telegram", "discord", "slack", "pushover", "ntfy", "email"}
+
+// parseNotifiers parses a comma-separated list of notification channels.
+func parseNotifiers(value string) ([]string, error) {
+	var names []string
+	for _, name := range parseList(strings.ToLower(value)) {
+		if !slices.Contains(notifierNames, name) {
+			return nil, fmt.Errorf("unknown notifier %q, want %s", name, strings.Join(notifierNames, ", "))
+		}
+		names = append(names, name)
+	}
+	return names, nil
+}
+
+// newNotifier builds the notification channels selected in config, logging the
+// ones it leaves out.
+func newNotifier(config Config) *MultiNotifier {
+	m, problems := buildNotifier(config)
+	for _, problem := range problems {
+		log.Printf("Warning: %s", problem)
+	}
+	return m
+}
+
+// buildNotifier builds the notification channels selected in config and returns
+// the problems of those it leaves out or degrades.
+func buildNotifier(config Config) (*MultiNotifier, []string) {
+	var problems []string
+	m := &MultiNotifier{quiet: quietHours{windows: config.QuietHours, minSeverity: config.QuietMinSeverity}}
+	for _, name := range config.Notifiers {
+		var notifier Notifier
+		switch name {
+		case "telegram":
+			if config.TelegramBotToken == "" || config.TelegramChatID == "" {
+				problems = append(problems, "Telegram notifier enabled but TELEGRAM_BOT_TOKEN or TELEGRAM_CHAT_ID is missing")
+				continue
+			}
+			notifier = &TelegramNotifier{BotToken: config.TelegramBotToken, ChatID: config.TelegramChatID}
+		case "discord":
+			if config.DiscordWebhookURL == "" {
+				problems = append(problems, "Discord notifier enabled but DISCORD_WEBHOOK_URL is missing")
+				continue
+			}
+			notifier = &DiscordNotifier{WebhookURL: config.DiscordWebhookURL}
+		case "slack":
+			if config.SlackWebhookURL == "" {
+				problems = append(problems, "Slack notifier enabled but SLACK_WEBHOOK_URL is missing")
+				continue
+			}
+			notifier = &SlackNotifier{WebhookURL: config.SlackWebhookURL}
+		case "pushover":
+			if config.PushoverAppToken == "" || config.PushoverUserKey == "" {
+				problems = append(problems, "Pushover notifier enabled but PUSHOVER_APP_TOKEN or PUSHOVER_USER_KEY is missing")
+				continue
+			}
+			notifier = &PushoverNotifier{AppToken: config.PushoverAppToken, UserKey: config.PushoverUserKey}
+		case "ntfy":
+			if config.NtfyTopic == "" {
+				problems = append(problems, "ntfy notifier enabled but NTFY_TOPIC is missing")
+				continue
+			}
+			notifier = &NtfyNotifier{URL: config.NtfyServer + "/" + config.NtfyTopic, Token: config.NtfyToken}
+		case "email":
+			if config.SMTPHost == "" || len(config.EmailTo) == 0 {
+				problems = append(problems, "Email notifier enabled but SMTP_HOST or EMAIL_TO is missing")
+				continue
+			}
+			email, err := newEmailNotifier(config)
+			if err != nil {
+				problems = append(problems, fmt.Sprintf("%v, using the built-in digest layout", err))
+			}
+			if config.EmailDigest {
+				m.AddDigest(email, config.NotifierMinSeverity[name])
+				continue
+			}
+			notifier = email
+		default:
+			problems = append(problems, fmt.Sprintf("Unknown notifier %q", name))
+			continue
+		}
+		m.Add(notifier, config.NotifierMinSeverity[name])
+	}
+	if config.TelegramUrgentChatID != "" {
+		if config.TelegramBotToken == "" {
+			problems = append(problems, "TELEGRAM_URGENT_CHAT_ID is set but TELEGRAM_BOT_TOKEN is missing")
+		} else {
+			// Critical notifications page the urgent chat as well
+			m.Add(&TelegramNotifier{BotToken: config.TelegramBotToken, ChatID: config.TelegramUrgentChatID}, SeverityCritical)
+		}
+	}
+	return m, problems
+}
+
+// loadNotifierConfig reads the notification channel settings from src.
+func loadNotifierConfig(src *settings.Settings, config *Config) {
+	config.Notifiers = settings.Parse(src, "NOTIFIERS", []string{"telegram"}, parseNotifiers)
+	explicit := src.String("NOTIFIERS") != ""
+
+	// Telegram is only on by default, so its settings are required once asked for
+	if explicit && slices.Contains(config.Notifiers, "telegram") {
+		config.TelegramBotToken = src.Required("TELEGRAM_BOT_TOKEN")
+		config.TelegramChatID = src.Required("TELEGRAM_CHAT_ID")
+	} else {
+		config.TelegramBotToken = src.String("TELEGRAM_BOT_TOKEN")
+		config.TelegramChatID = src.String("TELEGRAM_CHAT_ID")
+	}
+	config.TelegramCommands = src.Bool("TELEGRAM_COMMANDS", config.TelegramCommands)
+	config.TelegramUrgentChatID = src.String("TELEGRAM_URGENT_CHAT_ID")
+	config.DiscordWebhookURL = src.String("DISCORD_WEBHOOK_URL")
+	config.SlackWebhookURL = src.String("SLACK_WEBHOOK_URL")
+	loadEmailConfig(src, config)
+	loadPushConfig(src, config)
+	config.NotifyLocale = settings.Parse(src, "NOTIFY_LOCALE", defaultNotifyLocale, parseNotifyLocale)
+	config.NotifyLocaleFile = src.String("NOTIFY_LOCALE_FILE")
+	config.NotifyTemplate = src.String("NOTIFY_TEMPLATE")
+	config.WebhookURLs = parseList(src.String("WEBHOOK_URLS"))
+	config.WebhookSecret = src.String("WEBHOOK_SECRET")
+	config.WebhookEvents = settings.Parse(src, "WEBHOOK_EVENTS", nil, parseEventTypes)
+	config.EventBrokerURL = src.String("EVENT_BROKER_URL")
+	if config.EventBrokerURL != "" {
+		if err := checkEventBrokerURL(config.EventBrokerURL); err != nil {
+			src.Fail("EVENT_BROKER_URL", err)
+			config.EventBrokerURL = ""
+		}
+	}
+	config.EventTopic = defaultEventTopic
+	if topic := src.String("EVENT_TOPIC"); topic != "" {
+		config.EventTopic = topic
+	}
+	config.EventBrokerEvents = settings.Parse(src, "EVENT_BROKER_EVENTS", nil, parseEventTypes)
+	config.InfluxURL = src.String("INFLUX_URL")
+	config.InfluxToken = src.String("INFLUX_TOKEN")
+	config.InfluxOrg = src.String("INFLUX_ORG")
+	config.InfluxBucket = src.String("INFLUX_BUCKET")
+	config.InfluxMeasurement = defaultInfluxMeasurement
+	if measurement := src.String("INFLUX_MEASUREMENT"); measurement != "" {
+		config.InfluxMeasurement = measurement
+	}
+
+	config.QuietHours = settings.Parse(src, "QUIET_HOURS", nil, parseQuietHours)
+	config.QuietMinSeverity = settings.Parse(src, "QUIET_HOURS_MIN_SEVERITY", defaultQuietMinSeverity, parseSeverity)
+
+	config.NotifierMinSeverity = make(map[string]Severity)
+	for _, name := range config.Notifiers {
+		key := strings.ToUpper(name) + "_MIN_SEVERITY"
+		config.NotifierMinSeverity[name] = settings.Parse(src, key, channelMinSeverity[name], parseSeverity)
+	}
+}
+
+// notify sends a message to the configured notification channels, logging delivery failures.
+func (ts *TradingService) notify(severity Severity, message string) {
+	if ts.notifier == nil {
+		return
+	}
+	if err := ts.notifier.Notify(severity, message); err != nil {
+		log.Printf("Error sending notification: %v", err)
+	}
+}
+
+// httpClient is shared by the webhook-based notifiers.
+var httpClient = &http.Client{Timeout: notifierTimeout}
+
+// postJSON posts payload as JSON to url and checks for a successful status code.
+func postJSON(url string, payload any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	resp, err := httpClient.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned error code: %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// postMultipart posts fields and image, as the PNG file field fileField, to url
+// as a multipart form and checks for a successful status code.
+func postMultipart(url string, fields map[string]string, fileField string, image []byte) error {
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	for name, value := range fields {
+		if err := form.WriteField(name, value); err != nil {
+			return err
+		}
+	}
+	file, err := form.CreateFormFile(fileField, "chart.png")
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(image); err != nil {
+		return err
+	}
+	if err := form.Close(); err != nil {
+		return err
+	}
+
+	resp, err := httpClient.Post(url, form.FormDataContentType(), &body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("upload returned error code: %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// TelegramNotifier sends notifications to a Telegram chat through a bot.
+type TelegramNotifier struct {
+	BotToken string
+	ChatID   string
+}
+
+// Name returns the channel name.
+func (t *TelegramNotifier) Name() string { return "telegram" }
+
+// Send sends a message to the configured Telegram chat.
+func (t *TelegramNotifier) Send(message string) error {
+	apiURL := fmt.Sprintf("https://api.telegram.org/bot%s/sendMessage", t.BotToken)
+
+	resp, err := httpClient.PostForm(apiURL, url.Values{
+		"chat_id": {t.ChatID},
+		"text":    {message},
+	})
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("telegram API returned error code: %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// telegramCaptionLimit is the maximum caption length of a Telegram photo.
+const telegramCaptionLimit = 1024
+
+// SendImage sends image to the configured Telegram chat with message as its
+// caption, or followed by message when it is too long for a caption.
+func (t *TelegramNotifier) SendImage(message string, image []byte) error {
+	caption := message
+	if len(caption) > telegramCaptionLimit {
+		caption = ""
+	}
+	apiURL := fmt.Sprintf("https://api.telegram.org/bot%s/sendPhoto", t.BotToken)
+	if err := postMultipart(apiURL, map[string]string{"chat_id": t.ChatID, "caption": caption}, "photo", image); err != nil {
+		return err
+	}
+	if caption == "" {
+		return t.Send(message)
+	}
+	return nil
+}
+
+// discordMessageLimit is the maximum content length of a Discord webhook message.
+const discordMessageLimit = 2000
+
+// DiscordNotifier sends notifications to a Discord channel webhook.
+type DiscordNotifier struct {
+	WebhookURL string
+}
+
+// Name returns the channel name.
+func (d *DiscordNotifier) Name() string { return "discord" }
+
+// Send posts a message to the Discord webhook.
+func (d *DiscordNotifier) Send(message string) error {
+	if len(message) > discordMessageLimit {
+		message = message[:discordMessageLimit-3] + "..."
+	}
+	return postJSON(d.WebhookURL, map[string]string{"content": message})
+}
+
+// SendImage posts a message with image attached to the Discord webhook.
+func (d *DiscordNotifier) SendImage(message string, image []byte) error {
+	if len(message) > discordMessageLimit {
+		message = message[:discordMessageLimit-3] + "..."
+	}
+	payload, err := json.Marshal(map[string]string{"content": message})
+	if err != nil {
+		return err
+	}
+	return postMultipart(d.WebhookURL, map[string]string{"payload_json": string(payload)}, "files[0]", image)
+}
+
+// SlackNotifier sends notifications to a Slack incoming webhook.
+type SlackNotifier struct {
+	WebhookURL string
+}
+
+// Name returns the channel name.
+func (s *SlackNotifier) Name() string { return "slack" }
+
+// Send posts a message to the Slack webhook.
+func (s *SlackNotifier) Send(message string) error {
+	return postJSON(s.WebhookURL, map[string]string{"text": message})
+}
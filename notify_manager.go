@@ -0,0 +1,68 @@
+package main
+
+import (
+	"strings"
+	"time"
+)
+
+// NoticeState records what was last reported for a position.
+type NoticeState struct {
+	Stage     int       `json:"stage"`
+	StopPrice string    `json:"stopPrice"`
+	TakePrice string    `json:"takePrice"`
+	SentAt    time.Time `json:"sentAt"`
+}
+
+// positionChanges lists what changed for a position since its last notification.
+// It returns nil when nothing relevant changed.
+func (ts *TradingService) positionChanges(data *PositionData, last *NoticeState) []string {
+	if last == nil {
+		return []string{"new position"}
+	}
+
+	var changes []string
+	if stage := ts.ladderStage(data); stage > last.Stage {
+		changes = append(changes, "threshold crossed")
+	}
+	if data.StopPriceStr != last.StopPrice {
+		changes = append(changes, "SL moved")
+	}
+	if data.TakePriceStr != last.TakePrice {
+		changes = append(changes, "TP updated")
+	}
+	return changes
+}
+
+// notifyPosition sends the position summary when it changed since the last
+// notification and the per-symbol rate limit allows it.
+func (ts *TradingService) notifyPosition(data *PositionData, msg string) {
+	key := trackedKey(data.Symbol, data.PositionSide)
+	now := time.Now()
+
+	ts.mu.Lock()
+	last := ts.state.Notices[key]
+	changes := ts.positionChanges(data, last)
+
+	if ts.config().NotifyOnlyOnChange && len(changes) == 0 {
+		ts.mu.Unlock()
+		return
+	}
+	if last != nil && now.Sub(last.SentAt) < ts.config().NotifyMinInterval {
+		ts.mu.Unlock()
+		return
+	}
+
+	ts.state.Notices[key] = &NoticeState{
+		Stage:     ts.ladderStage(data),
+		StopPrice: data.StopPriceStr,
+		TakePrice: data.TakePriceStr,
+		SentAt:    now,
+	}
+	ts.mu.Unlock()
+	ts.saveState()
+
+	if len(changes) > 0 {
+		msg = "🔔 " + strings.Join(changes, ", ") + "\n" + msg
+	}
+	ts.notifyPositionChart(SeverityInfo, data, msg)
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"log"
+	"strconv"
+	"time"
+)
+
+// observeOnlyBanner heads position reports when no orders are managed.
+const observeOnlyBanner = "👁️ OBSERVE ONLY: recommended levels, no orders placed"
+
+// observerExchange wraps an Exchange for OBSERVE_ONLY mode. Reads go to the venue,
+// while order placement and cancellation are only logged, so the guard runs its full
+// analysis with read-only API keys without ever touching the book.
+type observerExchange struct {
+	Exchange
+}
+
+// observeOnly wraps exchange in an observerExchange when config enables OBSERVE_ONLY.
+func observeOnly(exchange Exchange, config Config) Exchange {
+	if !config.ObserveOnly {
+		return exchange
+	}
+	return &observerExchange{Exchange: exchange}
+}
+
+// CreateOrder implements Exchange, logging the order instead of placing it.
+func (e *observerExchange) CreateOrder(ctx context.Context, req OrderRequest) (*Order, error) {
+	log.Printf("OBSERVE_ONLY: would place %s %s %s (%s) qty %s stop %s",
+		req.Type, req.Side, req.Symbol, req.PositionSide, req.Quantity, req.StopPrice)
+	return observedOrder(req), nil
+}
+
+// CreateOrders implements Exchange, logging the orders instead of placing them.
+func (e *observerExchange) CreateOrders(ctx context.Context, reqs []OrderRequest) ([]*Order, []error, error) {
+	orders := make([]*Order, len(reqs))
+	for i, req := range reqs {
+		orders[i], _ = e.CreateOrder(ctx, req)
+	}
+	return orders, make([]error, len(reqs)), nil
+}
+
+// CancelOrder implements Exchange, logging the cancellation instead of sending it.
+func (e *observerExchange) CancelOrder(ctx context.Context, order *Order) error {
+	log.Printf("OBSERVE_ONLY: would cancel %s order %s for %s (%s)",
+		order.Type, order.ID, order.Symbol, order.PositionSide)
+	return nil
+}
+
+// SyncTime implements timeSyncer when the wrapped exchange does.
+func (e *observerExchange) SyncTime(ctx context.Context) error {
+	if syncer, ok := e.Exchange.(timeSyncer); ok {
+		return syncer.SyncTime(ctx)
+	}
+	return nil
+}
+
+// observedOrder describes the order req would have created. It has no ID.
+func observedOrder(req OrderRequest) *Order {
+	stopPrice, _ := strconv.ParseFloat(req.StopPrice, 64)
+	return &Order{
+		Symbol:       req.Symbol,
+		Type:         req.Type,
+		Side:         req.Side,
+		PositionSide: req.PositionSide,
+		StopPrice:    stopPrice,
+		UpdateTime:   time.Now(),
+	}
+}
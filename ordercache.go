@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"sync"
+)
+
+// orderCache wraps an Exchange so open orders are fetched once per symbol per
+// processing cycle. Placing or cancelling an order invalidates that symbol, and
+// Reset drops everything at the start of a cycle.
+type orderCache struct {
+	Exchange
+
+	mu     sync.Mutex
+	orders map[string][]*Order // By symbol; "" holds every symbol
+}
+
+// newOrderCache wraps exchange with an empty open-order cache.
+func newOrderCache(exchange Exchange) *orderCache {
+	return &orderCache{Exchange: exchange, orders: make(map[string][]*Order)}
+}
+
+// Reset drops every cached entry.
+func (c *orderCache) Reset() {
+	c.mu.Lock()
+	c.orders = make(map[string][]*Order)
+	c.mu.Unlock()
+}
+
+// invalidate drops the entries of symbol and of the all-symbol listing, or every
+// entry when symbol is empty.
+func (c *orderCache) invalidate(symbol string) {
+	if symbol == "" {
+		c.Reset()
+		return
+	}
+	c.mu.Lock()
+	delete(c.orders, symbol)
+	delete(c.orders, "")
+	c.mu.Unlock()
+}
+
+// OpenOrders implements Exchange, serving repeated requests from the cache.
+func (c *orderCache) OpenOrders(ctx context.Context, symbol string) ([]*Order, error) {
+	c.mu.Lock()
+	orders, ok := c.orders[symbol]
+	c.mu.Unlock()
+	if ok {
+		return append([]*Order(nil), orders...), nil
+	}
+
+	orders, err := c.Exchange.OpenOrders(ctx, symbol)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	c.orders[symbol] = orders
+	c.mu.Unlock()
+	return append([]*Order(nil), orders...), nil
+}
+
+// CreateOrder implements Exchange.
+func (c *orderCache) CreateOrder(ctx context.Context, req OrderRequest) (*Order, error) {
+	defer c.invalidate(req.Symbol)
+	return c.Exchange.CreateOrder(ctx, req)
+}
+
+// CreateOrders implements Exchange.
+func (c *orderCache) CreateOrders(ctx context.Context, reqs []OrderRequest) ([]*Order, []error, error) {
+	defer func() {
+		for _, req := range reqs {
+			c.invalidate(req.Symbol)
+		}
+	}()
+	return c.Exchange.CreateOrders(ctx, reqs)
+}
+
+// CancelOrder implements Exchange.
+func (c *orderCache) CancelOrder(ctx context.Context, order *Order) error {
+	defer c.invalidate(order.Symbol)
+	return c.Exchange.CancelOrder(ctx, order)
+}
+
+// SyncTime implements timeSyncer when the wrapped exchange does.
+func (c *orderCache) SyncTime(ctx context.Context) error {
+	if syncer, ok := c.Exchange.(timeSyncer); ok {
+		return syncer.SyncTime(ctx)
+	}
+	return nil
+}
+
+// connection is the exchange and market data client the service trades through,
+// replaced as a whole when the credentials rotate.
+type connection struct {
+	exchange   Exchange // Behind the open-order cache and observe-only layers
+	orderCache *orderCache
+	client     ExchangeClient // Binance market data and account history
+}
+
+// setExchange installs exchange behind the session recording, request pacing,
+// open-order cache and observe-only layers, and client with it.
+func (ts *TradingService) setExchange(exchange Exchange, client ExchangeClient) {
+	cache := newOrderCache(paced(recordSession(exchange, ts.recorder), *ts.config()))
+	ts.conn.Store(&connection{
+		exchange:   observeOnly(cache, *ts.config()),
+		orderCache: cache,
+		client:     client,
+	})
+}
+
+// exchange returns the exchange orders go through, nil without credentials.
+func (ts *TradingService) exchange() Exchange {
+	if conn := ts.conn.Load(); conn != nil {
+		return conn.exchange
+	}
+	return nil
+}
+
+// orderCache returns the open-order cache of the exchange, nil without credentials.
+func (ts *TradingService) orderCache() *orderCache {
+	if conn := ts.conn.Load(); conn != nil {
+		return conn.orderCache
+	}
+	return nil
+}
+
+// client returns the market data and account history client.
+func (ts *TradingService) client() ExchangeClient {
+	if conn := ts.conn.Load(); conn != nil {
+		return conn.client
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+)
+
+// Small position actions, applied when a position is below the symbol's minimum
+// order quantity or notional.
+const (
+	smallPositionClose = "close" // Fall back to closePosition orders where supported
+	smallPositionSkip  = "skip"  // Leave the position unprotected and warn
+)
+
+// orderSizeViolation describes why an order of qty at price would be rejected by
+// the symbol's LOT_SIZE or MIN_NOTIONAL filter, or returns "" when it is valid.
+func orderSizeViolation(precision SymbolPrecision, data *PositionData, price float64) string {
+	if precision.MinQty > 0 && data.AbsAmt < precision.MinQty {
+		return "quantity below the minimum"
+	}
+	// Inverse contracts are sized in contracts, which have no notional filter
+	if data.ContractSize > 0 || precision.MinNotional <= 0 || price <= 0 {
+		return ""
+	}
+	if data.AbsAmt*price < precision.MinNotional {
+		return "notional below the minimum"
+	}
+	return ""
+}
+
+// checkOrderSize validates the SL/TP orders of data against the symbol's filters
+// before they are submitted, instead of failing with an opaque -4164. Positions too
+// small to protect switch to closePosition orders when SMALL_POSITION_ACTION allows
+// it and the exchange supports them; otherwise checkOrderSize returns false and the
+// orders are skipped.
+func (ts *TradingService) checkOrderSize(data *PositionData) bool {
+	precision, _ := ts.symbolPrecision(data.Symbol)
+
+	reason := ""
+	for _, price := range []float64{data.StopPrice, data.TakePrice} {
+		if reason = orderSizeViolation(precision, data, price); reason != "" {
+			break
+		}
+	}
+	if reason == "" {
+		return true
+	}
+
+	if ts.config().SmallPositionAction == smallPositionClose && ts.exchange().Name() == exchangeBinance {
+		log.Printf("%s position of %s %s (min qty %g, min notional %g), using closePosition SL/TP orders",
+			data.Symbol, data.Quantity, reason, precision.MinQty, precision.MinNotional)
+		data.ClosePosition = true
+		return true
+	}
+
+	log.Printf("Warning: Skipping SL/TP for %s: position of %s %s (min qty %g, min notional %g)",
+		data.Symbol, data.Quantity, reason, precision.MinQty, precision.MinNotional)
+	return false
+}
+
+// parseSmallPositionAction normalizes the configured small position action.
+func parseSmallPositionAction(value string) (string, error) {
+	switch action := strings.ToLower(strings.TrimSpace(value)); action {
+	case smallPositionClose, smallPositionSkip:
+		return action, nil
+	default:
+		return "", fmt.Errorf("want %s or %s", smallPositionClose, smallPositionSkip)
+	}
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"strings"
+)
+
+// snapshotPositions returns the open positions with their live SL/TP orders and
+// risk metrics, without placing or cancelling any orders. An empty symbol returns
+// every managed position.
+func (ts *TradingService) snapshotPositions(symbol string) ([]*PositionData, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+
+	positions, err := ts.exchange().Positions(ctx, symbol)
+	if err != nil {
+		return nil, fmt.Errorf("error getting positions: %w", err)
+	}
+
+	// Report live orders rather than those cached by the last cycle
+	ts.orderCache().invalidate(symbol)
+
+	var snapshot []*PositionData
+	for _, position := range positions {
+		if !ts.isSymbolManaged(position.Symbol) {
+			continue
+		}
+		data, err := newPositionData(position)
+		if err != nil {
+			log.Printf("Error processing position %s: %v", position.Symbol, err)
+			continue
+		}
+		if data == nil {
+			continue
+		}
+		data.Tag, _ = ts.knownTag(data.Symbol, data.PositionSide)
+		data.Notes = ts.positionNotes(data.Symbol, data.PositionSide)
+		data.Manual = ts.isSymbolManual(data.Symbol)
+
+		if data.StopPrice, err = ts.getCurrentStopLoss(data.Symbol, data.PositionSide); err != nil {
+			log.Printf("Warning: Unable to get current stop loss: %v", err)
+		}
+		if data.StopPrice > 0 {
+			data.RawSLPct = rawStopLossPct(data, data.StopPrice)
+			data.LeveragedSLPct = data.RawSLPct * data.Leverage
+		} else {
+			// No live stop; mark it so reports show NONE
+			data.CurrentSLPct = -1
+		}
+
+		if data.TakePrice, err = ts.getCurrentTakeProfit(data.Symbol, data.PositionSide); err != nil {
+			log.Printf("Warning: Unable to get current take profit: %v", err)
+		}
+		if data.TakePrice > 0 {
+			data.RawTPPct = math.Abs((data.TakePrice - data.EntryPrice) / data.EntryPrice * 100)
+			data.LeveragedTPPct = data.RawTPPct * data.Leverage
+		}
+
+		if err := ts.calculateRiskMetrics(data); err != nil {
+			log.Printf("Warning: %v", err)
+		}
+		if data.TakePrice <= 0 {
+			data.PotentialProfit = 0
+		}
+		snapshot = append(snapshot, data)
+	}
+	return snapshot, nil
+}
+
+// formatStatusTable renders a compact one-line-per-position overview.
+func formatStatusTable(positions []*PositionData) string {
+	if len(positions) == 0 {
+		return "No open positions"
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%-14s %-6s %14s %14s %9s %14s %14s\n",
+		"SYMBOL", "SIDE", "ENTRY", "MARK", "P/L%", "SL", "TP")
+	for _, data := range positions {
+		side := "SHORT"
+		if data.IsLong {
+			side = "LONG"
+		}
+		fmt.Fprintf(&b, "%-14s %-6s %14.6f %14.6f %8.2f%% %14.6f %14.6f\n",
+			data.Symbol, side, data.EntryPrice, data.MarkPrice,
+			data.CurrentProfitPct, data.StopPrice, data.TakePrice)
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// closeSymbol cancels every open order on symbol and closes its positions at market.
+func (ts *TradingService) closeSymbol(symbol string) error {
+	unlock := ts.lockSymbol(symbol)
+	defer unlock()
+
+	if err := ts.cancelExistingOrders(symbol, ""); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+
+	positions, err := ts.exchange().Positions(ctx, symbol)
+	if err != nil {
+		return fmt.Errorf("error getting positions for %s: %w", symbol, err)
+	}
+
+	closed := 0
+	for _, position := range positions {
+		data, err := newPositionData(position)
+		if err != nil {
+			return err
+		}
+		if data == nil {
+			continue
+		}
+		if err := ts.reducePosition(data, 100, "manual close"); err != nil {
+			return err
+		}
+		closed++
+	}
+
+	if closed == 0 {
+		log.Printf("No open position on %s; cancelled its open orders", symbol)
+	}
+	return nil
+}
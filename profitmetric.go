@@ -0,0 +1,227 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"futures-guard/ladder"
+	"futures-guard/settings"
+)
+
+// Profit metrics the ladder thresholds and locks can be expressed in.
+const (
+	profitMetricLeveraged = string(ladder.Leveraged)
+	profitMetricRaw       = string(ladder.Raw)
+	profitMetricUSD       = string(ladder.USD)
+)
+
+// stopLadder returns the ladder of symbol: its LADDER_PRESET_OVERRIDES preset, or
+// the configured levels and metric, with the interpolation setting.
+func (ts *TradingService) stopLadder(symbol string) ladder.Ladder {
+	cfg := ts.config()
+	l := ladder.Ladder{
+		Levels:      cfg.LadderLevels,
+		Metric:      ladder.Metric(cfg.ProfitMetric),
+		DefaultSL:   cfg.DefaultSLPercent,
+		Interpolate: cfg.LadderInterpolate,
+	}
+	name, ok := cfg.LadderPresetOverrides[symbol]
+	if !ok {
+		name, _ = ts.trendLadder(cfg, symbol)
+		ok = name != ""
+	}
+	if ok {
+		if preset, ok := cfg.LadderPresets[name]; ok {
+			l.Levels, l.Metric = preset.Levels, preset.Metric
+		}
+	}
+	return l
+}
+
+// ladderPosition returns what the ladder math needs to know about data.
+func ladderPosition(data *PositionData) ladder.Position {
+	return ladder.Position{
+		Entry:    data.EntryPrice,
+		Leverage: data.Leverage,
+		Notional: entryNotional(data),
+		Long:     data.IsLong,
+	}
+}
+
+// ladderProfit returns the profit of data in the unit of its ladder: the
+// leveraged or raw percentage, or the absolute profit in the quote currency.
+// RawProfitPct already includes fees and funding when they are counted.
+func (ts *TradingService) ladderProfit(data *PositionData) float64 {
+	return ladder.Profit(ts.stopLadder(data.Symbol).Metric, ladderPosition(data), data.RawProfitPct)
+}
+
+// ladderStage returns the index of the highest stop level reached by data, or -1
+// when the first threshold has not been reached.
+func (ts *TradingService) ladderStage(data *PositionData) int {
+	return ts.profitStage(data.Symbol, ts.ladderProfit(data))
+}
+
+// lockPct converts a ladder lock, in the unit of the ladder of data, to the raw
+// percentage from entry it puts the stop at.
+func (ts *TradingService) lockPct(data *PositionData, lock float64) float64 {
+	return ladder.LockPct(ts.stopLadder(data.Symbol).Metric, ladderPosition(data), lock)
+}
+
+// entryNotional returns the quote value of the position at its entry price: the
+// contract value of inverse contracts, or the quantity times the entry price.
+func entryNotional(data *PositionData) float64 {
+	if data.ContractSize > 0 {
+		return data.AbsAmt * data.ContractSize
+	}
+	return data.AbsAmt * data.EntryPrice
+}
+
+// profitUnit returns the unit of the ladder thresholds of symbol for log messages.
+func (ts *TradingService) profitUnit(symbol string) string {
+	switch ts.stopLadder(symbol).Metric {
+	case ladder.Raw:
+		return "% raw"
+	case ladder.USD:
+		return " USD"
+	default:
+		return "% leveraged"
+	}
+}
+
+// parseProfitMetric normalizes the configured profit metric.
+func parseProfitMetric(value string) (string, error) {
+	switch metric := strings.ToLower(strings.TrimSpace(value)); metric {
+	case profitMetricLeveraged, profitMetricRaw, profitMetricUSD:
+		return metric, nil
+	default:
+		return "", fmt.Errorf("want %s, %s or %s", profitMetricLeveraged, profitMetricRaw, profitMetricUSD)
+	}
+}
+
+// parseLadderLevels parses ladder steps of the form "300:0,450:150" and sorts them
+// by profit threshold.
+func parseLadderLevels(value string) ([]StopLossLevel, error) {
+	var levels []StopLossLevel
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		profitStr, lockStr, ok := strings.Cut(item, ":")
+		profit, err1 := strconv.ParseFloat(strings.TrimSpace(profitStr), 64)
+		lock, err2 := strconv.ParseFloat(strings.TrimSpace(lockStr), 64)
+		if !ok || err1 != nil || err2 != nil || profit <= 0 || lock >= profit {
+			return nil, fmt.Errorf("invalid ladder level %q, want PROFIT:LOCK with a positive profit above the lock", item)
+		}
+		levels = append(levels, StopLossLevel{ProfitThreshold: profit, StopLossValue: lock})
+	}
+	sort.Slice(levels, func(i, j int) bool { return levels[i].ProfitThreshold < levels[j].ProfitThreshold })
+	return levels, nil
+}
+
+// ladderPresetFile is an entry of LADDER_PRESETS_FILE.
+type ladderPresetFile struct {
+	Metric string `json:"metric"`
+	Levels string `json:"levels"`
+}
+
+// loadLadderPresets reads the custom presets of path, a JSON object from names to
+// {"metric": "raw", "levels": "1:0,2:1"}.
+func loadLadderPresets(path string) (map[string]ladder.Preset, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading LADDER_PRESETS_FILE: %w", err)
+	}
+	var custom map[string]ladderPresetFile
+	if err := json.Unmarshal(content, &custom); err != nil {
+		return nil, fmt.Errorf("error parsing LADDER_PRESETS_FILE: %w", err)
+	}
+	presets := make(map[string]ladder.Preset, len(custom))
+	for _, name := range slices.Sorted(maps.Keys(custom)) {
+		entry := custom[name]
+		levels, err := parseLadderLevels(entry.Levels)
+		if err != nil {
+			return nil, fmt.Errorf("ladder preset %q: %w", name, err)
+		}
+		if len(levels) == 0 {
+			return nil, fmt.Errorf("ladder preset %q has no levels", name)
+		}
+		metric := profitMetricLeveraged
+		if entry.Metric != "" {
+			if metric, err = parseProfitMetric(entry.Metric); err != nil {
+				return nil, fmt.Errorf("ladder preset %q has metric %q: %w", name, entry.Metric, err)
+			}
+		}
+		presets[strings.ToLower(strings.TrimSpace(name))] = ladder.Preset{Metric: ladder.Metric(metric), Levels: levels}
+	}
+	return presets, nil
+}
+
+// loadProfitMetricConfig reads the ladder from src: LADDER_LEVELS in the
+// PROFIT_METRIC unit, or else the LADDER_PRESET preset with its own metric, the
+// per-symbol presets, and the interpolation and high-water mark switches.
+func loadProfitMetricConfig(src *settings.Settings, config *Config) {
+	config.LadderInterpolate = src.Bool("LADDER_INTERPOLATE", false)
+	config.LadderHighWaterMark = src.Bool("LADDER_HIGH_WATER_MARK", true)
+	config.LadderConfirmCycles = src.Int("LADDER_CONFIRM_CYCLES", 0)
+	if config.LadderConfirmCycles < 0 {
+		src.Fail("LADDER_CONFIRM_CYCLES", fmt.Errorf("must not be negative, got %d", config.LadderConfirmCycles))
+		config.LadderConfirmCycles = 0
+	}
+	config.LadderConfirmInterval = src.String("LADDER_CONFIRM_INTERVAL")
+
+	config.LadderPresets = ladder.Presets()
+	if path := src.String("LADDER_PRESETS_FILE"); path != "" {
+		custom, err := loadLadderPresets(path)
+		if err != nil {
+			src.Fail("LADDER_PRESETS_FILE", err)
+		}
+		maps.Copy(config.LadderPresets, custom)
+	}
+	names := slices.Sorted(maps.Keys(config.LadderPresets))
+	preset := func(name string) (string, error) {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if _, ok := config.LadderPresets[name]; !ok {
+			return "", fmt.Errorf("want one of %s", strings.Join(names, ", "))
+		}
+		return name, nil
+	}
+
+	config.LadderPresetOverrides = make(map[string]string)
+	for symbol, name := range settings.Parse(src, "LADDER_PRESET_OVERRIDES", nil, parseSymbolOverrides) {
+		preset, err := preset(name)
+		if err != nil {
+			src.Fail("LADDER_PRESET_OVERRIDES", fmt.Errorf("invalid preset %q for %s: %w", name, symbol, err))
+			continue
+		}
+		config.LadderPresetOverrides[symbol] = preset
+	}
+
+	if levels := settings.Parse(src, "LADDER_LEVELS", nil, parseLadderLevels); len(levels) > 0 {
+		config.ProfitMetric = settings.Parse(src, "PROFIT_METRIC", profitMetricLeveraged, parseProfitMetric)
+		config.LadderLevels = levels
+		if src.String("LADDER_PRESET") != "" {
+			src.Warn("LADDER_PRESET", errors.New("ignored while LADDER_LEVELS is set"))
+		}
+		return
+	}
+
+	config.LadderPreset = settings.Parse(src, "LADDER_PRESET", ladder.DefaultPreset, preset)
+	selected := config.LadderPresets[config.LadderPreset]
+	config.LadderLevels, config.ProfitMetric = selected.Levels, string(selected.Metric)
+	if metric := src.String("PROFIT_METRIC"); metric != "" {
+		if parsed, err := parseProfitMetric(metric); err != nil {
+			src.Fail("PROFIT_METRIC", fmt.Errorf("invalid value %q: %w", metric, err))
+		} else if parsed != config.ProfitMetric {
+			src.Warn("PROFIT_METRIC", fmt.Errorf("%s is ignored without LADDER_LEVELS, the %s preset uses %s",
+				metric, config.LadderPreset, config.ProfitMetric))
+		}
+	}
+}
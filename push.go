@@ -0,0 +1,122 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"futures-guard/settings"
+)
+
+// pushoverAPIURL is the Pushover message endpoint.
+const pushoverAPIURL = "https://api.pushover.net/1/messages.json"
+
+// defaultNtfyServer is the public ntfy server.
+const defaultNtfyServer = "https://ntfy.sh"
+
+// pushTitle is the title of push notifications.
+const pushTitle = "Futures Guard"
+
+// SeverityNotifier is a Notifier that delivers messages with a priority matching
+// their severity, so urgent ones can break through the phone's silent mode.
+type SeverityNotifier interface {
+	SendSeverity(severity Severity, message string) error
+}
+
+// PushoverNotifier sends push notifications through a Pushover application.
+type PushoverNotifier struct {
+	AppToken string
+	UserKey  string
+}
+
+// Name returns the channel name.
+func (p *PushoverNotifier) Name() string { return "pushover" }
+
+// Send pushes message with normal priority.
+func (p *PushoverNotifier) Send(message string) error {
+	return p.SendSeverity(SeverityInfo, message)
+}
+
+// SendSeverity pushes message with high priority for critical notifications,
+// which bypasses the user's quiet hours in the Pushover app.
+func (p *PushoverNotifier) SendSeverity(severity Severity, message string) error {
+	priority := "0"
+	if severity == SeverityCritical {
+		priority = "1"
+	}
+	resp, err := httpClient.PostForm(pushoverAPIURL, url.Values{
+		"token":    {p.AppToken},
+		"user":     {p.UserKey},
+		"title":    {pushTitle},
+		"message":  {message},
+		"priority": {priority},
+	})
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("pushover API returned error code: %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// NtfyNotifier publishes notifications to a topic of an ntfy server.
+type NtfyNotifier struct {
+	URL   string // Server URL with the topic, e.g. https://ntfy.sh/my-topic
+	Token string // Access token of protected topics
+}
+
+// Name returns the channel name.
+func (n *NtfyNotifier) Name() string { return "ntfy" }
+
+// Send publishes message with the default priority.
+func (n *NtfyNotifier) Send(message string) error {
+	return n.SendSeverity(SeverityInfo, message)
+}
+
+// SendSeverity publishes message with the ntfy priority of severity: default,
+// high or urgent.
+func (n *NtfyNotifier) SendSeverity(severity Severity, message string) error {
+	priority := 3
+	switch severity {
+	case SeverityWarning:
+		priority = 4
+	case SeverityCritical:
+		priority = 5
+	}
+	req, err := http.NewRequest(http.MethodPost, n.URL, strings.NewReader(message))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Title", pushTitle)
+	req.Header.Set("Priority", strconv.Itoa(priority))
+	if n.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+n.Token)
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("ntfy server returned error code: %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// loadPushConfig reads the Pushover and ntfy settings from src.
+func loadPushConfig(src *settings.Settings, config *Config) {
+	config.PushoverAppToken = src.String("PUSHOVER_APP_TOKEN")
+	config.PushoverUserKey = src.String("PUSHOVER_USER_KEY")
+	config.NtfyServer = defaultNtfyServer
+	if server := src.String("NTFY_SERVER"); server != "" {
+		config.NtfyServer = strings.TrimRight(server, "/")
+	}
+	config.NtfyTopic = src.String("NTFY_TOPIC")
+	config.NtfyToken = src.String("NTFY_TOKEN")
+}
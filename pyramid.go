@@ -0,0 +1,176 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+)
+
+// clientOrderKindPyramid marks the market orders that add to a winning position.
+const clientOrderKindPyramid = "PYR"
+
+// defaultPyramidMaxAdds is the default maximum number of pyramid adds per position.
+const defaultPyramidMaxAdds = 3
+
+// initialRisk returns the loss the position would take at the initial stop,
+// DefaultSLPercent from its entry: the risk unit R pyramiding never exceeds.
+func (ts *TradingService) initialRisk(data *PositionData) float64 {
+	offset := ts.config().DefaultSLPercent / 100
+	stop := data.EntryPrice * (1 - offset)
+	if data.IsShort {
+		stop = data.EntryPrice * (1 + offset)
+	}
+	return -positionPnL(data, stop)
+}
+
+// riskStop returns the stop at which closing the position loses exactly risk.
+// It returns zero when no such stop exists.
+func riskStop(data *PositionData, risk float64) float64 {
+	if data.AbsAmt <= 0 || data.EntryPrice <= 0 {
+		return 0
+	}
+	sign := 1.0
+	if data.IsShort {
+		sign = -1
+	}
+
+	var stop float64
+	if data.ContractSize > 0 {
+		// Inverse contracts lose coins in proportion to the change of 1/price
+		inverse := 1/data.EntryPrice + sign*risk/(data.AbsAmt*data.ContractSize)
+		if inverse <= 0 {
+			return 0
+		}
+		stop = 1 / inverse
+	} else {
+		stop = data.EntryPrice - sign*risk/data.AbsAmt
+	}
+	return math.Max(stop, 0)
+}
+
+// pyramidRisk returns the original risk of a position that has been pyramided,
+// or zero when it has not.
+func (ts *TradingService) pyramidRisk(data *PositionData) float64 {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	st, ok := ts.state.Orders[trackedKey(data.Symbol, data.PositionSide)]
+	if !ok || st.PyramidAdds == 0 {
+		return 0
+	}
+	return st.InitialRisk
+}
+
+// applyPyramidGuard tightens the stop of a pyramided position so closing the
+// combined position there loses no more than the original risk.
+func (ts *TradingService) applyPyramidGuard(data *PositionData, stopPrice float64) float64 {
+	risk := ts.pyramidRisk(data)
+	if risk <= 0 {
+		return stopPrice
+	}
+
+	capped := riskStop(data, risk)
+	if capped <= 0 || (data.IsLong && capped <= stopPrice) || (data.IsShort && capped >= stopPrice) {
+		return stopPrice
+	}
+	if (data.IsLong && capped >= data.MarkPrice) || (data.IsShort && capped <= data.MarkPrice) {
+		log.Printf("Warning: Risk-capped SL %.8f for %s is past the mark price %.8f, keeping %.8f",
+			capped, data.Symbol, data.MarkPrice, stopPrice)
+		return stopPrice
+	}
+
+	log.Printf("Tightening SL for %s from %.8f to %.8f to keep the pyramided risk within %.4f",
+		data.Symbol, stopPrice, capped, risk)
+	data.RawSLPct = rawStopLossPct(data, capped)
+	data.LeveragedSLPct = data.RawSLPct * data.Leverage
+	return capped
+}
+
+// checkPyramid adds PyramidFraction of the position at market the first time each
+// new ladder stage is reached, up to PyramidMaxAdds adds. The add is skipped when
+// the combined position could not be stopped within the original risk below the
+// mark price; otherwise its SL/TP are replaced right away for the new size.
+func (ts *TradingService) checkPyramid(data *PositionData) {
+	if ts.config().PyramidFraction <= 0 || ts.exchange().Name() == exchangeBinanceSpot || data.GridLow > 0 {
+		return
+	}
+	if ts.entryBlocked(data.Symbol) != nil {
+		return
+	}
+	stage := ts.ladderStage(data)
+
+	ts.mu.Lock()
+	st := ts.orderState(data.Symbol, data.PositionSide)
+	if st.InitialRisk <= 0 {
+		st.InitialRisk = ts.initialRisk(data)
+	}
+	risk, adds, lastStage := st.InitialRisk, st.PyramidAdds, st.PyramidStage
+	ts.mu.Unlock()
+
+	// PyramidStage is one past the stage of the last add, zero before any
+	if stage < 0 || stage+1 <= lastStage || adds >= ts.config().PyramidMaxAdds || risk <= 0 {
+		return
+	}
+
+	precision, _ := ts.symbolPrecision(data.Symbol)
+	quantity := truncateToPrecision(data.AbsAmt*ts.config().PyramidFraction, precision.QuantityPrecision)
+	if quantity <= 0 {
+		return
+	}
+
+	// The add fills near the mark price, moving the average entry towards it
+	combined := *data
+	combined.AbsAmt = data.AbsAmt + quantity
+	combined.PositionAmt = math.Copysign(combined.AbsAmt, data.PositionAmt)
+	if data.ContractSize > 0 {
+		setEntryPrice(&combined, combined.AbsAmt/(data.AbsAmt/data.EntryPrice+quantity/data.MarkPrice))
+	} else {
+		setEntryPrice(&combined, (data.EntryPrice*data.AbsAmt+data.MarkPrice*quantity)/combined.AbsAmt)
+	}
+	capped := riskStop(&combined, risk)
+	if capped <= 0 || (data.IsLong && capped >= data.MarkPrice) || (data.IsShort && capped <= data.MarkPrice) {
+		log.Printf("Skipping pyramid add on %s at stage %d: no stop within the original risk %.4f",
+			data.Symbol, stage, risk)
+		return
+	}
+
+	req := OrderRequest{
+		Symbol:        data.Symbol,
+		Side:          sideSell,
+		PositionSide:  data.PositionSide,
+		Type:          orderTypeMarket,
+		Quantity:      formatDecimal(quantity, precision.QuantityPrecision),
+		ClientOrderID: ts.clientOrderID(clientOrderKindPyramid, data),
+	}
+	if data.IsLong {
+		req.Side = sideBuy
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+	if _, err := ts.exchange().CreateOrder(ctx, req); err != nil {
+		log.Printf("Warning: Error adding to %s at stage %d: %v", data.Symbol, stage, err)
+		return
+	}
+
+	ts.mu.Lock()
+	st = ts.orderState(data.Symbol, data.PositionSide)
+	st.PyramidStage = stage + 1
+	st.PyramidAdds++
+	st.Quantity = combined.AbsAmt
+	ts.mu.Unlock()
+	ts.saveState()
+
+	msg := fmt.Sprintf("🔺 Added %s to %s %s at stage %d (%d/%d adds), combined stop capped at %.4f",
+		req.Quantity, data.Symbol, data.PositionSide, stage+1, adds+1, ts.config().PyramidMaxAdds, capped)
+	log.Println(msg)
+	ts.notify(SeverityInfo, msg)
+
+	// Protect the combined position right away instead of waiting for the next cycle
+	ts.orderCache().invalidate(data.Symbol)
+	*data = combined
+	data.ScaledIn = true
+	if err := ts.updatePositionOrders(data); err != nil {
+		log.Printf("Warning: Error updating orders after pyramid add on %s: %v", data.Symbol, err)
+	}
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// defaultQuietMinSeverity is the lowest severity still delivered during quiet hours.
+const defaultQuietMinSeverity = SeverityCritical
+
+// parseQuietHours parses QUIET_HOURS entries such as "22:00-07:00" (every day) or
+// "Sat 00:00-Mon 06:00", in UTC and comma-separated.
+func parseQuietHours(value string) ([]scheduleWindow, error) {
+	var windows []scheduleWindow
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		// Bare times repeat every day
+		if !strings.Contains(entry, " ") {
+			start, end, _ := strings.Cut(entry, "-")
+			entry = "* " + start + "-* " + end
+		}
+		parsed, err := parseScheduleSpan(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid entry %q: %w", entry, err)
+		}
+		windows = append(windows, parsed...)
+	}
+	return windows, nil
+}
+
+// quietHours suppresses notifications below a severity inside its windows.
+type quietHours struct {
+	windows     []scheduleWindow
+	minSeverity Severity
+}
+
+// suppresses reports whether a notification of severity is held back at now.
+func (q quietHours) suppresses(severity Severity, now time.Time) bool {
+	if severity >= q.minSeverity {
+		return false
+	}
+	for _, w := range q.windows {
+		if w.contains(now) {
+			return true
+		}
+	}
+	return false
+}
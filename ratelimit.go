@@ -0,0 +1,138 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Concurrency and pacing defaults.
+const (
+	defaultMaxConcurrency = 4
+	defaultAPIRateLimit   = 10.0 // Requests per second
+)
+
+// rateLimiter spaces calls evenly so at most a fixed number start per second.
+type rateLimiter struct {
+	mu       sync.Mutex
+	interval time.Duration
+	next     time.Time
+}
+
+// newRateLimiter returns a limiter allowing perSecond calls per second, or nil when
+// perSecond is not positive.
+func newRateLimiter(perSecond float64) *rateLimiter {
+	if perSecond <= 0 {
+		return nil
+	}
+	return &rateLimiter{interval: time.Duration(float64(time.Second) / perSecond)}
+}
+
+// Wait blocks until the caller's slot, or until ctx is done. A nil limiter never blocks.
+func (l *rateLimiter) Wait(ctx context.Context) error {
+	if l == nil {
+		return nil
+	}
+
+	l.mu.Lock()
+	now := time.Now()
+	slot := l.next
+	if slot.Before(now) {
+		slot = now
+	}
+	l.next = slot.Add(l.interval)
+	l.mu.Unlock()
+
+	delay := time.Until(slot)
+	if delay <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// pacedExchange wraps an Exchange so every REST call waits for the shared rate
+// limiter, keeping concurrent workers under the venue's request limits.
+type pacedExchange struct {
+	Exchange
+	limiter *rateLimiter
+}
+
+// paced wraps exchange with the API_RATE_LIMIT limiter when one is configured.
+func paced(exchange Exchange, config Config) Exchange {
+	limiter := newRateLimiter(config.APIRateLimit)
+	if limiter == nil {
+		return exchange
+	}
+	return &pacedExchange{Exchange: exchange, limiter: limiter}
+}
+
+// ServerTime implements Exchange.
+func (e *pacedExchange) ServerTime(ctx context.Context) (time.Time, error) {
+	if err := e.limiter.Wait(ctx); err != nil {
+		return time.Time{}, err
+	}
+	return e.Exchange.ServerTime(ctx)
+}
+
+// SymbolPrecisions implements Exchange.
+func (e *pacedExchange) SymbolPrecisions(ctx context.Context) (map[string]SymbolPrecision, error) {
+	if err := e.limiter.Wait(ctx); err != nil {
+		return nil, err
+	}
+	return e.Exchange.SymbolPrecisions(ctx)
+}
+
+// Positions implements Exchange.
+func (e *pacedExchange) Positions(ctx context.Context, symbol string) ([]*Position, error) {
+	if err := e.limiter.Wait(ctx); err != nil {
+		return nil, err
+	}
+	return e.Exchange.Positions(ctx, symbol)
+}
+
+// OpenOrders implements Exchange.
+func (e *pacedExchange) OpenOrders(ctx context.Context, symbol string) ([]*Order, error) {
+	if err := e.limiter.Wait(ctx); err != nil {
+		return nil, err
+	}
+	return e.Exchange.OpenOrders(ctx, symbol)
+}
+
+// CreateOrder implements Exchange.
+func (e *pacedExchange) CreateOrder(ctx context.Context, req OrderRequest) (*Order, error) {
+	if err := e.limiter.Wait(ctx); err != nil {
+		return nil, err
+	}
+	return e.Exchange.CreateOrder(ctx, req)
+}
+
+// CreateOrders implements Exchange.
+func (e *pacedExchange) CreateOrders(ctx context.Context, reqs []OrderRequest) ([]*Order, []error, error) {
+	if err := e.limiter.Wait(ctx); err != nil {
+		return nil, nil, err
+	}
+	return e.Exchange.CreateOrders(ctx, reqs)
+}
+
+// CancelOrder implements Exchange.
+func (e *pacedExchange) CancelOrder(ctx context.Context, order *Order) error {
+	if err := e.limiter.Wait(ctx); err != nil {
+		return err
+	}
+	return e.Exchange.CancelOrder(ctx, order)
+}
+
+// SyncTime implements timeSyncer when the wrapped exchange does.
+func (e *pacedExchange) SyncTime(ctx context.Context) error {
+	if syncer, ok := e.Exchange.(timeSyncer); ok {
+		return syncer.SyncTime(ctx)
+	}
+	return nil
+}
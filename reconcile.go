@@ -0,0 +1,230 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+)
+
+// isProtectiveOrder reports whether order is a stop-loss or take-profit order managed by the bot.
+func isProtectiveOrder(order *Order) bool {
+	return isStopLossOrder(order) || order.Type == orderTypeTakeProfitMarket
+}
+
+// protectiveOrders holds the live stop-loss and take-profit orders of one position.
+type protectiveOrders struct {
+	stops []*Order
+	takes []*Order
+}
+
+// reconcile compares the persisted state with live positions and open orders before
+// the first processing cycle. It cancels orphaned orders of closed positions and
+// leftover duplicates placed by the bot, and adopts stops or targets that were
+// modified manually. Orders placed manually are never cancelled here.
+func (ts *TradingService) reconcile() error {
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+	ts.recorder.record(sessionKindReconcile, "", nil, nil, nil)
+
+	positions, err := ts.exchange().Positions(ctx, "")
+	if err != nil {
+		return fmt.Errorf("error getting positions: %w", err)
+	}
+
+	openOrders, err := ts.exchange().OpenOrders(ctx, "")
+	if err != nil {
+		return fmt.Errorf("error fetching open orders: %w", err)
+	}
+
+	openPositions := make(map[string]bool)
+	for _, position := range positions {
+		if position.PositionAmt != 0 {
+			openPositions[trackedKey(position.Symbol, position.PositionSide)] = true
+		}
+	}
+
+	grouped := make(map[string]*protectiveOrders)
+	pendingEntries := make(map[string]bool)
+	for _, order := range openOrders {
+		if !ts.isSymbolManaged(order.Symbol) || ts.isSymbolManual(order.Symbol) {
+			continue
+		}
+		if !isProtectiveOrder(order) {
+			// A resting entry keeps the bracket placed with it
+			pendingEntries[trackedKey(order.Symbol, order.PositionSide)] = true
+			continue
+		}
+		key := trackedKey(order.Symbol, order.PositionSide)
+		group, ok := grouped[key]
+		if !ok {
+			group = &protectiveOrders{}
+			grouped[key] = group
+		}
+		if isStopLossOrder(order) {
+			group.stops = append(group.stops, order)
+		} else {
+			group.takes = append(group.takes, order)
+		}
+	}
+
+	// Plan the cancels under the lock and make them without it, so the network
+	// calls do not hold up the stream and API goroutines
+	var report []string
+	var cancels []reconcileCancel
+	kept := make(map[string]keptOrders)
+	ts.mu.Lock()
+	for key, group := range grouped {
+		if !openPositions[key] && pendingEntries[key] {
+			report = append(report, fmt.Sprintf("kept the bracket of the pending entry on %s", key))
+			continue
+		}
+		if !openPositions[key] {
+			// Orders left behind by a position that has since been closed
+			for _, order := range append(group.stops, group.takes...) {
+				if !isBotOrder(order) {
+					report = append(report, fmt.Sprintf("left manual %s order %s on %s", order.Type, order.ID, key))
+					continue
+				}
+				cancels = append(cancels, reconcileCancel{order: order, key: key, reason: "orphaned"})
+			}
+			continue
+		}
+
+		var recordedStop, recordedTake OrderID
+		if st, ok := ts.state.Orders[key]; ok {
+			recordedStop, recordedTake = st.StopOrderID, st.TakeOrderID
+		}
+		stop, duplicateStops := keepOne(group.stops, recordedStop)
+		take, duplicateTakes := keepOne(group.takes, recordedTake)
+		for _, order := range append(duplicateStops, duplicateTakes...) {
+			cancels = append(cancels, reconcileCancel{order: order, key: key, reason: "duplicate"})
+		}
+		kept[key] = keptOrders{stop: stop, take: take}
+	}
+	ts.mu.Unlock()
+
+	for _, c := range cancels {
+		if err := ts.cancelOrder(ctx, c.order); err != nil {
+			log.Printf("Warning: %v", err)
+			continue
+		}
+		report = append(report, fmt.Sprintf("cancelled %s %s order %s on %s", c.reason, c.order.Type, c.order.ID, c.key))
+	}
+
+	ts.mu.Lock()
+	for key, orders := range kept {
+		symbol, positionSide, _ := strings.Cut(key, ":")
+		st := ts.orderState(symbol, positionSide)
+		if stop := orders.stop; stop != nil {
+			if st.StopOrderID != stop.ID || st.StopPrice != stop.StopPrice {
+				report = append(report, fmt.Sprintf("adopted SL %s at %v on %s", stop.ID, stop.StopPrice, key))
+				st.StopOrderID = stop.ID
+				st.StopPrice = stop.StopPrice
+			}
+		}
+		if take := orders.take; take != nil {
+			if st.TakeOrderID != take.ID || st.TakePrice != take.StopPrice {
+				report = append(report, fmt.Sprintf("adopted TP %s at %v on %s", take.ID, take.StopPrice, key))
+				st.TakeOrderID = take.ID
+				st.TakePrice = take.StopPrice
+			}
+		}
+	}
+
+	for key := range ts.state.Notices {
+		if !openPositions[key] {
+			delete(ts.state.Notices, key)
+		}
+	}
+
+	// Report recorded orders that are no longer live and forget closed positions
+	for key, st := range ts.state.Orders {
+		if !openPositions[key] {
+			delete(ts.state.Orders, key)
+			continue
+		}
+		group := grouped[key]
+		if st.StopOrderID != "" && (group == nil || len(group.stops) == 0) {
+			report = append(report, fmt.Sprintf("SL %s on %s is missing and will be recreated", st.StopOrderID, key))
+			st.StopOrderID, st.StopPrice = "", 0
+		}
+		if st.TakeOrderID != "" && (group == nil || len(group.takes) == 0) {
+			report = append(report, fmt.Sprintf("TP %s on %s is missing and will be recreated", st.TakeOrderID, key))
+			st.TakeOrderID, st.TakePrice = "", 0
+		}
+	}
+	ts.mu.Unlock()
+
+	ts.saveState()
+
+	if len(report) == 0 {
+		log.Println("State reconciliation: live orders match the persisted state")
+		return nil
+	}
+
+	msg := "🔄 State reconciliation:\n- " + strings.Join(report, "\n- ")
+	log.Println(msg)
+	ts.notify(SeverityWarning, msg)
+	return nil
+}
+
+// keptOrders holds the stop-loss and take-profit order reconcile keeps on a position.
+type keptOrders struct {
+	stop *Order
+	take *Order
+}
+
+// reconcileCancel is an order reconcile cancels: an orphaned or duplicate order
+// placed by the bot on the position key.
+type reconcileCancel struct {
+	order  *Order
+	key    string
+	reason string
+}
+
+// keepOne picks the single order to keep out of orders and returns it with the
+// other orders placed by the bot, the duplicates to cancel. The order recorded
+// in state is preferred, then orders placed by the bot, then the most recently
+// updated one.
+func keepOne(orders []*Order, recordedID OrderID) (*Order, []*Order) {
+	if len(orders) == 0 {
+		return nil, nil
+	}
+
+	keep := orders[0]
+	for _, order := range orders[1:] {
+		if keep.ID == recordedID {
+			break
+		}
+		if order.ID == recordedID {
+			keep = order
+			continue
+		}
+		if isBotOrder(order) != isBotOrder(keep) {
+			if isBotOrder(order) {
+				keep = order
+			}
+			continue
+		}
+		if order.UpdateTime.After(keep.UpdateTime) {
+			keep = order
+		}
+	}
+
+	var duplicates []*Order
+	for _, order := range orders {
+		if order != keep && isBotOrder(order) {
+			duplicates = append(duplicates, order)
+		}
+	}
+	return keep, duplicates
+}
+
+// cancelOrder cancels a single open order.
+func (ts *TradingService) cancelOrder(ctx context.Context, order *Order) error {
+	if err := ts.exchange().CancelOrder(ctx, order); err != nil {
+		return fmt.Errorf("error canceling order %s for %s: %w", order.ID, order.Symbol, err)
+	}
+	return nil
+}
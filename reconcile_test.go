@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+)
+
+// lockCheckingExchange is a memoryExchange recording whether the state lock of
+// ts was held while an order was cancelled.
+type lockCheckingExchange struct {
+	*memoryExchange
+	ts     *TradingService
+	locked bool
+}
+
+// CancelOrder implements Exchange.
+func (e *lockCheckingExchange) CancelOrder(ctx context.Context, order *Order) error {
+	if e.ts.mu.TryLock() {
+		e.ts.mu.Unlock()
+	} else {
+		e.locked = true
+	}
+	return e.memoryExchange.CancelOrder(ctx, order)
+}
+
+func TestReconcile(t *testing.T) {
+	memory := newMemoryExchange("BTCUSDT", "ETHUSDT")
+	memory.Open = []*Position{{Symbol: "BTCUSDT", PositionSide: "BOTH", PositionAmt: 1, EntryPrice: 100, MarkPrice: 100, Leverage: 10}}
+	now := time.Now()
+	memory.Orders = []*Order{
+		// Two bot stops on the open position, the newer one is kept
+		{ID: "1", ClientOrderID: "fg-SL-BTCUSDT-BOTH-0", Symbol: "BTCUSDT", PositionSide: "BOTH", Type: orderTypeStopMarket, StopPrice: 95, UpdateTime: now.Add(-time.Minute)},
+		{ID: "2", ClientOrderID: "fg-SL-BTCUSDT-BOTH-1", Symbol: "BTCUSDT", PositionSide: "BOTH", Type: orderTypeStopMarket, StopPrice: 98, UpdateTime: now},
+		// Orders left on the closed ETHUSDT position
+		{ID: "3", ClientOrderID: "fg-TP-ETHUSDT-BOTH-0", Symbol: "ETHUSDT", PositionSide: "BOTH", Type: orderTypeTakeProfitMarket, StopPrice: 2000},
+		{ID: "4", ClientOrderID: "manual", Symbol: "ETHUSDT", PositionSide: "BOTH", Type: orderTypeStopMarket, StopPrice: 1500},
+	}
+	exchange := &lockCheckingExchange{memoryExchange: memory}
+	ts := newTestTradingService(t, exchange, newMemoryClient(), nil)
+	exchange.ts = ts
+
+	if err := ts.reconcile(); err != nil {
+		t.Fatalf("reconcile: %v", err)
+	}
+	if exchange.locked {
+		t.Error("orders cancelled while holding the state lock")
+	}
+	var cancelled []OrderID
+	for _, order := range memory.Cancelled {
+		cancelled = append(cancelled, order.ID)
+	}
+	if len(cancelled) != 2 || !slices.Contains(cancelled, "1") || !slices.Contains(cancelled, "3") {
+		t.Errorf("cancelled %v, want the duplicate stop 1 and the orphaned target 3", cancelled)
+	}
+	st := ts.state.Orders[trackedKey("BTCUSDT", "BOTH")]
+	if st == nil || st.StopOrderID != "2" || st.StopPrice != 98 {
+		t.Errorf("order state %+v, want the stop 2 at 98 adopted", st)
+	}
+	if _, ok := ts.state.Orders[trackedKey("ETHUSDT", "BOTH")]; ok {
+		t.Error("order state of the closed ETHUSDT position kept")
+	}
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// defaultRedisKeyPrefix namespaces the keys of the bot unless REDIS_KEY_PREFIX is set.
+const defaultRedisKeyPrefix = "futures-guard:"
+
+// redisStateStore persists the bot state as JSON under one Redis key, so the
+// daemon, the API server and reporting commands on other hosts share it.
+type redisStateStore struct {
+	client *redis.Client
+	key    string
+}
+
+// newRedisStateStore connects to the Redis server at url, such as
+// redis://:password@localhost:6379/0, and checks that it is reachable.
+func newRedisStateStore(url, prefix string) (*redisStateStore, error) {
+	opts, err := redis.ParseURL(url)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing REDIS_URL: %w", err)
+	}
+	client := redis.NewClient(opts)
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+	if err := client.Ping(ctx).Err(); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("error connecting to Redis: %w", err)
+	}
+	return &redisStateStore{client: client, key: prefix + "state"}, nil
+}
+
+// Load reads the state, returning an empty state when none was saved yet.
+func (s *redisStateStore) Load() (*BotState, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+
+	data, err := s.client.Get(ctx, s.key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return newBotState(), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading state from Redis key %s: %w", s.key, err)
+	}
+
+	state := newBotState()
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, fmt.Errorf("error parsing state from Redis key %s: %w", s.key, err)
+	}
+	if state.Orders == nil {
+		state.Orders = make(map[string]*OrderState)
+	}
+	if state.Notices == nil {
+		state.Notices = make(map[string]*NoticeState)
+	}
+	return state, nil
+}
+
+// Save replaces the state in a single write.
+func (s *redisStateStore) Save(state *BotState) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("error encoding state: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+	if err := s.client.Set(ctx, s.key, data, 0).Err(); err != nil {
+		return fmt.Errorf("error writing state to Redis key %s: %w", s.key, err)
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+)
+
+// quantityTolerance absorbs float noise when comparing order and position sizes.
+const quantityTolerance = 1e-9
+
+// checkReduceOnly verifies that the protective order req can only reduce the
+// position of data: it must close the position's side and, unless it closes the
+// whole position, be reduce-only or in hedge mode and no larger than the position.
+func checkReduceOnly(req OrderRequest, data *PositionData) error {
+	if req.Side != closeSide(data) {
+		return fmt.Errorf("%s %s order for %s would add to the %s position", req.Side, req.Type, data.Symbol, data.PositionSide)
+	}
+	if req.ClosePosition {
+		return nil
+	}
+	if !req.ReduceOnly && (req.PositionSide == "" || req.PositionSide == "BOTH") {
+		return fmt.Errorf("%s order for %s is not reduce-only in one-way mode", req.Type, data.Symbol)
+	}
+	quantity, err := strconv.ParseFloat(req.Quantity, 64)
+	if err != nil || quantity <= 0 {
+		return fmt.Errorf("invalid %s quantity %q for %s", req.Type, req.Quantity, data.Symbol)
+	}
+	if quantity > data.AbsAmt*(1+quantityTolerance) {
+		return fmt.Errorf("%s quantity %s for %s exceeds the position of %v", req.Type, req.Quantity, data.Symbol, data.AbsAmt)
+	}
+	return nil
+}
+
+// oversizedProtection reports whether the live stop-loss and take-profit of data
+// are sized for more than the position, as left behind by a partial close.
+func (ts *TradingService) oversizedProtection(data *PositionData) (stop, take bool) {
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+
+	openOrders, err := ts.exchange().OpenOrders(ctx, data.Symbol)
+	if err != nil {
+		log.Printf("Warning: Unable to check protective order sizes for %s: %v", data.Symbol, err)
+		return false, false
+	}
+	for _, order := range openOrders {
+		if !isProtectiveOrder(order) || !orderMatchesSide(order, data.PositionSide) || !ts.ownsOrder(order) {
+			continue
+		}
+		if order.Quantity <= data.AbsAmt*(1+quantityTolerance) {
+			continue
+		}
+		log.Printf("%s order %s for %s is sized %v for a position of %v, resizing it",
+			order.Type, order.ID, data.Symbol, order.Quantity, data.AbsAmt)
+		if isStopLossOrder(order) {
+			stop = true
+		} else {
+			take = true
+		}
+	}
+	return stop, take
+}
@@ -0,0 +1,215 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"reflect"
+	"strings"
+	"time"
+
+	"futures-guard/settings"
+)
+
+// Config reload defaults.
+const (
+	defaultConfigFile           = ".env"
+	defaultConfigReloadInterval = 10 * time.Second
+)
+
+// configFile returns the env file the configuration is loaded from.
+func configFile() string {
+	if file := os.Getenv("CONFIG_FILE"); file != "" {
+		return file
+	}
+	return defaultConfigFile
+}
+
+// configOverrides are the KEY=VALUE settings of the --set flags.
+var configOverrides []string
+
+// loadSettings returns the settings of the --set flags, the environment and the
+// config file, in that order of precedence. The default config file may be
+// missing, one named by CONFIG_FILE or --config may not.
+func loadSettings() (*settings.Settings, error) {
+	flags, err := settings.ParseFlags(configOverrides)
+	if err != nil {
+		return nil, err
+	}
+	file, err := settings.ReadFile(configFile())
+	if err != nil && (os.Getenv("CONFIG_FILE") != "" || !errors.Is(err, fs.ErrNotExist)) {
+		return nil, err
+	}
+	return settings.New(flags, settings.Env{}, file), nil
+}
+
+// hotReloadField is a setting that can change without a restart.
+type hotReloadField struct {
+	name string
+	get  func(c *Config) any
+	set  func(dst, src *Config)
+}
+
+// hotReloadFields lists the settings applied to the running service on reload.
+// Connection, exchange and daemon settings still require a restart.
+var hotReloadFields = []hotReloadField{
+	{"DEFAULT_SL_PERCENT", func(c *Config) any { return c.DefaultSLPercent }, func(d, s *Config) { d.DefaultSLPercent = s.DefaultSLPercent }},
+	{"TP_PERCENT", func(c *Config) any { return c.TPPercent }, func(d, s *Config) { d.TPPercent = s.TPPercent }},
+	{"SL_FIXED", func(c *Config) any { return c.SLFixed }, func(d, s *Config) { d.SLFixed = s.SLFixed }},
+	{"SL_UPDATE_HYSTERESIS", func(c *Config) any { return c.SLUpdateHysteresis }, func(d, s *Config) { d.SLUpdateHysteresis = s.SLUpdateHysteresis }},
+	{"TP_UPDATE_HYSTERESIS", func(c *Config) any { return c.TPUpdateHysteresis }, func(d, s *Config) { d.TPUpdateHysteresis = s.TPUpdateHysteresis }},
+	{"SL_UPDATE_HYSTERESIS_OVERRIDES", func(c *Config) any { return c.SLUpdateHysteresisOverrides }, func(d, s *Config) { d.SLUpdateHysteresisOverrides = s.SLUpdateHysteresisOverrides }},
+	{"TP_UPDATE_HYSTERESIS_OVERRIDES", func(c *Config) any { return c.TPUpdateHysteresisOverrides }, func(d, s *Config) { d.TPUpdateHysteresisOverrides = s.TPUpdateHysteresisOverrides }},
+	{"ORDER_UPDATE_COOLDOWN", func(c *Config) any { return c.OrderUpdateCooldown }, func(d, s *Config) { d.OrderUpdateCooldown = s.OrderUpdateCooldown }},
+	{"TP_DISABLED", func(c *Config) any { return c.TPDisabled }, func(d, s *Config) { d.TPDisabled = s.TPDisabled }},
+	{"TP_DISABLED_SYMBOLS", func(c *Config) any { return c.TPDisabledSymbols }, func(d, s *Config) { d.TPDisabledSymbols = s.TPDisabledSymbols }},
+	{"TP_MIN_PROFIT", func(c *Config) any { return c.TPMinProfit }, func(d, s *Config) { d.TPMinProfit = s.TPMinProfit }},
+	{"TP_MIN_AGE", func(c *Config) any { return c.TPMinAge }, func(d, s *Config) { d.TPMinAge = s.TPMinAge }},
+	{"SL_ORDER_TYPE", func(c *Config) any { return c.SLOrderType }, func(d, s *Config) { d.SLOrderType = s.SLOrderType }},
+	{"SL_LIMIT_OFFSET", func(c *Config) any { return c.SLLimitOffset }, func(d, s *Config) { d.SLLimitOffset = s.SLLimitOffset }},
+	{"SL_LIMIT_TIMEOUT", func(c *Config) any { return c.SLLimitTimeout }, func(d, s *Config) { d.SLLimitTimeout = s.SLLimitTimeout }},
+	{"SYMBOL_WHITELIST", func(c *Config) any { return c.SymbolWhitelist }, func(d, s *Config) { d.SymbolWhitelist = s.SymbolWhitelist }},
+	{"SYMBOL_BLACKLIST", func(c *Config) any { return c.SymbolBlacklist }, func(d, s *Config) { d.SymbolBlacklist = s.SymbolBlacklist }},
+	{"PROTECT_ONLY_BOT_ORDERS", func(c *Config) any { return c.ProtectOnlyBotOrders }, func(d, s *Config) { d.ProtectOnlyBotOrders = s.ProtectOnlyBotOrders }},
+	{"SMALL_POSITION_ACTION", func(c *Config) any { return c.SmallPositionAction }, func(d, s *Config) { d.SmallPositionAction = s.SmallPositionAction }},
+	{"SL_STRATEGY", func(c *Config) any { return c.SLStrategy }, func(d, s *Config) { d.SLStrategy = s.SLStrategy }},
+	{"TP_STRATEGY", func(c *Config) any { return c.TPStrategy }, func(d, s *Config) { d.TPStrategy = s.TPStrategy }},
+	{"SL_STRATEGY_OVERRIDES", func(c *Config) any { return c.SLStrategyOverrides }, func(d, s *Config) { d.SLStrategyOverrides = s.SLStrategyOverrides }},
+	{"TP_STRATEGY_OVERRIDES", func(c *Config) any { return c.TPStrategyOverrides }, func(d, s *Config) { d.TPStrategyOverrides = s.TPStrategyOverrides }},
+	{"R_LADDER", func(c *Config) any { return c.RLadder }, func(d, s *Config) { d.RLadder = s.RLadder }},
+	{"PROFIT_METRIC", func(c *Config) any { return c.ProfitMetric }, func(d, s *Config) { d.ProfitMetric = s.ProfitMetric }},
+	{"LADDER_LEVELS", func(c *Config) any { return c.LadderLevels }, func(d, s *Config) { d.LadderLevels = s.LadderLevels }},
+	{"LADDER_PRESET", func(c *Config) any { return c.LadderPreset }, func(d, s *Config) { d.LadderPreset = s.LadderPreset }},
+	{"LADDER_PRESET_OVERRIDES", func(c *Config) any { return c.LadderPresetOverrides }, func(d, s *Config) { d.LadderPresetOverrides = s.LadderPresetOverrides }},
+	{"LADDER_PRESETS_FILE", func(c *Config) any { return c.LadderPresets }, func(d, s *Config) { d.LadderPresets = s.LadderPresets }},
+	{"LADDER_INTERPOLATE", func(c *Config) any { return c.LadderInterpolate }, func(d, s *Config) { d.LadderInterpolate = s.LadderInterpolate }},
+	{"LADDER_HIGH_WATER_MARK", func(c *Config) any { return c.LadderHighWaterMark }, func(d, s *Config) { d.LadderHighWaterMark = s.LadderHighWaterMark }},
+	{"LADDER_CONFIRM_CYCLES", func(c *Config) any { return c.LadderConfirmCycles }, func(d, s *Config) { d.LadderConfirmCycles = s.LadderConfirmCycles }},
+	{"LADDER_CONFIRM_INTERVAL", func(c *Config) any { return c.LadderConfirmInterval }, func(d, s *Config) { d.LadderConfirmInterval = s.LadderConfirmInterval }},
+	{"TP_RISK_REWARD", func(c *Config) any { return c.TPRiskReward }, func(d, s *Config) { d.TPRiskReward = s.TPRiskReward }},
+	{"TP_RISK_REWARD_OVERRIDES", func(c *Config) any { return c.TPRiskRewardOverrides }, func(d, s *Config) { d.TPRiskRewardOverrides = s.TPRiskRewardOverrides }},
+	{"TP_VOL_SCALE", func(c *Config) any { return c.TPVolScale }, func(d, s *Config) { d.TPVolScale = s.TPVolScale }},
+	{"TP_VOL_SCALE_SYMBOLS", func(c *Config) any { return c.TPVolScaleSymbols }, func(d, s *Config) { d.TPVolScaleSymbols = s.TPVolScaleSymbols }},
+	{"TP_VOL_REFERENCE", func(c *Config) any { return c.TPVolReference }, func(d, s *Config) { d.TPVolReference = s.TPVolReference }},
+	{"TP_VOL_REFERENCE_OVERRIDES", func(c *Config) any { return c.TPVolReferenceOverrides }, func(d, s *Config) { d.TPVolReferenceOverrides = s.TPVolReferenceOverrides }},
+	{"TREND_FILTER", func(c *Config) any { return c.TrendFilter }, func(d, s *Config) { d.TrendFilter = s.TrendFilter }},
+	{"TREND_FILTER_SYMBOLS", func(c *Config) any { return c.TrendFilterSymbols }, func(d, s *Config) { d.TrendFilterSymbols = s.TrendFilterSymbols }},
+	{"TREND_STRONG", func(c *Config) any { return c.TrendStrong }, func(d, s *Config) { d.TrendStrong = s.TrendStrong }},
+	{"TREND_CHOPPY", func(c *Config) any { return c.TrendChoppy }, func(d, s *Config) { d.TrendChoppy = s.TrendChoppy }},
+	{"TREND_LADDERS", func(c *Config) any { return c.TrendLadders }, func(d, s *Config) { d.TrendLadders = s.TrendLadders }},
+	{"TREND_LADDER_OVERRIDES", func(c *Config) any { return c.TrendLadderOverrides }, func(d, s *Config) { d.TrendLadderOverrides = s.TrendLadderOverrides }},
+	{"LIQUIDATION_GUARD_PERCENT", func(c *Config) any { return c.LiquidationGuardPct }, func(d, s *Config) { d.LiquidationGuardPct = s.LiquidationGuardPct }},
+	{"LIQUIDATION_ACTION", func(c *Config) any { return c.LiquidationAction }, func(d, s *Config) { d.LiquidationAction = s.LiquidationAction }},
+	{"LIQUIDATION_REDUCE_PERCENT", func(c *Config) any { return c.LiquidationReducePct }, func(d, s *Config) { d.LiquidationReducePct = s.LiquidationReducePct }},
+	{"SPREAD_GUARD_DISTANCE", func(c *Config) any { return c.SpreadGuardDistance }, func(d, s *Config) { d.SpreadGuardDistance = s.SpreadGuardDistance }},
+	{"SPREAD_MAX_PERCENT", func(c *Config) any { return c.SpreadMaxPct }, func(d, s *Config) { d.SpreadMaxPct = s.SpreadMaxPct }},
+	{"SPREAD_MIN_DEPTH", func(c *Config) any { return c.SpreadMinDepth }, func(d, s *Config) { d.SpreadMinDepth = s.SpreadMinDepth }},
+	{"SPREAD_BUFFER_PERCENT", func(c *Config) any { return c.SpreadBufferPct }, func(d, s *Config) { d.SpreadBufferPct = s.SpreadBufferPct }},
+	{"ROUND_NUMBER_NUDGE_TICKS", func(c *Config) any { return c.RoundNumberNudgeTicks }, func(d, s *Config) { d.RoundNumberNudgeTicks = s.RoundNumberNudgeTicks }},
+	{"ADL_ALERT_QUANTILE", func(c *Config) any { return c.ADLAlertQuantile }, func(d, s *Config) { d.ADLAlertQuantile = s.ADLAlertQuantile }},
+	{"MARGIN_RATIO_ALERT_PERCENT", func(c *Config) any { return c.MarginRatioAlertPct }, func(d, s *Config) { d.MarginRatioAlertPct = s.MarginRatioAlertPct }},
+	{"MARGIN_RISK_ACTION", func(c *Config) any { return c.MarginRiskAction }, func(d, s *Config) { d.MarginRiskAction = s.MarginRiskAction }},
+	{"MARGIN_ADD_AMOUNT", func(c *Config) any { return c.MarginAddAmount }, func(d, s *Config) { d.MarginAddAmount = s.MarginAddAmount }},
+	{"MARGIN_REDUCE_PERCENT", func(c *Config) any { return c.MarginReducePct }, func(d, s *Config) { d.MarginReducePct = s.MarginReducePct }},
+	{"SCHEDULE_WINDOWS", func(c *Config) any { return c.ScheduleWindows }, func(d, s *Config) { d.ScheduleWindows = s.ScheduleWindows }},
+	{"ACCOUNT_PNL_RETRACE_PERCENT", func(c *Config) any { return c.AccountPnLRetracePct }, func(d, s *Config) { d.AccountPnLRetracePct = s.AccountPnLRetracePct }},
+	{"ACCOUNT_PNL_ACTION", func(c *Config) any { return c.AccountPnLAction }, func(d, s *Config) { d.AccountPnLAction = s.AccountPnLAction }},
+	{"FREE_MARGIN_ALERT_PERCENT", func(c *Config) any { return c.FreeMarginAlertPct }, func(d, s *Config) { d.FreeMarginAlertPct = s.FreeMarginAlertPct }},
+	{"FREE_MARGIN_BLOCK_ADDS", func(c *Config) any { return c.FreeMarginBlockAdds }, func(d, s *Config) { d.FreeMarginBlockAdds = s.FreeMarginBlockAdds }},
+	{"STRATEGY_TAGS_FILE", func(c *Config) any { return c.StrategyTags }, func(d, s *Config) { d.StrategyTags = s.StrategyTags }},
+	{"STRATEGY_TAG_PREFIXES", func(c *Config) any { return c.StrategyTagPrefixes }, func(d, s *Config) { d.StrategyTagPrefixes = s.StrategyTagPrefixes }},
+	{"STRATEGY_TAG_MAX_POSITIONS", func(c *Config) any { return c.StrategyTagMaxPositions }, func(d, s *Config) { d.StrategyTagMaxPositions = s.StrategyTagMaxPositions }},
+	{"DCA_LEVELS", func(c *Config) any { return c.DCALevels }, func(d, s *Config) { d.DCALevels = s.DCALevels }},
+	{"DCA_SIZE_MULTIPLIER", func(c *Config) any { return c.DCASizeMultiplier }, func(d, s *Config) { d.DCASizeMultiplier = s.DCASizeMultiplier }},
+	{"DCA_MAX_ADDS", func(c *Config) any { return c.DCAMaxAdds }, func(d, s *Config) { d.DCAMaxAdds = s.DCAMaxAdds }},
+	{"PYRAMID_FRACTION", func(c *Config) any { return c.PyramidFraction }, func(d, s *Config) { d.PyramidFraction = s.PyramidFraction }},
+	{"PYRAMID_MAX_ADDS", func(c *Config) any { return c.PyramidMaxAdds }, func(d, s *Config) { d.PyramidMaxAdds = s.PyramidMaxAdds }},
+	{"SCALE_OUT_FRACTION", func(c *Config) any { return c.ScaleOutFraction }, func(d, s *Config) { d.ScaleOutFraction = s.ScaleOutFraction }},
+	{"GRID_ORDER_PREFIXES", func(c *Config) any { return c.GridOrderPrefixes }, func(d, s *Config) { d.GridOrderPrefixes = s.GridOrderPrefixes }},
+	{"GRID_STOP_BUFFER_PERCENT", func(c *Config) any { return c.GridStopBufferPct }, func(d, s *Config) { d.GridStopBufferPct = s.GridStopBufferPct }},
+	{"GRID_RECENTER_PERCENT", func(c *Config) any { return c.GridRecenterPct }, func(d, s *Config) { d.GridRecenterPct = s.GridRecenterPct }},
+	{"STOP_TRIGGER_ACTION", func(c *Config) any { return c.StopTriggerAction }, func(d, s *Config) { d.StopTriggerAction = s.StopTriggerAction }},
+	{"STOP_TRIGGER_TICKS", func(c *Config) any { return c.StopTriggerTicks }, func(d, s *Config) { d.StopTriggerTicks = s.StopTriggerTicks }},
+	{"MAX_HOLDING_TIME", func(c *Config) any { return c.MaxHoldingTime }, func(d, s *Config) { d.MaxHoldingTime = s.MaxHoldingTime }},
+	{"MAX_HOLDING_TIME_OVERRIDES", func(c *Config) any { return c.MaxHoldingTimeOverrides }, func(d, s *Config) { d.MaxHoldingTimeOverrides = s.MaxHoldingTimeOverrides }},
+	{"LEVERAGE_TARGET", func(c *Config) any { return c.LeverageTarget }, func(d, s *Config) { d.LeverageTarget = s.LeverageTarget }},
+	{"LEVERAGE_TARGET_OVERRIDES", func(c *Config) any { return c.LeverageTargetOverrides }, func(d, s *Config) { d.LeverageTargetOverrides = s.LeverageTargetOverrides }},
+	{"MARGIN_TYPE_TARGET", func(c *Config) any { return c.MarginTypeTarget }, func(d, s *Config) { d.MarginTypeTarget = s.MarginTypeTarget }},
+	{"MARGIN_TYPE_TARGET_OVERRIDES", func(c *Config) any { return c.MarginTypeTargetOverrides }, func(d, s *Config) { d.MarginTypeTargetOverrides = s.MarginTypeTargetOverrides }},
+	{"LEVERAGE_ACTION", func(c *Config) any { return c.LeverageAction }, func(d, s *Config) { d.LeverageAction = s.LeverageAction }},
+}
+
+// watchConfig polls the config file and signals reloads when its modification
+// time changes, until ctx is cancelled.
+func watchConfig(ctx context.Context, file string, interval time.Duration, reloads chan<- struct{}) {
+	lastMod := time.Time{}
+	if info, err := os.Stat(file); err == nil {
+		lastMod = info.ModTime()
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			info, err := os.Stat(file)
+			if err != nil || !info.ModTime().After(lastMod) {
+				continue
+			}
+			lastMod = info.ModTime()
+			select {
+			case reloads <- struct{}{}:
+			default:
+				// A reload is already pending
+			}
+		}
+	}
+}
+
+// reloadConfig re-reads the config file and applies the hot-reloadable settings,
+// notifying a summary of what changed. The settings are swapped in together, so
+// a cycle or stream update running meanwhile sees either the old or the new ones.
+// An invalid config is reported and leaves the running one in place.
+func (ts *TradingService) reloadConfig() {
+	file := configFile()
+	fresh, err := loadConfig()
+	if err == nil {
+		err = checkConfig(fresh)
+	}
+	if err != nil {
+		msg := fmt.Sprintf("⚠️ Config file %s changed but is invalid, keeping the running config:\n%v", file, err)
+		log.Println(msg)
+		ts.notify(SeverityWarning, msg)
+		return
+	}
+
+	// The running config is shared with the stream and API goroutines, so the
+	// changes go to a copy swapped in at once
+	current := ts.config()
+	next := *current
+	var changes []string
+	for _, field := range hotReloadFields {
+		before, after := field.get(current), field.get(&fresh)
+		if reflect.DeepEqual(before, after) {
+			continue
+		}
+		changes = append(changes, fmt.Sprintf("%s: %v → %v", field.name, before, after))
+		field.set(&next, &fresh)
+	}
+
+	if len(changes) == 0 {
+		log.Printf("Config file %s changed, no hot-reloadable settings differ", file)
+		return
+	}
+	ts.cfg.Store(&next)
+
+	msg := fmt.Sprintf("🔄 Config reloaded from %s\n%s", file, strings.Join(changes, "\n"))
+	log.Println(msg)
+	ts.notify(SeverityInfo, msg)
+}
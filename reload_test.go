@@ -0,0 +1,118 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"sync"
+	"testing"
+
+	"futures-guard/ladder"
+)
+
+// TestReloadConfigWhileProcessing reloads the config file over and over while
+// cycles and readers of the config run, for go test -race to catch a setting
+// read while it is replaced.
+func TestReloadConfigWhileProcessing(t *testing.T) {
+	exchange := newMemoryExchange("BTCUSDT")
+	exchange.Open = []*Position{{Symbol: "BTCUSDT", PositionSide: "BOTH", PositionAmt: 1, EntryPrice: 100, MarkPrice: 104, Leverage: 10}}
+	ts := newTestTradingService(t, exchange, newMemoryClient(), nil)
+
+	// The environment would take precedence over the file, Setenv restores it
+	for _, key := range []string{"DEFAULT_SL_PERCENT", "TP_PERCENT"} {
+		t.Setenv(key, "")
+		os.Unsetenv(key)
+	}
+	writeEnv := func(i int) {
+		env := fmt.Sprintf("DEFAULT_SL_PERCENT=%d\nTP_PERCENT=%d\n", 2+i%3, 5+i%4)
+		if err := os.WriteFile(configFile(), []byte(env), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	const rounds = 20
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for range rounds {
+			if err := ts.processPositions(); err != nil {
+				t.Errorf("processPositions: %v", err)
+			}
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for range rounds * 10 {
+			config := ts.config()
+			if config.DefaultSLPercent <= 0 || config.TPPercent <= 0 {
+				t.Errorf("read SL %v%% and TP %v%%", config.DefaultSLPercent, config.TPPercent)
+			}
+		}
+	}()
+	for i := range rounds {
+		writeEnv(i)
+		ts.reloadConfig()
+	}
+	wg.Wait()
+
+	writeEnv(1)
+	ts.reloadConfig()
+	if got := ts.config(); got.DefaultSLPercent != 3 || got.TPPercent != 6 {
+		t.Errorf("after reload SL %v%% and TP %v%%, want 3%% and 6%%", got.DefaultSLPercent, got.TPPercent)
+	}
+}
+
+func TestReloadConfigKeepsRunningOnProblems(t *testing.T) {
+	ts := newTestTradingService(t, newMemoryExchange("BTCUSDT"), newMemoryClient(), nil)
+	levels := ts.config().LadderLevels
+
+	// A ladder locking less at a higher threshold loads but would loosen the stop
+	t.Setenv("LADDER_LEVELS", "300:100,450:50")
+	ts.reloadConfig()
+	if got := ts.config().LadderLevels; !reflect.DeepEqual(got, levels) {
+		t.Errorf("ladder %v after reloading a loosening one, want the running %v", got, levels)
+	}
+}
+
+// TestReloadConfigSnapshot switches between a leveraged and a raw ladder while
+// the ladder is read, which must never pair one metric with the other's levels.
+func TestReloadConfigSnapshot(t *testing.T) {
+	ts := newTestTradingService(t, newMemoryExchange("BTCUSDT"), newMemoryClient(), nil)
+	for _, key := range []string{"PROFIT_METRIC", "LADDER_LEVELS"} {
+		t.Setenv(key, "")
+		os.Unsetenv(key)
+	}
+	ladders := []struct {
+		env    string
+		metric ladder.Metric
+		first  float64 // Threshold of the first level
+	}{
+		{"PROFIT_METRIC=leveraged\nLADDER_LEVELS=300:0,450:150\n", ladder.Leveraged, 300},
+		{"PROFIT_METRIC=raw\nLADDER_LEVELS=3:0,4.5:1.5\n", ladder.Raw, 3},
+	}
+
+	const rounds = 20
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for range rounds * 10 {
+			l := ts.stopLadder("BTCUSDT")
+			for _, want := range ladders {
+				if l.Metric == want.metric && l.Levels[0].ProfitThreshold != want.first {
+					t.Errorf("%s ladder starting at %v, want %v", l.Metric, l.Levels[0].ProfitThreshold, want.first)
+				}
+			}
+		}
+	}()
+	for i := range rounds {
+		if err := os.WriteFile(configFile(), []byte(ladders[i%2].env), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		ts.reloadConfig()
+		if got := ts.config().ProfitMetric; got != string(ladders[i%2].metric) {
+			t.Fatalf("PROFIT_METRIC %s after reload %d, want %s", got, i, ladders[i%2].metric)
+		}
+	}
+	<-done
+}
@@ -0,0 +1,324 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	binance "github.com/adshao/go-binance/v2/futures"
+)
+
+// replayExchange serves the exchange responses of a recorded session in the order
+// they were recorded, and compares the orders the bot places and cancels with the
+// recorded ones.
+type replayExchange struct {
+	name string
+
+	mu          sync.Mutex
+	calls       map[string][]*sessionEntry // Recorded calls by kind and symbol, in order
+	writes      int
+	divergences []string
+}
+
+// newReplayExchange queues the exchange calls among entries.
+func newReplayExchange(name string, entries []*sessionEntry) *replayExchange {
+	e := &replayExchange{name: name, calls: make(map[string][]*sessionEntry)}
+	for _, entry := range entries {
+		switch entry.Kind {
+		case sessionKindHeader, sessionKindCycle, sessionKindReconcile, sessionKindMarkPrices:
+			continue
+		}
+		key := replayKey(entry.Kind, entry.Symbol)
+		e.calls[key] = append(e.calls[key], entry)
+	}
+	return e
+}
+
+// replayKey identifies the queue of recorded calls of kind for symbol.
+func replayKey(kind, symbol string) string {
+	return kind + ":" + symbol
+}
+
+// isSessionWrite reports whether kind places or cancels orders.
+func isSessionWrite(kind string) bool {
+	return kind == sessionKindCreateOrder || kind == sessionKindCreateOrders || kind == sessionKindCancelOrder
+}
+
+// diverge records a difference between the replay and the recording.
+func (e *replayExchange) diverge(format string, args ...interface{}) {
+	msg := fmt.Sprintf(format, args...)
+	log.Printf("REPLAY DIVERGENCE: %s", msg)
+	e.divergences = append(e.divergences, msg)
+}
+
+// next returns the next recorded call of kind for symbol. For orders placed or
+// cancelled, request is compared with the recorded request.
+func (e *replayExchange) next(kind, symbol string, request interface{}) (*sessionEntry, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	key := replayKey(kind, symbol)
+	queue := e.calls[key]
+	if len(queue) == 0 {
+		if isSessionWrite(kind) {
+			body, _ := json.Marshal(request)
+			e.diverge("%s %s was not recorded: %s", kind, symbol, body)
+		}
+		return nil, fmt.Errorf("no recorded %s response for %q left in the session", kind, symbol)
+	}
+	entry := queue[0]
+	e.calls[key] = queue[1:]
+
+	if isSessionWrite(kind) {
+		e.writes++
+		body, err := json.Marshal(request)
+		if err != nil {
+			return nil, err
+		}
+		if !bytes.Equal(body, entry.Request) {
+			e.diverge("%s %s differs from the recording (seq %d)\n  recorded: %s\n  replayed: %s",
+				kind, symbol, entry.Seq, entry.Request, body)
+		}
+	}
+	return entry, nil
+}
+
+// decode reads the next recorded call of kind into result and returns its error.
+func (e *replayExchange) decode(kind, symbol string, request, result interface{}) error {
+	entry, err := e.next(kind, symbol, request)
+	if err != nil {
+		return err
+	}
+	if result != nil && len(entry.Result) > 0 {
+		if err := json.Unmarshal(entry.Result, result); err != nil {
+			return fmt.Errorf("error decoding recorded %s (seq %d): %w", kind, entry.Seq, err)
+		}
+	}
+	return entry.Error.err()
+}
+
+// unreplayed reports the recorded orders placed or cancelled that the replay never
+// reached, as divergences.
+func (e *replayExchange) unreplayed() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	var missing []*sessionEntry
+	for _, queue := range e.calls {
+		for _, entry := range queue {
+			if isSessionWrite(entry.Kind) {
+				missing = append(missing, entry)
+			}
+		}
+	}
+	sort.Slice(missing, func(i, j int) bool { return missing[i].Seq < missing[j].Seq })
+	for _, entry := range missing {
+		e.diverge("%s %s was recorded but not replayed (seq %d): %s", entry.Kind, entry.Symbol, entry.Seq, entry.Request)
+	}
+}
+
+// Name implements Exchange.
+func (e *replayExchange) Name() string {
+	return e.name
+}
+
+// ServerTime implements Exchange.
+func (e *replayExchange) ServerTime(ctx context.Context) (time.Time, error) {
+	var t time.Time
+	err := e.decode(sessionKindServerTime, "", nil, &t)
+	return t, err
+}
+
+// SymbolPrecisions implements Exchange.
+func (e *replayExchange) SymbolPrecisions(ctx context.Context) (map[string]SymbolPrecision, error) {
+	var precisions map[string]SymbolPrecision
+	err := e.decode(sessionKindPrecisions, "", nil, &precisions)
+	return precisions, err
+}
+
+// Positions implements Exchange.
+func (e *replayExchange) Positions(ctx context.Context, symbol string) ([]*Position, error) {
+	var positions []*Position
+	err := e.decode(sessionKindPositions, symbol, nil, &positions)
+	return positions, err
+}
+
+// OpenOrders implements Exchange.
+func (e *replayExchange) OpenOrders(ctx context.Context, symbol string) ([]*Order, error) {
+	var orders []*Order
+	err := e.decode(sessionKindOpenOrders, symbol, nil, &orders)
+	return orders, err
+}
+
+// CreateOrder implements Exchange.
+func (e *replayExchange) CreateOrder(ctx context.Context, req OrderRequest) (*Order, error) {
+	var order *Order
+	err := e.decode(sessionKindCreateOrder, req.Symbol, req, &order)
+	return order, err
+}
+
+// CreateOrders implements Exchange.
+func (e *replayExchange) CreateOrders(ctx context.Context, reqs []OrderRequest) ([]*Order, []error, error) {
+	symbol := ""
+	if len(reqs) > 0 {
+		symbol = reqs[0].Symbol
+	}
+	entry, err := e.next(sessionKindCreateOrders, symbol, reqs)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	orders := make([]*Order, len(reqs))
+	errs := make([]error, len(reqs))
+	var recorded []*Order
+	if len(entry.Result) > 0 {
+		if err := json.Unmarshal(entry.Result, &recorded); err != nil {
+			return nil, nil, fmt.Errorf("error decoding recorded %s (seq %d): %w", entry.Kind, entry.Seq, err)
+		}
+	}
+	copy(orders, recorded)
+	for i, orderErr := range entry.Errors {
+		if i < len(errs) {
+			errs[i] = orderErr.err()
+		}
+	}
+	return orders, errs, entry.Error.err()
+}
+
+// CancelOrder implements Exchange.
+func (e *replayExchange) CancelOrder(ctx context.Context, order *Order) error {
+	return e.decode(sessionKindCancelOrder, order.Symbol, order, nil)
+}
+
+// replayResult summarizes a replayed session.
+type replayResult struct {
+	Cycles      int
+	Reconciles  int
+	StreamTicks int
+	Writes      int
+	Divergences []string
+}
+
+// readSession reads the entries of a session file.
+func readSession(path string) ([]*sessionEntry, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("error opening session file: %w", err)
+	}
+	defer file.Close()
+
+	var entries []*sessionEntry
+	dec := json.NewDecoder(file)
+	for {
+		var entry sessionEntry
+		if err := dec.Decode(&entry); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("error reading session file %s: %w", path, err)
+		}
+		entries = append(entries, &entry)
+	}
+	if len(entries) == 0 || entries[0].Kind != sessionKindHeader {
+		return nil, fmt.Errorf("%s is not a recorded session", path)
+	}
+	return entries, nil
+}
+
+// replayConfig prepares config for a replay: the recorded exchange, one position
+// at a time, and nothing sent anywhere or persisted outside stateFile.
+func replayConfig(config Config, exchange, stateFile string) Config {
+	config.Exchange = exchange
+	config.RecordSession = ""
+	config.StateFile = stateFile
+	config.ObserveOnly = false
+	config.MaxConcurrency = 1
+	config.APIRateLimit = 0
+	config.Notifiers = nil
+	config.WebhookURLs = nil
+	config.InfluxURL = ""
+	return config
+}
+
+// replaySession feeds the session recorded at path back through a trading service
+// configured by config, cycle by cycle and tick by tick, and reports where the
+// orders it places or cancels differ from the recorded ones.
+func replaySession(path string, config Config) (*replayResult, error) {
+	entries, err := readSession(path)
+	if err != nil {
+		return nil, err
+	}
+	var header sessionHeader
+	if err := json.Unmarshal(entries[0].Result, &header); err != nil {
+		return nil, fmt.Errorf("error reading session header: %w", err)
+	}
+
+	// The replay starts from the recorded state and must not touch the live one
+	dir, err := os.MkdirTemp("", "futures-guard-replay")
+	if err != nil {
+		return nil, fmt.Errorf("error creating replay state directory: %w", err)
+	}
+	defer os.RemoveAll(dir)
+	stateFile := filepath.Join(dir, "state.json")
+	if header.State != nil {
+		if err := newFileStateStore(stateFile).Save(header.State); err != nil {
+			return nil, fmt.Errorf("error writing the recorded state: %w", err)
+		}
+	}
+
+	config = replayConfig(config, header.Exchange, stateFile)
+	exchange := newReplayExchange(header.Exchange, entries)
+	ts, err := NewTradingService(exchange, newMemoryClient(), config)
+	if err != nil {
+		return nil, fmt.Errorf("error initializing trading service: %w", err)
+	}
+
+	result := &replayResult{}
+	for _, entry := range entries {
+		switch entry.Kind {
+		case sessionKindCycle:
+			result.Cycles++
+			if err := ts.processPositions(); err != nil {
+				log.Printf("Replayed cycle (seq %d) failed: %v", entry.Seq, err)
+			}
+		case sessionKindReconcile:
+			result.Reconciles++
+			if err := ts.reconcile(); err != nil {
+				log.Printf("Replayed reconciliation (seq %d) failed: %v", entry.Seq, err)
+			}
+		case sessionKindMarkPrices:
+			var event binance.WsAllMarkPriceEvent
+			if err := json.Unmarshal(entry.Result, &event); err != nil {
+				return nil, fmt.Errorf("error decoding mark prices (seq %d): %w", entry.Seq, err)
+			}
+			result.StreamTicks++
+			for _, symbol := range ts.markPriceCrossings(event) {
+				ts.refreshSymbol(symbol)
+			}
+		}
+	}
+
+	exchange.unreplayed()
+	exchange.mu.Lock()
+	result.Writes = exchange.writes
+	result.Divergences = exchange.divergences
+	exchange.mu.Unlock()
+	return result, nil
+}
+
+// formatReplayResult creates the summary printed by `replay`.
+func formatReplayResult(result *replayResult) string {
+	summary := fmt.Sprintf("Replayed %d cycles, %d reconciliations and %d mark price events: %d orders placed or cancelled",
+		result.Cycles, result.Reconciles, result.StreamTicks, result.Writes)
+	if len(result.Divergences) == 0 {
+		return summary + ", all matching the recording"
+	}
+	return fmt.Sprintf("%s, %d divergences from the recording", summary, len(result.Divergences))
+}
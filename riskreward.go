@@ -0,0 +1,89 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+
+	"futures-guard/settings"
+)
+
+// strategyRiskReward is the name of the risk/reward take-profit strategy.
+const strategyRiskReward = "rr"
+
+// defaultTPRiskReward targets twice the risk unless TP_RISK_REWARD is set.
+const defaultTPRiskReward = 2.0
+
+// riskRewardStrategy targets a fixed multiple of the position's risk, the distance
+// from entry to its stop-loss, so the target follows whatever SL strategy the
+// symbol uses. Once the stop has moved to breakeven or beyond, the last distance
+// seen on the losing side is kept, so a trailing stop does not pull the target in.
+type riskRewardStrategy struct{}
+
+func (riskRewardStrategy) Name() string { return strategyRiskReward }
+
+func (riskRewardStrategy) TakeProfit(ts *TradingService, data *PositionData) (float64, error) {
+	ratio := ts.tpRiskReward(data.Symbol)
+	if ratio <= 0 {
+		return 0, fmt.Errorf("risk/reward take-profit needs a positive TP_RISK_REWARD")
+	}
+	distance := ts.riskDistance(data)
+	if distance <= 0 {
+		return 0, fmt.Errorf("no stop-loss distance for %s", data.Symbol)
+	}
+
+	log.Printf("DEBUG: Risk/reward TP for %s: %.2fR with R = %.8f", data.Symbol, ratio, distance)
+	if data.IsLong {
+		return data.EntryPrice + ratio*distance, nil
+	}
+	return data.EntryPrice - ratio*distance, nil
+}
+
+// tpRiskReward returns the target risk/reward ratio of symbol.
+func (ts *TradingService) tpRiskReward(symbol string) float64 {
+	if ratio, ok := ts.config().TPRiskRewardOverrides[symbol]; ok {
+		return ratio
+	}
+	return ts.config().TPRiskReward
+}
+
+// riskDistance returns the price distance from entry to the stop-loss while the
+// stop is on the losing side, remembering it in the order state for when the stop
+// trails past entry. Without either it falls back to DEFAULT_SL_PERCENT.
+func (ts *TradingService) riskDistance(data *PositionData) float64 {
+	distance := data.EntryPrice - data.StopPrice
+	if data.IsShort {
+		distance = -distance
+	}
+
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	st := ts.orderState(data.Symbol, data.PositionSide)
+	switch {
+	case data.StopPrice > 0 && distance > 0:
+		st.RiskDistance = distance
+	case st.RiskDistance > 0:
+		distance = st.RiskDistance
+	default:
+		distance = data.EntryPrice * ts.config().DefaultSLPercent / 100
+	}
+	return distance
+}
+
+// loadRiskRewardConfig reads the risk/reward take-profit settings from src.
+func loadRiskRewardConfig(src *settings.Settings, config *Config) {
+	config.TPRiskReward = defaultTPRiskReward
+	config.TPRiskReward = src.Float("TP_RISK_REWARD", config.TPRiskReward)
+	config.TPRiskRewardOverrides = settings.Parse(src, "TP_RISK_REWARD_OVERRIDES", nil, parseRiskRewardOverrides)
+}
+
+// parseRiskRewardOverrides parses per-symbol risk/reward ratios such as "BTCUSDT=3,ETHUSDT=1.5".
+func parseRiskRewardOverrides(value string) (map[string]float64, error) {
+	return parseOverrides(value, func(setting string) (float64, error) {
+		ratio, err := strconv.ParseFloat(setting, 64)
+		if err != nil || ratio <= 0 {
+			return 0, fmt.Errorf("invalid TP risk/reward %q", setting)
+		}
+		return ratio, nil
+	})
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+
+	"futures-guard/settings"
+)
+
+// strategyRMultiple is the name of the R-multiple ladder strategy.
+const strategyRMultiple = "rmultiple"
+
+// defaultRLadder moves the stop to breakeven at +1R and then trails one R behind.
+const defaultRLadder = "1:0,2:1,3:2"
+
+// RLevel is a step of the R-multiple ladder: once the position is ProfitR risk
+// units in profit, the stop locks in LockR risk units.
+type RLevel struct {
+	ProfitR float64
+	LockR   float64
+}
+
+// rMultipleStrategy is a ladder expressed in risk units (R), the distance from entry
+// to the initial stop at DefaultSLPercent. Unlike the leveraged-percent ladder it
+// behaves the same at any leverage.
+type rMultipleStrategy struct{}
+
+func (rMultipleStrategy) Name() string { return strategyRMultiple }
+
+func (rMultipleStrategy) StopLoss(ts *TradingService, data *PositionData) (float64, error) {
+	risk := ts.config().DefaultSLPercent
+	if risk <= 0 {
+		return 0, fmt.Errorf("R-multiple ladder needs a positive DEFAULT_SL_PERCENT")
+	}
+
+	// Start at the initial stop, one R against the position
+	lockR := -1.0
+	profitR := data.RawProfitPct / risk
+	for _, level := range ts.config().RLadder {
+		if profitR < level.ProfitR {
+			break
+		}
+		lockR = level.LockR
+	}
+
+	log.Printf("DEBUG: R-multiple SL for %s: profit %.2fR, locking %.2fR (R = %.2f%%)",
+		data.Symbol, profitR, lockR, risk)
+
+	if data.IsLong {
+		return data.EntryPrice * (1 + lockR*risk/100), nil
+	}
+	return data.EntryPrice * (1 - lockR*risk/100), nil
+}
+
+// parseRLadder parses R-multiple ladder steps of the form "1:0,2:1,3:2" and sorts
+// them by profit.
+func parseRLadder(value string) ([]RLevel, error) {
+	var ladder []RLevel
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		profitStr, lockStr, ok := strings.Cut(item, ":")
+		profit, err1 := strconv.ParseFloat(strings.TrimSpace(profitStr), 64)
+		lock, err2 := strconv.ParseFloat(strings.TrimSpace(lockStr), 64)
+		if !ok || err1 != nil || err2 != nil || lock >= profit {
+			return nil, fmt.Errorf("invalid R ladder step %q, want PROFIT:LOCK with the lock below the profit", item)
+		}
+		ladder = append(ladder, RLevel{ProfitR: profit, LockR: lock})
+	}
+	sort.Slice(ladder, func(i, j int) bool { return ladder[i].ProfitR < ladder[j].ProfitR })
+	return ladder, nil
+}
+
+// loadRLadderConfig reads the R-multiple ladder from src.
+func loadRLadderConfig(src *settings.Settings, config *Config) {
+	defaultLadder, _ := parseRLadder(defaultRLadder)
+	config.RLadder = settings.Parse(src, "R_LADDER", defaultLadder, parseRLadder)
+}
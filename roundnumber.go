@@ -0,0 +1,53 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"math"
+)
+
+// roundNumberStep returns the spacing of the round numbers around price: a tenth
+// of its order of magnitude, e.g. 1000 for 65000 and 100 for 3450.
+func roundNumberStep(price float64) float64 {
+	return math.Pow(10, math.Floor(math.Log10(price))-1)
+}
+
+// applyRoundNumberNudge moves a stop within RoundNumberNudgeTicks price ticks of a
+// round number to that many ticks beyond it, away from the mark price, since
+// stops parked on round numbers are where stop hunts aim. It returns the stop
+// and, when nudged, a note for the audit log.
+func (ts *TradingService) applyRoundNumberNudge(data *PositionData, stopPrice float64) (float64, string) {
+	if ts.config().RoundNumberNudgeTicks <= 0 || stopPrice <= 0 {
+		return stopPrice, ""
+	}
+	precision, ok := ts.symbolPrecision(data.Symbol)
+	if !ok {
+		return stopPrice, ""
+	}
+	tick := tickSize(precision.PricePrecision)
+	step := roundNumberStep(stopPrice)
+	if step <= tick {
+		// Every tick would be a round number
+		return stopPrice, ""
+	}
+
+	offset := float64(ts.config().RoundNumberNudgeTicks) * tick
+	round := math.Round(stopPrice/step) * step
+	if math.Abs(stopPrice-round) > offset+tick/2 {
+		return stopPrice, ""
+	}
+	nudged := roundToPrecision(round-offset, precision.PricePrecision)
+	if data.IsShort {
+		nudged = roundToPrecision(round+offset, precision.PricePrecision)
+	}
+	if nudged == stopPrice || nudged <= 0 {
+		return stopPrice, ""
+	}
+
+	note := fmt.Sprintf("nudged %d ticks past the round number %s",
+		ts.config().RoundNumberNudgeTicks, formatDecimal(round, precision.PricePrecision))
+	log.Printf("Moving SL for %s from %.8f to %.8f: %s", data.Symbol, stopPrice, nudged, note)
+	data.RawSLPct = rawStopLossPct(data, nudged)
+	data.LeveragedSLPct = data.RawSLPct * data.Leverage
+	return nudged, note
+}
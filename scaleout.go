@@ -0,0 +1,50 @@
+package main
+
+import (
+	"fmt"
+	"log"
+)
+
+// checkScaleOut closes ScaleOutFraction of the position at market, reduce-only,
+// the first time each new ladder stage is reached, so profit is realized as the
+// ladder climbs. The SL/TP are then recalculated for the remaining quantity by
+// the order update that follows, which replaces orders sized for more.
+func (ts *TradingService) checkScaleOut(data *PositionData) {
+	if ts.config().ScaleOutFraction <= 0 || data.GridLow > 0 {
+		return
+	}
+	stage := ts.ladderStage(data)
+
+	ts.mu.Lock()
+	lastStage := ts.orderState(data.Symbol, data.PositionSide).ScaleOutStage
+	ts.mu.Unlock()
+
+	// ScaleOutStage is one past the stage of the last exit, zero before any
+	if stage < 0 || stage+1 <= lastStage {
+		return
+	}
+
+	precision, _ := ts.symbolPrecision(data.Symbol)
+	if truncateToPrecision(data.AbsAmt*ts.config().ScaleOutFraction, precision.QuantityPrecision) <= 0 {
+		// Too small to split; the stop and target exit the whole position
+		log.Printf("Skipping scale-out of %s at stage %d: the position is too small to split", data.Symbol, stage+1)
+	} else {
+		before := data.AbsAmt
+		if err := ts.reducePosition(data, ts.config().ScaleOutFraction*100, fmt.Sprintf("scale-out at stage %d", stage+1)); err != nil {
+			// Retried at the next cycle
+			log.Printf("Warning: Error scaling out of %s at stage %d: %v", data.Symbol, stage+1, err)
+			return
+		}
+		msg := fmt.Sprintf("💰 Scaled out of %s %s at stage %d: closed %s, %s remaining",
+			data.Symbol, data.PositionSide, stage+1,
+			formatDecimal(before-data.AbsAmt, precision.QuantityPrecision), formatDecimal(data.AbsAmt, precision.QuantityPrecision))
+		log.Println(msg)
+		ts.notify(SeverityInfo, msg)
+		ts.orderCache().invalidate(data.Symbol)
+	}
+
+	ts.mu.Lock()
+	ts.orderState(data.Symbol, data.PositionSide).ScaleOutStage = stage + 1
+	ts.mu.Unlock()
+	ts.saveState()
+}
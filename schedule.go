@@ -0,0 +1,209 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Schedule window actions.
+const (
+	scheduleActionTighten = "tighten"
+	scheduleActionFlatten = "flatten"
+	scheduleActionPause   = "pause"
+)
+
+// minutesPerWeek is the length of the weekly cycle schedule windows repeat on.
+const minutesPerWeek = 7 * 24 * 60
+
+// scheduleWindow is a weekly UTC time range during which an action applies.
+// Start and End are minutes since Sunday 00:00; a window may wrap past the
+// end of the week. Daily windows are expanded to one window per weekday.
+type scheduleWindow struct {
+	Action string
+	Start  int
+	End    int
+}
+
+// contains reports whether t falls inside the window.
+func (w scheduleWindow) contains(t time.Time) bool {
+	t = t.UTC()
+	minute := int(t.Weekday())*24*60 + t.Hour()*60 + t.Minute()
+	if w.Start <= w.End {
+		return minute >= w.Start && minute < w.End
+	}
+	return minute >= w.Start || minute < w.End
+}
+
+// scheduleActions returns the actions of the windows active at now, sorted.
+func (ts *TradingService) scheduleActions(now time.Time) []string {
+	var actions []string
+	for _, w := range ts.config().ScheduleWindows {
+		if w.contains(now) && !slices.Contains(actions, w.Action) {
+			actions = append(actions, w.Action)
+		}
+	}
+	slices.Sort(actions)
+	return actions
+}
+
+// scheduleActive reports whether a window with action is active at now.
+func (ts *TradingService) scheduleActive(action string, now time.Time) bool {
+	return slices.Contains(ts.scheduleActions(now), action)
+}
+
+// checkScheduleTransition notifies when the set of active schedule windows changes.
+func (ts *TradingService) checkScheduleTransition(now time.Time) {
+	active := strings.Join(ts.scheduleActions(now), ", ")
+
+	ts.mu.Lock()
+	previous := ts.scheduleState
+	ts.scheduleState = active
+	ts.mu.Unlock()
+
+	if active == previous {
+		return
+	}
+	msg := "🗓️ Scheduled windows ended; normal order management resumed"
+	if active != "" {
+		msg = fmt.Sprintf("🗓️ Scheduled window active: %s", active)
+	}
+	log.Println(msg)
+	ts.notify(SeverityWarning, msg)
+}
+
+// checkSchedule applies the active flatten and tighten windows to a position:
+// flatten closes it at market, tighten moves its stop to breakeven.
+func (ts *TradingService) checkSchedule(data *PositionData) {
+	now := time.Now()
+	if ts.scheduleActive(scheduleActionFlatten, now) {
+		msg := fmt.Sprintf("🗓️ Closing %s %s inside a scheduled flatten window", data.Symbol, data.PositionSide)
+		log.Println(msg)
+		ts.notify(SeverityWarning, msg)
+		if err := ts.reducePosition(data, 100, "scheduled flatten"); err != nil {
+			log.Printf("Warning: %v", err)
+		}
+		return
+	}
+	data.ScheduleTighten = ts.scheduleActive(scheduleActionTighten, now)
+}
+
+// applyScheduleGuard moves the stop to breakeven inside tighten windows.
+func (ts *TradingService) applyScheduleGuard(data *PositionData, stopPrice float64) float64 {
+	if !data.ScheduleTighten {
+		return stopPrice
+	}
+	return moveStopToBreakeven(data, stopPrice, "scheduled tighten window")
+}
+
+// scheduleDays maps the accepted day names to time.Weekday.
+var scheduleDays = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+// parseScheduleWindows parses SCHEDULE_WINDOWS entries such as
+// "tighten=Fri 21:00-Sun 22:00,pause=Wed 02:00-Wed 04:00,flatten=* 13:25-* 13:35".
+// Times are UTC; "*" makes the window repeat every day.
+func parseScheduleWindows(value string) ([]scheduleWindow, error) {
+	var windows []scheduleWindow
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		parsed, err := parseScheduleWindow(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid entry %q: %w", entry, err)
+		}
+		windows = append(windows, parsed...)
+	}
+	return windows, nil
+}
+
+// parseScheduleWindow parses a single "<action>=<day> HH:MM-<day> HH:MM" entry.
+func parseScheduleWindow(entry string) ([]scheduleWindow, error) {
+	action, span, ok := strings.Cut(entry, "=")
+	if !ok {
+		return nil, fmt.Errorf("expected <action>=<start>-<end>")
+	}
+	action = strings.ToLower(strings.TrimSpace(action))
+	switch action {
+	case scheduleActionTighten, scheduleActionFlatten, scheduleActionPause:
+	default:
+		return nil, fmt.Errorf("unknown action %q", action)
+	}
+
+	windows, err := parseScheduleSpan(span)
+	if err != nil {
+		return nil, err
+	}
+	for i := range windows {
+		windows[i].Action = action
+	}
+	return windows, nil
+}
+
+// parseScheduleSpan parses a "<day> HH:MM-<day> HH:MM" range into windows without
+// an action.
+func parseScheduleSpan(span string) ([]scheduleWindow, error) {
+	startStr, endStr, ok := strings.Cut(strings.TrimSuffix(strings.TrimSpace(span), " UTC"), "-")
+	if !ok {
+		return nil, fmt.Errorf("expected a <start>-<end> range")
+	}
+	startDay, start, err := parseScheduleTime(startStr)
+	if err != nil {
+		return nil, err
+	}
+	endDay, end, err := parseScheduleTime(endStr)
+	if err != nil {
+		return nil, err
+	}
+
+	if (startDay < 0) != (endDay < 0) {
+		return nil, fmt.Errorf("daily windows need \"*\" on both ends")
+	}
+	if startDay >= 0 {
+		return []scheduleWindow{{
+			Start: int(startDay)*24*60 + start,
+			End:   int(endDay)*24*60 + end,
+		}}, nil
+	}
+
+	windows := make([]scheduleWindow, 0, 7)
+	for day := range 7 {
+		w := scheduleWindow{Start: day*24*60 + start, End: day*24*60 + end}
+		if end <= start {
+			// Daily windows crossing midnight end on the next day
+			w.End = (w.End + 24*60) % minutesPerWeek
+		}
+		windows = append(windows, w)
+	}
+	return windows, nil
+}
+
+// parseScheduleTime parses "<day> HH:MM" into the weekday (-1 for "*") and the
+// minute of the day.
+func parseScheduleTime(value string) (time.Weekday, int, error) {
+	dayStr, clock, ok := strings.Cut(strings.TrimSpace(value), " ")
+	if !ok {
+		return 0, 0, fmt.Errorf("expected <day> HH:MM, got %q", value)
+	}
+
+	day := time.Weekday(-1)
+	if dayStr != "*" {
+		d, ok := scheduleDays[strings.ToLower(dayStr)[:min(3, len(dayStr))]]
+		if !ok {
+			return 0, 0, fmt.Errorf("unknown day %q", dayStr)
+		}
+		day = d
+	}
+
+	t, err := time.Parse("15:04", strings.TrimSpace(clock))
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid time %q: %w", clock, err)
+	}
+	return day, t.Hour()*60 + t.Minute(), nil
+}
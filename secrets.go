@@ -0,0 +1,358 @@
+package main
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/spf13/cobra"
+	"github.com/zalando/go-keyring"
+	"golang.org/x/term"
+
+	"futures-guard/settings"
+)
+
+// Secret storage backends.
+const (
+	secretsBackendFile    = "file"
+	secretsBackendKeyring = "keyring"
+)
+
+// Secret file encryption parameters.
+const (
+	secretsFileVersion = 1
+	secretsKDFIter     = 600000
+	secretsKeyringName = "futures-guard"
+)
+
+// secretKeys are the credentials that may be stored encrypted instead of in plain text.
+var secretKeys = []string{
+	"BINANCE_API_KEY", "BINANCE_API_SECRET",
+	"BYBIT_API_KEY", "BYBIT_API_SECRET",
+	"OKX_API_KEY", "OKX_API_SECRET", "OKX_PASSPHRASE",
+	"TELEGRAM_BOT_TOKEN", "DISCORD_WEBHOOK_URL", "SLACK_WEBHOOK_URL", "SMTP_PASSWORD",
+	"PUSHOVER_APP_TOKEN", "PUSHOVER_USER_KEY", "NTFY_TOKEN",
+	"API_TOKEN", "WEBHOOK_SECRET", "TRADINGVIEW_SECRET", "INFLUX_TOKEN",
+}
+
+// SecretStore reads and writes credentials outside the plain-text env file.
+type SecretStore interface {
+	Get(key string) (string, bool, error)
+	Set(key, value string) error
+}
+
+// encryptedFile is the on-disk format of the encrypted secrets file. The payload is
+// a JSON object of credentials sealed with AES-256-GCM under a PBKDF2-SHA256 key.
+type encryptedFile struct {
+	Version    int    `json:"version"`
+	Iterations int    `json:"iterations"`
+	Salt       []byte `json:"salt"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// fileSecretStore keeps credentials in a passphrase-encrypted file.
+type fileSecretStore struct {
+	path       string
+	passphrase string
+
+	once    sync.Once
+	secrets map[string]string
+	err     error
+}
+
+// newFileSecretStore returns a store for the encrypted file at path.
+func newFileSecretStore(path, passphrase string) *fileSecretStore {
+	return &fileSecretStore{path: path, passphrase: passphrase}
+}
+
+// load decrypts the file once; a missing file holds no secrets.
+func (s *fileSecretStore) load() (map[string]string, error) {
+	s.once.Do(func() {
+		s.secrets = make(map[string]string)
+		raw, err := os.ReadFile(s.path)
+		if errors.Is(err, os.ErrNotExist) {
+			return
+		}
+		if err != nil {
+			s.err = fmt.Errorf("error reading secrets file: %w", err)
+			return
+		}
+
+		var file encryptedFile
+		if err := json.Unmarshal(raw, &file); err != nil {
+			s.err = fmt.Errorf("error parsing secrets file: %w", err)
+			return
+		}
+		if file.Version != secretsFileVersion {
+			s.err = fmt.Errorf("unsupported secrets file version %d", file.Version)
+			return
+		}
+
+		gcm, err := secretsCipher(s.passphrase, file.Salt, file.Iterations)
+		if err != nil {
+			s.err = err
+			return
+		}
+		plain, err := gcm.Open(nil, file.Nonce, file.Ciphertext, nil)
+		if err != nil {
+			s.err = fmt.Errorf("error decrypting secrets file (wrong passphrase?)")
+			return
+		}
+		if err := json.Unmarshal(plain, &s.secrets); err != nil {
+			s.err = fmt.Errorf("error parsing decrypted secrets: %w", err)
+		}
+	})
+	return s.secrets, s.err
+}
+
+// Get implements SecretStore.
+func (s *fileSecretStore) Get(key string) (string, bool, error) {
+	secrets, err := s.load()
+	if err != nil {
+		return "", false, err
+	}
+	value, ok := secrets[key]
+	return value, ok, nil
+}
+
+// Set implements SecretStore, re-encrypting the file with a fresh salt and nonce.
+func (s *fileSecretStore) Set(key, value string) error {
+	secrets, err := s.load()
+	if err != nil {
+		return err
+	}
+	secrets[key] = value
+
+	plain, err := json.Marshal(secrets)
+	if err != nil {
+		return fmt.Errorf("error encoding secrets: %w", err)
+	}
+
+	file := encryptedFile{Version: secretsFileVersion, Iterations: secretsKDFIter, Salt: make([]byte, 16)}
+	if _, err := rand.Read(file.Salt); err != nil {
+		return err
+	}
+	gcm, err := secretsCipher(s.passphrase, file.Salt, file.Iterations)
+	if err != nil {
+		return err
+	}
+	file.Nonce = make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(file.Nonce); err != nil {
+		return err
+	}
+	file.Ciphertext = gcm.Seal(nil, file.Nonce, plain, nil)
+
+	raw, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error encoding secrets file: %w", err)
+	}
+	// Write to a temporary file first so a crash never leaves a truncated file
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, raw, 0o600); err != nil {
+		return fmt.Errorf("error writing secrets file: %w", err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return fmt.Errorf("error replacing secrets file: %w", err)
+	}
+	return nil
+}
+
+// secretsCipher derives the AES-256-GCM cipher for passphrase and salt.
+func secretsCipher(passphrase string, salt []byte, iterations int) (cipher.AEAD, error) {
+	if passphrase == "" {
+		return nil, fmt.Errorf("secrets passphrase not configured")
+	}
+	key, err := pbkdf2.Key(sha256.New, passphrase, salt, iterations, 32)
+	if err != nil {
+		return nil, fmt.Errorf("error deriving secrets key: %w", err)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// keyringSecretStore keeps credentials in the OS keyring (Keychain, Secret Service
+// or Windows Credential Manager).
+type keyringSecretStore struct{}
+
+// Get implements SecretStore.
+func (keyringSecretStore) Get(key string) (string, bool, error) {
+	value, err := keyring.Get(secretsKeyringName, key)
+	if errors.Is(err, keyring.ErrNotFound) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, fmt.Errorf("error reading %s from keyring: %w", key, err)
+	}
+	return value, true, nil
+}
+
+// Set implements SecretStore.
+func (keyringSecretStore) Set(key, value string) error {
+	if err := keyring.Set(secretsKeyringName, key, value); err != nil {
+		return fmt.Errorf("error writing %s to keyring: %w", key, err)
+	}
+	return nil
+}
+
+// openSecretStore returns the store src selects with SECRETS_BACKEND, or nil when
+// credentials are only read from the settings.
+func openSecretStore(src *settings.Settings) (SecretStore, error) {
+	switch backend := strings.ToLower(strings.TrimSpace(src.String("SECRETS_BACKEND"))); backend {
+	case "":
+		return nil, nil
+	case secretsBackendKeyring:
+		return keyringSecretStore{}, nil
+	case secretsBackendFile:
+		path := src.String("SECRETS_FILE")
+		if path == "" {
+			return nil, fmt.Errorf("SECRETS_FILE not configured")
+		}
+		passphrase, err := secretsPassphrase(src)
+		if err != nil {
+			return nil, err
+		}
+		return newFileSecretStore(path, passphrase), nil
+	default:
+		return nil, fmt.Errorf("unknown SECRETS_BACKEND %q (use %s or %s)", backend, secretsBackendFile, secretsBackendKeyring)
+	}
+}
+
+// secretsPassphrase returns SECRETS_PASSPHRASE, prompting for it on a terminal.
+func secretsPassphrase(src *settings.Settings) (string, error) {
+	if passphrase := src.String("SECRETS_PASSPHRASE"); passphrase != "" {
+		return passphrase, nil
+	}
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return "", fmt.Errorf("SECRETS_PASSPHRASE not configured")
+	}
+	fmt.Fprint(os.Stderr, "Secrets passphrase: ")
+	raw, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", fmt.Errorf("error reading passphrase: %w", err)
+	}
+	// Keep it for config reloads so the prompt only appears once
+	os.Setenv("SECRETS_PASSPHRASE", string(raw))
+	return string(raw), nil
+}
+
+// loadSecrets fills the credentials missing from src from the configured secret
+// store, through the environment. Values src already has take precedence.
+func loadSecrets(src *settings.Settings) {
+	store, err := openSecretStore(src)
+	if err != nil {
+		src.Warn("SECRETS_BACKEND", err)
+		return
+	}
+	if store == nil {
+		return
+	}
+
+	for _, key := range secretKeys {
+		if src.String(key) != "" {
+			continue
+		}
+		value, ok, err := store.Get(key)
+		if err != nil {
+			src.Warn("SECRETS_BACKEND", err)
+			return
+		}
+		if ok {
+			os.Setenv(key, value)
+		}
+	}
+}
+
+// isSecretKey reports whether key is a credential the secret store accepts.
+func isSecretKey(key string) bool {
+	for _, k := range secretKeys {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
+
+// newSecretsCommand builds the `secrets` command that manages stored credentials.
+func newSecretsCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "secrets",
+		Short: "Manage credentials kept in the encrypted secrets file or OS keyring",
+	}
+	cmd.AddCommand(newSecretsSetCommand())
+	return cmd
+}
+
+// newSecretsSetCommand builds the `secrets set` command that stores a credential.
+func newSecretsSetCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "set <KEY> [VALUE]",
+		Short: "Store a credential, prompting for the value when it is omitted",
+		Long: "Store a credential in the backend selected by SECRETS_BACKEND.\n" +
+			"Supported keys: " + strings.Join(secretKeys, ", "),
+		Example: "  futures-guard secrets set BINANCE_API_SECRET",
+		Args:    cobra.RangeArgs(1, 2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			key := strings.ToUpper(args[0])
+			if !isSecretKey(key) {
+				return fmt.Errorf("unsupported secret %s", key)
+			}
+
+			src, err := loadSettings()
+			if err != nil {
+				return err
+			}
+			store, err := openSecretStore(src)
+			if err != nil {
+				return err
+			}
+			if store == nil {
+				return fmt.Errorf("SECRETS_BACKEND not configured (use %s or %s)", secretsBackendFile, secretsBackendKeyring)
+			}
+
+			var value string
+			if len(args) == 2 {
+				value = args[1]
+			} else if value, err = promptSecret(key); err != nil {
+				return err
+			}
+			if value == "" {
+				return fmt.Errorf("empty value for %s", key)
+			}
+
+			if err := store.Set(key, value); err != nil {
+				return err
+			}
+			fmt.Printf("Stored %s\n", key)
+			return nil
+		},
+	}
+}
+
+// promptSecret reads the value of key without echoing it.
+func promptSecret(key string) (string, error) {
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return "", fmt.Errorf("no value given for %s and stdin is not a terminal", key)
+	}
+	fmt.Fprintf(os.Stderr, "%s: ", key)
+	raw, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", fmt.Errorf("error reading %s: %w", key, err)
+	}
+	return strings.TrimSpace(string(raw)), nil
+}
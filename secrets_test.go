@@ -0,0 +1,46 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFileSecretStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "secrets.enc")
+	store := newFileSecretStore(path, "correct horse")
+	for key, value := range map[string]string{"BINANCE_API_KEY": "key-123", "BINANCE_API_SECRET": "secret-456"} {
+		if err := store.Set(key, value); err != nil {
+			t.Fatalf("Set(%s): %v", key, err)
+		}
+	}
+
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(raw, []byte("secret-456")) {
+		t.Error("secrets file holds a value in plain text")
+	}
+
+	// A fresh store decrypts what the first one wrote
+	reopened := newFileSecretStore(path, "correct horse")
+	for key, want := range map[string]string{"BINANCE_API_KEY": "key-123", "BINANCE_API_SECRET": "secret-456"} {
+		if got, ok, err := reopened.Get(key); err != nil || !ok || got != want {
+			t.Errorf("Get(%s) = %q, %v, %v, want %q", key, got, ok, err, want)
+		}
+	}
+
+	wrong := newFileSecretStore(path, "wrong horse")
+	if _, _, err := wrong.Get("BINANCE_API_KEY"); err == nil || !strings.Contains(err.Error(), "wrong passphrase") {
+		t.Errorf("Get with a wrong passphrase: %v, want a decryption error", err)
+	}
+	if err := wrong.Set("BINANCE_API_KEY", "other"); err == nil {
+		t.Error("Set with a wrong passphrase succeeded, want the file left alone")
+	}
+	if got, _, _ := newFileSecretStore(path, "correct horse").Get("BINANCE_API_KEY"); got != "key-123" {
+		t.Errorf("Get after a rejected Set = %q, want key-123", got)
+	}
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/adshao/go-binance/v2/common"
+)
+
+// Actions taken when a stop is rejected because it would trigger immediately.
+const (
+	stopTriggerActionRetry = "retry"
+	stopTriggerActionClose = "close"
+)
+
+const (
+	// immediateTriggerErrorCode is Binance's "Order would immediately trigger".
+	immediateTriggerErrorCode = -2021
+	// Bybit rejects a trigger price on the wrong side of the last price with these.
+	bybitRisingTriggerErrorCode  = 110092
+	bybitFallingTriggerErrorCode = 110093
+
+	// defaultStopTriggerTicks is the default distance of a re-placed stop from mark.
+	defaultStopTriggerTicks = 10
+)
+
+// isImmediateTrigger reports whether err rejected a stop order because the price
+// has already moved beyond it.
+func isImmediateTrigger(err error) bool {
+	var apiErr *common.APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.Code == immediateTriggerErrorCode
+	}
+	var bybitErr *bybitError
+	if errors.As(err, &bybitErr) {
+		return bybitErr.Code == bybitRisingTriggerErrorCode || bybitErr.Code == bybitFallingTriggerErrorCode
+	}
+	return false
+}
+
+// currentMarkPrice fetches the latest mark price of the position from the exchange.
+func (ts *TradingService) currentMarkPrice(data *PositionData) (float64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+
+	positions, err := ts.exchange().Positions(ctx, data.Symbol)
+	if err != nil {
+		return 0, fmt.Errorf("error fetching mark price for %s: %w", data.Symbol, err)
+	}
+	for _, position := range positions {
+		if position.PositionSide == data.PositionSide && position.MarkPrice > 0 {
+			return position.MarkPrice, nil
+		}
+	}
+	return 0, fmt.Errorf("no mark price found for %s %s", data.Symbol, data.PositionSide)
+}
+
+// healStopLoss handles a stop rejected because the mark price is already beyond
+// it. Depending on STOP_TRIGGER_ACTION the position is closed at market, or a stop
+// is placed STOP_TRIGGER_TICKS price ticks from the current mark price, so the
+// position is never left without protection.
+func (ts *TradingService) healStopLoss(data *PositionData, cause error) error {
+	if ts.config().StopTriggerAction == stopTriggerActionClose {
+		msg := fmt.Sprintf("🩹 SL for %s %s at %.8f would trigger immediately, closing the position",
+			data.Symbol, data.PositionSide, data.StopPrice)
+		log.Println(msg)
+		ts.notify(SeverityCritical, msg)
+		return ts.reducePosition(data, 100, "stop would trigger immediately")
+	}
+
+	precision, ok := ts.symbolPrecision(data.Symbol)
+	if !ok {
+		return fmt.Errorf("precision information not found for %s", data.Symbol)
+	}
+	markPrice, err := ts.currentMarkPrice(data)
+	if err != nil {
+		log.Printf("Warning: %v", err)
+		markPrice = data.MarkPrice
+	}
+
+	distance := float64(ts.config().StopTriggerTicks) * tickSize(precision.PricePrecision)
+	stop := roundToPrecision(markPrice-distance, precision.PricePrecision)
+	if data.IsShort {
+		stop = roundToPrecision(markPrice+distance, precision.PricePrecision)
+	}
+	if stop <= 0 {
+		return fmt.Errorf("no valid stop for %s near mark price %.8f: %w", data.Symbol, markPrice, cause)
+	}
+
+	previous := data.StopPrice
+	data.MarkPrice = markPrice
+	data.StopPrice = stop
+	data.RawSLPct = rawStopLossPct(data, stop)
+	data.LeveragedSLPct = data.RawSLPct * data.Leverage
+	if err := ts.calculateRiskMetrics(data); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+
+	req := ts.stopLossOrder(data)
+	if err := checkReduceOnly(req, data); err != nil {
+		return fmt.Errorf("refusing Stop Loss order: %w", err)
+	}
+	req.ClientOrderID = ts.clientOrderID(clientOrderKindSL, data)
+	order, err := ts.placeOrder(ctx, req)
+	if err != nil {
+		ts.publish(EventOrderRejected, data, Event{Price: stop, Reason: "Stop Loss", Error: err.Error()})
+		return fmt.Errorf("error re-placing Stop Loss order for %s near mark price: %w", data.Symbol, err)
+	}
+	ts.publish(EventSLMoved, data, Event{Price: stop, OrderID: order.ID, Reason: "immediate trigger"})
+
+	msg := fmt.Sprintf("🩹 SL for %s %s at %.8f would trigger immediately, placed at %.8f, %d ticks from mark %.8f",
+		data.Symbol, data.PositionSide, previous, stop, ts.config().StopTriggerTicks, markPrice)
+	log.Println(msg)
+	ts.notify(SeverityWarning, msg)
+	return nil
+}
+
+// parseStopTriggerAction normalizes the configured immediate-trigger action.
+func parseStopTriggerAction(value string) (string, error) {
+	switch action := strings.ToLower(strings.TrimSpace(value)); action {
+	case stopTriggerActionRetry, stopTriggerActionClose:
+		return action, nil
+	default:
+		return "", fmt.Errorf("want %s or %s", stopTriggerActionRetry, stopTriggerActionClose)
+	}
+}
@@ -0,0 +1,258 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/adshao/go-binance/v2/common"
+	binance "github.com/adshao/go-binance/v2/futures"
+)
+
+// Kinds of the entries of a session file. Exchange calls are named after the
+// Exchange method they record; cycle, reconcile and mark_prices entries mark what
+// the bot was doing and drive the replay.
+const (
+	sessionKindHeader       = "session"
+	sessionKindCycle        = "cycle"
+	sessionKindReconcile    = "reconcile"
+	sessionKindMarkPrices   = "mark_prices"
+	sessionKindServerTime   = "server_time"
+	sessionKindPrecisions   = "symbol_precisions"
+	sessionKindPositions    = "positions"
+	sessionKindOpenOrders   = "open_orders"
+	sessionKindCreateOrder  = "create_order"
+	sessionKindCreateOrders = "create_orders"
+	sessionKindCancelOrder  = "cancel_order"
+)
+
+// sessionEntry is one line of a session file.
+type sessionEntry struct {
+	Seq     int64           `json:"seq"`
+	Time    time.Time       `json:"time"`
+	Kind    string          `json:"kind"`
+	Symbol  string          `json:"symbol,omitempty"`
+	Request json.RawMessage `json:"request,omitempty"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *sessionError   `json:"error,omitempty"`
+	Errors  []*sessionError `json:"errors,omitempty"` // Per-order errors of create_orders
+}
+
+// sessionHeader is the result of the first entry of a session file: the exchange
+// and the persisted state the session started from.
+type sessionHeader struct {
+	Exchange string    `json:"exchange"`
+	State    *BotState `json:"state"`
+}
+
+// sessionError is a recorded error. Venue and Code keep the API errors the guard
+// reacts to, such as stops rejected as immediately triggering.
+type sessionError struct {
+	Message string `json:"message"`
+	Venue   string `json:"venue,omitempty"`
+	Code    int64  `json:"code,omitempty"`
+}
+
+// newSessionError records err, nil when err is nil.
+func newSessionError(err error) *sessionError {
+	if err == nil {
+		return nil
+	}
+	recorded := &sessionError{Message: err.Error()}
+	var apiErr *common.APIError
+	var bybitErr *bybitError
+	switch {
+	case errors.As(err, &apiErr):
+		recorded.Venue, recorded.Code = exchangeBinance, apiErr.Code
+	case errors.As(err, &bybitErr):
+		recorded.Venue, recorded.Code = exchangeBybit, int64(bybitErr.Code)
+	}
+	return recorded
+}
+
+// err rebuilds the recorded error with the same message and API error.
+func (e *sessionError) err() error {
+	if e == nil {
+		return nil
+	}
+	replayed := &replayedError{message: e.Message}
+	switch e.Venue {
+	case exchangeBinance:
+		replayed.cause = &common.APIError{Code: e.Code, Message: e.Message}
+	case exchangeBybit:
+		replayed.cause = &bybitError{Code: int(e.Code), Message: e.Message}
+	}
+	return replayed
+}
+
+// replayedError is an error read back from a session file.
+type replayedError struct {
+	message string
+	cause   error
+}
+
+func (e *replayedError) Error() string { return e.message }
+func (e *replayedError) Unwrap() error { return e.cause }
+
+// sessionRecorder writes everything the bot consumes to a session file, one JSON
+// entry per line, so the session can be replayed later.
+type sessionRecorder struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+	seq int64
+}
+
+// newSessionRecorder creates the session file at path, or returns nil when path
+// is empty. An existing file is overwritten.
+func newSessionRecorder(path, exchange string, state *BotState) (*sessionRecorder, error) {
+	if path == "" {
+		return nil, nil
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("error creating session file %s: %w", path, err)
+	}
+	r := &sessionRecorder{enc: json.NewEncoder(file)}
+	r.record(sessionKindHeader, "", nil, sessionHeader{Exchange: exchange, State: state}, nil)
+	log.Printf("Recording the session to %s", path)
+	return r, nil
+}
+
+// record appends an entry. Recording failures are logged and never interrupt
+// order management. A nil recorder records nothing.
+func (r *sessionRecorder) record(kind, symbol string, request, result interface{}, err error) {
+	if r == nil {
+		return
+	}
+	r.write(&sessionEntry{Kind: kind, Symbol: symbol, Error: newSessionError(err)}, request, result)
+}
+
+// write marshals request and result into entry and appends it to the file.
+func (r *sessionRecorder) write(entry *sessionEntry, request, result interface{}) {
+	var err error
+	if request != nil {
+		if entry.Request, err = json.Marshal(request); err != nil {
+			log.Printf("Warning: Error recording %s request: %v", entry.Kind, err)
+			return
+		}
+	}
+	if result != nil {
+		if entry.Result, err = json.Marshal(result); err != nil {
+			log.Printf("Warning: Error recording %s result: %v", entry.Kind, err)
+			return
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.seq++
+	entry.Seq = r.seq
+	entry.Time = time.Now().UTC()
+	if err := r.enc.Encode(entry); err != nil {
+		log.Printf("Warning: Error writing session file: %v", err)
+	}
+}
+
+// recordMarkPrices records the ticks of a mark price stream event for the tracked
+// symbols only, as the stream carries every market once a second.
+func (r *sessionRecorder) recordMarkPrices(event binance.WsAllMarkPriceEvent, tracked map[string]*trackedPosition) {
+	if r == nil {
+		return
+	}
+	symbols := make(map[string]bool, len(tracked))
+	for _, pos := range tracked {
+		symbols[pos.Symbol] = true
+	}
+	var ticks binance.WsAllMarkPriceEvent
+	for _, e := range event {
+		if symbols[e.Symbol] {
+			ticks = append(ticks, e)
+		}
+	}
+	if len(ticks) > 0 {
+		r.record(sessionKindMarkPrices, "", nil, ticks, nil)
+	}
+}
+
+// recordingExchange records every call to the wrapped exchange in a session file.
+type recordingExchange struct {
+	Exchange
+	recorder *sessionRecorder
+}
+
+// recordSession wraps exchange in a recordingExchange when recorder is set.
+func recordSession(exchange Exchange, recorder *sessionRecorder) Exchange {
+	if _, ok := exchange.(*recordingExchange); ok || recorder == nil {
+		return exchange
+	}
+	return &recordingExchange{Exchange: exchange, recorder: recorder}
+}
+
+// ServerTime implements Exchange.
+func (e *recordingExchange) ServerTime(ctx context.Context) (time.Time, error) {
+	t, err := e.Exchange.ServerTime(ctx)
+	e.recorder.record(sessionKindServerTime, "", nil, t, err)
+	return t, err
+}
+
+// SymbolPrecisions implements Exchange.
+func (e *recordingExchange) SymbolPrecisions(ctx context.Context) (map[string]SymbolPrecision, error) {
+	precisions, err := e.Exchange.SymbolPrecisions(ctx)
+	e.recorder.record(sessionKindPrecisions, "", nil, precisions, err)
+	return precisions, err
+}
+
+// Positions implements Exchange.
+func (e *recordingExchange) Positions(ctx context.Context, symbol string) ([]*Position, error) {
+	positions, err := e.Exchange.Positions(ctx, symbol)
+	e.recorder.record(sessionKindPositions, symbol, nil, positions, err)
+	return positions, err
+}
+
+// OpenOrders implements Exchange.
+func (e *recordingExchange) OpenOrders(ctx context.Context, symbol string) ([]*Order, error) {
+	orders, err := e.Exchange.OpenOrders(ctx, symbol)
+	e.recorder.record(sessionKindOpenOrders, symbol, nil, orders, err)
+	return orders, err
+}
+
+// CreateOrder implements Exchange.
+func (e *recordingExchange) CreateOrder(ctx context.Context, req OrderRequest) (*Order, error) {
+	order, err := e.Exchange.CreateOrder(ctx, req)
+	e.recorder.record(sessionKindCreateOrder, req.Symbol, req, order, err)
+	return order, err
+}
+
+// CreateOrders implements Exchange.
+func (e *recordingExchange) CreateOrders(ctx context.Context, reqs []OrderRequest) ([]*Order, []error, error) {
+	orders, errs, err := e.Exchange.CreateOrders(ctx, reqs)
+	entry := &sessionEntry{Kind: sessionKindCreateOrders, Error: newSessionError(err)}
+	if len(reqs) > 0 {
+		entry.Symbol = reqs[0].Symbol
+	}
+	for _, orderErr := range errs {
+		entry.Errors = append(entry.Errors, newSessionError(orderErr))
+	}
+	e.recorder.write(entry, reqs, orders)
+	return orders, errs, err
+}
+
+// CancelOrder implements Exchange.
+func (e *recordingExchange) CancelOrder(ctx context.Context, order *Order) error {
+	err := e.Exchange.CancelOrder(ctx, order)
+	e.recorder.record(sessionKindCancelOrder, order.Symbol, order, nil, err)
+	return err
+}
+
+// SyncTime implements timeSyncer when the wrapped exchange does.
+func (e *recordingExchange) SyncTime(ctx context.Context) error {
+	if syncer, ok := e.Exchange.(timeSyncer); ok {
+		return syncer.SyncTime(ctx)
+	}
+	return nil
+}
@@ -0,0 +1,211 @@
+// Package settings resolves configuration values from layered sources. A key is
+// taken from the first source that has it: command-line flags, then the process
+// environment, then the config file; callers supply the default used when no
+// source has it. Malformed values and missing required keys are collected as
+// errors rather than replaced by defaults, so a configuration either loads as
+// written or fails as a whole.
+package settings
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+)
+
+// Source is a layer of settings.
+type Source interface {
+	// Name describes the source in error messages, e.g. "environment".
+	Name() string
+	// Lookup returns the value of key and whether the source sets it.
+	Lookup(key string) (string, bool)
+}
+
+// Env is the process environment.
+type Env struct{}
+
+// Name implements Source.
+func (Env) Name() string { return "environment" }
+
+// Lookup implements Source.
+func (Env) Lookup(key string) (string, bool) { return os.LookupEnv(key) }
+
+// Values is a fixed set of settings, such as a parsed config file or flags.
+type Values struct {
+	name   string
+	values map[string]string
+}
+
+// NewValues returns the source name holding values.
+func NewValues(name string, values map[string]string) Values {
+	return Values{name: name, values: values}
+}
+
+// Name implements Source.
+func (v Values) Name() string { return v.name }
+
+// Lookup implements Source.
+func (v Values) Lookup(key string) (string, bool) {
+	value, ok := v.values[key]
+	return value, ok
+}
+
+// ReadFile parses the env file at path. An unreadable file is reported with an
+// empty source, so a caller treating a missing file as empty can ignore it.
+func ReadFile(path string) (Values, error) {
+	values, err := godotenv.Read(path)
+	if err != nil {
+		return NewValues(path, nil), fmt.Errorf("error reading config file %s: %w", path, err)
+	}
+	return NewValues(path, values), nil
+}
+
+// ParseFlags parses KEY=VALUE assignments given on the command line. A later
+// assignment of a key replaces an earlier one.
+func ParseFlags(assignments []string) (Values, error) {
+	values := make(map[string]string, len(assignments))
+	for _, assignment := range assignments {
+		key, value, ok := strings.Cut(assignment, "=")
+		if key = strings.TrimSpace(key); !ok || key == "" {
+			return Values{}, fmt.Errorf("invalid setting %q, want KEY=VALUE", assignment)
+		}
+		values[key] = value
+	}
+	return NewValues("flags", values), nil
+}
+
+// Error is an invalid or missing setting.
+type Error struct {
+	Key    string
+	Source string // Name of the source of the value, empty when missing
+	Err    error
+}
+
+// Error implements error.
+func (e *Error) Error() string {
+	if e.Source == "" {
+		return fmt.Sprintf("%s: %v", e.Key, e.Err)
+	}
+	return fmt.Sprintf("%s (from %s): %v", e.Key, e.Source, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *Error) Unwrap() error { return e.Err }
+
+// ErrMissing reports a required setting no source sets.
+var ErrMissing = errors.New("required setting is missing")
+
+// Settings reads typed values from its sources in precedence order. The getters
+// return the default for unset and empty keys, and for malformed ones after
+// recording an error Err reports. Settings is not safe for concurrent use.
+type Settings struct {
+	sources  []Source
+	errs     []error
+	warnings []error
+}
+
+// New returns Settings reading sources, the first taking precedence.
+func New(sources ...Source) *Settings {
+	return &Settings{sources: sources}
+}
+
+// lookup returns the value of key with the name of its source.
+func (s *Settings) lookup(key string) (value, source string, ok bool) {
+	for _, src := range s.sources {
+		if value, ok := src.Lookup(key); ok {
+			return value, src.Name(), true
+		}
+	}
+	return "", "", false
+}
+
+// Lookup returns the value of key and whether any source sets it, possibly empty.
+func (s *Settings) Lookup(key string) (string, bool) {
+	value, _, ok := s.lookup(key)
+	return value, ok
+}
+
+// Source returns the name of the source setting key, empty when none does.
+func (s *Settings) Source(key string) string {
+	_, source, _ := s.lookup(key)
+	return source
+}
+
+// String returns the value of key, empty when unset.
+func (s *Settings) String(key string) string {
+	value, _, _ := s.lookup(key)
+	return value
+}
+
+// Required returns the value of key, recording an error when it is unset or empty.
+func (s *Settings) Required(key string) string {
+	value, _, _ := s.lookup(key)
+	if value == "" {
+		s.errs = append(s.errs, &Error{Key: key, Err: ErrMissing})
+	}
+	return value
+}
+
+// Float returns the float value of key, or def.
+func (s *Settings) Float(key string, def float64) float64 {
+	return Parse(s, key, def, func(v string) (float64, error) { return strconv.ParseFloat(v, 64) })
+}
+
+// Int returns the integer value of key, or def.
+func (s *Settings) Int(key string, def int) int {
+	return Parse(s, key, def, strconv.Atoi)
+}
+
+// Bool returns the boolean value of key, or def.
+func (s *Settings) Bool(key string, def bool) bool {
+	return Parse(s, key, def, strconv.ParseBool)
+}
+
+// Duration returns the duration value of key, or def.
+func (s *Settings) Duration(key string, def time.Duration) time.Duration {
+	return Parse(s, key, def, time.ParseDuration)
+}
+
+// Parse returns the value of key parsed by fn, or def when the key is unset,
+// empty or malformed, recording the error of fn for the latter.
+func Parse[T any](s *Settings, key string, def T, fn func(string) (T, error)) T {
+	raw, source, _ := s.lookup(key)
+	if raw = strings.TrimSpace(raw); raw == "" {
+		return def
+	}
+	value, err := fn(raw)
+	if err != nil {
+		var numErr *strconv.NumError
+		if errors.As(err, &numErr) {
+			err = numErr.Err
+		}
+		s.errs = append(s.errs, &Error{Key: key, Source: source, Err: fmt.Errorf("invalid value %q: %w", raw, err)})
+		return def
+	}
+	return value
+}
+
+// Fail records err for key, for values the caller validates itself.
+func (s *Settings) Fail(key string, err error) {
+	s.errs = append(s.errs, &Error{Key: key, Source: s.Source(key), Err: err})
+}
+
+// Warn records err for key as a warning: a value that loads but is not used as
+// written, such as a setting another one overrides.
+func (s *Settings) Warn(key string, err error) {
+	s.warnings = append(s.warnings, &Error{Key: key, Source: s.Source(key), Err: err})
+}
+
+// Err returns the errors recorded so far joined, nil when there are none.
+func (s *Settings) Err() error {
+	return errors.Join(s.errs...)
+}
+
+// Warnings returns the warnings recorded so far.
+func (s *Settings) Warnings() []error {
+	return s.warnings
+}
@@ -0,0 +1,193 @@
+package settings
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// layers returns Settings of the flags, environment and file, in that order, as
+// the CLI stacks them.
+func layers(t *testing.T, flags []string, file string) *Settings {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), ".env")
+	if err := os.WriteFile(path, []byte(file), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	fileSource, err := ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	flagSource, err := ParseFlags(flags)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return New(flagSource, Env{}, fileSource)
+}
+
+func TestPrecedence(t *testing.T) {
+	t.Setenv("GUARD_FLAG_ENV_FILE", "env")
+	t.Setenv("GUARD_ENV_FILE", "env")
+	s := layers(t, []string{"GUARD_FLAG_ENV_FILE=flag", "GUARD_FLAG=flag"},
+		"GUARD_FLAG_ENV_FILE=file\nGUARD_ENV_FILE=file\nGUARD_FILE=file\n")
+
+	tests := []struct {
+		key        string
+		want       string
+		wantSource string
+	}{
+		{"GUARD_FLAG_ENV_FILE", "flag", "flags"},
+		{"GUARD_FLAG", "flag", "flags"},
+		{"GUARD_ENV_FILE", "env", "environment"},
+		{"GUARD_FILE", "file", ".env"},
+		{"GUARD_NONE", "", ""},
+	}
+	for _, tt := range tests {
+		if got := s.String(tt.key); got != tt.want {
+			t.Errorf("String(%s) = %q, want %q", tt.key, got, tt.want)
+		}
+		if got := filepath.Base(s.Source(tt.key)); tt.wantSource != "" && got != tt.wantSource {
+			t.Errorf("Source(%s) = %q, want %q", tt.key, got, tt.wantSource)
+		}
+	}
+	if got := s.Int("GUARD_NONE", 7); got != 7 {
+		t.Errorf("Int of an unset key = %d, want the default 7", got)
+	}
+	if err := s.Err(); err != nil {
+		t.Errorf("Err() = %v", err)
+	}
+}
+
+func TestEmptyValueShadowsLowerLayers(t *testing.T) {
+	t.Setenv("GUARD_LIST", "")
+	s := layers(t, nil, "GUARD_LIST=a,b\nGUARD_PERCENT=2\n")
+	if value, ok := s.Lookup("GUARD_LIST"); !ok || value != "" {
+		t.Errorf("Lookup(GUARD_LIST) = %q, %v, want the empty environment value", value, ok)
+	}
+	t.Setenv("GUARD_PERCENT", " ")
+	if got := s.Float("GUARD_PERCENT", 1); got != 1 {
+		t.Errorf("Float of a blank value = %v, want the default 1", got)
+	}
+}
+
+func TestTypedGetters(t *testing.T) {
+	s := New(NewValues("test", map[string]string{
+		"FLOAT": "2.5", "INT": " 12 ", "BOOL": "true", "DURATION": "90s",
+	}))
+	if got := s.Float("FLOAT", 0); got != 2.5 {
+		t.Errorf("Float = %v, want 2.5", got)
+	}
+	if got := s.Int("INT", 0); got != 12 {
+		t.Errorf("Int = %v, want 12", got)
+	}
+	if got := s.Bool("BOOL", false); !got {
+		t.Errorf("Bool = %v, want true", got)
+	}
+	if got := s.Duration("DURATION", 0); got != 90*time.Second {
+		t.Errorf("Duration = %v, want 90s", got)
+	}
+	if err := s.Err(); err != nil {
+		t.Errorf("Err() = %v", err)
+	}
+}
+
+func TestInvalidValues(t *testing.T) {
+	s := New(NewValues("test", map[string]string{
+		"FLOAT": "abc", "INT": "1.5", "BOOL": "maybe", "DURATION": "10", "CHOICE": "sideways",
+	}))
+	if got := s.Float("FLOAT", 1); got != 1 {
+		t.Errorf("Float of an invalid value = %v, want the default", got)
+	}
+	s.Int("INT", 0)
+	s.Bool("BOOL", false)
+	s.Duration("DURATION", 0)
+	Parse(s, "CHOICE", "up", func(v string) (string, error) {
+		if v != "up" && v != "down" {
+			return "", errors.New("want up or down")
+		}
+		return v, nil
+	})
+	s.Fail("FLOAT", errors.New("must be positive"))
+
+	err := s.Err()
+	if err == nil {
+		t.Fatal("Err() = nil, want the invalid settings")
+	}
+	for _, want := range []string{
+		`FLOAT (from test): invalid value "abc": invalid syntax`,
+		`INT (from test): invalid value "1.5"`,
+		`BOOL (from test): invalid value "maybe"`,
+		`DURATION (from test): invalid value "10"`,
+		`CHOICE (from test): invalid value "sideways": want up or down`,
+		`FLOAT (from test): must be positive`,
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Err() = %v, want it to contain %q", err, want)
+		}
+	}
+	var invalid *Error
+	if !errors.As(err, &invalid) || invalid.Key != "FLOAT" {
+		t.Errorf("errors.As(Err()) = %v, want the FLOAT *Error first", invalid)
+	}
+}
+
+func TestRequired(t *testing.T) {
+	s := New(NewValues("test", map[string]string{"SET": "x", "EMPTY": ""}))
+	if got := s.Required("SET"); got != "x" {
+		t.Errorf("Required(SET) = %q, want x", got)
+	}
+	if err := s.Err(); err != nil {
+		t.Fatalf("Err() = %v after a set required key", err)
+	}
+	s.Required("EMPTY")
+	s.Required("UNSET")
+	err := s.Err()
+	if !errors.Is(err, ErrMissing) {
+		t.Fatalf("Err() = %v, want ErrMissing", err)
+	}
+	for _, key := range []string{"EMPTY", "UNSET"} {
+		if !strings.Contains(err.Error(), key+": "+ErrMissing.Error()) {
+			t.Errorf("Err() = %v, want %s missing", err, key)
+		}
+	}
+}
+
+func TestWarn(t *testing.T) {
+	s := New(NewValues("test", map[string]string{"PRESET": "swing"}))
+	s.Warn("PRESET", errors.New("ignored while LEVELS is set"))
+	if err := s.Err(); err != nil {
+		t.Errorf("Err() = %v, want warnings kept apart from errors", err)
+	}
+	warnings := s.Warnings()
+	if len(warnings) != 1 || warnings[0].Error() != "PRESET (from test): ignored while LEVELS is set" {
+		t.Errorf("Warnings() = %v, want the PRESET warning with its source", warnings)
+	}
+}
+
+func TestParseFlags(t *testing.T) {
+	flags, err := ParseFlags([]string{"A=1", "B=x=y", "C=", "A=2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for key, want := range map[string]string{"A": "2", "B": "x=y", "C": ""} {
+		if got, ok := flags.Lookup(key); !ok || got != want {
+			t.Errorf("Lookup(%s) = %q, %v, want %q", key, got, ok, want)
+		}
+	}
+	for _, invalid := range []string{"A", "=1", " =1"} {
+		if _, err := ParseFlags([]string{invalid}); err == nil {
+			t.Errorf("ParseFlags(%q) succeeded, want an error", invalid)
+		}
+	}
+}
+
+func TestReadFileMissing(t *testing.T) {
+	_, err := ReadFile(filepath.Join(t.TempDir(), "missing.env"))
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("ReadFile of a missing file = %v, want fs.ErrNotExist", err)
+	}
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// SizeResult describes the position size that risks a given share of equity.
+type SizeResult struct {
+	Symbol      string
+	IsLong      bool
+	EntryPrice  float64
+	StopPrice   float64
+	Equity      float64
+	RiskPct     float64
+	RiskAmount  float64
+	Quantity    float64
+	QuantityStr string
+	Notional    float64
+	Leverage    float64
+	Margin      float64
+}
+
+// parseDirection converts a long/short argument into an isLong flag.
+func parseDirection(value string) (bool, error) {
+	switch strings.ToLower(value) {
+	case "long", "buy":
+		return true, nil
+	case "short", "sell":
+		return false, nil
+	default:
+		return false, fmt.Errorf("invalid direction %q, expected long or short", value)
+	}
+}
+
+// parseRiskPercent parses a risk percentage such as "1%" or "0.5".
+func parseRiskPercent(value string) (float64, error) {
+	pct, err := strconv.ParseFloat(strings.TrimSuffix(strings.TrimSpace(value), "%"), 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid risk percentage %q: %w", value, err)
+	}
+	if pct <= 0 || pct > 100 {
+		return 0, fmt.Errorf("risk percentage must be between 0 and 100, got %.2f", pct)
+	}
+	return pct, nil
+}
+
+// calculatePositionSize computes the quantity for a new position on symbol such that
+// hitting the default stop-loss loses riskPct of the account equity.
+func (ts *TradingService) calculatePositionSize(symbol string, isLong bool, riskPct float64) (*SizeResult, error) {
+	precision, ok := ts.symbolPrecision(symbol)
+	if !ok {
+		return nil, fmt.Errorf("precision information not found for %s", symbol)
+	}
+	if ts.config().DefaultSLPercent <= 0 {
+		return nil, fmt.Errorf("DEFAULT_SL_PERCENT must be positive to size positions")
+	}
+
+	if err := ts.requireBinance("position sizing"); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+
+	account, err := ts.client().Account(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error getting account information: %w", err)
+	}
+	equity, err := strconv.ParseFloat(account.TotalMarginBalance, 64)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing margin balance: %w", err)
+	}
+
+	premium, err := ts.client().PremiumIndex(ctx, symbol)
+	if err != nil || len(premium) == 0 {
+		return nil, fmt.Errorf("error fetching mark price for %s: %v", symbol, err)
+	}
+	entryPrice, err := strconv.ParseFloat(premium[0].MarkPrice, 64)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing mark price: %w", err)
+	}
+
+	leverage := 1.0
+	for _, position := range account.Positions {
+		if position.Symbol == symbol {
+			if val, err := strconv.ParseFloat(position.Leverage, 64); err == nil && val > 0 {
+				leverage = val
+			}
+			break
+		}
+	}
+
+	// The default stop sits DefaultSLPercent of the entry price away from entry
+	stopDistance := entryPrice * ts.config().DefaultSLPercent / 100
+	stopPrice := entryPrice - stopDistance
+	if !isLong {
+		stopPrice = entryPrice + stopDistance
+	}
+
+	riskAmount := equity * riskPct / 100
+	quantity := truncateToPrecision(riskAmount/stopDistance, precision.QuantityPrecision)
+	if quantity <= 0 {
+		return nil, fmt.Errorf("risk of %.2f USD is too small for the minimum quantity of %s", riskAmount, symbol)
+	}
+
+	notional := quantity * entryPrice
+	return &SizeResult{
+		Symbol:      symbol,
+		IsLong:      isLong,
+		EntryPrice:  entryPrice,
+		StopPrice:   stopPrice,
+		Equity:      equity,
+		RiskPct:     riskPct,
+		RiskAmount:  quantity * stopDistance,
+		Quantity:    quantity,
+		QuantityStr: formatDecimal(quantity, precision.QuantityPrecision),
+		Notional:    notional,
+		Leverage:    leverage,
+		Margin:      notional / leverage,
+	}, nil
+}
+
+// formatSizeResult creates a human-readable summary of a sizing calculation.
+func formatSizeResult(res *SizeResult) string {
+	side := "🔴 SHORT"
+	if res.IsLong {
+		side = "🟢 LONG"
+	}
+	return fmt.Sprintf(`📐 %s %s
+💵 Entry (mark): %.8f  🛑 SL: %.8f
+🏦 Equity: %.2f USD  🎲 Risk: %.2f%% (%.2f USD)
+📦 Quantity: %s  Notional: %.2f USD
+🧮 Margin at x%d: %.2f USD`,
+		res.Symbol, side,
+		res.EntryPrice, res.StopPrice,
+		res.Equity, res.RiskPct, res.RiskAmount,
+		res.QuantityStr, res.Notional,
+		int(res.Leverage), res.Margin)
+}
+
+// runSizeCommand handles `futures-guard size <symbol> <long|short> <risk%>`.
+func runSizeCommand(ts *TradingService, args []string) error {
+	isLong, err := parseDirection(args[1])
+	if err != nil {
+		return err
+	}
+	riskPct, err := parseRiskPercent(args[2])
+	if err != nil {
+		return err
+	}
+
+	res, err := ts.calculatePositionSize(strings.ToUpper(args[0]), isLong, riskPct)
+	if err != nil {
+		return err
+	}
+	fmt.Println(formatSizeResult(res))
+	return nil
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+)
+
+// spreadBookLevels is the number of order book levels per side summed as the
+// top-of-book depth.
+const spreadBookLevels = 5
+
+// bookLiquidity is the spread and depth at the top of a symbol's order book.
+type bookLiquidity struct {
+	SpreadPct float64 // Best ask over best bid, in % of the mid price
+	BidDepth  float64 // Quote value of the top bid levels
+	AskDepth  float64 // Quote value of the top ask levels
+}
+
+// getBookLiquidity reads the spread and top-of-book depth of symbol.
+func (ts *TradingService) getBookLiquidity(symbol string) (*bookLiquidity, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+
+	book, err := ts.client().Depth(ctx, symbol, spreadBookLevels)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching the order book of %s: %w", symbol, err)
+	}
+	if len(book.Bids) == 0 || len(book.Asks) == 0 {
+		return nil, fmt.Errorf("the order book of %s is empty", symbol)
+	}
+
+	liquidity := &bookLiquidity{}
+	var bestBid, bestAsk float64
+	for i, bid := range book.Bids {
+		price, quantity, err := bid.Parse()
+		if err != nil {
+			return nil, fmt.Errorf("error parsing the order book of %s: %w", symbol, err)
+		}
+		if i == 0 {
+			bestBid = price
+		}
+		liquidity.BidDepth += price * quantity
+	}
+	for i, ask := range book.Asks {
+		price, quantity, err := ask.Parse()
+		if err != nil {
+			return nil, fmt.Errorf("error parsing the order book of %s: %w", symbol, err)
+		}
+		if i == 0 {
+			bestAsk = price
+		}
+		liquidity.AskDepth += price * quantity
+	}
+	if mid := (bestBid + bestAsk) / 2; mid > 0 {
+		liquidity.SpreadPct = (bestAsk - bestBid) / mid * 100
+	}
+	return liquidity, nil
+}
+
+// applySpreadGuard widens a stop within SpreadGuardDistance of the mark price by
+// SpreadBufferPct when the book is thin: its spread is above SpreadMaxPct or the
+// depth the stop would fill against is below SpreadMinDepth. It returns the stop
+// and, when widened, what was found, for the audit log.
+func (ts *TradingService) applySpreadGuard(data *PositionData, stopPrice float64) (float64, string) {
+	if ts.config().SpreadBufferPct <= 0 || stopPrice <= 0 || data.NearLiquidation {
+		return stopPrice, ""
+	}
+	if math.Abs(data.MarkPrice-stopPrice)/data.MarkPrice*100 > ts.config().SpreadGuardDistance {
+		return stopPrice, ""
+	}
+
+	liquidity, err := ts.getBookLiquidity(data.Symbol)
+	if err != nil {
+		log.Printf("Warning: Unable to check the spread before a tight stop: %v", err)
+		return stopPrice, ""
+	}
+	// A long's stop sells into the bids, a short's buys from the asks
+	depth := liquidity.BidDepth
+	if data.IsShort {
+		depth = liquidity.AskDepth
+	}
+	wide := ts.config().SpreadMaxPct > 0 && liquidity.SpreadPct > ts.config().SpreadMaxPct
+	shallow := ts.config().SpreadMinDepth > 0 && depth < ts.config().SpreadMinDepth
+	if !wide && !shallow {
+		return stopPrice, ""
+	}
+
+	widened := stopPrice * (1 - ts.config().SpreadBufferPct/100)
+	if data.IsShort {
+		widened = stopPrice * (1 + ts.config().SpreadBufferPct/100)
+	}
+	note := fmt.Sprintf("widened %.2f%% for a thin book (spread %.3f%%, depth %.0f)",
+		ts.config().SpreadBufferPct, liquidity.SpreadPct, depth)
+	log.Printf("Widening SL for %s from %.8f to %.8f: %s", data.Symbol, stopPrice, widened, note)
+
+	data.RawSLPct = rawStopLossPct(data, widened)
+	data.LeveragedSLPct = data.RawSLPct * data.Leverage
+	return widened, note
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"strings"
+)
+
+// sendStartupReport notifies an account summary when the daemon starts, so users
+// can confirm at a glance that the bot sees the account and positions they expect.
+func (ts *TradingService) sendStartupReport() {
+	msg, err := ts.startupReport()
+	if err != nil {
+		log.Printf("Warning: Unable to build the startup report: %v", err)
+		return
+	}
+	log.Println(msg)
+	ts.notify(SeverityInfo, msg)
+}
+
+// startupReport builds the startup summary: the balances of the account, on
+// Binance USDⓈ-M, and one line per open position with its margin mode.
+func (ts *TradingService) startupReport() (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+
+	positions, err := ts.exchange().Positions(ctx, "")
+	if err != nil {
+		return "", fmt.Errorf("error getting positions: %w", err)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "🟢 Futures Guard started on %s", ts.exchange().Name())
+	if ts.config().ObserveOnly {
+		b.WriteString(" (observe only)")
+	}
+	if !ts.isLeader() {
+		b.WriteString(" (standby)")
+	}
+
+	if ts.exchange().Name() == exchangeBinance {
+		account, err := ts.client().Account(ctx)
+		if err != nil {
+			log.Printf("Warning: Unable to get account balances: %v", err)
+		} else {
+			fmt.Fprintf(&b, "\n💼 Wallet balance: %s", formatPnL(parseFloatOrZero(account.TotalWalletBalance), "USDT"))
+			fmt.Fprintf(&b, "\n💵 Available margin: %s", formatPnL(parseFloatOrZero(account.AvailableBalance), "USDT"))
+			fmt.Fprintf(&b, "\n📊 Margin balance: %s", formatPnL(parseFloatOrZero(account.TotalMarginBalance), "USDT"))
+			fmt.Fprintf(&b, "\n📈 Unrealized PnL: %s", formatPnL(parseFloatOrZero(account.TotalUnrealizedProfit), "USDT"))
+		}
+	}
+
+	var open []*Position
+	for _, position := range positions {
+		if position.PositionAmt != 0 {
+			open = append(open, position)
+		}
+	}
+	if len(open) == 0 {
+		b.WriteString("\n📭 No open positions")
+		return b.String(), nil
+	}
+
+	fmt.Fprintf(&b, "\n📋 %d open positions:", len(open))
+	for _, position := range open {
+		side := "🔴 SHORT"
+		if position.PositionAmt > 0 {
+			side = "🟢 LONG"
+		}
+		if position.PositionSide != "" && position.PositionSide != "BOTH" {
+			side += " (" + position.PositionSide + ")"
+		}
+		marginType := position.MarginType
+		if marginType == "" {
+			marginType = "n/a"
+		}
+		managed := ""
+		if !ts.isSymbolManaged(position.Symbol) {
+			managed = ", not managed"
+		}
+		fmt.Fprintf(&b, "\n• %s %s %g @ %.8g, mark %.8g, x%g %s%s",
+			position.Symbol, side, math.Abs(position.PositionAmt), position.EntryPrice, position.MarkPrice,
+			position.Leverage, marginType, managed)
+	}
+	return b.String(), nil
+}
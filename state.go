@@ -0,0 +1,235 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// defaultStateFile is the default location of the persisted bot state.
+const defaultStateFile = "futures-guard-state.json"
+
+// State backends.
+const (
+	stateBackendFile     = "file"
+	stateBackendRedis    = "redis"
+	stateBackendPostgres = "postgres"
+)
+
+// OrderState records the protective orders the bot last placed for a position
+// and the ladder stage it last reached. With DCA_ENABLED or pyramiding it also tracks the position size last seen, its
+// adds and, for pyramiding, the original risk and the stage of the last add. When
+// and how far in and out of profit the position went feed its close report.
+type OrderState struct {
+	Symbol       string    `json:"symbol"`
+	PositionSide string    `json:"positionSide"`
+	StopOrderID  OrderID   `json:"stopOrderId,omitempty"`
+	StopPrice    float64   `json:"stopPrice,omitempty"`
+	TakeOrderID  OrderID   `json:"takeOrderId,omitempty"`
+	TakePrice    float64   `json:"takePrice,omitempty"`
+	Stage        int       `json:"stage,omitempty"`
+	Quantity     float64   `json:"quantity,omitempty"`
+	Adds         int       `json:"adds,omitempty"`
+	InitialRisk  float64   `json:"initialRisk,omitempty"`
+	RiskDistance float64   `json:"riskDistance,omitempty"`
+	PyramidStage int       `json:"pyramidStage,omitempty"`
+	PyramidAdds  int       `json:"pyramidAdds,omitempty"`
+	OpenedAt     time.Time `json:"openedAt,omitzero"`
+	MaxProfitPct float64   `json:"maxProfitPct,omitempty"`
+	MinProfitPct float64   `json:"minProfitPct,omitempty"`
+	// PeakProfitPct is the highest raw profit of the position from the entry
+	// price PeakEntryPrice, the high-water mark of the ladder.
+	PeakProfitPct  float64 `json:"peakProfitPct,omitempty"`
+	PeakEntryPrice float64 `json:"peakEntryPrice,omitempty"`
+	// ScaleOutStage is one past the ladder stage of the last scale-out.
+	ScaleOutStage int `json:"scaleOutStage,omitempty"`
+	// LiquidationReduceDistPct is the liquidation distance of the last reduce by
+	// the liquidation guard, zero when the position has not approached it since.
+	LiquidationReduceDistPct float64 `json:"liquidationReduceDistPct,omitempty"`
+	// Confirmation tracks the ladder stages confirmed under LADDER_CONFIRM_*.
+	Confirmation StageConfirmation `json:"confirmation,omitzero"`
+	// Tag is the strategy that opened the position; TagChecked is set once it is
+	// known or the order history has been searched for it.
+	Tag        string `json:"tag,omitempty"`
+	TagChecked bool   `json:"tagChecked,omitempty"`
+	// Notes are the journal notes written about the position so far.
+	Notes []JournalNote `json:"notes,omitempty"`
+	// StopTriggeredAt is when the mark price was first seen past a stop-limit
+	// that had not filled yet.
+	StopTriggeredAt time.Time `json:"stopTriggeredAt,omitzero"`
+	UpdatedAt       time.Time `json:"updatedAt"`
+}
+
+// TradeRecord is the outcome of a closed position, kept for the statistics.
+type TradeRecord struct {
+	Symbol       string    `json:"symbol"`
+	PositionSide string    `json:"positionSide"`
+	IsLong       bool      `json:"isLong"`
+	OpenedAt     time.Time `json:"openedAt,omitzero"`
+	ClosedAt     time.Time `json:"closedAt"`
+	EntryPrice   float64   `json:"entryPrice"`
+	ExitPrice    float64   `json:"exitPrice"` // Last mark price seen
+	Quantity     float64   `json:"quantity"`
+	Leverage     float64   `json:"leverage"`
+	Reason       string    `json:"reason"`
+	RealizedPnL  float64   `json:"realizedPnl"`
+	Fees         float64   `json:"fees,omitempty"`
+	PnLAsset     string    `json:"pnlAsset,omitempty"`
+	Estimated    bool      `json:"estimated,omitempty"`
+	Risk         float64   `json:"risk,omitempty"` // Initial risk R in PnLAsset
+	ExitStage    int       `json:"exitStage"`
+	MaxProfitPct float64   `json:"maxProfitPct"`
+	MinProfitPct float64   `json:"minProfitPct"`
+	Tag          string    `json:"tag,omitempty"`
+	// Notes are the journal notes of the position, from Telegram replies.
+	Notes []JournalNote `json:"notes,omitempty"`
+}
+
+// NetPnL returns the realized PnL after fees and funding.
+func (t *TradeRecord) NetPnL() float64 {
+	return t.RealizedPnL + t.Fees
+}
+
+// BotState is the state persisted between runs.
+type BotState struct {
+	Orders  map[string]*OrderState  `json:"orders"`
+	Notices map[string]*NoticeState `json:"notices"`
+	Trades  []*TradeRecord          `json:"trades,omitempty"`
+	Control ControlState            `json:"control"`
+	TopUps  []*TopUpRecord          `json:"topUps,omitempty"`
+	Audit   []*AuditRecord          `json:"audit,omitempty"`
+}
+
+// newBotState returns an empty bot state.
+func newBotState() *BotState {
+	return &BotState{
+		Orders:  make(map[string]*OrderState),
+		Notices: make(map[string]*NoticeState),
+	}
+}
+
+// StateStore loads and saves the bot state.
+type StateStore interface {
+	Load() (*BotState, error)
+	Save(state *BotState) error
+}
+
+// openStateStore returns the state store selected by STATE_BACKEND.
+func openStateStore(config Config) (StateStore, error) {
+	switch config.StateBackend {
+	case stateBackendRedis:
+		if config.RedisURL == "" {
+			return nil, fmt.Errorf("REDIS_URL not configured")
+		}
+		return newRedisStateStore(config.RedisURL, config.RedisKeyPrefix)
+	case stateBackendPostgres:
+		if config.PostgresURL == "" {
+			return nil, fmt.Errorf("POSTGRES_URL not configured")
+		}
+		return newPostgresStateStore(config.PostgresURL)
+	default:
+		return newFileStateStore(config.StateFile), nil
+	}
+}
+
+// parseStateBackend normalizes the configured state backend.
+func parseStateBackend(value string) (string, error) {
+	switch backend := strings.ToLower(strings.TrimSpace(value)); backend {
+	case stateBackendFile, stateBackendRedis, stateBackendPostgres:
+		return backend, nil
+	default:
+		return "", fmt.Errorf("want %s, %s or %s", stateBackendFile, stateBackendRedis, stateBackendPostgres)
+	}
+}
+
+// fileStateStore persists the bot state as a JSON file.
+type fileStateStore struct {
+	path string
+}
+
+// newFileStateStore creates a state store backed by the JSON file at path.
+func newFileStateStore(path string) *fileStateStore {
+	return &fileStateStore{path: path}
+}
+
+// Load reads the state file, returning an empty state when it does not exist yet.
+func (s *fileStateStore) Load() (*BotState, error) {
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return newBotState(), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading state file %s: %w", s.path, err)
+	}
+
+	state := newBotState()
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, fmt.Errorf("error parsing state file %s: %w", s.path, err)
+	}
+	if state.Orders == nil {
+		state.Orders = make(map[string]*OrderState)
+	}
+	if state.Notices == nil {
+		state.Notices = make(map[string]*NoticeState)
+	}
+	return state, nil
+}
+
+// Save writes the state file atomically via a temporary file and rename.
+func (s *fileStateStore) Save(state *BotState) error {
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error encoding state: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), ".futures-guard-state-*")
+	if err != nil {
+		return fmt.Errorf("error creating temporary state file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("error writing state file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("error writing state file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("error replacing state file %s: %w", s.path, err)
+	}
+	return nil
+}
+
+// orderState returns the recorded order state for a position, creating it when missing.
+// The caller must hold ts.mu.
+func (ts *TradingService) orderState(symbol, positionSide string) *OrderState {
+	key := trackedKey(symbol, positionSide)
+	st, ok := ts.state.Orders[key]
+	if !ok {
+		st = &OrderState{Symbol: symbol, PositionSide: positionSide}
+		ts.state.Orders[key] = st
+	}
+	return st
+}
+
+// saveState persists the current bot state, logging any failure.
+func (ts *TradingService) saveState() {
+	// A standby would overwrite the state saved by the leader
+	if ts.store == nil || !ts.isLeader() {
+		return
+	}
+
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	// Keep control changes made by another process since our last save
+	ts.syncControl()
+	if err := ts.store.Save(ts.state); err != nil {
+		log.Printf("Warning: %v", err)
+	}
+}
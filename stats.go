@@ -0,0 +1,230 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// statsDateLayout is the layout of the --from and --to dates of `stats`.
+const statsDateLayout = "2006-01-02"
+
+// tradeFilter selects the recorded trades the statistics are computed over.
+type tradeFilter struct {
+	From   time.Time // Inclusive, zero for no lower bound
+	To     time.Time // Exclusive, zero for no upper bound
+	Symbol string
+	Tag    string // Strategy tag, untaggedLabel for untagged trades
+}
+
+// matches reports whether trade closed inside the filter's range on its symbol
+// and strategy tag.
+func (f tradeFilter) matches(trade *TradeRecord) bool {
+	if f.Symbol != "" && trade.Symbol != f.Symbol {
+		return false
+	}
+	if f.Tag != "" && tagLabel(trade.Tag) != f.Tag {
+		return false
+	}
+	if !f.From.IsZero() && trade.ClosedAt.Before(f.From) {
+		return false
+	}
+	return f.To.IsZero() || trade.ClosedAt.Before(f.To)
+}
+
+// tradeStats aggregates the outcome of a set of closed trades.
+type tradeStats struct {
+	Trades      int
+	Wins        int
+	Losses      int
+	NetPnL      float64
+	GrossProfit float64
+	GrossLoss   float64 // Negative
+	TotalR      float64
+	RTrades     int         // Trades with a known initial risk
+	ExitStages  map[int]int // Ladder stage at exit, -1 below the first threshold
+}
+
+// add folds trade into the statistics.
+func (s *tradeStats) add(trade *TradeRecord) {
+	if s.ExitStages == nil {
+		s.ExitStages = make(map[int]int)
+	}
+	net := trade.NetPnL()
+	s.Trades++
+	s.NetPnL += net
+	if net > 0 {
+		s.Wins++
+		s.GrossProfit += net
+	} else if net < 0 {
+		s.Losses++
+		s.GrossLoss += net
+	}
+	if trade.Risk > 0 {
+		s.TotalR += net / trade.Risk
+		s.RTrades++
+	}
+	s.ExitStages[trade.ExitStage]++
+}
+
+// WinRate returns the share of winning trades as a percentage.
+func (s *tradeStats) WinRate() float64 {
+	if s.Trades == 0 {
+		return 0
+	}
+	return float64(s.Wins) / float64(s.Trades) * 100
+}
+
+// Expectancy returns the average net PnL per trade.
+func (s *tradeStats) Expectancy() float64 {
+	if s.Trades == 0 {
+		return 0
+	}
+	return s.NetPnL / float64(s.Trades)
+}
+
+// AverageR returns the average outcome in risk units of the trades with a known risk.
+func (s *tradeStats) AverageR() float64 {
+	if s.RTrades == 0 {
+		return 0
+	}
+	return s.TotalR / float64(s.RTrades)
+}
+
+// ProfitFactor returns gross profit over gross loss, +Inf without losses.
+func (s *tradeStats) ProfitFactor() float64 {
+	if s.GrossLoss == 0 {
+		if s.GrossProfit > 0 {
+			return math.Inf(1)
+		}
+		return 0
+	}
+	return s.GrossProfit / -s.GrossLoss
+}
+
+// computeStats aggregates the trades selected by filter, overall, per symbol and
+// per strategy tag.
+func computeStats(trades []*TradeRecord, filter tradeFilter) (total *tradeStats, bySymbol, byTag map[string]*tradeStats) {
+	total = &tradeStats{}
+	bySymbol = make(map[string]*tradeStats)
+	byTag = make(map[string]*tradeStats)
+	for _, trade := range trades {
+		if !filter.matches(trade) {
+			continue
+		}
+		total.add(trade)
+		if bySymbol[trade.Symbol] == nil {
+			bySymbol[trade.Symbol] = &tradeStats{}
+		}
+		bySymbol[trade.Symbol].add(trade)
+		tag := tagLabel(trade.Tag)
+		if byTag[tag] == nil {
+			byTag[tag] = &tradeStats{}
+		}
+		byTag[tag].add(trade)
+	}
+	return total, bySymbol, byTag
+}
+
+// formatStats creates the statistics summary printed by `stats`. The per-tag
+// table is left out while no trade carries a strategy tag.
+func formatStats(total *tradeStats, bySymbol, byTag map[string]*tradeStats, filter tradeFilter) string {
+	var b strings.Builder
+
+	period := "all recorded trades"
+	if !filter.From.IsZero() || !filter.To.IsZero() {
+		from, to := "start", "now"
+		if !filter.From.IsZero() {
+			from = filter.From.Format(statsDateLayout)
+		}
+		if !filter.To.IsZero() {
+			to = filter.To.Add(-time.Nanosecond).Format(statsDateLayout)
+		}
+		period = from + " to " + to
+	}
+	if filter.Tag != "" {
+		period += ", tag " + filter.Tag
+	}
+	fmt.Fprintf(&b, "📊 Trade statistics (%s)\n", period)
+	if total.Trades == 0 {
+		b.WriteString("No closed trades recorded in this range\n")
+		return b.String()
+	}
+
+	fmt.Fprintf(&b, "Trades: %d (%d wins, %d losses)\n", total.Trades, total.Wins, total.Losses)
+	fmt.Fprintf(&b, "Win rate: %.1f%%\n", total.WinRate())
+	fmt.Fprintf(&b, "Net PnL: %.4f | Expectancy: %.4f per trade\n", total.NetPnL, total.Expectancy())
+	fmt.Fprintf(&b, "Average R: %.2fR (%d trades with known risk)\n", total.AverageR(), total.RTrades)
+	fmt.Fprintf(&b, "Profit factor: %.2f\n", total.ProfitFactor())
+
+	b.WriteString("\nExit stage distribution:\n")
+	stages := make([]int, 0, len(total.ExitStages))
+	for stage := range total.ExitStages {
+		stages = append(stages, stage)
+	}
+	sort.Ints(stages)
+	for _, stage := range stages {
+		count := total.ExitStages[stage]
+		label := fmt.Sprintf("stage %d", stage)
+		if stage < 0 {
+			label = "below first threshold"
+		}
+		fmt.Fprintf(&b, "  %-22s %4d (%.1f%%)\n", label, count, float64(count)/float64(total.Trades)*100)
+	}
+
+	b.WriteString("\nPer symbol:\n")
+	writeStatsTable(&b, "Symbol", bySymbol)
+	if byTag[untaggedLabel] == nil || len(byTag) > 1 {
+		b.WriteString("\nPer strategy tag:\n")
+		writeStatsTable(&b, "Tag", byTag)
+	}
+	return b.String()
+}
+
+// writeStatsTable writes one row of statistics per key of groups, sorted.
+func writeStatsTable(b *strings.Builder, label string, groups map[string]*tradeStats) {
+	keys := make([]string, 0, len(groups))
+	for key := range groups {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	fmt.Fprintf(b, "  %-14s %6s %8s %14s %8s %8s\n", label, "Trades", "Win %", "Net PnL", "Avg R", "PF")
+	for _, key := range keys {
+		s := groups[key]
+		fmt.Fprintf(b, "  %-14s %6d %7.1f%% %14.4f %7.2fR %8.2f\n",
+			key, s.Trades, s.WinRate(), s.NetPnL, s.AverageR(), s.ProfitFactor())
+	}
+}
+
+// parseStatsRange builds the date range of `stats` from --from/--to dates or the
+// number of days back from now, in UTC.
+func parseStatsRange(from, to string, days int, now time.Time) (tradeFilter, error) {
+	var filter tradeFilter
+	if days > 0 {
+		if from != "" {
+			return filter, fmt.Errorf("--days and --from cannot be combined")
+		}
+		filter.From = now.UTC().AddDate(0, 0, -days)
+	}
+	if from != "" {
+		t, err := time.Parse(statsDateLayout, from)
+		if err != nil {
+			return filter, fmt.Errorf("invalid --from date %q (use YYYY-MM-DD)", from)
+		}
+		filter.From = t
+	}
+	if to != "" {
+		t, err := time.Parse(statsDateLayout, to)
+		if err != nil {
+			return filter, fmt.Errorf("invalid --to date %q (use YYYY-MM-DD)", to)
+		}
+		// The end date is included in full
+		filter.To = t.AddDate(0, 0, 1)
+	}
+	if !filter.From.IsZero() && !filter.To.IsZero() && !filter.From.Before(filter.To) {
+		return filter, fmt.Errorf("the start of the range must be before its end")
+	}
+	return filter, nil
+}
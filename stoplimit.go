@@ -0,0 +1,138 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"futures-guard/settings"
+)
+
+// Stop-loss order types.
+const (
+	stopOrderMarket = "market"
+	stopOrderLimit  = "limit"
+)
+
+// Stop-limit defaults: the limit sits defaultSLLimitOffset percent beyond the
+// trigger and a triggered order unfilled after defaultSLLimitTimeout is replaced
+// by a market close.
+const (
+	defaultSLLimitOffset  = 0.5
+	defaultSLLimitTimeout = 30 * time.Second
+)
+
+// isStopLossOrder reports whether order is a stop-loss, at market or with a limit.
+func isStopLossOrder(order *Order) bool {
+	return order.Type == orderTypeStopMarket || order.Type == orderTypeStop
+}
+
+// stopLimit reports whether the stop-loss of data is placed as a stop-limit order.
+// Spot stops are always stop-limit orders, and closePosition orders must be
+// stop-market ones.
+func (ts *TradingService) stopLimit(data *PositionData) bool {
+	return ts.config().SLOrderType == stopOrderLimit && ts.config().Exchange != exchangeBinanceSpot && !data.ClosePosition
+}
+
+// stopLossOrder builds the stop-loss order request for a position as configured
+// by SL_ORDER_TYPE.
+func (ts *TradingService) stopLossOrder(data *PositionData) OrderRequest {
+	req := newStopLossOrder(data)
+	if !ts.stopLimit(data) {
+		return req
+	}
+
+	// The limit sits beyond the trigger so the order still fills in a fast market
+	offset := ts.config().SLLimitOffset
+	if req.Side == sideSell {
+		offset = -offset
+	}
+	precision, _ := ts.symbolPrecision(data.Symbol)
+	req.Type = orderTypeStop
+	req.Price = formatDecimal(scalePrice(data.StopPrice, offset), precision.PricePrecision)
+	return req
+}
+
+// checkStopLimitFill closes a position at market when its stop-limit order was
+// triggered but has not filled within SL_LIMIT_TIMEOUT, so a price that gapped
+// through the limit never leaves the position unprotected. The order counts as
+// triggered once the mark price has passed its stop price.
+func (ts *TradingService) checkStopLimitFill(data *PositionData) {
+	if ts.config().SLOrderType != stopOrderLimit || ts.config().SLLimitTimeout <= 0 || ts.config().ObserveOnly {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+
+	openOrders, err := ts.exchange().OpenOrders(ctx, data.Symbol)
+	if err != nil {
+		log.Printf("Warning: Unable to check the stop-limit of %s: %v", data.Symbol, err)
+		return
+	}
+	var stop *Order
+	for _, order := range openOrders {
+		if order.Type == orderTypeStop && orderMatchesSide(order, data.PositionSide) && ts.ownsOrder(order) {
+			stop = order
+			break
+		}
+	}
+	triggered := stop != nil && ((data.IsLong && data.MarkPrice <= stop.StopPrice) ||
+		(data.IsShort && data.MarkPrice >= stop.StopPrice))
+
+	ts.mu.Lock()
+	st := ts.orderState(data.Symbol, data.PositionSide)
+	if !triggered {
+		st.StopTriggeredAt = time.Time{}
+		ts.mu.Unlock()
+		return
+	}
+	if st.StopTriggeredAt.IsZero() {
+		st.StopTriggeredAt = time.Now()
+	}
+	waited := time.Since(st.StopTriggeredAt)
+	ts.mu.Unlock()
+	if waited < ts.config().SLLimitTimeout {
+		log.Printf("Stop-limit of %s (%s) triggered at %.8f, waiting %s for the limit %.8f to fill",
+			data.Symbol, data.PositionSide, stop.StopPrice, (ts.config().SLLimitTimeout - waited).Round(time.Second), stop.Price)
+		return
+	}
+
+	msg := fmt.Sprintf("⏳ Stop-limit of %s (%s) triggered at %.8f but the limit %.8f did not fill within %s, closing at market (mark %.8f)",
+		data.Symbol, data.PositionSide, stop.StopPrice, stop.Price, ts.config().SLLimitTimeout, data.MarkPrice)
+	log.Println(msg)
+	ts.notify(SeverityCritical, msg)
+
+	if err := ts.cancelOrder(ctx, stop); err != nil {
+		log.Printf("Warning: %v", err)
+	}
+	if err := ts.reducePosition(data, 100, "stop-limit not filled"); err != nil {
+		log.Printf("Warning: %v", err)
+		return
+	}
+
+	ts.mu.Lock()
+	st.StopTriggeredAt = time.Time{}
+	ts.mu.Unlock()
+}
+
+// parseStopOrderType normalizes the configured stop-loss order type.
+func parseStopOrderType(value string) (string, error) {
+	switch orderType := strings.ToLower(strings.TrimSpace(value)); orderType {
+	case stopOrderMarket, stopOrderLimit:
+		return orderType, nil
+	default:
+		return "", fmt.Errorf("want %s or %s", stopOrderMarket, stopOrderLimit)
+	}
+}
+
+// loadStopLimitConfig reads the stop-loss order type settings from src.
+func loadStopLimitConfig(src *settings.Settings, config *Config) {
+	config.SLOrderType = settings.Parse(src, "SL_ORDER_TYPE", stopOrderMarket, parseStopOrderType)
+	config.SLLimitOffset = defaultSLLimitOffset
+	config.SLLimitOffset = src.Float("SL_LIMIT_OFFSET", config.SLLimitOffset)
+	config.SLLimitTimeout = defaultSLLimitTimeout
+	config.SLLimitTimeout = src.Duration("SL_LIMIT_TIMEOUT", config.SLLimitTimeout)
+}
@@ -0,0 +1,425 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"futures-guard/settings"
+)
+
+// Built-in strategy names.
+const (
+	strategyLadder     = "ladder"
+	strategyFixed      = "fixed"
+	strategyATR        = "atr"
+	strategyChandelier = "chandelier"
+	strategySwing      = "swing"
+	strategyPivot      = "pivot"
+	strategyPercent    = "percent"
+)
+
+// StopLossStrategy calculates the stop price of a position.
+type StopLossStrategy interface {
+	Name() string
+	StopLoss(ts *TradingService, data *PositionData) (float64, error)
+}
+
+// TakeProfitStrategy calculates the take-profit price of a position.
+type TakeProfitStrategy interface {
+	Name() string
+	TakeProfit(ts *TradingService, data *PositionData) (float64, error)
+}
+
+var (
+	strategiesMu         sync.RWMutex
+	stopLossStrategies   = make(map[string]StopLossStrategy)
+	takeProfitStrategies = make(map[string]TakeProfitStrategy)
+)
+
+// RegisterStopLossStrategy makes a stop-loss strategy selectable by name in config.
+func RegisterStopLossStrategy(s StopLossStrategy) {
+	strategiesMu.Lock()
+	defer strategiesMu.Unlock()
+	stopLossStrategies[strings.ToLower(s.Name())] = s
+}
+
+// RegisterTakeProfitStrategy makes a take-profit strategy selectable by name in config.
+func RegisterTakeProfitStrategy(s TakeProfitStrategy) {
+	strategiesMu.Lock()
+	defer strategiesMu.Unlock()
+	takeProfitStrategies[strings.ToLower(s.Name())] = s
+}
+
+// lookupStopLossStrategy returns the registered stop-loss strategy called name.
+func lookupStopLossStrategy(name string) (StopLossStrategy, bool) {
+	strategiesMu.RLock()
+	defer strategiesMu.RUnlock()
+	s, ok := stopLossStrategies[strings.ToLower(name)]
+	return s, ok
+}
+
+// lookupTakeProfitStrategy returns the registered take-profit strategy called name.
+func lookupTakeProfitStrategy(name string) (TakeProfitStrategy, bool) {
+	strategiesMu.RLock()
+	defer strategiesMu.RUnlock()
+	s, ok := takeProfitStrategies[strings.ToLower(name)]
+	return s, ok
+}
+
+// strategyNames lists the registered strategy names for error messages.
+func strategyNames[T any](registry map[string]T) string {
+	strategiesMu.RLock()
+	defer strategiesMu.RUnlock()
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+func init() {
+	RegisterStopLossStrategy(ladderStrategy{})
+	RegisterStopLossStrategy(fixedStrategy{})
+	RegisterStopLossStrategy(atrStrategy{})
+	RegisterStopLossStrategy(chandelierStrategy{})
+	RegisterStopLossStrategy(swingStrategy{})
+	RegisterStopLossStrategy(pivotStrategy{})
+	RegisterStopLossStrategy(rMultipleStrategy{})
+
+	RegisterTakeProfitStrategy(percentStrategy{})
+	RegisterTakeProfitStrategy(atrStrategy{})
+	RegisterTakeProfitStrategy(riskRewardStrategy{})
+}
+
+// stopLossStrategyFor returns the configured stop-loss strategy for symbol.
+func (ts *TradingService) stopLossStrategyFor(symbol string) StopLossStrategy {
+	name := ts.config().SLStrategy
+	if override, ok := ts.config().SLStrategyOverrides[symbol]; ok {
+		name = override
+	}
+	if s, ok := lookupStopLossStrategy(name); ok {
+		return s
+	}
+	log.Printf("Warning: Unknown SL strategy %q for %s (available: %s), using %s",
+		name, symbol, strategyNames(stopLossStrategies), strategyLadder)
+	return ladderStrategy{}
+}
+
+// takeProfitStrategyFor returns the configured take-profit strategy for symbol.
+func (ts *TradingService) takeProfitStrategyFor(symbol string) TakeProfitStrategy {
+	name := ts.config().TPStrategy
+	if override, ok := ts.config().TPStrategyOverrides[symbol]; ok {
+		name = override
+	}
+	if s, ok := lookupTakeProfitStrategy(name); ok {
+		return s
+	}
+	log.Printf("Warning: Unknown TP strategy %q for %s (available: %s), using %s",
+		name, symbol, strategyNames(takeProfitStrategies), strategyPercent)
+	return percentStrategy{}
+}
+
+// stopLossFor calculates the stop price with the symbol's strategy, falling back to
+// the ladder when the strategy fails, and fills in the reporting percentages.
+func (ts *TradingService) stopLossFor(data *PositionData) float64 {
+	strategy := ts.stopLossStrategyFor(data.Symbol)
+	if strategy.Name() == strategyLadder {
+		return ts.calculateStopLoss(data)
+	}
+
+	stopPrice, err := strategy.StopLoss(ts, data)
+	if err != nil || stopPrice <= 0 {
+		log.Printf("Warning: %s SL strategy failed for %s (%v), using %s", strategy.Name(), data.Symbol, err, strategyLadder)
+		return ts.calculateStopLoss(data)
+	}
+
+	data.RawSLPct = rawStopLossPct(data, stopPrice)
+	data.LeveragedSLPct = data.RawSLPct * data.Leverage
+	data.CurrentSLPct = math.Abs(data.LeveragedSLPct)
+	log.Printf("DEBUG: %s SL for %s: price=%.8f, raw=%.2f%%, leveraged=%.2f%%",
+		strategy.Name(), data.Symbol, stopPrice, data.RawSLPct, data.LeveragedSLPct)
+	return stopPrice
+}
+
+// tpDisabled reports whether positions on symbol are managed without a take-profit.
+func (ts *TradingService) tpDisabled(symbol string) bool {
+	if !ts.config().TPDisabled {
+		return false
+	}
+	return len(ts.config().TPDisabledSymbols) == 0 || matchesSymbolPattern(symbol, ts.config().TPDisabledSymbols)
+}
+
+// tpDeferred reports whether data has no take-profit yet because it has neither
+// reached TP_MIN_PROFIT nor been open for TP_MIN_AGE, letting a new winner run
+// uncapped at first.
+func (ts *TradingService) tpDeferred(data *PositionData) bool {
+	minProfit, minAge := ts.config().TPMinProfit, ts.config().TPMinAge
+	if minProfit <= 0 && minAge <= 0 {
+		return false
+	}
+	if minProfit > 0 && ts.ladderProfit(data) >= minProfit {
+		return false
+	}
+	return minAge <= 0 || ts.positionAge(data) < minAge
+}
+
+// positionAge returns how long the position of data has been open, from its
+// opening time when known or else from when the bot first saw it.
+func (ts *TradingService) positionAge(data *PositionData) time.Duration {
+	opened := data.OpenedAt
+	ts.mu.Lock()
+	if st, ok := ts.state.Orders[trackedKey(data.Symbol, data.PositionSide)]; ok && !st.OpenedAt.IsZero() {
+		if opened.IsZero() || st.OpenedAt.Before(opened) {
+			opened = st.OpenedAt
+		}
+	}
+	ts.mu.Unlock()
+	if opened.IsZero() {
+		return 0
+	}
+	return time.Since(opened)
+}
+
+// takeProfitFor calculates the take-profit price with the symbol's strategy, falling
+// back to the percentage target when the strategy fails. It returns 0 for symbols
+// whose take-profit is disabled.
+func (ts *TradingService) takeProfitFor(data *PositionData) float64 {
+	if ts.tpDisabled(data.Symbol) {
+		data.RawTPPct, data.LeveragedTPPct = 0, 0
+		return 0
+	}
+	strategy := ts.takeProfitStrategyFor(data.Symbol)
+	if strategy.Name() == strategyPercent {
+		return ts.calculateTakeProfit(data)
+	}
+
+	takePrice, err := strategy.TakeProfit(ts, data)
+	if err != nil || takePrice <= 0 {
+		log.Printf("Warning: %s TP strategy failed for %s (%v), using %s", strategy.Name(), data.Symbol, err, strategyPercent)
+		return ts.calculateTakeProfit(data)
+	}
+
+	// Never target a price the market has already passed
+	if data.IsLong && takePrice <= data.MarkPrice {
+		takePrice = data.MarkPrice * 1.005
+	} else if data.IsShort && takePrice >= data.MarkPrice {
+		takePrice = data.MarkPrice * 0.995
+	}
+
+	data.RawTPPct = math.Abs((takePrice - data.EntryPrice) / data.EntryPrice * 100)
+	data.LeveragedTPPct = data.RawTPPct * data.Leverage
+	return takePrice
+}
+
+// strategyCandles fetches the candles used by the indicator-based strategies.
+func (ts *TradingService) strategyCandles(symbol string) ([]Candle, error) {
+	limit := ts.config().StrategyLookback
+	if ts.config().StrategyATRPeriod*3 > limit {
+		limit = ts.config().StrategyATRPeriod * 3
+	}
+	return ts.getKlines(symbol, ts.config().StrategyInterval, limit+1)
+}
+
+// ladderStrategy is the built-in profit-threshold ladder.
+type ladderStrategy struct{}
+
+func (ladderStrategy) Name() string { return strategyLadder }
+
+func (ladderStrategy) StopLoss(ts *TradingService, data *PositionData) (float64, error) {
+	return ts.calculateStopLoss(data), nil
+}
+
+// fixedStrategy keeps the stop DefaultSLPercent of the entry price away from entry.
+type fixedStrategy struct{}
+
+func (fixedStrategy) Name() string { return strategyFixed }
+
+func (fixedStrategy) StopLoss(ts *TradingService, data *PositionData) (float64, error) {
+	if data.IsLong {
+		return data.EntryPrice * (1 - ts.config().DefaultSLPercent/100), nil
+	}
+	return data.EntryPrice * (1 + ts.config().DefaultSLPercent/100), nil
+}
+
+// atrStrategy places the stop (or target) a multiple of the ATR from the mark price.
+type atrStrategy struct{}
+
+func (atrStrategy) Name() string { return strategyATR }
+
+func (atrStrategy) StopLoss(ts *TradingService, data *PositionData) (float64, error) {
+	atr, err := ts.strategyATR(data.Symbol)
+	if err != nil {
+		return 0, err
+	}
+	distance := atr * ts.config().StrategyATRMultiplier
+	if data.IsLong {
+		return data.MarkPrice - distance, nil
+	}
+	return data.MarkPrice + distance, nil
+}
+
+func (atrStrategy) TakeProfit(ts *TradingService, data *PositionData) (float64, error) {
+	atr, err := ts.strategyATR(data.Symbol)
+	if err != nil {
+		return 0, err
+	}
+	distance := atr * ts.config().StrategyATRMultiplier * ts.config().StrategyATRTPRatio
+	if data.IsLong {
+		return data.EntryPrice + distance, nil
+	}
+	return data.EntryPrice - distance, nil
+}
+
+// strategyATR returns the ATR of symbol for the strategy interval.
+func (ts *TradingService) strategyATR(symbol string) (float64, error) {
+	candles, err := ts.strategyCandles(symbol)
+	if err != nil {
+		return 0, err
+	}
+	atr := averageTrueRange(candles, ts.config().StrategyATRPeriod)
+	if atr <= 0 {
+		return 0, fmt.Errorf("not enough candles for ATR(%d)", ts.config().StrategyATRPeriod)
+	}
+	return atr, nil
+}
+
+// chandelierStrategy hangs the stop a multiple of the ATR from the extreme of the lookback window.
+type chandelierStrategy struct{}
+
+func (chandelierStrategy) Name() string { return strategyChandelier }
+
+func (chandelierStrategy) StopLoss(ts *TradingService, data *PositionData) (float64, error) {
+	candles, err := ts.strategyCandles(data.Symbol)
+	if err != nil {
+		return 0, err
+	}
+	atr := averageTrueRange(candles, ts.config().StrategyATRPeriod)
+	if atr <= 0 {
+		return 0, fmt.Errorf("not enough candles for ATR(%d)", ts.config().StrategyATRPeriod)
+	}
+	distance := atr * ts.config().StrategyATRMultiplier
+	if data.IsLong {
+		return highestHigh(candles, ts.config().StrategyLookback) - distance, nil
+	}
+	return lowestLow(candles, ts.config().StrategyLookback) + distance, nil
+}
+
+// swingStrategy places the stop just beyond the lowest low (long) or highest high
+// (short) of the lookback window.
+type swingStrategy struct{}
+
+func (swingStrategy) Name() string { return strategySwing }
+
+func (swingStrategy) StopLoss(ts *TradingService, data *PositionData) (float64, error) {
+	candles, err := ts.strategyCandles(data.Symbol)
+	if err != nil {
+		return 0, err
+	}
+	// Exclude the candle still forming
+	closed := candles[:len(candles)-1]
+	if len(closed) == 0 {
+		return 0, fmt.Errorf("no closed candles for %s", data.Symbol)
+	}
+	if data.IsLong {
+		return lowestLow(closed, ts.config().StrategyLookback) * (1 - ts.config().StrategySwingBufferPct/100), nil
+	}
+	return highestHigh(closed, ts.config().StrategyLookback) * (1 + ts.config().StrategySwingBufferPct/100), nil
+}
+
+// pivotStrategy places the stop just beyond the most recent swing low (long) or swing
+// high (short), a pivot confirmed by StrategyPivotLookback candles on either side.
+// As new pivots form the stop follows the market structure.
+type pivotStrategy struct{}
+
+func (pivotStrategy) Name() string { return strategyPivot }
+
+func (pivotStrategy) StopLoss(ts *TradingService, data *PositionData) (float64, error) {
+	candles, err := ts.strategyCandles(data.Symbol)
+	if err != nil {
+		return 0, err
+	}
+	// Exclude the candle still forming
+	closed := candles[:len(candles)-1]
+	if n := ts.config().StrategyLookback; len(closed) > n {
+		closed = closed[len(closed)-n:]
+	}
+
+	lookback := ts.config().StrategyPivotLookback
+	if data.IsLong {
+		low := lastPivotLow(closed, lookback, data.MarkPrice)
+		if low <= 0 {
+			return 0, fmt.Errorf("no swing low below the mark price of %s", data.Symbol)
+		}
+		return low * (1 - ts.config().StrategySwingBufferPct/100), nil
+	}
+	high := lastPivotHigh(closed, lookback, data.MarkPrice)
+	if high <= 0 {
+		return 0, fmt.Errorf("no swing high above the mark price of %s", data.Symbol)
+	}
+	return high * (1 + ts.config().StrategySwingBufferPct/100), nil
+}
+
+// percentStrategy is the built-in TPPercent-from-entry target.
+type percentStrategy struct{}
+
+func (percentStrategy) Name() string { return strategyPercent }
+
+func (percentStrategy) TakeProfit(ts *TradingService, data *PositionData) (float64, error) {
+	return ts.calculateTakeProfit(data), nil
+}
+
+// loadStrategyConfig reads the strategy selection and indicator settings from src.
+func loadStrategyConfig(src *settings.Settings, config *Config) {
+	config.SLStrategy = strategyLadder
+	config.TPStrategy = strategyPercent
+	config.StrategyInterval = "1h"
+	config.StrategyLookback = 20
+	config.StrategyATRPeriod = 14
+	config.StrategyATRMultiplier = 2
+	config.StrategyATRTPRatio = 2
+	config.StrategySwingBufferPct = 0.1
+	config.StrategyPivotLookback = 3
+
+	config.SLStrategy = settings.Parse(src, "SL_STRATEGY", config.SLStrategy, parseStopLossStrategy)
+	config.TPStrategy = settings.Parse(src, "TP_STRATEGY", config.TPStrategy, parseTakeProfitStrategy)
+	config.SLStrategyOverrides = settings.Parse(src, "SL_STRATEGY_OVERRIDES", nil, func(v string) (map[string]string, error) {
+		return parseOverrides(v, parseStopLossStrategy)
+	})
+	config.TPStrategyOverrides = settings.Parse(src, "TP_STRATEGY_OVERRIDES", nil, func(v string) (map[string]string, error) {
+		return parseOverrides(v, parseTakeProfitStrategy)
+	})
+
+	if v := src.String("STRATEGY_INTERVAL"); v != "" {
+		config.StrategyInterval = v
+	}
+	config.StrategyLookback = src.Int("STRATEGY_LOOKBACK", config.StrategyLookback)
+	config.StrategyATRPeriod = src.Int("STRATEGY_ATR_PERIOD", config.StrategyATRPeriod)
+	config.StrategyATRMultiplier = src.Float("STRATEGY_ATR_MULTIPLIER", config.StrategyATRMultiplier)
+	config.StrategyATRTPRatio = src.Float("STRATEGY_ATR_TP_RATIO", config.StrategyATRTPRatio)
+	config.StrategySwingBufferPct = src.Float("STRATEGY_SWING_BUFFER_PERCENT", config.StrategySwingBufferPct)
+	config.StrategyPivotLookback = src.Int("STRATEGY_PIVOT_LOOKBACK", config.StrategyPivotLookback)
+}
+
+// parseStopLossStrategy normalizes the name of a registered stop-loss strategy.
+func parseStopLossStrategy(value string) (string, error) {
+	name := strings.ToLower(strings.TrimSpace(value))
+	if _, ok := lookupStopLossStrategy(name); !ok {
+		return "", fmt.Errorf("unknown stop-loss strategy %q, want one of %s", name, strategyNames(stopLossStrategies))
+	}
+	return name, nil
+}
+
+// parseTakeProfitStrategy normalizes the name of a registered take-profit strategy.
+func parseTakeProfitStrategy(value string) (string, error) {
+	name := strings.ToLower(strings.TrimSpace(value))
+	if _, ok := lookupTakeProfitStrategy(name); !ok {
+		return "", fmt.Errorf("unknown take-profit strategy %q, want one of %s", name, strategyNames(takeProfitStrategies))
+	}
+	return name, nil
+}
@@ -0,0 +1,284 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	binance "github.com/adshao/go-binance/v2/futures"
+
+	"futures-guard/ladder"
+)
+
+// streamReconnectDelay is the pause before reconnecting a dropped mark price stream.
+const streamReconnectDelay = 5 * time.Second
+
+// trackedPosition is the minimal state needed to evaluate ladder crossings from mark price ticks.
+type trackedPosition struct {
+	Symbol     string
+	EntryPrice float64
+	Leverage   float64
+	Notional   float64
+	IsLong     bool
+	Stage      int
+	refreshing bool
+}
+
+// trackedKey returns the key identifying a position by symbol and position side.
+func trackedKey(symbol, positionSide string) string {
+	return symbol + ":" + positionSide
+}
+
+// lockPosition acquires the processing lock of one side of a symbol and returns
+// its release function. Hedge-mode LONG and SHORT positions lock independently.
+func (ts *TradingService) lockPosition(symbol, positionSide string) func() {
+	key := trackedKey(symbol, positionSide)
+
+	ts.mu.Lock()
+	lock, ok := ts.positionLocks[key]
+	if !ok {
+		lock = &sync.Mutex{}
+		ts.positionLocks[key] = lock
+	}
+	ts.mu.Unlock()
+
+	lock.Lock()
+	return lock.Unlock
+}
+
+// lockSymbol acquires the processing locks of every side of a symbol.
+func (ts *TradingService) lockSymbol(symbol string) func() {
+	unlocks := []func(){
+		ts.lockPosition(symbol, "BOTH"),
+		ts.lockPosition(symbol, "LONG"),
+		ts.lockPosition(symbol, "SHORT"),
+	}
+	return func() {
+		for i := len(unlocks) - 1; i >= 0; i-- {
+			unlocks[i]()
+		}
+	}
+}
+
+// resetTracked clears the tracked positions before a full processing cycle.
+func (ts *TradingService) resetTracked() {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	ts.tracked = make(map[string]*trackedPosition)
+}
+
+// trackPosition records the current ladder stage of a processed position, also in
+// its order state so other processes and later runs see it.
+func (ts *TradingService) trackPosition(data *PositionData) {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	key := trackedKey(data.Symbol, data.PositionSide)
+	stage := ts.ladderStage(data)
+	ts.tracked[key] = &trackedPosition{
+		Symbol:     data.Symbol,
+		EntryPrice: data.EntryPrice,
+		Leverage:   data.Leverage,
+		Notional:   entryNotional(data),
+		IsLong:     data.IsLong,
+		Stage:      stage,
+	}
+	if st, ok := ts.state.Orders[key]; ok {
+		st.Stage = stage
+	}
+}
+
+// run processes positions every RunInterval until ctx is cancelled, optionally
+// watching the mark price stream between cycles.
+func (ts *TradingService) run(ctx context.Context) error {
+	cfg := ts.config()
+	log.Printf("Running in daemon mode with interval %s", cfg.RunInterval)
+
+	if err := ts.processPositions(); err != nil {
+		log.Printf("Error processing positions: %v", err)
+	}
+
+	if ts.leader != nil {
+		go ts.runLeaderElection(ctx)
+	}
+	if cfg.MarkPriceStream {
+		go ts.watchMarkPrices(ctx)
+	}
+	if cfg.DailyReport {
+		go ts.runDailyReporter(ctx)
+	}
+	if cfg.APIAddr != "" {
+		go ts.serveAPI(ctx)
+	}
+	if cfg.GRPCAddr != "" {
+		go ts.serveGRPC(ctx)
+	}
+	if cfg.TelegramCommands && cfg.TelegramBotToken != "" && cfg.TelegramChatID != "" {
+		go ts.listenTelegram(ctx)
+	}
+	if cfg.TimeSync && cfg.TimeSyncInterval > 0 {
+		go ts.runTimeSync(ctx)
+	}
+	if cfg.SymbolRefreshInterval > 0 {
+		go ts.runSymbolRefresh(ctx)
+	}
+	if len(cfg.SubAccountEmails) > 0 && cfg.SubAccountMarginRatioPct > 0 && cfg.SubAccountCheckInterval > 0 {
+		go ts.runSubAccountGuard(ctx)
+	}
+	reloads := make(chan struct{}, 1)
+	if cfg.ConfigReload && cfg.ConfigReloadInterval > 0 {
+		go watchConfig(ctx, configFile(), cfg.ConfigReloadInterval, reloads)
+	}
+	rotations := make(chan struct{}, 1)
+	if src, err := loadSettings(); err == nil && cfg.CredentialRefreshInterval > 0 {
+		if provider, err := newCredentialProvider(src); err == nil && provider != nil {
+			go watchCredentials(ctx, provider, cfg.CredentialRefreshInterval, rotations)
+		}
+	}
+
+	ticker := time.NewTicker(cfg.RunInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if err := ts.processPositions(); err != nil {
+				log.Printf("Error processing positions: %v", err)
+			}
+		case <-reloads:
+			ts.reloadConfig()
+		case <-rotations:
+			ts.rotateCredentials()
+		}
+	}
+}
+
+// watchMarkPrices subscribes to the all-market mark price stream and keeps it
+// connected until ctx is cancelled.
+func (ts *TradingService) watchMarkPrices(ctx context.Context) {
+	for {
+		doneC, stopC, err := binance.WsAllMarkPriceServe(ts.handleMarkPrices, func(err error) {
+			log.Printf("Mark price stream error: %v", err)
+		})
+		if err != nil {
+			log.Printf("Error connecting to mark price stream: %v", err)
+		} else {
+			log.Println("Connected to mark price stream")
+			ts.recordStream(true)
+			select {
+			case <-ctx.Done():
+				close(stopC)
+				<-doneC
+				ts.recordStream(false)
+				return
+			case <-doneC:
+				log.Println("Mark price stream disconnected")
+				ts.recordStream(false)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(streamReconnectDelay):
+		}
+	}
+}
+
+// handleMarkPrices evaluates each mark price tick against the tracked positions and
+// triggers an immediate refresh when a new profit threshold has been crossed.
+func (ts *TradingService) handleMarkPrices(event binance.WsAllMarkPriceEvent) {
+	for _, symbol := range ts.markPriceCrossings(event) {
+		go ts.refreshSymbol(symbol)
+	}
+}
+
+// markPriceCrossings returns the symbols of the tracked positions whose mark price
+// in event crossed a new profit threshold, and marks them as refreshing.
+func (ts *TradingService) markPriceCrossings(event binance.WsAllMarkPriceEvent) []string {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	ts.health.lastStreamEventAt = time.Now()
+	if len(ts.tracked) == 0 || ts.state.Control.Paused || !ts.isLeader() || ts.scheduleActive(scheduleActionPause, time.Now()) {
+		return nil
+	}
+	ts.recorder.recordMarkPrices(event, ts.tracked)
+
+	prices := make(map[string]float64, len(event))
+	for _, e := range event {
+		price, err := strconv.ParseFloat(e.MarkPrice, 64)
+		if err != nil {
+			continue
+		}
+		prices[e.Symbol] = price
+	}
+
+	var symbols []string
+	for key, pos := range ts.tracked {
+		markPrice, ok := prices[pos.Symbol]
+		if !ok || pos.refreshing || pos.EntryPrice <= 0 || slices.Contains(ts.state.Control.PausedSymbols, pos.Symbol) {
+			continue
+		}
+
+		rawProfitPct := (markPrice - pos.EntryPrice) / pos.EntryPrice * 100
+		if !pos.IsLong {
+			rawProfitPct = -rawProfitPct
+		}
+		if st, ok := ts.state.Orders[key]; ok && !st.OpenedAt.IsZero() {
+			recordExcursion(st, rawProfitPct*pos.Leverage)
+		}
+		ts.recordPeakMark(key, pos, rawProfitPct)
+		position := ladder.Position{Entry: pos.EntryPrice, Leverage: pos.Leverage, Notional: pos.Notional, Long: pos.IsLong}
+		stage := ts.profitStage(pos.Symbol, ladder.Profit(ts.stopLadder(pos.Symbol).Metric, position, rawProfitPct))
+		if stage <= pos.Stage {
+			continue
+		}
+
+		log.Printf("Mark price %.8f for %s crossed stop level %d (was %d), refreshing orders",
+			markPrice, pos.Symbol, stage, pos.Stage)
+		pos.refreshing = true
+		symbols = append(symbols, pos.Symbol)
+	}
+	return symbols
+}
+
+// refreshSymbol re-fetches and processes the positions of a single symbol.
+func (ts *TradingService) refreshSymbol(symbol string) {
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+
+	// Orders may have filled or been edited since the cycle cached them
+	ts.orderCache().invalidate(symbol)
+
+	positions, err := ts.exchange().Positions(ctx, symbol)
+	if err != nil {
+		log.Printf("Error refreshing position %s: %v", symbol, err)
+		ts.clearRefreshing(symbol)
+		return
+	}
+
+	for _, position := range positions {
+		if err := ts.processPosition(position); err != nil {
+			log.Println(fmt.Errorf("error processing position %s: %w", symbol, err))
+		}
+	}
+
+	ts.clearRefreshing(symbol)
+}
+
+// clearRefreshing re-enables stream-triggered refreshes for symbol.
+func (ts *TradingService) clearRefreshing(symbol string) {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	for _, pos := range ts.tracked {
+		if pos.Symbol == symbol {
+			pos.refreshing = false
+		}
+	}
+}
@@ -0,0 +1,319 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	spot "github.com/adshao/go-binance/v2"
+
+	"futures-guard/settings"
+)
+
+// Sub-account top-up defaults.
+const (
+	defaultSubAccountCheckInterval = time.Minute
+	defaultSubAccountTopUpFrom     = "SPOT"
+	// subAccountTopUpAsset is the asset transferred to the USDⓈ-M futures wallet.
+	subAccountTopUpAsset = "USDT"
+	// topUpConfirmWindow is how long a top-up waits for its Telegram confirmation.
+	topUpConfirmWindow = 10 * time.Minute
+	// topUpRetention is how long past top-ups are kept for the daily cap.
+	topUpRetention = 48 * time.Hour
+)
+
+// TopUpRecord is a transfer from the master account to a sub-account, persisted
+// so the daily cap holds across restarts.
+type TopUpRecord struct {
+	Email  string    `json:"email"`
+	Amount float64   `json:"amount"`
+	TranID int64     `json:"tranId"`
+	At     time.Time `json:"at"`
+}
+
+// subAccountClient is the part of the Binance master account API the top-up
+// guard uses.
+type subAccountClient interface {
+	// FuturesAccount returns the USDⓈ-M futures account of the sub-account email.
+	FuturesAccount(ctx context.Context, email string) (*spot.SubAccountFuturesAccountV2, error)
+	// Transfer moves amount of asset from the fromType wallet of the master
+	// account to the USDⓈ-M futures wallet of the sub-account email.
+	Transfer(ctx context.Context, email, fromType, asset string, amount float64) (int64, error)
+}
+
+// binanceSubAccounts implements subAccountClient with a Binance spot client
+// holding master account credentials.
+type binanceSubAccounts struct {
+	client *spot.Client
+	config Config
+}
+
+// newBinanceSubAccounts creates a sub-account client with the Binance credentials.
+func newBinanceSubAccounts(config Config) *binanceSubAccounts {
+	return &binanceSubAccounts{
+		client: spot.NewClient(config.Credentials.BinanceAPIKey, config.Credentials.BinanceAPISecret),
+		config: config,
+	}
+}
+
+// signed returns the request options for signed requests.
+func (c *binanceSubAccounts) signed() []spot.RequestOption {
+	window := c.config.RecvWindow
+	if window > maxRecvWindow {
+		window = maxRecvWindow
+	}
+	return []spot.RequestOption{spot.WithRecvWindow(window.Milliseconds())}
+}
+
+// FuturesAccount implements subAccountClient.
+func (c *binanceSubAccounts) FuturesAccount(ctx context.Context, email string) (*spot.SubAccountFuturesAccountV2, error) {
+	res, err := c.client.NewSubAccountFuturesAccountV2Service().Email(email).FuturesType(1).Do(ctx, c.signed()...)
+	if err != nil {
+		return nil, err
+	}
+	if res.FutureAccountResp == nil {
+		return nil, fmt.Errorf("no USDⓈ-M futures account for %s", email)
+	}
+	return res.FutureAccountResp, nil
+}
+
+// Transfer implements subAccountClient.
+func (c *binanceSubAccounts) Transfer(ctx context.Context, email, fromType, asset string, amount float64) (int64, error) {
+	res, err := c.client.NewSubAccountUniversalTransferService().
+		ToEmail(email).
+		FromAccountType(fromType).
+		ToAccountType("USDT_FUTURE").
+		Asset(asset).
+		Amount(strconv.FormatFloat(amount, 'f', -1, 64)).
+		Do(ctx, c.signed()...)
+	if err != nil {
+		return 0, err
+	}
+	return res.TranId, nil
+}
+
+// subAccountGuard tracks the sub-accounts whose margin ratio is above
+// SUBACCOUNT_MARGIN_RATIO_PERCENT and the top-ups awaiting confirmation.
+type subAccountGuard struct {
+	alerted map[string]bool
+	pending map[string]float64   // Amount of each top-up awaiting confirmation
+	expires map[string]time.Time // Confirmation deadline of each pending top-up
+}
+
+// newSubAccountGuard creates an empty sub-account guard.
+func newSubAccountGuard() subAccountGuard {
+	return subAccountGuard{
+		alerted: make(map[string]bool),
+		pending: make(map[string]float64),
+		expires: make(map[string]time.Time),
+	}
+}
+
+// runSubAccountGuard checks the margin ratio of SUBACCOUNT_EMAILS every
+// SUBACCOUNT_CHECK_INTERVAL until ctx is cancelled. It needs the credentials of
+// the master account.
+func (ts *TradingService) runSubAccountGuard(ctx context.Context) {
+	if err := ts.requireBinance("the sub-account top-up"); err != nil {
+		log.Printf("Warning: %v", err)
+		return
+	}
+	cfg := ts.config()
+	if cfg.SubAccountTopUpConfirm && !cfg.TelegramCommands {
+		log.Println("Warning: SUBACCOUNT_TOPUP_CONFIRM needs TELEGRAM_COMMANDS to confirm top-ups")
+	}
+	client := newBinanceSubAccounts(*cfg)
+
+	ticker := time.NewTicker(cfg.SubAccountCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		if ts.isLeader() {
+			for _, email := range ts.config().SubAccountEmails {
+				ts.checkSubAccount(client, email)
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// checkSubAccount alerts when the margin ratio of the sub-account email, its
+// maintenance margin over its margin balance, reaches the threshold and tops it
+// up from the master account. While the ratio stays above the threshold, each
+// completed top-up allows another one at the next check, up to the daily cap.
+func (ts *TradingService) checkSubAccount(client subAccountClient, email string) {
+	cfg := ts.config()
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+
+	account, err := client.FuturesAccount(ctx, email)
+	if err != nil {
+		log.Printf("Warning: Unable to get the futures account of sub-account %s: %v", email, err)
+		return
+	}
+	balance := parseFloatOrZero(account.TotalMarginBalance)
+	if balance <= 0 {
+		return
+	}
+	ratio := parseFloatOrZero(account.TotalMaintenanceMargin) / balance * 100
+	above := ratio >= cfg.SubAccountMarginRatioPct
+
+	ts.mu.Lock()
+	guard := &ts.subAccounts
+	raised := above && !guard.alerted[email]
+	guard.alerted[email] = above
+	if !above {
+		delete(guard.pending, email)
+		delete(guard.expires, email)
+	}
+	ts.mu.Unlock()
+	if !raised {
+		return
+	}
+
+	msg := fmt.Sprintf("🚨 Sub-account %s margin ratio %.2f%% (threshold %.2f%%), margin balance %s",
+		email, ratio, cfg.SubAccountMarginRatioPct, formatPnL(balance, subAccountTopUpAsset))
+	log.Println(msg)
+	ts.notify(SeverityCritical, msg)
+
+	amount := ts.topUpAllowance(cfg, email)
+	switch {
+	case cfg.SubAccountTopUpAmount <= 0:
+		return
+	case amount <= 0:
+		ts.notify(SeverityWarning, fmt.Sprintf("⛔ Daily top-up cap of %s reached for sub-account %s",
+			formatPnL(cfg.SubAccountTopUpDailyCap, subAccountTopUpAsset), email))
+	case cfg.SubAccountTopUpConfirm:
+		ts.mu.Lock()
+		guard.pending[email] = amount
+		guard.expires[email] = time.Now().Add(topUpConfirmWindow)
+		ts.mu.Unlock()
+		ts.notify(SeverityCritical, fmt.Sprintf("💸 Send /topup %s within %s to transfer %s from the master account",
+			email, topUpConfirmWindow, formatPnL(amount, subAccountTopUpAsset)))
+	default:
+		ts.topUp(cfg, client, email, amount)
+	}
+}
+
+// topUpAllowance returns how much may be transferred to the sub-account email
+// now: SUBACCOUNT_TOPUP_AMOUNT, up to what the daily cap has left for today
+// (UTC). Nothing may be transferred without a cap.
+func (ts *TradingService) topUpAllowance(cfg *Config, email string) float64 {
+	amount := cfg.SubAccountTopUpAmount
+	if cfg.SubAccountTopUpDailyCap <= 0 {
+		return 0
+	}
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	used := 0.0
+	ts.mu.Lock()
+	for _, record := range ts.state.TopUps {
+		if record.Email == email && !record.At.Before(today) {
+			used += record.Amount
+		}
+	}
+	ts.mu.Unlock()
+	return max(min(amount, cfg.SubAccountTopUpDailyCap-used), 0)
+}
+
+// topUp transfers amount from the master account to the sub-account email and
+// records it against the daily cap.
+func (ts *TradingService) topUp(cfg *Config, client subAccountClient, email string, amount float64) error {
+	if cfg.ObserveOnly {
+		log.Printf("OBSERVE_ONLY: would transfer %g %s to sub-account %s", amount, subAccountTopUpAsset, email)
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+	id, err := client.Transfer(ctx, email, cfg.SubAccountTopUpFrom, subAccountTopUpAsset, amount)
+	if err != nil {
+		err = fmt.Errorf("error transferring %g %s to sub-account %s: %w", amount, subAccountTopUpAsset, email, err)
+		log.Printf("Warning: %v", err)
+		ts.notify(SeverityCritical, "❌ "+err.Error())
+		return err
+	}
+
+	now := time.Now()
+	ts.mu.Lock()
+	ts.state.TopUps = slices.DeleteFunc(ts.state.TopUps, func(r *TopUpRecord) bool { return now.Sub(r.At) > topUpRetention })
+	ts.state.TopUps = append(ts.state.TopUps, &TopUpRecord{Email: email, Amount: amount, TranID: id, At: now})
+	// Check again at the next interval whether the top-up was enough
+	ts.subAccounts.alerted[email] = false
+	ts.mu.Unlock()
+	ts.saveState()
+
+	msg := fmt.Sprintf("💸 Transferred %s from the master %s wallet to sub-account %s (transfer %d)",
+		formatPnL(amount, subAccountTopUpAsset), cfg.SubAccountTopUpFrom, email, id)
+	log.Println(msg)
+	ts.notify(SeverityWarning, msg)
+	return nil
+}
+
+// confirmTopUp carries out the pending top-up of the sub-account email, or the
+// only pending one when email is empty, and returns the Telegram reply.
+func (ts *TradingService) confirmTopUp(email string) string {
+	now := time.Now()
+	ts.mu.Lock()
+	guard := &ts.subAccounts
+	for pending, deadline := range guard.expires {
+		if now.After(deadline) {
+			delete(guard.pending, pending)
+			delete(guard.expires, pending)
+		}
+	}
+	if email == "" && len(guard.pending) == 1 {
+		for pending := range guard.pending {
+			email = pending
+		}
+	}
+	amount, ok := guard.pending[email]
+	delete(guard.pending, email)
+	delete(guard.expires, email)
+	ts.mu.Unlock()
+
+	if !ok {
+		return "⌛ No pending top-up; send /topup <email> after a sub-account top-up is proposed"
+	}
+	// The daily cap may have been used up since the top-up was proposed
+	cfg := ts.config()
+	if amount = min(amount, ts.topUpAllowance(cfg, email)); amount <= 0 {
+		return fmt.Sprintf("⛔ Daily top-up cap reached for sub-account %s", email)
+	}
+	if err := ts.topUp(cfg, newBinanceSubAccounts(*cfg), email, amount); err != nil {
+		return "❌ " + err.Error()
+	}
+	return fmt.Sprintf("💸 Top-up of %s to sub-account %s confirmed via Telegram", formatPnL(amount, subAccountTopUpAsset), email)
+}
+
+// parseTopUpFrom normalizes the configured master account wallet of top-ups.
+func parseTopUpFrom(value string) (string, error) {
+	switch from := strings.ToUpper(strings.TrimSpace(value)); from {
+	case "SPOT", "USDT_FUTURE":
+		return from, nil
+	default:
+		return "", errors.New("want SPOT or USDT_FUTURE")
+	}
+}
+
+// loadSubAccountConfig reads the sub-account top-up settings from src.
+func loadSubAccountConfig(src *settings.Settings, config *Config) {
+	config.SubAccountEmails = parseList(src.String("SUBACCOUNT_EMAILS"))
+	config.SubAccountMarginRatioPct = src.Float("SUBACCOUNT_MARGIN_RATIO_PERCENT", config.SubAccountMarginRatioPct)
+	config.SubAccountTopUpAmount = src.Float("SUBACCOUNT_TOPUP_AMOUNT", config.SubAccountTopUpAmount)
+	config.SubAccountTopUpDailyCap = src.Float("SUBACCOUNT_TOPUP_DAILY_CAP", config.SubAccountTopUpDailyCap)
+	if config.SubAccountTopUpAmount > 0 && config.SubAccountTopUpDailyCap <= 0 {
+		src.Fail("SUBACCOUNT_TOPUP_DAILY_CAP", errors.New("must be positive when SUBACCOUNT_TOPUP_AMOUNT is set"))
+	}
+	config.SubAccountTopUpConfirm = src.Bool("SUBACCOUNT_TOPUP_CONFIRM", config.SubAccountTopUpConfirm)
+	config.SubAccountTopUpFrom = settings.Parse(src, "SUBACCOUNT_TOPUP_FROM", defaultSubAccountTopUpFrom, parseTopUpFrom)
+	config.SubAccountCheckInterval = defaultSubAccountCheckInterval
+	config.SubAccountCheckInterval = src.Duration("SUBACCOUNT_CHECK_INTERVAL", config.SubAccountCheckInterval)
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	spot "github.com/adshao/go-binance/v2"
+)
+
+// memorySubAccounts is a subAccountClient whose sub-accounts stay at the margin
+// ratio Ratio whatever is transferred to them.
+type memorySubAccounts struct {
+	Ratio     float64
+	Transfers []float64
+}
+
+// FuturesAccount implements subAccountClient.
+func (c *memorySubAccounts) FuturesAccount(_ context.Context, email string) (*spot.SubAccountFuturesAccountV2, error) {
+	return &spot.SubAccountFuturesAccountV2{
+		Email:                  email,
+		TotalMarginBalance:     "1000",
+		TotalMaintenanceMargin: strconv.FormatFloat(c.Ratio*10, 'f', -1, 64),
+	}, nil
+}
+
+// Transfer implements subAccountClient.
+func (c *memorySubAccounts) Transfer(_ context.Context, _, _, _ string, amount float64) (int64, error) {
+	c.Transfers = append(c.Transfers, amount)
+	return int64(len(c.Transfers)), nil
+}
+
+func TestCheckSubAccountDailyCap(t *testing.T) {
+	ts := newTestTradingService(t, newMemoryExchange(), newMemoryClient(), func(c *Config) {
+		c.SubAccountMarginRatioPct = 80
+		c.SubAccountTopUpAmount = 100
+		c.SubAccountTopUpDailyCap = 250
+	})
+	client := &memorySubAccounts{Ratio: 90}
+	for range 5 {
+		ts.checkSubAccount(client, "sub@example.com")
+	}
+
+	want := []float64{100, 100, 50}
+	if len(client.Transfers) != len(want) {
+		t.Fatalf("transfers %v, want %v", client.Transfers, want)
+	}
+	for i := range want {
+		if client.Transfers[i] != want[i] {
+			t.Errorf("transfers %v, want %v", client.Transfers, want)
+			break
+		}
+	}
+}
+
+func TestCheckSubAccountWithoutCap(t *testing.T) {
+	ts := newTestTradingService(t, newMemoryExchange(), newMemoryClient(), func(c *Config) {
+		c.SubAccountMarginRatioPct = 80
+		c.SubAccountTopUpAmount = 100
+	})
+	client := &memorySubAccounts{Ratio: 90}
+	for range 3 {
+		ts.checkSubAccount(client, "sub@example.com")
+	}
+	if len(client.Transfers) != 0 {
+		t.Errorf("transfers %v without a daily cap, want none", client.Transfers)
+	}
+}
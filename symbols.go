@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultSymbolRefreshInterval is how often the daemon reloads the exchange
+// information unless SYMBOL_REFRESH_INTERVAL is set.
+const defaultSymbolRefreshInterval = time.Hour
+
+// symbolFetchCooldown is the minimum time between on-demand reloads triggered by
+// symbols missing from the cache, so an unknown symbol cannot hammer the endpoint.
+const symbolFetchCooldown = time.Minute
+
+// symbolCache holds the precision and filters of every tradable symbol, shared by
+// the positions processed concurrently and reloaded while the daemon runs.
+type symbolCache struct {
+	mu        sync.RWMutex
+	info      map[string]SymbolPrecision
+	fetchedAt time.Time
+}
+
+// newSymbolCache creates a cache holding info, fetched just now.
+func newSymbolCache(info map[string]SymbolPrecision) *symbolCache {
+	return &symbolCache{info: info, fetchedAt: time.Now()}
+}
+
+// get returns the precision of symbol.
+func (c *symbolCache) get(symbol string) (SymbolPrecision, bool) {
+	if c == nil {
+		return SymbolPrecision{}, false
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	precision, ok := c.info[symbol]
+	return precision, ok
+}
+
+// replace swaps in freshly fetched information and returns the symbols it adds.
+func (c *symbolCache) replace(info map[string]SymbolPrecision) []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var added []string
+	for symbol := range info {
+		if _, ok := c.info[symbol]; !ok {
+			added = append(added, symbol)
+		}
+	}
+	c.info = info
+	c.fetchedAt = time.Now()
+	slices.Sort(added)
+	return added
+}
+
+// claimFetch reports whether an on-demand reload may run now, and if so pushes
+// the next one back by symbolFetchCooldown.
+func (c *symbolCache) claimFetch() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if time.Since(c.fetchedAt) < symbolFetchCooldown {
+		return false
+	}
+	c.fetchedAt = time.Now()
+	return true
+}
+
+// symbolPrecision returns the precision of symbol. A symbol missing from the
+// cache, such as a new listing, triggers a reload of the exchange information.
+func (ts *TradingService) symbolPrecision(symbol string) (SymbolPrecision, bool) {
+	if precision, ok := ts.symbolInfo.get(symbol); ok || ts.symbolInfo == nil || ts.exchange() == nil {
+		return precision, ok
+	}
+	if !ts.symbolInfo.claimFetch() {
+		return SymbolPrecision{}, false
+	}
+
+	log.Printf("No precision information for %s, reloading the exchange information", symbol)
+	if err := ts.refreshSymbols(); err != nil {
+		log.Printf("Warning: %v", err)
+	}
+	return ts.symbolInfo.get(symbol)
+}
+
+// refreshSymbols reloads the exchange information, logging newly listed symbols.
+func (ts *TradingService) refreshSymbols() error {
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+
+	info, err := ts.exchange().SymbolPrecisions(ctx)
+	if err != nil {
+		return fmt.Errorf("error refreshing exchange information: %w", err)
+	}
+	if added := ts.symbolInfo.replace(info); len(added) > 0 {
+		log.Printf("Exchange information refreshed, %d new symbols: %s", len(added), strings.Join(added, ", "))
+	}
+	return nil
+}
+
+// runSymbolRefresh reloads the exchange information every SymbolRefreshInterval
+// until ctx is cancelled, so precision and filter changes are picked up.
+func (ts *TradingService) runSymbolRefresh(ctx context.Context) {
+	ticker := time.NewTicker(ts.config().SymbolRefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := ts.refreshSymbols(); err != nil {
+				log.Printf("Warning: %v", err)
+			}
+		}
+	}
+}
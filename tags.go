@@ -0,0 +1,221 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	binance "github.com/adshao/go-binance/v2/futures"
+
+	"futures-guard/settings"
+)
+
+// untaggedLabel groups the trades of positions without a strategy tag in reports.
+const untaggedLabel = "untagged"
+
+// tagHistoryLimit is the number of recent orders searched for the opening order
+// of a position.
+const tagHistoryLimit = 50
+
+// tagPrefix maps client order IDs starting with Prefix to the strategy Tag.
+type tagPrefix struct {
+	Prefix string
+	Tag    string
+}
+
+// tagPosition labels data with the strategy that opened it: the entry of
+// STRATEGY_TAGS_FILE for the position, else the tag recorded when the bot opened
+// it or found from the client order ID of its opening order. The order history is
+// only searched once per position.
+func (ts *TradingService) tagPosition(data *PositionData) {
+	tag, checked := ts.knownTag(data.Symbol, data.PositionSide)
+	if tag != "" || checked || len(ts.config().StrategyTagPrefixes) == 0 || ts.exchange().Name() != exchangeBinance {
+		data.Tag = tag
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+	orders, err := ts.client().Orders(ctx, data.Symbol, tagHistoryLimit)
+	if err != nil {
+		log.Printf("Warning: Unable to find the opening order of %s: %v", data.Symbol, err)
+		return
+	}
+	tag = ts.tagFromOrders(data, orders)
+	if tag != "" {
+		log.Printf("Tagged %s %s as %s", data.Symbol, data.PositionSide, tag)
+	}
+
+	ts.setPositionTag(data.Symbol, data.PositionSide, tag)
+	data.Tag = tag
+}
+
+// knownTag returns the tag of a position without searching the order history,
+// and whether the search is settled: the entry of STRATEGY_TAGS_FILE, or the
+// recorded tag of the position.
+func (ts *TradingService) knownTag(symbol, positionSide string) (string, bool) {
+	if tag, ok := ts.config().StrategyTags[trackedKey(symbol, positionSide)]; ok {
+		return tag, true
+	}
+	if tag, ok := ts.config().StrategyTags[symbol]; ok {
+		return tag, true
+	}
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	if st, ok := ts.state.Orders[trackedKey(symbol, positionSide)]; ok {
+		return st.Tag, st.TagChecked
+	}
+	return "", false
+}
+
+// tagFromOrders returns the tag of the most recent filled order that added to the
+// position of data and carries a STRATEGY_TAG_PREFIXES client order ID, ignoring
+// the adds of the bot itself. orders are oldest first.
+func (ts *TradingService) tagFromOrders(data *PositionData, orders []*binance.Order) string {
+	openSide := binance.SideTypeSell
+	if data.IsLong {
+		openSide = binance.SideTypeBuy
+	}
+	for i := len(orders) - 1; i >= 0; i-- {
+		order := orders[i]
+		if order.Status != binance.OrderStatusTypeFilled || order.Side != openSide || order.ReduceOnly || order.ClosePosition ||
+			!strings.EqualFold(string(order.PositionSide), data.PositionSide) {
+			continue
+		}
+		for _, prefix := range ts.config().StrategyTagPrefixes {
+			if strings.HasPrefix(order.ClientOrderID, prefix.Prefix) {
+				return prefix.Tag
+			}
+		}
+	}
+	return ""
+}
+
+// setPositionTag records tag for the position of symbol and positionSide.
+func (ts *TradingService) setPositionTag(symbol, positionSide, tag string) {
+	ts.mu.Lock()
+	st := ts.orderState(symbol, positionSide)
+	st.Tag, st.TagChecked = tag, true
+	ts.mu.Unlock()
+	ts.saveState()
+}
+
+// tagLimitReached returns an error when opening a position of symbol for the
+// strategy tag would exceed its STRATEGY_TAG_MAX_POSITIONS. Adding to a position
+// already open for the tag does not count as a new one.
+func (ts *TradingService) tagLimitReached(tag, symbol, positionSide string) error {
+	limit, ok := ts.config().StrategyTagMaxPositions[tag]
+	if !ok || tag == "" {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+	positions, err := ts.exchange().Positions(ctx, "")
+	if err != nil {
+		return fmt.Errorf("error getting positions: %w", err)
+	}
+	open := 0
+	for _, position := range positions {
+		if position.PositionAmt == 0 {
+			continue
+		}
+		if known, _ := ts.knownTag(position.Symbol, position.PositionSide); known != tag {
+			continue
+		}
+		if position.Symbol == symbol && position.PositionSide == positionSide {
+			return nil
+		}
+		open++
+	}
+	if open >= limit {
+		return fmt.Errorf("tag %s already has %d open positions (STRATEGY_TAG_MAX_POSITIONS %d)", tag, open, limit)
+	}
+	return nil
+}
+
+// tagLabel returns tag, or the label grouping untagged trades.
+func tagLabel(tag string) string {
+	if tag == "" {
+		return untaggedLabel
+	}
+	return tag
+}
+
+// normalizeTag trims and lowercases a strategy tag.
+func normalizeTag(tag string) string {
+	return strings.ToLower(strings.TrimSpace(tag))
+}
+
+// parseTagPrefixes parses client order ID conventions of the form
+// "trend=TRD-,scalp=SCP-", longest prefix first so the most specific one wins.
+func parseTagPrefixes(value string) ([]tagPrefix, error) {
+	var prefixes []tagPrefix
+	for _, item := range strings.Split(value, ",") {
+		if strings.TrimSpace(item) == "" {
+			continue
+		}
+		tag, prefix, ok := strings.Cut(item, "=")
+		tag, prefix = normalizeTag(tag), strings.TrimSpace(prefix)
+		if !ok || tag == "" || prefix == "" {
+			return nil, fmt.Errorf("malformed strategy tag prefix %q, want TAG=PREFIX", strings.TrimSpace(item))
+		}
+		prefixes = append(prefixes, tagPrefix{Prefix: prefix, Tag: tag})
+	}
+	sort.SliceStable(prefixes, func(i, j int) bool { return len(prefixes[i].Prefix) > len(prefixes[j].Prefix) })
+	return prefixes, nil
+}
+
+// parseTagLimits parses per-strategy tag position limits such as "trend=3,scalp=5".
+func parseTagLimits(value string) (map[string]int, error) {
+	limits := make(map[string]int)
+	for _, item := range strings.Split(value, ",") {
+		if strings.TrimSpace(item) == "" {
+			continue
+		}
+		tag, setting, ok := strings.Cut(item, "=")
+		limit, err := strconv.Atoi(strings.TrimSpace(setting))
+		if !ok || err != nil || limit < 0 || normalizeTag(tag) == "" {
+			return nil, fmt.Errorf("malformed strategy tag position limit %q, want TAG=COUNT", strings.TrimSpace(item))
+		}
+		limits[normalizeTag(tag)] = limit
+	}
+	return limits, nil
+}
+
+// loadStrategyTags reads the manual strategy mapping of path, a JSON object from
+// symbols such as "BTCUSDT", or positions such as "ETHUSDT:SHORT" in hedge mode,
+// to tags.
+func loadStrategyTags(path string) (map[string]string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading STRATEGY_TAGS_FILE: %w", err)
+	}
+	var mapping map[string]string
+	if err := json.Unmarshal(content, &mapping); err != nil {
+		return nil, fmt.Errorf("error parsing STRATEGY_TAGS_FILE: %w", err)
+	}
+	tags := make(map[string]string, len(mapping))
+	for position, tag := range mapping {
+		tags[strings.ToUpper(strings.TrimSpace(position))] = normalizeTag(tag)
+	}
+	return tags, nil
+}
+
+// loadStrategyTagConfig reads the strategy tagging settings from src.
+func loadStrategyTagConfig(src *settings.Settings, config *Config) {
+	config.StrategyTagPrefixes = settings.Parse(src, "STRATEGY_TAG_PREFIXES", nil, parseTagPrefixes)
+	config.StrategyTagMaxPositions = settings.Parse(src, "STRATEGY_TAG_MAX_POSITIONS", nil, parseTagLimits)
+	if path := src.String("STRATEGY_TAGS_FILE"); path != "" {
+		tags, err := loadStrategyTags(path)
+		if err != nil {
+			src.Fail("STRATEGY_TAGS_FILE", err)
+		}
+		config.StrategyTags = tags
+	}
+}
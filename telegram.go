@@ -0,0 +1,207 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// telegramPollTimeout is the long-polling timeout of a getUpdates request.
+	telegramPollTimeout = 30 * time.Second
+	// telegramRetryDelay is the pause after a failed getUpdates request.
+	telegramRetryDelay = 5 * time.Second
+	// closeAllConfirmWindow is how long a /closeall request waits for its confirmation.
+	closeAllConfirmWindow = time.Minute
+)
+
+// telegramPollClient outlives the long-polling timeout of getUpdates.
+var telegramPollClient = &http.Client{Timeout: telegramPollTimeout + notifierTimeout}
+
+// telegramUpdate is the part of a Telegram bot update the command listener reads.
+type telegramUpdate struct {
+	UpdateID int64 `json:"update_id"`
+	Message  *struct {
+		Date int64  `json:"date"`
+		Text string `json:"text"`
+		Chat struct {
+			ID int64 `json:"id"`
+		} `json:"chat"`
+		ReplyToMessage *struct {
+			Text string `json:"text"`
+		} `json:"reply_to_message"`
+	} `json:"message"`
+}
+
+// listenTelegram long-polls the Telegram bot for commands sent from TELEGRAM_CHAT_ID
+// until ctx is cancelled. Commands sent before startup are ignored. Replies to
+// position messages that are not commands become journal notes of the position.
+func (ts *TradingService) listenTelegram(ctx context.Context) {
+	bot := &TelegramNotifier{BotToken: ts.config().TelegramBotToken, ChatID: ts.config().TelegramChatID}
+	started := time.Now().Unix()
+	var offset int64
+	var closeAllDeadline time.Time
+
+	log.Println("Listening for Telegram commands")
+	for {
+		// Only the leader answers commands; the bot serves one poller at a time
+		if !ts.isLeader() {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(telegramRetryDelay):
+			}
+			continue
+		}
+		updates, err := telegramUpdates(ctx, ts.config().TelegramBotToken, offset)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			log.Printf("Error polling Telegram commands: %v", err)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(telegramRetryDelay):
+			}
+			continue
+		}
+
+		for _, update := range updates {
+			offset = update.UpdateID + 1
+			msg := update.Message
+			if msg == nil || msg.Date < started || strconv.FormatInt(msg.Chat.ID, 10) != ts.config().TelegramChatID {
+				continue
+			}
+
+			fields := strings.Fields(msg.Text)
+			if len(fields) == 0 {
+				continue
+			}
+			if msg.ReplyToMessage != nil && !strings.HasPrefix(fields[0], "/") {
+				reply := ts.addJournalNote(msg.ReplyToMessage.Text, msg.Text)
+				log.Println(reply)
+				if err := bot.Send(reply); err != nil {
+					log.Printf("Error replying to Telegram note: %v", err)
+				}
+				continue
+			}
+			// Group chats address commands as /command@botname
+			command, _, _ := strings.Cut(strings.ToLower(fields[0]), "@")
+
+			var reply string
+			switch command {
+			case "/closeall":
+				switch {
+				case len(fields) < 2 || !strings.EqualFold(fields[1], "confirm"):
+					closeAllDeadline = time.Now().Add(closeAllConfirmWindow)
+					reply = fmt.Sprintf("⚠️ This cancels all open orders and closes every position at market.\n"+
+						"Send /closeall confirm within %s to proceed.", closeAllConfirmWindow)
+				case time.Now().After(closeAllDeadline):
+					reply = "⌛ No pending /closeall request; send /closeall first"
+				default:
+					closeAllDeadline = time.Time{}
+					result, err := ts.closeAll()
+					reply = fmt.Sprintf("🚨 Emergency flatten via Telegram: %s", result)
+					if err != nil {
+						reply += fmt.Sprintf("\n⚠️ Errors: %v", err)
+					}
+				}
+			case "/pause", "/resume":
+				reply = ts.pauseFromTelegram(command == "/pause", fields[1:])
+			case "/manual", "/unlock":
+				reply = ts.manualFromTelegram(command == "/manual", fields[1:])
+			case "/reset":
+				ts.resetKillSwitch()
+				reply = "🔓 Kill switch reset via Telegram; new entries allowed"
+			case "/control":
+				reply = "🎛️ " + ts.control().String()
+			case "/topup":
+				email := ""
+				if len(fields) > 1 {
+					email = fields[1]
+				}
+				reply = ts.confirmTopUp(email)
+			default:
+				continue
+			}
+
+			log.Println(reply)
+			if err := bot.Send(reply); err != nil {
+				log.Printf("Error replying to Telegram command: %v", err)
+			}
+		}
+	}
+}
+
+// pauseFromTelegram pauses or resumes order management of the symbols given as
+// arguments, or of every symbol without arguments, and returns the reply.
+func (ts *TradingService) pauseFromTelegram(paused bool, symbols []string) string {
+	verb := "resumed"
+	if paused {
+		verb = "paused"
+	}
+	if len(symbols) == 0 {
+		ts.setPaused(paused)
+		return fmt.Sprintf("⏯️ Order management %s via Telegram", verb)
+	}
+	for i, symbol := range symbols {
+		symbols[i] = strings.ToUpper(symbol)
+		ts.setSymbolPaused(symbols[i], paused)
+	}
+	return fmt.Sprintf("⏯️ Order management of %s %s via Telegram", strings.Join(symbols, ", "), verb)
+}
+
+// manualFromTelegram puts the symbols given as arguments under manual control or
+// unlocks them, and returns the reply.
+func (ts *TradingService) manualFromTelegram(manual bool, symbols []string) string {
+	if len(symbols) == 0 {
+		return "⚠️ Name the symbols, e.g. /manual BTCUSDT"
+	}
+	for i, symbol := range symbols {
+		symbols[i] = strings.ToUpper(symbol)
+		ts.setSymbolManual(symbols[i], manual)
+	}
+	if manual {
+		return fmt.Sprintf("✋ %s under manual control via Telegram; the bot reports but changes no orders", strings.Join(symbols, ", "))
+	}
+	return fmt.Sprintf("🤖 %s unlocked via Telegram; the bot manages its orders again", strings.Join(symbols, ", "))
+}
+
+// telegramUpdates long-polls the bot for updates starting at offset.
+func telegramUpdates(ctx context.Context, token string, offset int64) ([]telegramUpdate, error) {
+	query := url.Values{
+		"offset":          {strconv.FormatInt(offset, 10)},
+		"timeout":         {strconv.Itoa(int(telegramPollTimeout.Seconds()))},
+		"allowed_updates": {`["message"]`},
+	}
+	apiURL := fmt.Sprintf("https://api.telegram.org/bot%s/getUpdates?%s", token, query.Encode())
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, apiURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := telegramPollClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("telegram API returned error code: %d", resp.StatusCode)
+	}
+
+	var body struct {
+		Result []telegramUpdate `json:"result"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("error decoding Telegram updates: %w", err)
+	}
+	return body.Result, nil
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	binance "github.com/adshao/go-binance/v2/futures"
+)
+
+// Default time synchronization settings.
+const (
+	defaultRecvWindow       = 5 * time.Second
+	defaultTimeSyncInterval = time.Hour
+
+	// maxRecvWindow is the largest recvWindow Binance accepts.
+	maxRecvWindow = time.Minute
+
+	// minResyncInterval throttles resyncs triggered by timestamp errors.
+	minResyncInterval = 10 * time.Second
+)
+
+// timestampErrorCode is the Binance error returned when a signed request's
+// timestamp falls outside the recvWindow.
+const timestampErrorCode = -1021
+
+// timeSyncTransport watches Binance responses for timestamp errors and
+// recalibrates the client's time offset when one occurs.
+type timeSyncTransport struct {
+	base   http.RoundTripper
+	client *binance.Client
+
+	mu       sync.Mutex
+	lastSync time.Time
+}
+
+// RoundTrip performs the request and triggers a resync on a -1021 response.
+func (t *timeSyncTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if err != nil || resp.StatusCode < http.StatusBadRequest {
+		return resp, err
+	}
+
+	body, readErr := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	if readErr != nil {
+		return resp, nil
+	}
+
+	if bytes.Contains(body, []byte(`"code":`+strconv.Itoa(timestampErrorCode))) {
+		log.Println("Warning: Binance rejected a request timestamp, resynchronizing server time")
+		go t.resync()
+	}
+	return resp, nil
+}
+
+// resync recalibrates the time offset unless it was done moments ago.
+func (t *timeSyncTransport) resync() {
+	t.mu.Lock()
+	if time.Since(t.lastSync) < minResyncInterval {
+		t.mu.Unlock()
+		return
+	}
+	t.lastSync = time.Now()
+	t.mu.Unlock()
+
+	if err := syncServerTime(context.Background(), t.client); err != nil {
+		log.Printf("Warning: %v", err)
+	}
+}
+
+// enableTimeSync calibrates the client's clock offset against Binance server time
+// and installs the transport that resyncs it on timestamp errors.
+func enableTimeSync(client *binance.Client) {
+	base := http.DefaultTransport
+	timeout := time.Duration(0)
+	if client.HTTPClient != nil {
+		timeout = client.HTTPClient.Timeout
+		if client.HTTPClient.Transport != nil {
+			base = client.HTTPClient.Transport
+		}
+	}
+
+	transport := &timeSyncTransport{base: base, client: client, lastSync: time.Now()}
+	// Replace the client rather than mutating it, since it may be http.DefaultClient
+	client.HTTPClient = &http.Client{Transport: transport, Timeout: timeout}
+
+	if err := syncServerTime(context.Background(), client); err != nil {
+		log.Printf("Warning: %v", err)
+	}
+}
+
+// syncServerTime sets the client's time offset from /fapi/v1/time.
+func syncServerTime(ctx context.Context, client *binance.Client) error {
+	ctx, cancel := context.WithTimeout(ctx, defaultTimeout)
+	defer cancel()
+
+	offset, err := client.NewSetServerTimeService().Do(ctx)
+	if err != nil {
+		return fmt.Errorf("error synchronizing server time: %w", err)
+	}
+	log.Printf("Synchronized with Binance server time (local clock offset %dms)", offset)
+	return nil
+}
+
+// timeSyncer is implemented by exchanges that calibrate a local clock offset.
+type timeSyncer interface {
+	SyncTime(ctx context.Context) error
+}
+
+// runTimeSync periodically recalibrates the time offset until ctx is cancelled.
+func (ts *TradingService) runTimeSync(ctx context.Context) {
+	syncer, ok := ts.exchange().(timeSyncer)
+	if !ok {
+		return
+	}
+
+	ticker := time.NewTicker(ts.config().TimeSyncInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := syncer.SyncTime(ctx); err != nil {
+				log.Printf("Warning: %v", err)
+			}
+		}
+	}
+}
+
+// recvWindowOption returns the request option applying the configured recvWindow.
+func recvWindowOption(config Config) binance.RequestOption {
+	window := config.RecvWindow
+	if window > maxRecvWindow {
+		window = maxRecvWindow
+	}
+	return binance.WithRecvWindow(window.Milliseconds())
+}
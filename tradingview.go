@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// tradingViewAlert is the JSON message of a TradingView alert. TradingView cannot
+// set request headers, so the shared secret travels in the body.
+type tradingViewAlert struct {
+	Secret       string    `json:"secret"`
+	Action       string    `json:"action"`
+	Symbol       string    `json:"symbol"`
+	PositionSide string    `json:"position_side"`
+	Quantity     flexFloat `json:"quantity"`
+	Risk         flexFloat `json:"risk"`
+	Tag          string    `json:"tag"`
+}
+
+// flexFloat decodes a JSON number or a numeric string, since alert templates
+// such as {{strategy.order.contracts}} are often quoted.
+type flexFloat float64
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (f *flexFloat) UnmarshalJSON(b []byte) error {
+	s := strings.Trim(string(b), `"`)
+	if s == "" || s == "null" {
+		*f = 0
+		return nil
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return fmt.Errorf("invalid number %s", b)
+	}
+	*f = flexFloat(v)
+	return nil
+}
+
+// handleTradingView executes a TradingView alert: buy/long and sell/short open or
+// add to a position at market together with its SL/TP bracket, close flattens it.
+func (ts *TradingService) handleTradingView(w http.ResponseWriter, r *http.Request) {
+	var alert tradingViewAlert
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<16)).Decode(&alert); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("error decoding alert: %w", err))
+		return
+	}
+	if subtle.ConstantTimeCompare([]byte(alert.Secret), []byte(ts.config().TradingViewSecret)) != 1 {
+		writeError(w, http.StatusUnauthorized, errors.New("invalid alert secret"))
+		return
+	}
+
+	// TradingView suffixes perpetual tickers with .P
+	symbol := strings.TrimSuffix(strings.ToUpper(strings.TrimSpace(alert.Symbol)), ".P")
+	positionSide := strings.ToUpper(alert.PositionSide)
+	if positionSide == "" {
+		positionSide = "BOTH"
+	}
+	if !ts.isSymbolManaged(symbol) {
+		writeError(w, http.StatusForbidden, fmt.Errorf("%s is not managed by the guard", symbol))
+		return
+	}
+
+	var err error
+	action := strings.ToLower(strings.TrimSpace(alert.Action))
+	switch action {
+	case "close", "exit", "flat":
+		err = ts.closeFromAlert(symbol, positionSide)
+	case "buy", "long", "sell", "short":
+		if err := ts.entryBlocked(symbol); err != nil {
+			writeError(w, http.StatusConflict, err)
+			return
+		}
+		_, err = ts.openPosition(entryRequest{
+			Symbol:       symbol,
+			PositionSide: positionSide,
+			IsLong:       action == "buy" || action == "long",
+			Quantity:     float64(alert.Quantity),
+			RiskPct:      float64(alert.Risk),
+			Tag:          alert.Tag,
+		})
+	default:
+		writeError(w, http.StatusBadRequest, fmt.Errorf("unknown action %q, expected buy, sell or close", alert.Action))
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusBadGateway, err)
+		return
+	}
+
+	msg := fmt.Sprintf("📡 TradingView alert: %s %s (%s)", action, symbol, positionSide)
+	log.Println(msg)
+	ts.notify(SeverityInfo, msg)
+	writeJSON(w, http.StatusOK, map[string]string{"symbol": symbol, "action": action, "status": "executed"})
+}
+
+// closeFromAlert closes one side of symbol, or the whole symbol with its orders
+// when positionSide is BOTH.
+func (ts *TradingService) closeFromAlert(symbol, positionSide string) error {
+	if positionSide == "BOTH" {
+		return ts.closeSymbol(symbol)
+	}
+
+	unlock := ts.lockPosition(symbol, positionSide)
+	defer unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+	positions, err := ts.exchange().Positions(ctx, symbol)
+	if err != nil {
+		return fmt.Errorf("error getting positions for %s: %w", symbol, err)
+	}
+	for _, position := range positions {
+		data, err := newPositionData(position)
+		if err != nil {
+			return err
+		}
+		if data != nil && data.PositionSide == positionSide {
+			if err := ts.cancelExistingOrders(symbol, positionSide); err != nil {
+				log.Printf("Warning: %v", err)
+			}
+			return ts.reducePosition(data, 100, "TradingView alert")
+		}
+	}
+	return fmt.Errorf("no open %s position on %s", positionSide, symbol)
+}
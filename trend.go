@@ -0,0 +1,230 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"futures-guard/settings"
+)
+
+// Trend indicators of TREND_INDICATOR.
+const (
+	trendIndicatorADX = "adx"
+	trendIndicatorEMA = "ema"
+)
+
+// Trend regimes. A symbol between the choppy and strong thresholds keeps its
+// last regime, and has none until it first crosses one.
+const (
+	trendStrong = "strong"
+	trendChoppy = "choppy"
+)
+
+// trendRefreshInterval is how long a symbol's regime is reused before its candles
+// are fetched again, so both sides of a hedge-mode position share one fetch.
+const trendRefreshInterval = time.Minute
+
+// trendLadders names the ladder presets used in each trend regime.
+type trendLadders struct {
+	Strong string
+	Choppy string
+}
+
+// trendReading is the last trend regime of a symbol and the indicator value it
+// came from.
+type trendReading struct {
+	Regime    string
+	Value     float64
+	CheckedAt time.Time
+}
+
+// trendCache holds the trend reading of each symbol. It has its own lock because
+// stopLadder, which reads it, is also called with ts.mu held.
+type trendCache struct {
+	mu       sync.RWMutex
+	readings map[string]trendReading
+}
+
+// get returns the trend reading of symbol.
+func (c *trendCache) get(symbol string) (trendReading, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	reading, ok := c.readings[symbol]
+	return reading, ok
+}
+
+// set stores the trend reading of symbol.
+func (c *trendCache) set(symbol string, reading trendReading) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.readings == nil {
+		c.readings = make(map[string]trendReading)
+	}
+	c.readings[symbol] = reading
+}
+
+// trendFiltered reports whether symbol switches ladders with its trend under cfg.
+func trendFiltered(cfg *Config, symbol string) bool {
+	if !cfg.TrendFilter {
+		return false
+	}
+	return len(cfg.TrendFilterSymbols) == 0 || matchesSymbolPattern(symbol, cfg.TrendFilterSymbols)
+}
+
+// trendLadder returns the ladder preset of symbol for its current trend regime
+// and that regime, or an empty preset when the filter does not apply to it.
+// An explicit LADDER_PRESET_OVERRIDES preset always wins.
+func (ts *TradingService) trendLadder(cfg *Config, symbol string) (string, string) {
+	if !trendFiltered(cfg, symbol) {
+		return "", ""
+	}
+	if _, ok := cfg.LadderPresetOverrides[symbol]; ok {
+		return "", ""
+	}
+	reading, ok := ts.trends.get(symbol)
+	if !ok {
+		return "", ""
+	}
+
+	ladders := cfg.TrendLadders
+	if override, ok := cfg.TrendLadderOverrides[symbol]; ok {
+		ladders = override
+	}
+	switch reading.Regime {
+	case trendStrong:
+		return ladders.Strong, reading.Regime
+	case trendChoppy:
+		return ladders.Choppy, reading.Regime
+	}
+	return "", ""
+}
+
+// trendStrength measures the trend of candles with the configured indicator:
+// the ADX, or the absolute EMA slope in % per candle.
+func (ts *TradingService) trendStrength(candles []Candle) float64 {
+	if ts.config().TrendIndicator == trendIndicatorEMA {
+		slope := emaSlope(candles, ts.config().TrendPeriod)
+		if slope < 0 {
+			return -slope
+		}
+		return slope
+	}
+	return averageDirectionalIndex(candles, ts.config().TrendPeriod)
+}
+
+// checkTrend refreshes the trend regime of the symbol of data from its recent
+// TrendInterval candles, so the ladder trails tighter in choppy markets and
+// gives more room in strong trends, and notifies when the regime changes.
+func (ts *TradingService) checkTrend(data *PositionData) {
+	cfg := ts.config()
+	if !trendFiltered(cfg, data.Symbol) {
+		return
+	}
+	last, known := ts.trends.get(data.Symbol)
+	if known && time.Since(last.CheckedAt) < trendRefreshInterval {
+		return
+	}
+
+	// Both indicators need two periods, plus one for the first ADX change
+	candles, err := ts.getKlines(data.Symbol, cfg.TrendInterval, cfg.TrendPeriod*3+1)
+	if err != nil {
+		log.Printf("Warning: Unable to check the trend of %s: %v", data.Symbol, err)
+		return
+	}
+	value := ts.trendStrength(candles)
+	if value <= 0 {
+		log.Printf("Warning: Not enough %s candles for the trend of %s", cfg.TrendInterval, data.Symbol)
+		return
+	}
+
+	reading := trendReading{Regime: last.Regime, Value: value, CheckedAt: time.Now()}
+	switch {
+	case value >= cfg.TrendStrong:
+		reading.Regime = trendStrong
+	case value <= cfg.TrendChoppy:
+		reading.Regime = trendChoppy
+	}
+	ts.trends.set(data.Symbol, reading)
+	if reading.Regime == last.Regime {
+		return
+	}
+
+	preset, _ := ts.trendLadder(cfg, data.Symbol)
+	if preset == "" {
+		return
+	}
+	msg := fmt.Sprintf("📈 %s trend is %s (%s %.2f), trailing on the %s ladder",
+		data.Symbol, reading.Regime, strings.ToUpper(cfg.TrendIndicator), value, preset)
+	log.Println(msg)
+	ts.notify(SeverityInfo, msg)
+}
+
+// loadTrendConfig reads the trend filter settings from src. It runs after
+// loadProfitMetricConfig, whose presets the trend ladders must name.
+func loadTrendConfig(src *settings.Settings, config *Config) {
+	config.TrendFilter = src.Bool("TREND_FILTER", false)
+	config.TrendFilterSymbols = settings.Parse(src, "TREND_FILTER_SYMBOLS", nil, parseSymbolList)
+	config.TrendIndicator = settings.Parse(src, "TREND_INDICATOR", trendIndicatorADX, func(v string) (string, error) {
+		switch indicator := strings.ToLower(v); indicator {
+		case trendIndicatorADX, trendIndicatorEMA:
+			return indicator, nil
+		default:
+			return "", fmt.Errorf("want %s or %s", trendIndicatorADX, trendIndicatorEMA)
+		}
+	})
+	config.TrendInterval = "1h"
+	if v := src.String("TREND_INTERVAL"); v != "" {
+		config.TrendInterval = v
+	}
+	config.TrendPeriod = src.Int("TREND_PERIOD", 14)
+	if config.TrendPeriod < 2 {
+		src.Fail("TREND_PERIOD", fmt.Errorf("must be at least 2, got %d", config.TrendPeriod))
+		config.TrendPeriod = 14
+	}
+
+	// ADX above 25 is a trend and below 20 a range; the EMA slope is in % per candle
+	config.TrendStrong, config.TrendChoppy = 25, 20
+	if config.TrendIndicator == trendIndicatorEMA {
+		config.TrendStrong, config.TrendChoppy = 0.1, 0.02
+	}
+	config.TrendStrong = src.Float("TREND_STRONG", config.TrendStrong)
+	config.TrendChoppy = src.Float("TREND_CHOPPY", config.TrendChoppy)
+	if config.TrendChoppy > config.TrendStrong {
+		src.Fail("TREND_CHOPPY", fmt.Errorf("%.4f is above TREND_STRONG %.4f", config.TrendChoppy, config.TrendStrong))
+	}
+
+	config.TrendLadders = settings.Parse(src, "TREND_LADDERS", trendLadders{Strong: "swing", Choppy: "scalper"},
+		func(v string) (trendLadders, error) { return parseTrendLadders(config, v) })
+	config.TrendLadderOverrides = make(map[string]trendLadders)
+	for symbol, v := range settings.Parse(src, "TREND_LADDER_OVERRIDES", nil, parseSymbolOverrides) {
+		ladders, err := parseTrendLadders(config, v)
+		if err != nil {
+			src.Fail("TREND_LADDER_OVERRIDES", fmt.Errorf("invalid ladders %q for %s: %w", v, symbol, err))
+			continue
+		}
+		config.TrendLadderOverrides[symbol] = ladders
+	}
+}
+
+// parseTrendLadders parses a "strong/choppy" pair of ladder presets such as
+// "swing/scalper". Either may be empty to keep the symbol's own ladder in that
+// regime.
+func parseTrendLadders(config *Config, value string) (trendLadders, error) {
+	strong, choppy, ok := strings.Cut(value, "/")
+	if !ok {
+		return trendLadders{}, fmt.Errorf("want strong/choppy presets")
+	}
+	ladders := trendLadders{
+		Strong: strings.ToLower(strings.TrimSpace(strong)),
+		Choppy: strings.ToLower(strings.TrimSpace(choppy)),
+	}
+	for _, name := range []string{ladders.Strong, ladders.Choppy} {
+		if _, known := config.LadderPresets[name]; name != "" && !known {
+			return trendLadders{}, fmt.Errorf("unknown preset %q", name)
+		}
+	}
+	return ladders, nil
+}
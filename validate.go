@@ -0,0 +1,231 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"futures-guard/settings"
+)
+
+// symbolPattern matches symbols and SYMBOL_WHITELIST/SYMBOL_BLACKLIST patterns:
+// upper-case letters and digits, delivery suffixes and glob characters.
+var symbolPattern = regexp.MustCompile(`^[A-Z0-9_*?\[\]^!-]+$`)
+
+// validateTestMessage is sent to every notification channel by config validate.
+const validateTestMessage = "🧪 Futures Guard configuration check: this channel receives notifications"
+
+// newConfigCommand builds the `config` command that checks the configuration.
+func newConfigCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "config",
+		Short: "Check the configuration",
+	}
+	cmd.AddCommand(newConfigValidateCommand(), newConfigShowCommand())
+	return cmd
+}
+
+// newConfigShowCommand builds the `config show` command that prints the loaded
+// configuration with its secrets redacted.
+func newConfigShowCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:     "show",
+		Short:   "Print the configuration as loaded, with defaults applied and secrets redacted",
+		Example: "  futures-guard config show\n  futures-guard --config prod.env config show",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			config, err := loadConfig()
+			if err != nil {
+				return err
+			}
+			out, err := json.MarshalIndent(redactConfig(config), "", "  ")
+			if err != nil {
+				return fmt.Errorf("error encoding the configuration: %w", err)
+			}
+			fmt.Println(string(out))
+			return nil
+		},
+	}
+}
+
+// newConfigValidateCommand builds the `config validate` command that reports every
+// problem of the configuration at once.
+func newConfigValidateCommand() *cobra.Command {
+	var noNotify bool
+
+	cmd := &cobra.Command{
+		Use:   "validate",
+		Short: "Load the configuration, report all its problems and send a test notification",
+		Example: "  futures-guard config validate\n" +
+			"  CONFIG_FILE=prod.env futures-guard config validate --no-notify",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			config, warnings, err := readConfig()
+			if invalid := (*settings.Error)(nil); err != nil && !errors.As(err, &invalid) {
+				// Neither the flags nor the config file could be read
+				return err
+			}
+			problems := settingProblems(err)
+			for _, warning := range warnings {
+				problems = append(problems, warning.Error())
+			}
+			problems = append(problems, validateConfig(config)...)
+			if !noNotify {
+				problems = append(problems, testNotifiers(config)...)
+			}
+
+			if len(problems) == 0 {
+				fmt.Printf("✅ %s is valid\n", configFile())
+				return nil
+			}
+			fmt.Printf("❌ %d problems in %s:\n", len(problems), configFile())
+			for _, problem := range problems {
+				fmt.Println("- " + problem)
+			}
+			return fmt.Errorf("invalid configuration: %d problems", len(problems))
+		},
+	}
+	cmd.Flags().BoolVar(&noNotify, "no-notify", false, "do not send a test message to the notification channels")
+	return cmd
+}
+
+// settingProblems returns the invalid settings loadConfig reported in err.
+func settingProblems(err error) []string {
+	var problems []string
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		for _, err := range joined.Unwrap() {
+			problems = append(problems, err.Error())
+		}
+	} else if err != nil {
+		problems = append(problems, err.Error())
+	}
+	return problems
+}
+
+// checkConfig returns an error listing the problems validateConfig finds, so a
+// configuration that cannot work as intended is never run.
+func checkConfig(config Config) error {
+	problems := validateConfig(config)
+	if len(problems) == 0 {
+		return nil
+	}
+	return fmt.Errorf("invalid configuration:\n- %s", strings.Join(problems, "\n- "))
+}
+
+// validateConfig checks the settings the loaders accept but that cannot work as
+// intended: ladders that loosen the stop, stops beyond liquidation and symbol
+// filters that can never match.
+func validateConfig(config Config) []string {
+	var problems []string
+	add := func(format string, args ...any) { problems = append(problems, fmt.Sprintf(format, args...)) }
+
+	if config.DefaultSLPercent <= 0 {
+		add("DEFAULT_SL_PERCENT must be positive, %g would place the stop at the entry", config.DefaultSLPercent)
+	}
+	if config.TPPercent <= 0 && !config.TPDisabled {
+		add("TP_PERCENT must be positive, or set TP_DISABLED=true")
+	}
+
+	ladders := map[string][]StopLossLevel{"LADDER_LEVELS": config.LadderLevels}
+	if config.LadderPreset != "" {
+		ladders = map[string][]StopLossLevel{"LADDER_PRESET " + config.LadderPreset: config.LadderLevels}
+	}
+	for name, preset := range config.LadderPresets {
+		if name != config.LadderPreset {
+			ladders["ladder preset "+name] = preset.Levels
+		}
+	}
+	for _, name := range sortedKeys(ladders) {
+		levels := ladders[name]
+		if len(levels) == 0 {
+			add("%s has no valid step", name)
+		}
+		for i := 1; i < len(levels); i++ {
+			prev, level := levels[i-1], levels[i]
+			if level.ProfitThreshold == prev.ProfitThreshold {
+				add("%s has two steps at the profit threshold %g", name, level.ProfitThreshold)
+			}
+			if level.StopLossValue < prev.StopLossValue {
+				add("%s step %g:%g locks less than the step before it (%g:%g), the stop would loosen",
+					name, level.ProfitThreshold, level.StopLossValue, prev.ProfitThreshold, prev.StopLossValue)
+			}
+		}
+	}
+	for i := 1; i < len(config.RLadder); i++ {
+		prev, level := config.RLadder[i-1], config.RLadder[i]
+		if level.ProfitR == prev.ProfitR {
+			add("R_LADDER has two steps at %gR", level.ProfitR)
+		}
+		if level.LockR < prev.LockR {
+			add("R_LADDER step %g:%g locks less than the step before it (%g:%g), the stop would loosen",
+				level.ProfitR, level.LockR, prev.ProfitR, prev.LockR)
+		}
+	}
+
+	// An isolated position is liquidated roughly 100/leverage percent from entry,
+	// a little earlier with the maintenance margin
+	leverages := map[string]int{"LEVERAGE_TARGET": config.LeverageTarget}
+	for symbol, leverage := range config.LeverageTargetOverrides {
+		leverages["LEVERAGE_TARGET_OVERRIDES "+symbol] = leverage
+	}
+	for _, name := range sortedKeys(leverages) {
+		leverage := leverages[name]
+		if leverage <= 0 {
+			continue
+		}
+		if distance := 100 / float64(leverage); config.DefaultSLPercent >= distance {
+			add("DEFAULT_SL_PERCENT %g%% is beyond the liquidation distance of about %.2f%% at %s %dx, the stop would never trigger",
+				config.DefaultSLPercent, distance, name, leverage)
+		}
+	}
+
+	for _, filter := range []struct {
+		name     string
+		patterns []string
+	}{{"SYMBOL_WHITELIST", config.SymbolWhitelist}, {"SYMBOL_BLACKLIST", config.SymbolBlacklist}} {
+		for _, pattern := range filter.patterns {
+			if !symbolPattern.MatchString(pattern) {
+				add("%s entry %q is not a symbol such as BTCUSDT or a pattern such as *USDT", filter.name, pattern)
+			}
+		}
+	}
+	for _, pattern := range config.SymbolWhitelist {
+		if slices.Contains(config.SymbolBlacklist, pattern) {
+			add("%s is in both SYMBOL_WHITELIST and SYMBOL_BLACKLIST, so it is never managed", pattern)
+		}
+	}
+	return problems
+}
+
+// testNotifiers sends a test message to every configured notification channel and
+// returns the channels that are missing credentials or failed to deliver it.
+func testNotifiers(config Config) []string {
+	notifier, problems := buildNotifier(config)
+	if len(notifier.channels) == 0 {
+		return append(problems, "no notification channel is configured (NOTIFIERS)")
+	}
+	for _, ch := range notifier.channels {
+		if err := ch.notifier.Send(validateTestMessage); err != nil {
+			problems = append(problems, fmt.Sprintf("%s notifier could not send a test message: %v", ch.notifier.Name(), err))
+			continue
+		}
+		fmt.Printf("📨 Test message sent to %s\n", ch.notifier.Name())
+	}
+	return problems
+}
+
+// sortedKeys returns the keys of m in order, for stable reports.
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestReadConfigWarnings(t *testing.T) {
+	useConfig(t, "LADDER_LEVELS=300:0,450:150\nLADDER_PRESET=swing\n")
+	config, warnings, err := readConfig()
+	if err != nil {
+		t.Fatalf("readConfig: %v", err)
+	}
+	if len(config.LadderLevels) != 2 {
+		t.Errorf("ladder %v, want the two LADDER_LEVELS steps", config.LadderLevels)
+	}
+	if len(warnings) != 1 || !strings.Contains(warnings[0].Error(), "LADDER_PRESET") {
+		t.Errorf("warnings %v, want LADDER_PRESET reported as ignored", warnings)
+	}
+}
+
+func TestCheckConfig(t *testing.T) {
+	tests := []struct {
+		name string
+		env  string
+		want []string // Problems reported, none when empty
+	}{
+		{"defaults", "", nil},
+		{"loosening ladder", "LADDER_LEVELS=300:100,450:50\n", []string{"LADDER_LEVELS step 450:50"}},
+		{"stop beyond liquidation", "DEFAULT_SL_PERCENT=6\nLEVERAGE_TARGET=20\n", []string{"LEVERAGE_TARGET 20x"}},
+		{"whitelisted and blacklisted", "SYMBOL_WHITELIST=BTCUSDT\nSYMBOL_BLACKLIST=BTCUSDT\n", []string{"BTCUSDT is in both"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useConfig(t, tt.env)
+			config, err := loadConfig()
+			if err != nil {
+				t.Fatalf("loadConfig: %v", err)
+			}
+			err = checkConfig(config)
+			if len(tt.want) == 0 {
+				if err != nil {
+					t.Errorf("checkConfig: %v, want no problem", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatal("checkConfig succeeded, want a problem")
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("checkConfig: %v, want it to report %s", err, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+
+	"futures-guard/settings"
+)
+
+// realizedVolatility returns the standard deviation, in percent, of the close-to-close
+// returns of candles. It returns 0 when there are fewer than two returns.
+func realizedVolatility(candles []Candle) float64 {
+	var returns []float64
+	for i := 1; i < len(candles); i++ {
+		if prev := candles[i-1].Close; prev > 0 {
+			returns = append(returns, (candles[i].Close-prev)/prev*100)
+		}
+	}
+	if len(returns) < 2 {
+		return 0
+	}
+
+	var mean float64
+	for _, r := range returns {
+		mean += r
+	}
+	mean /= float64(len(returns))
+
+	var variance float64
+	for _, r := range returns {
+		variance += (r - mean) * (r - mean)
+	}
+	variance /= float64(len(returns) - 1)
+	return math.Sqrt(variance)
+}
+
+// tpVolReference returns the volatility at which symbol keeps the plain TPPercent.
+func (ts *TradingService) tpVolReference(symbol string) float64 {
+	if ref, ok := ts.config().TPVolReferenceOverrides[symbol]; ok {
+		return ref
+	}
+	return ts.config().TPVolReference
+}
+
+// tpVolScaled reports whether symbol uses the volatility-scaled take-profit.
+func (ts *TradingService) tpVolScaled(symbol string) bool {
+	if !ts.config().TPVolScale {
+		return false
+	}
+	return len(ts.config().TPVolScaleSymbols) == 0 || matchesSymbolPattern(symbol, ts.config().TPVolScaleSymbols)
+}
+
+// takeProfitPercent returns the take-profit distance for a position. With volatility
+// scaling, TPPercent is multiplied by the ratio of the symbol's recent realized
+// volatility to its reference, clamped to [TPVolMinFactor, TPVolMaxFactor], so quiet
+// symbols get closer targets and fast movers get room.
+func (ts *TradingService) takeProfitPercent(data *PositionData) float64 {
+	if !ts.tpVolScaled(data.Symbol) {
+		return ts.config().TPPercent
+	}
+
+	factor, err := ts.volatilityFactor(data.Symbol)
+	if err != nil {
+		log.Printf("Warning: %v, using unscaled TP for %s", err, data.Symbol)
+		return ts.config().TPPercent
+	}
+
+	tpPercent := ts.config().TPPercent * factor
+	log.Printf("DEBUG: Volatility-scaled TP for %s: %.2f%% x %.2f = %.2f%%",
+		data.Symbol, ts.config().TPPercent, factor, tpPercent)
+	return tpPercent
+}
+
+// volatilityFactor returns the clamped ratio of symbol's realized volatility to its reference.
+func (ts *TradingService) volatilityFactor(symbol string) (float64, error) {
+	reference := ts.tpVolReference(symbol)
+	if reference <= 0 {
+		return 0, fmt.Errorf("invalid TP volatility reference %.4f for %s", reference, symbol)
+	}
+
+	candles, err := ts.getKlines(symbol, ts.config().TPVolInterval, ts.config().TPVolPeriod+1)
+	if err != nil {
+		return 0, err
+	}
+	vol := realizedVolatility(candles)
+	if vol <= 0 {
+		return 0, fmt.Errorf("not enough %s candles for the volatility of %s", ts.config().TPVolInterval, symbol)
+	}
+
+	factor := vol / reference
+	return math.Max(ts.config().TPVolMinFactor, math.Min(ts.config().TPVolMaxFactor, factor)), nil
+}
+
+// loadVolatilityConfig reads the volatility-scaled take-profit settings from src.
+func loadVolatilityConfig(src *settings.Settings, config *Config) {
+	config.TPVolInterval = "1h"
+	config.TPVolPeriod = 24
+	config.TPVolReference = 1
+	config.TPVolMinFactor = 0.5
+	config.TPVolMaxFactor = 3
+
+	config.TPVolScale = src.Bool("TP_VOL_SCALE", config.TPVolScale)
+	config.TPVolScaleSymbols = settings.Parse(src, "TP_VOL_SCALE_SYMBOLS", nil, parseSymbolList)
+	if v := src.String("TP_VOL_INTERVAL"); v != "" {
+		config.TPVolInterval = v
+	}
+	config.TPVolPeriod = src.Int("TP_VOL_PERIOD", config.TPVolPeriod)
+	config.TPVolReference = src.Float("TP_VOL_REFERENCE", config.TPVolReference)
+	config.TPVolReferenceOverrides = settings.Parse(src, "TP_VOL_REFERENCE_OVERRIDES", nil, parseVolReferenceOverrides)
+	config.TPVolMinFactor = src.Float("TP_VOL_MIN_FACTOR", config.TPVolMinFactor)
+	config.TPVolMaxFactor = src.Float("TP_VOL_MAX_FACTOR", config.TPVolMaxFactor)
+}
+
+// parseVolReferenceOverrides parses per-symbol volatility references such as "BTCUSDT=0.6,DOGEUSDT=2".
+func parseVolReferenceOverrides(value string) (map[string]float64, error) {
+	return parseOverrides(value, func(setting string) (float64, error) {
+		ref, err := strconv.ParseFloat(setting, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid TP volatility reference %q", setting)
+		}
+		return ref, nil
+	})
+}
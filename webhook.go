@@ -0,0 +1,104 @@
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// Webhook request headers. The signature is the hex HMAC-SHA256 of
+// "<timestamp>.<body>" keyed with WEBHOOK_SECRET.
+const (
+	webhookTimestampHeader = "X-Futures-Guard-Timestamp"
+	webhookSignatureHeader = "X-Futures-Guard-Signature"
+	webhookEventHeader     = "X-Futures-Guard-Event"
+)
+
+// webhookNotifier posts events as JSON to the configured WEBHOOK_URLS.
+type webhookNotifier struct {
+	urls   []string
+	secret string
+	events []EventType // empty sends every event
+}
+
+// newWebhookNotifier returns the webhook notifier configured in config, or nil
+// when no URL is set.
+func newWebhookNotifier(config Config) *webhookNotifier {
+	if len(config.WebhookURLs) == 0 {
+		return nil
+	}
+	if config.WebhookSecret == "" {
+		log.Println("Warning: WEBHOOK_SECRET is empty; webhook requests are not signed")
+	}
+	return &webhookNotifier{urls: config.WebhookURLs, secret: config.WebhookSecret, events: config.WebhookEvents}
+}
+
+// handle delivers e to every URL in the background so slow endpoints never
+// delay order management.
+func (w *webhookNotifier) handle(e Event) {
+	if len(w.events) > 0 && !slices.Contains(w.events, e.Type) {
+		return
+	}
+
+	body, err := json.Marshal(e)
+	if err != nil {
+		log.Printf("Warning: Error encoding webhook event: %v", err)
+		return
+	}
+	for _, url := range w.urls {
+		go func() {
+			if err := w.post(url, e.Type, body); err != nil {
+				log.Printf("Warning: Error sending %s webhook to %s: %v", e.Type, url, err)
+			}
+		}()
+	}
+}
+
+// post sends one signed webhook request.
+func (w *webhookNotifier) post(url string, eventType EventType, body []byte) error {
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(webhookEventHeader, string(eventType))
+	req.Header.Set(webhookTimestampHeader, timestamp)
+	if w.secret != "" {
+		req.Header.Set(webhookSignatureHeader, "sha256="+hex.EncodeToString(hmacSHA256([]byte(w.secret), timestamp+"."+string(body))))
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned error code: %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// parseEventTypes parses a comma-separated list of event types.
+func parseEventTypes(value string) ([]EventType, error) {
+	known := []EventType{
+		EventPositionSnapshot, EventThresholdCrossed, EventSLMoved, EventTPUpdated,
+		EventTPHit, EventOrderRejected, EventPositionClosed, EventError, EventKillSwitch,
+	}
+	var types []EventType
+	for _, item := range parseList(value) {
+		t := EventType(item)
+		if !slices.Contains(known, t) {
+			return nil, fmt.Errorf("unknown event type %q", item)
+		}
+		types = append(types, t)
+	}
+	return types, nil
+}